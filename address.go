@@ -0,0 +1,159 @@
+package trifle
+
+import (
+	"log/slog"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+var (
+	ipColor  = color.New(color.FgCyan)
+	urlColor = color.New(color.FgBlue, color.Underline)
+)
+
+// WithAddresses returns an Option that styles values recognized as IP
+// addresses, with or without a port, and URLs, so that they stand out
+// from the values around them. On terminals that support OSC 8
+// hyperlinks, URLs are also links that can be opened with a click.
+func WithAddresses() Option {
+	return func(h *TextHandler) {
+		h.addresses = true
+		h.hyperlinks = supportsHyperlinks()
+	}
+}
+
+// WithRedactedIPs returns an Option that zeroes the last octet of IPv4
+// addresses, and all but the first 48 bits of IPv6 addresses, wherever they
+// appear as values: on their own, with a port, or as the host of a URL.
+// Unlike the styling of [WithAddresses], redaction also applies when colors
+// are disabled, so that logs written to files don't keep the addresses
+// either.
+func WithRedactedIPs() Option {
+	return func(h *TextHandler) {
+		h.redactIPs = true
+	}
+}
+
+// address renders v styled, and redacted when asked for, when it is an IP
+// address or URL. Other values are returned as they are.
+func (h *commonHandler) address(v slog.Value) slog.Value {
+	var s string
+	switch v.Kind() {
+	case slog.KindString:
+		s = v.String()
+	case slog.KindAny:
+		switch a := v.Any().(type) {
+		case netip.Addr:
+			s = a.String()
+		case netip.AddrPort:
+			s = a.String()
+		case net.IP:
+			s = a.String()
+		case *url.URL:
+			s = a.String()
+		default:
+			return v
+		}
+	default:
+		return v
+	}
+
+	if addr, ok := parseIP(s); ok {
+		if h.redactIPs {
+			s = redactIP(addr)
+		}
+		if !h.addresses || color.NoColor {
+			return slog.StringValue(s)
+		}
+		return slog.AnyValue(rendered(ipColor.Sprint(s)))
+	}
+
+	if u, ok := parseURL(s); ok {
+		if h.redactIPs {
+			if host, port, ok := redactURLHost(u); ok {
+				u.Host = host + port
+				s = u.String()
+			}
+		}
+		if !h.addresses || color.NoColor {
+			return slog.StringValue(s)
+		}
+		text := s
+		if needsQuoting(text) {
+			text = strconv.Quote(text)
+		}
+		styled := urlColor.Sprint(text)
+		if h.hyperlinks {
+			styled = hyperlink(s, styled)
+		}
+		return slog.AnyValue(rendered(styled))
+	}
+
+	return v
+}
+
+// parseIP parses s as an IP address, optionally with a port.
+func parseIP(s string) (netip.AddrPort, bool) {
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return netip.AddrPortFrom(addr, 0), true
+	}
+	if ap, err := netip.ParseAddrPort(s); err == nil {
+		return ap, true
+	}
+	return netip.AddrPort{}, false
+}
+
+// parseURL parses s as an absolute URL with a host, such as
+// "https://example.com/path". Anything else, including paths and
+// "host:port" pairs, isn't taken for one.
+func parseURL(s string) (*url.URL, bool) {
+	if !strings.Contains(s, "://") || strings.ContainsAny(s, " \t\n") {
+		return nil, false
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, false
+	}
+	return u, true
+}
+
+// redactIP returns ap with its address redacted, and its port if it has
+// one.
+func redactIP(ap netip.AddrPort) string {
+	addr := ap.Addr()
+	bits := 24
+	switch {
+	case addr.Is4In6():
+		bits = 96 + 24
+	case addr.Is6():
+		bits = 48
+	}
+	masked, _ := addr.Prefix(bits)
+
+	if ap.Port() == 0 {
+		return masked.Addr().String()
+	}
+	return netip.AddrPortFrom(masked.Addr(), ap.Port()).String()
+}
+
+// redactURLHost returns the host of u redacted and its port, with its
+// colon, if the host is an IP address.
+func redactURLHost(u *url.URL) (host, port string, ok bool) {
+	addr, err := netip.ParseAddr(u.Hostname())
+	if err != nil {
+		return "", "", false
+	}
+	host = redactIP(netip.AddrPortFrom(addr, 0))
+	if addr.Is6() {
+		host = "[" + host + "]"
+	}
+	if p := u.Port(); p != "" {
+		port = ":" + p
+	}
+	return host, port, true
+}
@@ -0,0 +1,66 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestAddresses(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+	t.Setenv("TERM_PROGRAM", "WezTerm")
+
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithAddresses())
+
+	slog.New(h).Info("proxied",
+		"client", "192.0.2.17:51234",
+		"upstream", "https://api.example.com/v1/users?id=7",
+		"path", "/v1/users")
+
+	out := buf.String()
+	assert.Contains(t, out, ipColor.Sprint("192.0.2.17:51234"))
+	assert.Contains(t, out, "\x1b]8;;https://api.example.com/v1/users?id=7\x1b\\")
+	assert.True(t, ContainsAttr(out, "upstream", "https://api.example.com/v1/users?id=7"))
+	assert.True(t, ContainsAttr(out, "path", "/v1/users"))
+}
+
+func TestRedactedIPs(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithRedactedIPs())
+
+	slog.New(h).Info("request",
+		"client", "192.0.2.17",
+		"peer", netip.MustParseAddrPort("[2001:db8:85a3:8d3:1319:8a2e:370:7348]:443"),
+		"callback", "http://198.51.100.42:8080/hook",
+		"site", "https://example.com/")
+
+	out := buf.String()
+	assert.True(t, ContainsAttr(out, "client", "192.0.2.0"))
+	assert.True(t, ContainsAttr(out, "peer", "[2001:db8:85a3::]:443"))
+	assert.True(t, ContainsAttr(out, "callback", "http://198.51.100.0:8080/hook"))
+	assert.True(t, ContainsAttr(out, "site", "https://example.com/"))
+	assert.NotContains(t, out, "192.0.2.17")
+	assert.NotContains(t, out, "7348")
+}
+
+func TestParseURL(t *testing.T) {
+	for s, want := range map[string]bool{
+		"https://example.com":     true,
+		"postgres://db:5432/app":  true,
+		"/var/log/app.log":        false,
+		"example.com:443":         false,
+		"see https://example.com": false,
+	} {
+		_, ok := parseURL(s)
+		assert.Equal(t, want, ok, s)
+	}
+}
@@ -0,0 +1,233 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+// alertQueueSize is how many records may wait for a slow alert hook before
+// further ones are dropped.
+const alertQueueSize = 64
+
+// AlertFunc is called by an alert hook for every matching record.
+type AlertFunc func(ctx context.Context, r slog.Record)
+
+type alertHook struct {
+	minLevel slog.Level
+	fn       AlertFunc
+
+	mu     sync.Mutex
+	queue  chan alert    // started by the first record
+	done   chan struct{} // closed once run has drained queue
+	closed bool
+}
+
+type alert struct {
+	ctx context.Context
+	r   slog.Record
+}
+
+// WithAlertHook returns an Option that calls fn for every record at
+// minLevel or above, for instance to be notified when a long running job
+// fails. The record carries the attributes added with WithAttrs and
+// WithGroup, those of the log call and, if there is one, the module.
+//
+// fn runs on its own goroutine, one record at a time, so it never slows
+// down logging. If fn falls behind, records are dropped rather than queued
+// without bound. The context passed to fn is not canceled when the
+// original one is. [TextHandler.Close] waits for the queued records and
+// stops the goroutine. Use [DesktopAlert] or [WebhookAlert] for common
+// cases.
+func WithAlertHook(minLevel slog.Level, fn AlertFunc) Option {
+	return func(h *TextHandler) {
+		h.alerts = append(h.alerts, &alertHook{minLevel: minLevel, fn: fn})
+	}
+}
+
+// notify queues r for the hooks it matches.
+func (h *commonHandler) notify(ctx context.Context, r slog.Record, module string) {
+	for _, hook := range h.alerts {
+		if r.Level < hook.minLevel {
+			continue
+		}
+
+		hook.send(alert{ctx: context.WithoutCancel(ctx), r: h.alertRecord(r, module)})
+	}
+}
+
+// alertRecord returns r with the attributes and groups of h folded around
+// its own, innermost first as attrChain does, followed by the module.
+func (h *commonHandler) alertRecord(r slog.Record, module string) slog.Record {
+	var attrs []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	for i := len(h.goas) - 1; i >= 0; i-- {
+		goa := h.goas[i]
+		if goa.group != "" {
+			if len(attrs) > 0 {
+				attrs = []slog.Attr{{Key: goa.group, Value: slog.GroupValue(attrs...)}}
+			}
+			continue
+		}
+		attrs = append(slices.Clone(goa.attrs), attrs...)
+	}
+	if module != "" {
+		attrs = append(attrs, slog.String(ModuleKey, module))
+	}
+
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	out.AddAttrs(attrs...)
+	return out
+}
+
+func (a *alertHook) send(al alert) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return
+	}
+	if a.queue == nil {
+		a.queue = make(chan alert, alertQueueSize)
+		a.done = make(chan struct{})
+		go a.run()
+	}
+
+	select {
+	case a.queue <- al:
+	default:
+	}
+}
+
+func (a *alertHook) run() {
+	defer close(a.done)
+	for al := range a.queue {
+		a.call(al)
+	}
+}
+
+// close stops queuing records and waits for fn to be done with those
+// already queued.
+func (a *alertHook) close() {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return
+	}
+	a.closed = true
+	queue, done := a.queue, a.done
+	a.mu.Unlock()
+
+	if queue != nil {
+		close(queue)
+		<-done
+	}
+}
+
+func (a *alertHook) call(al alert) {
+	// A failing hook must not take the program down with it.
+	defer func() { _ = recover() }()
+	a.fn(al.ctx, al.r)
+}
+
+// alertFormatter renders records for alerts, on a single line without
+// color.
+var alertFormatter = NewFormatter(nil, WithTerminalWidth(0))
+
+// AlertText returns r as a single line of plain text, the way trifle
+// renders it without the time, for use in notifications. A "module"
+// attribute, as added for alert hooks, is shown as the module.
+func AlertText(r slog.Record) string {
+	f := alertFormatter
+
+	plain := slog.NewRecord(time.Time{}, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == ModuleKey && a.Value.Kind() == slog.KindString {
+			f = f.WithAttrs([]slog.Attr{a})
+		} else {
+			plain.AddAttrs(a)
+		}
+		return true
+	})
+
+	text := color.Strip(string(f.Format(plain)))
+	return strings.TrimSpace(text)
+}
+
+// DesktopAlert returns an AlertFunc that shows a desktop notification with
+// the given title, using notify-send on Linux and the BSDs and osascript
+// on macOS. On other systems, or when the tool is missing, it does
+// nothing.
+func DesktopAlert(title string) AlertFunc {
+	return func(ctx context.Context, r slog.Record) {
+		cmd := desktopNotifyCommand(ctx, title, AlertText(r))
+		if cmd != nil {
+			_ = cmd.Run()
+		}
+	}
+}
+
+func desktopNotifyCommand(ctx context.Context, title, text string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptQuote(text), appleScriptQuote(title))
+		return exec.CommandContext(ctx, "osascript", "-e", script)
+	case "linux", "freebsd", "openbsd", "netbsd", "dragonfly":
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return nil
+		}
+		return exec.CommandContext(ctx, "notify-send", "--", title, text)
+	default:
+		return nil
+	}
+}
+
+func appleScriptQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// webhookTimeout bounds a single webhook delivery.
+const webhookTimeout = 10 * time.Second
+
+// WebhookAlert returns an AlertFunc that posts each record to url as JSON
+// of the form {"text": "..."}, which Slack incoming webhooks and many
+// compatible services accept. Delivery errors are ignored.
+func WebhookAlert(url string) AlertFunc {
+	client := &http.Client{Timeout: webhookTimeout}
+
+	return func(ctx context.Context, r slog.Record) {
+		body, err := json.Marshal(struct {
+			Text string `json:"text"`
+		}{AlertText(r)})
+		if err != nil {
+			return
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return
+		}
+		resp.Body.Close()
+	}
+}
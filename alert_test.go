@@ -0,0 +1,108 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertHook(t *testing.T) {
+	alerts := make(chan slog.Record, 10)
+
+	handler := New(&bytes.Buffer{}, nil, WithAlertHook(slog.LevelError, func(ctx context.Context, r slog.Record) {
+		alerts <- r
+	}))
+
+	logger := slog.New(handler).With("module", "jobs")
+	logger.Warn("slow")
+	logger.Error("job failed", "error", "exit status 1")
+
+	select {
+	case r := <-alerts:
+		assert.Equal(t, "job failed", r.Message)
+		assert.Equal(t, "[ERROR] jobs job failed │ error: \"exit status 1\"", AlertText(r))
+	case <-time.After(5 * time.Second):
+		t.Fatal("alert hook not called")
+	}
+
+	select {
+	case r := <-alerts:
+		t.Fatalf("unexpected alert %q", r.Message)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestAlertHookPanicIsContained(t *testing.T) {
+	called := make(chan struct{}, 2)
+
+	handler := New(&bytes.Buffer{}, nil, WithAlertHook(slog.LevelInfo, func(ctx context.Context, r slog.Record) {
+		called <- struct{}{}
+		panic("bad hook")
+	}))
+
+	logger := slog.New(handler)
+	logger.Info("one")
+	logger.Info("two")
+
+	for range 2 {
+		select {
+		case <-called:
+		case <-time.After(5 * time.Second):
+			t.Fatal("alert hook stopped after a panic")
+		}
+	}
+}
+
+func TestAlertHookAttrsAndClose(t *testing.T) {
+	var alerts []string
+
+	handler := New(&bytes.Buffer{}, nil, WithAlertHook(slog.LevelError, func(ctx context.Context, r slog.Record) {
+		time.Sleep(10 * time.Millisecond)
+		alerts = append(alerts, AlertText(r))
+	}))
+
+	logger := slog.New(handler).With("module", "jobs", "job", "backup").WithGroup("run")
+	logger.Error("failed", "attempt", 1)
+	logger.Error("failed", "attempt", 2)
+	require.NoError(t, handler.Close())
+
+	// Close waited for both alerts, and later records aren't queued.
+	logger.Error("failed", "attempt", 3)
+	assert.Equal(t, []string{
+		"[ERROR] jobs failed │ job: backup run.attempt: 1",
+		"[ERROR] jobs failed │ job: backup run.attempt: 2",
+	}, alerts)
+}
+
+func TestWebhookAlert(t *testing.T) {
+	bodies := make(chan map[string]string, 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	r := slog.NewRecord(time.Now(), slog.LevelError, "deploy failed", 0)
+	r.AddAttrs(slog.String("stage", "migrate"))
+
+	WebhookAlert(srv.URL)(context.Background(), r)
+
+	select {
+	case body := <-bodies:
+		require.Contains(t, body, "text")
+		assert.Equal(t, "[ERROR] deploy failed │ stage: migrate", body["text"])
+	default:
+		t.Fatal("webhook not called")
+	}
+}
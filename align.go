@@ -0,0 +1,51 @@
+package trifle
+
+import "sync/atomic"
+
+// WithAttrsColumn returns an Option that pads the message so the " │ "
+// separator before the attributes starts at the given column, lining up
+// the attributes of consecutive records. Messages that extend past the
+// column are not truncated; their separator simply follows the message.
+func WithAttrsColumn(column int) Option {
+	return func(h *TextHandler) {
+		h.attrsColumn = column
+		h.attrsLeader = nil
+	}
+}
+
+// WithAdaptiveAttrsColumn returns an Option that aligns the " │ " separator
+// at the widest message seen so far, so the attributes line up without
+// choosing a column up front. A record whose message is wider moves the
+// column out for the records after it, up to limit. A limit of 0 means
+// no limit.
+func WithAdaptiveAttrsColumn(limit int) Option {
+	return func(h *TextHandler) {
+		h.attrsColumn = limit
+		h.attrsLeader = new(atomic.Int64)
+	}
+}
+
+// alignAttrs pads the line so the attrs separator is written at the
+// configured column.
+func (s *handleState) alignAttrs() {
+	column := s.h.attrsColumn
+
+	if leader := s.h.attrsLeader; leader != nil {
+		// Follow the widest message so far, and move the column out if this
+		// one is wider, unless that would exceed the limit.
+		lead := int(leader.Load())
+		for s.linePos > lead && (column <= 0 || s.linePos <= column) {
+			if leader.CompareAndSwap(int64(lead), int64(s.linePos)) {
+				lead = s.linePos
+				break
+			}
+			lead = int(leader.Load())
+		}
+		column = lead
+	}
+
+	for s.linePos < column {
+		s.buf.WriteByte(' ')
+		s.linePos++
+	}
+}
@@ -0,0 +1,61 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle/pkg/color"
+)
+
+// separatorColumns returns the display column of the attrs separator on
+// each line of output.
+func separatorColumns(t *testing.T, output string) []int {
+	var cols []int
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		i := strings.Index(line, "│")
+		require.GreaterOrEqual(t, i, 0, "line has no separator: %q", line)
+		cols = append(cols, len([]rune(line[:i])))
+	}
+	return cols
+}
+
+func TestAttrsColumn(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	var buf bytes.Buffer
+
+	logger := slog.New(New(&buf, nil, WithAttrsColumn(50)))
+	logger.Info("short", "a", 1)
+	logger.Info("a somewhat longer message", "b", 2)
+	logger.Info("a message that is far too long for the column", "c", 3)
+
+	cols := separatorColumns(t, buf.String())
+	// The separator " │ " starts at the column, so the bar is one further.
+	assert.Equal(t, []int{51, 51}, cols[:2])
+	assert.Greater(t, cols[2], 50)
+}
+
+func TestAdaptiveAttrsColumn(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	var buf bytes.Buffer
+
+	logger := slog.New(New(&buf, nil, WithAdaptiveAttrsColumn(50)))
+	logger.Info("a longer message first", "a", 1)
+	logger.With("module", "db").Info("short", "b", 2)
+	logger.Info("tiny", "c", 3)
+	logger.Info("a message much wider than the limit allows to lead", "d", 4)
+	logger.Info("x", "e", 5)
+
+	cols := separatorColumns(t, buf.String())
+	assert.Equal(t, cols[0], cols[1])
+	assert.Equal(t, cols[0], cols[2])
+	assert.Greater(t, cols[3], 50)
+	assert.Equal(t, cols[0], cols[4], "a message over the limit must not move the column")
+}
@@ -0,0 +1,94 @@
+package trifle
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+var bannerColor = color.New(color.Faint)
+
+// WithBanner returns an Option that writes a dim line describing the
+// handler's configuration before the first record: the level, filtering,
+// color and where the output goes. When reading a log later, it tells what
+// was left out.
+func WithBanner() Option {
+	return func(h *TextHandler) {
+		h.banner = new(sync.Once)
+	}
+}
+
+// writeBanner writes the banner line, once for h and all its clones.
+func (h *commonHandler) writeBanner() {
+	h.banner.Do(func() {
+		line := bannerColor.Styled(h.safe(h.bannerText())).String() + "\n"
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		_, _ = h.w.Write([]byte(line))
+		if h.shadow != nil {
+			h.shadow.write([]byte(line))
+		}
+	})
+}
+
+// bannerText summarizes the configuration of h.
+func (h *commonHandler) bannerText() string {
+	level := slog.LevelInfo
+	if h.opts.Level != nil {
+		level = h.opts.Level.Level()
+	}
+
+	parts := []string{"trifle", "level=" + levelLabel(level)}
+
+	if h.dedup != nil {
+		dedup := h.dedup.window.String()
+		if len(h.dedup.keys) > 0 {
+			dedup += " by " + strings.Join(h.dedup.keys, ",")
+		}
+		parts = append(parts, "dedup="+dedup)
+	}
+
+	if h.terminalWidth > 0 {
+		parts = append(parts, fmt.Sprintf("width=%d", h.terminalWidth))
+	} else {
+		parts = append(parts, "width=unwrapped")
+	}
+
+	if color.NoColor {
+		parts = append(parts, "color=off")
+	} else {
+		parts = append(parts, "color=on")
+	}
+
+	parts = append(parts, "output="+writerName(h.w))
+	if h.shadow != nil && h.shadow.f != nil {
+		parts = append(parts, "shadow="+h.shadow.f.Name())
+	}
+	if n := len(h.alerts); n > 0 {
+		parts = append(parts, fmt.Sprintf("alerts=%d", n))
+	}
+
+	return "── " + strings.Join(parts, " ") + " ──"
+}
+
+// levelLabel returns the name trifle shows for level.
+func levelLabel(level slog.Level) string {
+	if name, ok := _levelToName[level]; ok {
+		return strings.Trim(name, " []")
+	}
+	return level.String()
+}
+
+// writerName describes w for the banner.
+func writerName(w any) string {
+	if f, ok := w.(*os.File); ok {
+		return f.Name()
+	}
+	return fmt.Sprintf("%T", w)
+}
@@ -0,0 +1,41 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestBanner(t *testing.T) {
+	color.NoColor = false
+
+	var buf bytes.Buffer
+
+	shadow := filepath.Join(t.TempDir(), "session.log")
+	handler := New(&buf, &slog.HandlerOptions{Level: Trace},
+		WithBanner(), WithTerminalWidth(80), WithShadowFile(shadow), WithDedupKey("peer"))
+	defer handler.Close()
+
+	logger := slog.New(handler)
+	logger.With("module", "api").Info("first")
+	logger.Info("second")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+
+	assert.Equal(t, bannerColor.Sprint("── trifle level=TRACE dedup=1s by peer width=80 color=on output=*bytes.Buffer shadow="+shadow+" ──"), lines[0])
+	assert.Contains(t, lines[1], "first")
+}
+
+func TestNoBannerByDefault(t *testing.T) {
+	var buf bytes.Buffer
+
+	slog.New(New(&buf, nil, PresetTest)).Info("only")
+	assert.NotContains(t, buf.String(), "trifle")
+}
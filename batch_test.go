@@ -0,0 +1,161 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writes int
+	err    error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writes++
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.buf.Write(p)
+}
+
+func (c *countingWriter) state() (string, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.buf.String(), c.writes
+}
+
+func TestBatchWriterCoalesces(t *testing.T) {
+	var cw countingWriter
+
+	bw := NewBatchWriter(&cw, BatchOptions{MaxDelay: time.Hour})
+	logger := slog.New(New(bw, nil))
+
+	for i := 0; i < 100; i++ {
+		logger.Info("record", "n", i)
+	}
+
+	_, writes := cw.state()
+	assert.Equal(t, 0, writes)
+
+	require.NoError(t, bw.Flush())
+
+	output, writes := cw.state()
+	assert.Equal(t, 1, writes)
+	assert.Equal(t, 100, bytes.Count([]byte(output), []byte("record")))
+}
+
+func TestBatchWriterLimits(t *testing.T) {
+	var cw countingWriter
+
+	bw := NewBatchWriter(&cw, BatchOptions{MaxBytes: 10, MaxDelay: time.Hour})
+	_, err := bw.Write([]byte("0123456789"))
+	require.NoError(t, err)
+
+	_, writes := cw.state()
+	assert.Equal(t, 1, writes)
+
+	bw = NewBatchWriter(&cw, BatchOptions{MaxDelay: time.Millisecond})
+	_, err = bw.Write([]byte("delayed"))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		output, _ := cw.state()
+		return bytes.HasSuffix([]byte(output), []byte("delayed"))
+	}, time.Second, time.Millisecond)
+}
+
+func TestBatchWriterReportsErrors(t *testing.T) {
+	cw := countingWriter{err: errors.New("disk full")}
+
+	bw := NewBatchWriter(&cw, BatchOptions{MaxDelay: time.Hour})
+	_, err := bw.Write([]byte("lost"))
+	require.NoError(t, err)
+
+	assert.EqualError(t, bw.Flush(), "disk full")
+	assert.NoError(t, bw.Flush())
+}
+
+func TestHandleBatchWritesOnce(t *testing.T) {
+	var cw countingWriter
+
+	h := New(&cw, &slog.HandlerOptions{Level: slog.LevelInfo}, WithTerminalWidth(0))
+
+	records := []slog.Record{
+		slog.NewRecord(time.Now(), slog.LevelInfo, "first", 0),
+		slog.NewRecord(time.Now(), slog.LevelDebug, "hidden", 0),
+		slog.NewRecord(time.Now(), slog.LevelWarn, "second", 0),
+	}
+	records[0].AddAttrs(slog.Int("n", 1))
+
+	require.NoError(t, h.HandleBatch(context.Background(), records))
+
+	output, writes := cw.state()
+	assert.Equal(t, 1, writes)
+	assert.True(t, MatchesLine(output, `\[INFO\]\s+first │ n: 1`))
+	assert.True(t, MatchesLine(output, `\[WARN\]\s+second`))
+	assert.NotContains(t, output, "hidden")
+
+	stats := h.Stats()
+	assert.Equal(t, uint64(2), stats.Total())
+	assert.Equal(t, uint64(len(output)), stats.Bytes)
+}
+
+func TestHandleBatchSplitsByWriter(t *testing.T) {
+	var out, errOut countingWriter
+
+	h := CLI(&out, &errOut, WithTerminalWidth(0))
+	require.NoError(t, h.HandleBatch(context.Background(), []slog.Record{
+		slog.NewRecord(time.Now(), slog.LevelInfo, "one", 0),
+		slog.NewRecord(time.Now(), slog.LevelError, "two", 0),
+		slog.NewRecord(time.Now(), slog.LevelInfo, "three", 0),
+	}))
+
+	stdout, writes := out.state()
+	assert.Equal(t, 1, writes)
+	assert.True(t, MatchesLine(stdout, "one"))
+	assert.True(t, MatchesLine(stdout, "three"))
+
+	stderr, writes := errOut.state()
+	assert.Equal(t, 1, writes)
+	assert.Contains(t, Plain(stderr), "two")
+}
+
+func TestHandleBatchReportsErrors(t *testing.T) {
+	cw := countingWriter{err: errors.New("disk full")}
+
+	h := New(&cw, nil, WithTerminalWidth(0))
+	err := h.HandleBatch(context.Background(), []slog.Record{
+		slog.NewRecord(time.Now(), slog.LevelInfo, "one", 0),
+		slog.NewRecord(time.Now(), slog.LevelInfo, "two", 0),
+	})
+
+	assert.EqualError(t, err, "disk full")
+	assert.Equal(t, uint64(2), h.Stats().WriteErrors)
+}
+
+func TestLineWriterBatchesLines(t *testing.T) {
+	var cw countingWriter
+
+	lw := NewLineWriter(slog.New(New(&cw, nil, WithTerminalWidth(0))), slog.LevelInfo)
+	_, err := lw.Write([]byte("a\nb\nc\n"))
+	require.NoError(t, err)
+
+	output, writes := cw.state()
+	assert.Equal(t, 1, writes)
+	assert.Equal(t, 3, strings.Count(output, "\n"))
+}
@@ -0,0 +1,60 @@
+package trifle
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func benchmarkHandler(b *testing.B, h slog.Handler) {
+	logger := slog.New(h)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.LogAttrs(ctx, slog.LevelInfo, "request handled",
+			slog.String("method", "GET"),
+			slog.String("path", "/api/users"),
+			slog.Int("status", 200),
+			slog.Duration("took", 12*time.Millisecond),
+			slog.Bool("cached", false),
+		)
+	}
+}
+
+func BenchmarkTextHandler(b *testing.B) {
+	benchmarkHandler(b, New(io.Discard, nil, WithTerminalWidth(120)))
+}
+
+func BenchmarkTextHandlerUnwrapped(b *testing.B) {
+	benchmarkHandler(b, New(io.Discard, nil, WithTerminalWidth(0)))
+}
+
+func BenchmarkTextHandlerWithAttrs(b *testing.B) {
+	benchmarkHandler(b, New(io.Discard, nil, WithTerminalWidth(120), PresetServer).
+		WithAttrs([]slog.Attr{slog.String("module", "api"), slog.String("request_id", "req-1")}))
+}
+
+func BenchmarkSlogTextHandler(b *testing.B) {
+	benchmarkHandler(b, slog.NewTextHandler(io.Discard, nil))
+}
+
+func BenchmarkHandleBatch(b *testing.B) {
+	h := New(io.Discard, nil, WithTerminalWidth(120))
+
+	records := make([]slog.Record, 100)
+	for i := range records {
+		records[i] = slog.NewRecord(time.Now(), slog.LevelInfo, "request handled", 0)
+		records[i].AddAttrs(slog.String("method", "GET"), slog.Int("status", 200))
+	}
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = h.HandleBatch(ctx, records)
+	}
+}
@@ -0,0 +1,155 @@
+package trifle
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WithBinarySafe returns an Option that guarantees the output holds only
+// printable characters, newlines and tabs, and the handler's own styling:
+// SGR sequences for colors and OSC 8 sequences for hyperlinks. Control
+// characters, invalid UTF-8 and other unprintable characters in messages,
+// keys, group names and modules are escaped the way they are in values,
+// as in \x1b or \u202e, so that a record can't move the cursor, retitle
+// the window or reorder the text of a terminal it is shown on.
+//
+// Messages keep their newlines. Lines of a [NewRawLineWriter] lose their
+// colors, which are escaped like the rest. Use it when records carry input
+// from outside, or when trifle writes to a terminal that isn't under your
+// control. [CheckBinarySafe] checks the guarantee.
+func WithBinarySafe() Option {
+	return func(h *TextHandler) {
+		h.binarySafe = true
+	}
+}
+
+// safe returns s escaped when h is binary safe, and s otherwise.
+func (h *commonHandler) safe(s string) string {
+	if !h.binarySafe {
+		return s
+	}
+	return escapeText(s, false)
+}
+
+// escapeText returns s with every unprintable character but tabs, and
+// newlines when keepNewlines is set, written as an escape sequence. Spaces
+// other than ASCII ones, such as non-breaking spaces, count as printable.
+func escapeText(s string, keepNewlines bool) string {
+	if textIsSafe(s, keepNewlines) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&b, `\x%02x`, s[i])
+		case r == '\t' || r == '\n' && keepNewlines || unicode.IsGraphic(r):
+			b.WriteString(s[i : i+size])
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r < 0x80:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		case r < 0x10000:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			fmt.Fprintf(&b, `\U%08x`, r)
+		}
+		i += size
+	}
+	return b.String()
+}
+
+func textIsSafe(s string, keepNewlines bool) bool {
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c >= 0x20 && c < 0x7f || c == '\t' || c == '\n' && keepNewlines {
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 || !unicode.IsGraphic(r) {
+			return false
+		}
+		i += size
+	}
+	return true
+}
+
+// CheckBinarySafe reports the first byte of output, as written by a
+// [TextHandler] with [WithBinarySafe], that breaks its guarantee: a control
+// character other than newline or tab, an escape sequence other than SGR
+// or OSC 8, invalid UTF-8, or an unprintable character. It returns nil if
+// there is none. Use it in tests of programs that log input they don't
+// control.
+func CheckBinarySafe(output []byte) error {
+	for i := 0; i < len(output); {
+		c := output[i]
+		switch {
+		case c == 0x1b:
+			n := safeEscapeLen(output[i:])
+			if n == 0 {
+				return fmt.Errorf("trifle: escape sequence at offset %d is not SGR or OSC 8", i)
+			}
+			i += n
+			continue
+		case c == '\n' || c == '\t':
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRune(output[i:])
+		if r == utf8.RuneError && size == 1 {
+			return fmt.Errorf("trifle: invalid UTF-8 at offset %d", i)
+		}
+		if !unicode.IsGraphic(r) {
+			return fmt.Errorf("trifle: unprintable character %U at offset %d", r, i)
+		}
+		i += size
+	}
+	return nil
+}
+
+// safeEscapeLen returns the length of the SGR or OSC 8 sequence b starts
+// with, or 0 if it doesn't start with one.
+func safeEscapeLen(b []byte) int {
+	if len(b) < 2 {
+		return 0
+	}
+
+	switch b[1] {
+	case '[':
+		// CSI parameters, then "m".
+		for i := 2; i < len(b); i++ {
+			switch c := b[i]; {
+			case c >= '0' && c <= '9' || c == ';' || c == ':':
+			case c == 'm':
+				return i + 1
+			default:
+				return 0
+			}
+		}
+	case ']':
+		// "8;params;uri", ended by ST, without control characters.
+		if len(b) < 4 || b[2] != '8' || b[3] != ';' {
+			return 0
+		}
+		for i := 4; i < len(b); i++ {
+			switch c := b[i]; {
+			case c == 0x1b:
+				if i+1 < len(b) && b[i+1] == '\\' {
+					return i + 2
+				}
+				return 0
+			case c < 0x20 || c == 0x7f:
+				return 0
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,110 @@
+package trifle
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle/pkg/color"
+)
+
+// hostile holds what a terminal must never receive from a record: a color
+// change, a window title, a bell, a NUL, a C1 CSI, a bidi override and
+// invalid UTF-8.
+const hostile = "a\x1b[31mred\x1b]0;title\a\x00\u009b2J\u202e\xffz"
+
+func binarySafeOutput(t *testing.T, log func(*slog.Logger), options ...Option) string {
+	t.Helper()
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	var buf bytes.Buffer
+	h := New(&buf, nil, append([]Option{WithTerminalWidth(80), WithBinarySafe()}, options...)...)
+	log(slog.New(h))
+
+	require.NoError(t, CheckBinarySafe(buf.Bytes()), "%q", buf.String())
+	return buf.String()
+}
+
+func TestBinarySafe(t *testing.T) {
+	tests := map[string]func(*slog.Logger){
+		"message":   func(l *slog.Logger) { l.Info(hostile) },
+		"multiline": func(l *slog.Logger) { l.Info(hostile + "\n" + hostile) },
+		"key":       func(l *slog.Logger) { l.Info("m", hostile, 1) },
+		"value":     func(l *slog.Logger) { l.Info("m", "k", hostile) },
+		"lines":     func(l *slog.Logger) { l.Info("m", "k", hostile+"\n\t"+hostile) },
+		"error":     func(l *slog.Logger) { l.Info("m", "k", errors.New(hostile)) },
+		"bytes":     func(l *slog.Logger) { l.Info("m", "k", []byte(hostile)) },
+		"group":     func(l *slog.Logger) { l.WithGroup(hostile).Info("m", "k", 1) },
+		"attrGroup": func(l *slog.Logger) { l.Info("m", slog.Group(hostile, "k", 1)) },
+		"with":      func(l *slog.Logger) { l.WithGroup(hostile).With(hostile, hostile).Info("m") },
+		"module":    func(l *slog.Logger) { l.With(ModuleKey, hostile).Info("m") },
+		"raw": func(l *slog.Logger) {
+			w := NewRawLineWriter(l, slog.LevelInfo)
+			_, _ = w.Write([]byte(hostile + "\n"))
+		},
+	}
+	for name, log := range tests {
+		t.Run(name, func(t *testing.T) {
+			out := binarySafeOutput(t, log)
+			assert.Contains(t, out, `a\x1b[31mred`)
+		})
+	}
+}
+
+func TestBinarySafeKeepsStyling(t *testing.T) {
+	out := binarySafeOutput(t, func(l *slog.Logger) {
+		l.With(ModuleKey, "db").Warn("slow\nquery", "id", "3f2b8c1e-9d4a-4b6e-8f1a-2c3d4e5f6a7b", "url", "https://example.com/")
+	}, WithShortIDs(), WithAddresses(), WithContextKey("req"))
+
+	assert.Contains(t, out, "\x1b[")
+	assert.Contains(t, out, "slow\nquery")
+}
+
+func TestBinarySafeContextValue(t *testing.T) {
+	out := binarySafeOutput(t, func(l *slog.Logger) {
+		l.With("req", hostile).Info("m")
+	}, WithContextKey("req"))
+
+	assert.Contains(t, out, `\u202e`)
+}
+
+func TestCheckBinarySafe(t *testing.T) {
+	for s, ok := range map[string]bool{
+		"plain text\twith tab\n":                     true,
+		"\x1b[2;1mkey\x1b[22m: ünïcode":              true,
+		"\x1b]8;;https://x/\x1b\\link\x1b]8;;\x1b\\": true,
+		"bell\a":         false,
+		"\x1b[2J":        false,
+		"\x1b]0;title\a": false,
+		"\xff":           false,
+		"\u202e":         false,
+		"carriage\r":     false,
+	} {
+		err := CheckBinarySafe([]byte(s))
+		assert.Equal(t, ok, err == nil, "%q: %v", s, err)
+	}
+}
+
+func FuzzBinarySafe(f *testing.F) {
+	f.Add("msg", "key", "value", "group", "module")
+	f.Add(hostile, hostile, hostile, hostile, hostile)
+	f.Add("a\nb", "\t", "line\nline\r\n", "", "\x00")
+
+	f.Fuzz(func(t *testing.T, msg, key, value, group, module string) {
+		var buf bytes.Buffer
+		h := New(&buf, &slog.HandlerOptions{AddSource: true}, WithTerminalWidth(40), WithBinarySafe(),
+			WithVisibleWhitespace(), WithShortIDs(), WithAddresses(), WithLocale("fr"))
+
+		log := slog.New(h).With(ModuleKey, module)
+		log.WithGroup(group).Info(msg, key, value, "n", 123456, "price", Money{Amount: 1, Currency: value})
+		log.Info(msg, slog.Group(group, key, []byte(value)), "err", errors.New(value))
+
+		if err := CheckBinarySafe(buf.Bytes()); err != nil {
+			t.Fatalf("%v in %q", err, buf.String())
+		}
+	})
+}
@@ -0,0 +1,197 @@
+package trifle
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// Backpressure is what a [Broker] does with a record for a subscriber
+// whose buffer is full.
+type Backpressure int
+
+const (
+	// BackpressureDropNewest drops the record for that subscriber.
+	BackpressureDropNewest Backpressure = iota
+
+	// BackpressureDropOldest drops the oldest record the subscriber
+	// hasn't received yet to make room, so it sees the latest ones.
+	BackpressureDropOldest
+
+	// BackpressureBlock has Handle wait until the subscriber has room, or
+	// the context of the record is done, in which case it is dropped.
+	// A slow subscriber slows down logging.
+	BackpressureBlock
+)
+
+// Broker is a [slog.Handler] passing records to subscribers, for
+// applications that show logs in their own interface, such as a pane of a
+// desktop or terminal UI, while still writing them elsewhere:
+//
+//	broker := trifle.NewBroker()
+//	slog.SetDefault(slog.New(trifle.Multi(sink, broker)))
+//
+//	for r := range broker.Subscribe(ctx, 100, trifle.BackpressureDropOldest) {
+//		pane.Append(r)
+//	}
+//
+// Records carry the attributes added with WithAttrs and WithGroup, and
+// their module as a "module" attribute, as from [Ring.Records]. A Broker
+// is enabled at every level while it has subscribers, and at none without.
+type Broker struct {
+	chain attrChain
+	state *brokerState
+}
+
+// brokerState is shared by a Broker and the handlers derived from it.
+type brokerState struct {
+	mu      sync.Mutex
+	subs    map[*subscription]struct{}
+	dropped atomic.Uint64
+}
+
+type subscription struct {
+	c      chan slog.Record
+	policy Backpressure
+	done   chan struct{} // closed when the subscription ends
+
+	// sending counts the records being sent, which must finish before c
+	// is closed.
+	sending sync.WaitGroup
+}
+
+// NewBroker returns a Broker without subscribers.
+func NewBroker() *Broker {
+	return &Broker{state: &brokerState{subs: make(map[*subscription]struct{})}}
+}
+
+// Subscribe returns a channel receiving the records handled from now on,
+// holding up to buffer of them that haven't been received yet, with policy
+// deciding what happens once it is full. A buffer below one is taken as
+// one. The subscription ends when ctx is done, and the channel is closed
+// then.
+func (b *Broker) Subscribe(ctx context.Context, buffer int, policy Backpressure) <-chan slog.Record {
+	sub := &subscription{
+		c:      make(chan slog.Record, max(buffer, 1)),
+		policy: policy,
+		done:   make(chan struct{}),
+	}
+
+	st := b.state
+	st.mu.Lock()
+	st.subs[sub] = struct{}{}
+	st.mu.Unlock()
+
+	context.AfterFunc(ctx, func() {
+		st.mu.Lock()
+		delete(st.subs, sub)
+		st.mu.Unlock()
+
+		close(sub.done)
+		sub.sending.Wait()
+		close(sub.c)
+	})
+
+	return sub.c
+}
+
+// Subscribers returns the number of subscriptions that haven't ended.
+func (b *Broker) Subscribers() int {
+	b.state.mu.Lock()
+	defer b.state.mu.Unlock()
+	return len(b.state.subs)
+}
+
+// Dropped returns how many records subscribers missed because their
+// buffers were full.
+func (b *Broker) Dropped() uint64 {
+	return b.state.dropped.Load()
+}
+
+// Enabled reports whether b has subscribers.
+func (b *Broker) Enabled(context.Context, slog.Level) bool {
+	return b.Subscribers() > 0
+}
+
+// Handle passes a copy of r with the attributes added to b to every
+// subscriber.
+func (b *Broker) Handle(ctx context.Context, r slog.Record) error {
+	st := b.state
+	st.mu.Lock()
+	if len(st.subs) == 0 {
+		st.mu.Unlock()
+		return nil
+	}
+	subs := make([]*subscription, 0, len(st.subs))
+	for sub := range st.subs {
+		sub.sending.Add(1)
+		subs = append(subs, sub)
+	}
+	st.mu.Unlock()
+
+	published := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	published.AddAttrs(b.chain.attrs(ctx, r)...)
+	if b.chain.module != "" {
+		published.AddAttrs(slog.String(ModuleKey, b.chain.module))
+	}
+
+	for _, sub := range subs {
+		if sub.send(ctx, published.Clone()) {
+			st.dropped.Add(1)
+		}
+		sub.sending.Done()
+	}
+	return nil
+}
+
+// WithAttrs returns a Broker adding attrs to every record, publishing to
+// the same subscribers.
+func (b *Broker) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Broker{chain: b.chain.withAttrs(attrs), state: b.state}
+}
+
+// WithGroup returns a Broker nesting later attributes in group name,
+// publishing to the same subscribers.
+func (b *Broker) WithGroup(name string) slog.Handler {
+	return &Broker{chain: b.chain.withGroup(name), state: b.state}
+}
+
+// send passes r to the subscriber according to its policy, reporting
+// whether a record was dropped: r, or an older one to make room for it.
+func (sub *subscription) send(ctx context.Context, r slog.Record) (dropped bool) {
+	select {
+	case <-sub.done:
+		return false
+	case sub.c <- r:
+		return false
+	default:
+	}
+
+	switch sub.policy {
+	case BackpressureDropOldest:
+		for {
+			select {
+			case <-sub.c:
+				dropped = true
+			default:
+			}
+			select {
+			case sub.c <- r:
+				return dropped
+			default:
+			}
+		}
+	case BackpressureBlock:
+		select {
+		case sub.c <- r:
+			return false
+		case <-sub.done:
+			return false
+		case <-ctx.Done():
+			return true
+		}
+	default:
+		return true
+	}
+}
@@ -0,0 +1,117 @@
+package trifle
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// messages receives what is buffered in c, up to its closing.
+func messages(c <-chan slog.Record) []string {
+	var msgs []string
+	for {
+		select {
+		case r, ok := <-c:
+			if !ok {
+				return msgs
+			}
+			msgs = append(msgs, r.Message)
+		default:
+			return msgs
+		}
+	}
+}
+
+func TestBrokerPublishes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	broker := NewBroker()
+	logger := slog.New(broker).With("module", "ui").WithGroup("req")
+
+	logger.Info("before anyone listens")
+	assert.Zero(t, broker.Subscribers())
+
+	first := broker.Subscribe(ctx, 10, BackpressureDropNewest)
+	second := broker.Subscribe(ctx, 10, BackpressureDropNewest)
+	logger.Debug("hello", "id", 7)
+
+	for _, c := range []<-chan slog.Record{first, second} {
+		r := <-c
+		assert.Equal(t, "hello", r.Message)
+		assert.Equal(t, slog.LevelDebug, r.Level)
+
+		var attrs []string
+		r.Attrs(func(a slog.Attr) bool {
+			attrs = append(attrs, a.String())
+			return true
+		})
+		assert.Equal(t, []string{"req=[id=7]", "module=ui"}, attrs)
+	}
+
+	cancel()
+	_, open := <-first
+	assert.False(t, open, "the channel is closed once the subscription ends")
+	assert.Eventually(t, func() bool { return broker.Subscribers() == 0 }, time.Second, time.Millisecond)
+}
+
+func TestBrokerBackpressure(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker := NewBroker()
+	newest := broker.Subscribe(ctx, 2, BackpressureDropNewest)
+	oldest := broker.Subscribe(ctx, 2, BackpressureDropOldest)
+
+	logger := slog.New(broker)
+	for _, msg := range []string{"one", "two", "three", "four"} {
+		logger.Info(msg)
+	}
+
+	assert.Equal(t, []string{"one", "two"}, messages(newest))
+	assert.Equal(t, []string{"three", "four"}, messages(oldest))
+	assert.Equal(t, uint64(4), broker.Dropped())
+}
+
+func TestBrokerBlocks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker := NewBroker()
+	c := broker.Subscribe(ctx, 1, BackpressureBlock)
+	logger := slog.New(broker)
+
+	logger.Info("one")
+	handled := make(chan struct{})
+	go func() {
+		logger.Info("two")
+		close(handled)
+	}()
+
+	select {
+	case <-handled:
+		t.Fatal("Handle returned while the subscriber was full")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	assert.Equal(t, "one", (<-c).Message)
+	<-handled
+	assert.Equal(t, "two", (<-c).Message)
+
+	// A record whose context ends while waiting is dropped.
+	logger.Info("three")
+	rctx, rcancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer rcancel()
+	logger.InfoContext(rctx, "four")
+	assert.Equal(t, uint64(1), broker.Dropped())
+
+	// Ending the subscription releases a blocked Handle.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	require.NotPanics(t, func() { logger.Info("five") })
+	assert.Equal(t, []string{"three"}, messages(c))
+}
@@ -5,7 +5,12 @@ import (
 	"unicode/utf8"
 )
 
-// buffer adapted from go/src/fmt/print.go
+// Buffer is a byte slice with write methods, used to render records.
+// Buffers are pooled: obtain one with [NewBuffer] and return it with
+// [Buffer.Free] once its contents are no longer referenced, so that code
+// extending trifle's rendering shares the handler's allocations.
+//
+// Adapted from go/src/fmt/print.go.
 type Buffer []byte
 
 // Having an initial size gives a dramatic speedup.
@@ -16,12 +21,14 @@ var bufPool = sync.Pool{
 	},
 }
 
+// NewBuffer returns an empty Buffer from the pool.
 func NewBuffer() *Buffer {
 	buf := bufPool.Get().(*Buffer)
 	buf.Reset()
 	return buf
 }
 
+// Free returns b to the pool. b must not be used afterwards.
 func (b *Buffer) Free() {
 	// To reduce peak allocation, return only smaller buffers to the pool.
 	const maxBufferSize = 16 << 10
@@ -31,33 +38,41 @@ func (b *Buffer) Free() {
 	}
 }
 
+// Reset empties b, keeping its capacity.
 func (b *Buffer) Reset() {
 	b.SetLen(0)
 }
 
+// Write appends p to b. It always returns len(p), nil.
 func (b *Buffer) Write(p []byte) (int, error) {
 	*b = append(*b, p...)
 	return len(p), nil
 }
 
+// WriteString appends s to b. It always returns len(s), nil.
 func (b *Buffer) WriteString(s string) (int, error) {
 	*b = append(*b, s...)
 	return len(s), nil
 }
 
+// WriteByte appends c to b. It always returns nil.
 func (b *Buffer) WriteByte(c byte) error {
 	*b = append(*b, c)
 	return nil
 }
 
+// WriteNewLine appends a newline unless b already ends with one, so that
+// a record whose last value spanned several lines isn't followed by a
+// blank line.
 func (b *Buffer) WriteNewLine() {
-	if len(*b) > 0 && (*b)[0] == '\n' {
+	if len(*b) > 0 && (*b)[len(*b)-1] == '\n' {
 		return
 	}
 
 	*b = append(*b, '\n')
 }
 
+// WriteRune appends the UTF-8 encoding of r to b. It always returns nil.
 func (b *Buffer) WriteRune(r rune) error {
 	// Compare as uint32 to correctly handle negative runes.
 	if uint32(r) < utf8.RuneSelf {
@@ -68,14 +83,23 @@ func (b *Buffer) WriteRune(r rune) error {
 	return nil
 }
 
+// Bytes returns the contents of b. The slice is only valid until b is
+// modified or freed.
+func (b *Buffer) Bytes() []byte {
+	return *b
+}
+
+// String returns a copy of the contents of b as a string.
 func (b *Buffer) String() string {
 	return string(*b)
 }
 
+// Len returns the number of bytes in b.
 func (b *Buffer) Len() int {
 	return len(*b)
 }
 
+// SetLen truncates b to n bytes.
 func (b *Buffer) SetLen(n int) {
 	*b = (*b)[:n]
 }
@@ -0,0 +1,26 @@
+package trifle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBufferWriteNewLine(t *testing.T) {
+	buf := NewBuffer()
+	defer buf.Free()
+
+	buf.WriteNewLine()
+	assert.Equal(t, "\n", buf.String())
+
+	buf.Reset()
+	buf.WriteString("line")
+	buf.WriteNewLine()
+	buf.WriteNewLine()
+	assert.Equal(t, "line\n", buf.String())
+
+	buf.Reset()
+	buf.WriteString("\nstarts with a newline")
+	buf.WriteNewLine()
+	assert.Equal(t, "\nstarts with a newline\n", string(buf.Bytes()))
+}
@@ -0,0 +1,174 @@
+package trifle
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// CBOR major types, the top three bits of the first byte of a value.
+const (
+	cborUint   = 0 << 5
+	cborNegInt = 1 << 5
+	cborBytes  = 2 << 5
+	cborString = 3 << 5
+	cborArray  = 4 << 5
+	cborMap    = 5 << 5
+	cborTag    = 6 << 5
+	cborSimple = 7 << 5
+)
+
+// cborCodec writes and reads CBOR, as defined by RFC 8949. Tags are read
+// as the value they tag. Values of indefinite length are not supported.
+type cborCodec struct{}
+
+// appendHead appends the head of a value of the major type with the
+// argument n, in the shortest form.
+func (cborCodec) appendHead(b []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, major|27), n)
+	}
+}
+
+func (c cborCodec) appendMap(b []byte, n int) []byte {
+	return c.appendHead(b, cborMap, uint64(n))
+}
+
+func (c cborCodec) appendArray(b []byte, n int) []byte {
+	return c.appendHead(b, cborArray, uint64(n))
+}
+
+func (c cborCodec) appendString(b []byte, s string) []byte {
+	return append(c.appendHead(b, cborString, uint64(len(s))), s...)
+}
+
+func (c cborCodec) appendBytes(b []byte, bs []byte) []byte {
+	return append(c.appendHead(b, cborBytes, uint64(len(bs))), bs...)
+}
+
+func (c cborCodec) appendInt(b []byte, i int64) []byte {
+	if i < 0 {
+		return c.appendHead(b, cborNegInt, uint64(^i))
+	}
+	return c.appendHead(b, cborUint, uint64(i))
+}
+
+func (c cborCodec) appendUint(b []byte, u uint64) []byte {
+	return c.appendHead(b, cborUint, u)
+}
+
+func (cborCodec) appendFloat(b []byte, f float64) []byte {
+	return binary.BigEndian.AppendUint64(append(b, cborSimple|27), math.Float64bits(f))
+}
+
+func (cborCodec) appendBool(b []byte, v bool) []byte {
+	if v {
+		return append(b, cborSimple|21)
+	}
+	return append(b, cborSimple|20)
+}
+
+func (c cborCodec) decode(data []byte, depth int) (any, []byte, error) {
+	if depth > maxBinaryDepth {
+		return nil, nil, errBinaryDepth
+	}
+	if len(data) == 0 {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+
+	b, data := data[0], data[1:]
+	major, info := b&0xe0, b&0x1f
+
+	if major == cborSimple {
+		switch info {
+		case 20:
+			return false, data, nil
+		case 21:
+			return true, data, nil
+		case 22, 23:
+			return nil, data, nil
+		case 25:
+			n, rest, err := binaryNumber(data, 2)
+			return cborHalf(uint16(n)), rest, err
+		case 26:
+			n, rest, err := binaryNumber(data, 4)
+			return float64(math.Float32frombits(uint32(n))), rest, err
+		case 27:
+			n, rest, err := binaryNumber(data, 8)
+			return math.Float64frombits(n), rest, err
+		}
+		return nil, nil, fmt.Errorf("unsupported CBOR simple value %d", info)
+	}
+
+	var n uint64
+	switch {
+	case info < 24:
+		n = uint64(info)
+	case info <= 27:
+		var err error
+		if n, data, err = binaryNumber(data, 1<<(info-24)); err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported CBOR argument 0x%02x", b)
+	}
+
+	switch major {
+	case cborUint:
+		return n, data, nil
+	case cborNegInt:
+		if n > math.MaxInt64 {
+			return nil, nil, fmt.Errorf("CBOR integer -1-%d out of range", n)
+		}
+		return ^int64(n), data, nil
+	case cborBytes:
+		bs, rest, err := binaryTake(data, n)
+		if err != nil {
+			return nil, nil, err
+		}
+		return append([]byte(nil), bs...), rest, nil
+	case cborString:
+		s, rest, err := binaryTake(data, n)
+		return string(s), rest, err
+	case cborArray:
+		return decodeBinaryArray(c, data, n, depth)
+	case cborMap:
+		return decodeBinaryMap(c, data, n, depth)
+	default: // cborTag
+		return c.decode(data, depth+1)
+	}
+}
+
+// cborHalf returns the value of a half-precision float, as in appendix D
+// of RFC 8949.
+func cborHalf(h uint16) float64 {
+	exp, mant := int(h>>10)&0x1f, float64(h&0x3ff)
+
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
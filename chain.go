@@ -0,0 +1,88 @@
+package trifle
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+)
+
+// attrChain tracks what WithAttrs and WithGroup applied to a handler that
+// stores records rather than rendering them, so each record can be saved
+// with all of its attributes. Like [TextHandler], it takes "module"
+// attributes out as the record's module.
+type attrChain struct {
+	module string
+	goas   []groupOrAttrs
+}
+
+// groupOrAttrs is either a group opened by WithGroup or attributes added
+// by WithAttrs, in the order they were applied.
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+func (c attrChain) withAttrs(attrs []slog.Attr) attrChain {
+	var goodAttrs []slog.Attr
+
+	for _, a := range attrs {
+		if a.Key == ModuleKey && a.Value.Kind() == slog.KindString {
+			if c.module == "" {
+				c.module = a.Value.String()
+			} else {
+				c.module += "." + a.Value.String()
+			}
+		} else {
+			goodAttrs = append(goodAttrs, a)
+		}
+	}
+
+	if len(goodAttrs) > 0 {
+		c.goas = append(slices.Clip(c.goas), groupOrAttrs{attrs: goodAttrs})
+	}
+	return c
+}
+
+func (c attrChain) withGroup(name string) attrChain {
+	if name != "" {
+		c.goas = append(slices.Clip(c.goas), groupOrAttrs{group: name})
+	}
+	return c
+}
+
+// attrs returns the attributes of rec nested in the chain's groups, after
+// the chain's own attributes, followed by the values carried by ctx that
+// neither provides.
+func (c attrChain) attrs(ctx context.Context, rec slog.Record) []slog.Attr {
+	attrs := make([]slog.Attr, 0, rec.NumAttrs())
+	present := make(map[string]bool, rec.NumAttrs())
+	rec.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		present[a.Key] = true
+		return true
+	})
+
+	// Fold the attributes and groups around the record's own, innermost
+	// first.
+	for i := len(c.goas) - 1; i >= 0; i-- {
+		goa := c.goas[i]
+		if goa.group != "" {
+			if len(attrs) > 0 {
+				attrs = []slog.Attr{{Key: goa.group, Value: slog.GroupValue(attrs...)}}
+			}
+			continue
+		}
+		for _, a := range goa.attrs {
+			present[a.Key] = true
+		}
+		attrs = append(slices.Clone(goa.attrs), attrs...)
+	}
+
+	for _, a := range contextAttrs(ctx) {
+		if !present[a.Key] {
+			attrs = append(attrs, a)
+		}
+	}
+
+	return attrs
+}
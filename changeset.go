@@ -0,0 +1,104 @@
+package trifle
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+var (
+	changeAddColor    = color.New(color.FgGreen)
+	changeChangeColor = color.New(color.FgYellow)
+	changeRemoveColor = color.New(color.FgRed)
+	changeNoneColor   = color.New(color.Faint)
+)
+
+// ChangeSet counts the changes a plan makes, for infrastructure and
+// deployment tools that report them the way terraform plan does:
+//
+//	logger.Info("plan ready", "changes", trifle.ChangeSet{Add: 3, Change: 1, Remove: 2})
+//
+//	12:00:00.000 [INFO]  plan ready │ changes: +3 ~1 -2
+//
+// with the additions green, the changes yellow and the removals red, and
+// the counts that are zero dimmed. Other handlers see a group of add,
+// change and remove. The TextHandler renders any group of exactly those
+// three integers this way, so records read back by trifle cat keep it.
+type ChangeSet struct {
+	Add, Change, Remove int
+}
+
+// LogValue returns the counts as a group of add, change and remove.
+func (c ChangeSet) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("add", c.Add),
+		slog.Int("change", c.Change),
+		slog.Int("remove", c.Remove),
+	)
+}
+
+// String returns the counts in the form "+3 ~1 -2".
+func (c ChangeSet) String() string {
+	return fmt.Sprintf("+%d ~%d -%d", c.Add, c.Change, c.Remove)
+}
+
+// render returns c colored for the output.
+func (c ChangeSet) render() slog.Value {
+	part := func(sign string, n int, col *color.Color) string {
+		if n == 0 {
+			col = changeNoneColor
+		}
+		return col.Sprintf("%s%d", sign, n)
+	}
+	return slog.AnyValue(rendered(part("+", c.Add, changeAddColor) + " " +
+		part("~", c.Change, changeChangeColor) + " " +
+		part("-", c.Remove, changeRemoveColor)))
+}
+
+// changeSetOf returns the ChangeSet v is the group of, if it is one.
+func changeSetOf(v slog.Value) (ChangeSet, bool) {
+	var (
+		c    ChangeSet
+		seen int
+	)
+	attrs := v.Group()
+	if len(attrs) != 3 {
+		return c, false
+	}
+	for _, a := range attrs {
+		n, ok := changeCount(a.Value.Resolve())
+		if !ok {
+			return c, false
+		}
+		switch a.Key {
+		case "add":
+			c.Add = n
+			seen |= 1
+		case "change":
+			c.Change = n
+			seen |= 2
+		case "remove":
+			c.Remove = n
+			seen |= 4
+		default:
+			return c, false
+		}
+	}
+	return c, seen == 7
+}
+
+// changeCount returns the count v holds, if it is a whole number.
+func changeCount(v slog.Value) (int, bool) {
+	switch v.Kind() {
+	case slog.KindInt64:
+		return int(v.Int64()), true
+	case slog.KindUint64:
+		return int(v.Uint64()), v.Uint64() <= math.MaxInt32
+	case slog.KindFloat64:
+		f := v.Float64()
+		return int(f), f == math.Trunc(f) && math.Abs(f) <= math.MaxInt32
+	}
+	return 0, false
+}
@@ -0,0 +1,34 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestChangeSet(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	var buf bytes.Buffer
+	log := slog.New(New(&buf, nil, WithTerminalWidth(0)))
+	log.Info("plan ready", "changes", ChangeSet{Add: 3, Remove: 2})
+	log.Info("applied", slog.Group("changes", "remove", 1.0, "add", 0, "change", 4))
+	log.Info("partial", slog.Group("changes", "add", 1, "change", 2))
+
+	out := buf.String()
+	assert.Contains(t, out, "\x1b[32m+3\x1b[0m \x1b[2m~0\x1b[22m \x1b[31m-2\x1b[0m")
+	assert.True(t, MatchesLine(Plain(out), `applied │ changes: \+0 ~4 -1$`), Plain(out))
+	assert.True(t, ContainsAttr(out, "changes.add", 1))
+}
+
+func TestChangeSetOtherHandlers(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("plan", "changes", ChangeSet{Add: 1, Change: 2, Remove: 3})
+
+	assert.Contains(t, buf.String(), `"changes":{"add":1,"change":2,"remove":3}`)
+	assert.Equal(t, "+1 ~2 -3", ChangeSet{Add: 1, Change: 2, Remove: 3}.String())
+}
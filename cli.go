@@ -0,0 +1,33 @@
+package trifle
+
+import (
+	"io"
+	"log/slog"
+)
+
+// WithLevel returns an Option that sets the minimum level of records the
+// handler writes, as the Level field of [slog.HandlerOptions] does.
+func WithLevel(level slog.Leveler) Option {
+	return func(h *TextHandler) {
+		h.opts.Level = level
+	}
+}
+
+// CLI returns a [TextHandler] for command line tools, which writes Debug
+// and Info records to outW and Warn and Error records to errW, the split
+// users expect when they redirect a tool's output. Both streams share the
+// styling and a single lock, so records stay in order when both go to the
+// same terminal. The terminal width is taken from outW. If errW is nil,
+// all records go to outW.
+//
+// Records at Info and above are written unless [WithLevel] says otherwise.
+func CLI(outW, errW io.Writer, options ...Option) *TextHandler {
+	h := New(outW, nil, options...)
+	if !isNilWriter(errW) {
+		h.errW = consoleWriter(errW)
+		if s, ok := h.w.(*statusWriter); ok && isTerminal(errW) {
+			h.errW = s.sharing(h.errW)
+		}
+	}
+	return h
+}
@@ -0,0 +1,49 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCLI(t *testing.T) {
+	var out, errOut bytes.Buffer
+
+	logger := slog.New(CLI(&out, &errOut, WithLevel(slog.LevelDebug), PresetTest))
+	logger.Debug("resolving")
+	logger.With("module", "fetch").Info("downloaded")
+	logger.Warn("cache stale")
+	logger.Error("upload failed")
+
+	assert.Contains(t, out.String(), "resolving")
+	assert.Contains(t, out.String(), "downloaded")
+	assert.NotContains(t, out.String(), "cache stale")
+
+	assert.Contains(t, errOut.String(), "cache stale")
+	assert.Contains(t, errOut.String(), "upload failed")
+	assert.NotContains(t, errOut.String(), "downloaded")
+}
+
+func TestCLIDefaultLevel(t *testing.T) {
+	var out bytes.Buffer
+
+	logger := slog.New(CLI(&out, &out))
+	logger.Debug("hidden")
+	logger.Info("shown")
+
+	assert.NotContains(t, out.String(), "hidden")
+	assert.Contains(t, out.String(), "shown")
+}
+
+func TestCLINilErrorWriter(t *testing.T) {
+	var out bytes.Buffer
+
+	logger := slog.New(CLI(&out, (*bytes.Buffer)(nil), PresetTest))
+	logger.Info("downloaded")
+	logger.Error("upload failed")
+
+	assert.Contains(t, out.String(), "downloaded")
+	assert.Contains(t, out.String(), "upload failed")
+}
@@ -0,0 +1,372 @@
+package trifle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CloudHandler is a [slog.Handler] writing records as JSON lines in the
+// format a logging service ingests from the output of a program: Google
+// Cloud Logging with [NewGCP], the Embedded Metric Format of AWS
+// CloudWatch with [NewEMF], and the Elastic Common Schema with [NewECS].
+// Records are written with all of their
+// attributes, those of WithAttrs and WithGroup included; the fields the
+// service gives a meaning to are at the top level.
+type CloudHandler struct {
+	chain  attrChain
+	level  slog.Leveler
+	base   slog.Handler // the JSON handler of the standard library, without groups
+	fields func(r slog.Record, module string, attrs []slog.Attr) []slog.Attr
+}
+
+// Enabled reports whether c writes records at level.
+func (c *CloudHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if c.level != nil {
+		minLevel = c.level.Level()
+	}
+	return level >= minLevel
+}
+
+// Handle writes r as one JSON line.
+func (c *CloudHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	out.AddAttrs(c.fields(r, c.chain.module, c.chain.attrs(ctx, r))...)
+	return c.base.Handle(ctx, out)
+}
+
+// WithAttrs returns a CloudHandler adding attrs to every record, writing
+// to the same output.
+func (c *CloudHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	nc := *c
+	nc.chain = c.chain.withAttrs(attrs)
+	return &nc
+}
+
+// WithGroup returns a CloudHandler nesting later attributes in group name,
+// writing to the same output.
+func (c *CloudHandler) WithGroup(name string) slog.Handler {
+	nc := *c
+	nc.chain = c.chain.withGroup(name)
+	return &nc
+}
+
+// Fields of the structured logging format of Google Cloud Logging.
+const (
+	GCPTraceKey          = "logging.googleapis.com/trace"
+	GCPSpanIDKey         = "logging.googleapis.com/spanId"
+	GCPLabelsKey         = "logging.googleapis.com/labels"
+	GCPSourceLocationKey = "logging.googleapis.com/sourceLocation"
+)
+
+// GCPOptions configures [NewGCP]. The zero value writes records at Info
+// and above.
+type GCPOptions struct {
+	// Level is the minimum level written. Nil means Info.
+	Level slog.Leveler
+
+	// AddSource adds the source position of the log call to each record,
+	// as its sourceLocation.
+	AddSource bool
+
+	// ProjectID is the project the trace ids belong to. Cloud Logging
+	// links records to their traces only when their trace is given as
+	// "projects/ID/traces/TRACE".
+	ProjectID string
+}
+
+// NewGCP returns a CloudHandler writing to w in the structured logging
+// format of Google Cloud Logging, for services on Cloud Run, GKE and other
+// platforms that ingest the JSON lines of a program's output. The level is
+// written as the severity, by the names of [SeverityGCP], the message as
+// the message, the module as a label, and the trace and span ids of the
+// context, see [ContextWithTraceparent], as the trace and spanId fields.
+func NewGCP(w io.Writer, opts *GCPOptions) *CloudHandler {
+	if opts == nil {
+		opts = &GCPOptions{}
+	}
+	project := opts.ProjectID
+
+	return &CloudHandler{
+		level: opts.Level,
+		base: slog.NewJSONHandler(w, &slog.HandlerOptions{
+			Level:       slog.Level(math.MinInt),
+			AddSource:   opts.AddSource,
+			ReplaceAttr: replaceGCPAttr,
+		}),
+		fields: func(_ slog.Record, module string, attrs []slog.Attr) []slog.Attr {
+			for i, a := range attrs {
+				switch a.Key {
+				case TraceIDKey:
+					attrs[i].Key = GCPTraceKey
+					if project != "" {
+						attrs[i].Value = slog.StringValue("projects/" + project + "/traces/" + a.Value.String())
+					}
+				case SpanIDKey:
+					attrs[i].Key = GCPSpanIDKey
+				}
+			}
+			if module != "" {
+				attrs = append(attrs, slog.Group(GCPLabelsKey, slog.String(ModuleKey, module)))
+			}
+			return attrs
+		},
+	}
+}
+
+// replaceGCPAttr renames the built-in attributes to the fields of Cloud
+// Logging.
+func replaceGCPAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.LevelKey:
+		if l, ok := a.Value.Any().(slog.Level); ok {
+			return slog.String("severity", gcpSeverityName(l))
+		}
+	case slog.MessageKey:
+		return slog.Attr{Key: "message", Value: a.Value}
+	case slog.SourceKey:
+		return slog.Attr{Key: GCPSourceLocationKey, Value: a.Value}
+	}
+	return a
+}
+
+// gcpSeverityName returns the name of the LogSeverity of l.
+func gcpSeverityName(l slog.Level) string {
+	switch gcpSeverity(l) {
+	case 100:
+		return "DEBUG"
+	case 200:
+		return "INFO"
+	case 300:
+		return "NOTICE"
+	case 400:
+		return "WARNING"
+	case 500:
+		return "ERROR"
+	case 600:
+		return "CRITICAL"
+	case 700:
+		return "ALERT"
+	default:
+		return "EMERGENCY"
+	}
+}
+
+// EMFKey is the key of the metadata of the CloudWatch Embedded Metric
+// Format.
+const EMFKey = "_aws"
+
+// EMFOptions configures [NewEMF].
+type EMFOptions struct {
+	// Level is the minimum level written. Nil means Info.
+	Level slog.Leveler
+
+	// Namespace is the CloudWatch namespace of the metrics. It is
+	// required.
+	Namespace string
+
+	// Metrics maps the keys of the attributes that are metrics to their
+	// units, such as "Count" or "Bytes". An empty unit is left out, and
+	// durations are in "Milliseconds" unless given another unit.
+	Metrics map[string]string
+
+	// Dimensions lists the keys of the attributes whose values are the
+	// dimensions of the metrics of a record, when it has them.
+	Dimensions []string
+}
+
+// NewEMF returns a CloudHandler writing to w in the Embedded Metric Format
+// of AWS CloudWatch, for services on Lambda, ECS and other platforms whose
+// output goes to CloudWatch Logs. Records are written as JSON lines, and
+// those with any of the metrics of opts carry the metadata that makes
+// CloudWatch extract their values as metrics, with the dimensions of opts
+// the record has:
+//
+//	h, err := trifle.NewEMF(os.Stdout, &trifle.EMFOptions{
+//		Namespace:  "checkout",
+//		Metrics:    map[string]string{"latency": "Milliseconds", "items": "Count"},
+//		Dimensions: []string{"route"},
+//	})
+//	...
+//	logger.Info("order placed", "route", "/orders", "latency", 42*time.Millisecond, "items", 3)
+//
+// Metrics and dimensions are taken from the top-level attributes only,
+// since CloudWatch looks for them there. It returns an error if opts has
+// no namespace.
+func NewEMF(w io.Writer, opts *EMFOptions) (*CloudHandler, error) {
+	if opts == nil || opts.Namespace == "" {
+		return nil, errors.New("trifle: EMF namespace must not be empty")
+	}
+	namespace, metrics, dimensions := opts.Namespace, maps.Clone(opts.Metrics), slices.Clone(opts.Dimensions)
+
+	return &CloudHandler{
+		level: opts.Level,
+		base:  slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.Level(math.MinInt)}),
+		fields: func(r slog.Record, module string, attrs []slog.Attr) []slog.Attr {
+			if module != "" {
+				attrs = append(attrs, slog.String(ModuleKey, module))
+			}
+
+			var (
+				found []emfMetric
+				dims  = []string{}
+			)
+			for i, a := range attrs {
+				v := a.Value.Resolve()
+				if unit, ok := metrics[a.Key]; ok {
+					switch v.Kind() {
+					case slog.KindInt64, slog.KindUint64, slog.KindFloat64:
+					case slog.KindDuration:
+						if unit == "" {
+							unit = "Milliseconds"
+						}
+						attrs[i].Value = slog.Float64Value(float64(v.Duration()) / float64(emfUnitDuration(unit)))
+					default:
+						continue
+					}
+					found = append(found, emfMetric{Name: a.Key, Unit: unit})
+				}
+				if slices.Contains(dimensions, a.Key) && v.Kind() != slog.KindGroup {
+					attrs[i].Value = slog.StringValue(v.String())
+					dims = append(dims, a.Key)
+				}
+			}
+			if len(found) == 0 {
+				return attrs
+			}
+
+			meta := slog.Group(EMFKey,
+				slog.Int64("Timestamp", r.Time.UnixMilli()),
+				slog.Any("CloudWatchMetrics", []emfDirective{{
+					Namespace:  namespace,
+					Dimensions: [][]string{dims},
+					Metrics:    found,
+				}}),
+			)
+			return append([]slog.Attr{meta}, attrs...)
+		},
+	}, nil
+}
+
+type emfDirective struct {
+	Namespace  string
+	Dimensions [][]string
+	Metrics    []emfMetric
+}
+
+type emfMetric struct {
+	Name string
+	Unit string `json:",omitempty"`
+}
+
+// emfUnitDuration returns the length of the CloudWatch time unit, or a
+// millisecond for the units that are not about time.
+func emfUnitDuration(unit string) time.Duration {
+	switch unit {
+	case "Seconds":
+		return time.Second
+	case "Microseconds":
+		return time.Microsecond
+	default:
+		return time.Millisecond
+	}
+}
+
+// ECSVersion is the version of the Elastic Common Schema written by
+// [NewECS].
+const ECSVersion = "1.6.0"
+
+// ECSOptions configures [NewECS]. The zero value writes records at Info
+// and above.
+type ECSOptions struct {
+	// Level is the minimum level written. Nil means Info.
+	Level slog.Leveler
+
+	// AddSource adds the source position of the log call to each record,
+	// as its log.origin.
+	AddSource bool
+}
+
+// NewECS returns a CloudHandler writing to w in the Elastic Common Schema,
+// for Filebeat and Elastic Agent to ingest without processors. The time is
+// written as @timestamp, the level as log.level, the message as message
+// and the module as log.logger. Of the top-level attributes, an error is
+// written as error.message and error.type, and the request, trace and
+// span ids, such as those of the context, as http.request.id, trace.id
+// and span.id. Other attributes are written as they are.
+func NewECS(w io.Writer, opts *ECSOptions) *CloudHandler {
+	if opts == nil {
+		opts = &ECSOptions{}
+	}
+
+	return &CloudHandler{
+		level: opts.Level,
+		base: slog.NewJSONHandler(w, &slog.HandlerOptions{
+			Level:       slog.Level(math.MinInt),
+			AddSource:   opts.AddSource,
+			ReplaceAttr: replaceECSAttr,
+		}),
+		fields: func(_ slog.Record, module string, attrs []slog.Attr) []slog.Attr {
+			out := make([]slog.Attr, 0, len(attrs)+3)
+			if module != "" {
+				out = append(out, slog.String("log.logger", module))
+			}
+			for _, a := range attrs {
+				switch a.Key {
+				case "error":
+					v := a.Value.Resolve()
+					out = append(out, slog.String("error.message", v.String()))
+					if err, ok := v.Any().(error); ok && v.Kind() == slog.KindAny {
+						out = append(out, slog.String("error.type", fmt.Sprintf("%T", err)))
+					}
+				case RequestIDKey:
+					out = append(out, slog.Attr{Key: "http.request.id", Value: a.Value})
+				case TraceIDKey:
+					out = append(out, slog.Attr{Key: "trace.id", Value: a.Value})
+				case SpanIDKey:
+					out = append(out, slog.Attr{Key: "span.id", Value: a.Value})
+				default:
+					out = append(out, a)
+				}
+			}
+			return append(out, slog.String("ecs.version", ECSVersion))
+		},
+	}
+}
+
+// replaceECSAttr renames the built-in attributes to the fields of the
+// Elastic Common Schema.
+func replaceECSAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.TimeKey:
+		return slog.Attr{Key: "@timestamp", Value: a.Value}
+	case slog.LevelKey:
+		if l, ok := a.Value.Any().(slog.Level); ok {
+			return slog.String("log.level", strings.ToLower(l.String()))
+		}
+	case slog.MessageKey:
+		return slog.Attr{Key: "message", Value: a.Value}
+	case slog.SourceKey:
+		if src, ok := a.Value.Any().(*slog.Source); ok {
+			return slog.Group("log.origin",
+				slog.Group("file", slog.String("name", src.File), slog.Int("line", src.Line)),
+				slog.String("function", src.Function),
+			)
+		}
+	}
+	return a
+}
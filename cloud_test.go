@@ -0,0 +1,123 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jsonLines decodes the JSON lines in data.
+func jsonLines(t *testing.T, data string) []map[string]any {
+	var recs []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		var rec map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &rec), line)
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+func TestGCP(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewGCP(&buf, &GCPOptions{ProjectID: "shop", AddSource: true}))
+
+	ctx := ContextWithTraceparent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	logger.With("module", "payments").WithGroup("req").WarnContext(ctx, "slow", "ms", 1200)
+	logger.Debug("not written")
+	logger.Log(context.Background(), slog.LevelError+4, "down")
+
+	recs := jsonLines(t, buf.String())
+	require.Len(t, recs, 2)
+
+	rec := recs[0]
+	assert.Equal(t, "WARNING", rec["severity"])
+	assert.Equal(t, "slow", rec["message"])
+	assert.Equal(t, "projects/shop/traces/4bf92f3577b34da6a3ce929d0e0e4736", rec[GCPTraceKey])
+	assert.Equal(t, "00f067aa0ba902b7", rec[GCPSpanIDKey])
+	assert.Equal(t, map[string]any{"module": "payments"}, rec[GCPLabelsKey])
+	assert.Equal(t, map[string]any{"ms": 1200.0}, rec["req"])
+	assert.Contains(t, rec[GCPSourceLocationKey], "file")
+	assert.NotContains(t, rec, "level")
+	assert.NotContains(t, rec, "msg")
+
+	assert.Equal(t, "CRITICAL", recs[1]["severity"])
+}
+
+func TestEMF(t *testing.T) {
+	_, err := NewEMF(&bytes.Buffer{}, nil)
+	require.Error(t, err)
+
+	var buf bytes.Buffer
+	h, err := NewEMF(&buf, &EMFOptions{
+		Namespace:  "checkout",
+		Metrics:    map[string]string{"latency": "", "items": "Count"},
+		Dimensions: []string{"route", "status"},
+	})
+	require.NoError(t, err)
+	logger := slog.New(h)
+
+	at := time.UnixMilli(1700000000123)
+	r := slog.NewRecord(at, slog.LevelInfo, "order placed", 0)
+	r.Add("route", "/orders", "status", 201, "latency", 1500*time.Microsecond, "items", 3)
+	require.NoError(t, logger.Handler().Handle(context.Background(), r))
+	logger.Info("no metrics", "route", "/health")
+
+	recs := jsonLines(t, buf.String())
+	require.Len(t, recs, 2)
+
+	assert.Equal(t, map[string]any{
+		"Timestamp": 1700000000123.0,
+		"CloudWatchMetrics": []any{map[string]any{
+			"Namespace":  "checkout",
+			"Dimensions": []any{[]any{"route", "status"}},
+			"Metrics": []any{
+				map[string]any{"Name": "latency", "Unit": "Milliseconds"},
+				map[string]any{"Name": "items", "Unit": "Count"},
+			},
+		}},
+	}, recs[0][EMFKey])
+	assert.Equal(t, 1.5, recs[0]["latency"])
+	assert.Equal(t, 3.0, recs[0]["items"])
+	assert.Equal(t, "201", recs[0]["status"], "dimensions are strings")
+
+	assert.NotContains(t, recs[1], EMFKey)
+	assert.Equal(t, "/health", recs[1]["route"])
+}
+
+func TestECS(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewECS(&buf, &ECSOptions{AddSource: true}))
+
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	logger.With("module", "db").WithGroup("query").ErrorContext(ctx, "failed",
+		"table", "users")
+	logger.Warn("retrying", "error", errors.New("connection reset"))
+
+	recs := jsonLines(t, buf.String())
+	require.Len(t, recs, 2)
+
+	rec := recs[0]
+	assert.Equal(t, "error", rec["log.level"])
+	assert.Equal(t, "failed", rec["message"])
+	assert.Equal(t, "db", rec["log.logger"])
+	assert.Equal(t, "req-1", rec["http.request.id"])
+	assert.Equal(t, ECSVersion, rec["ecs.version"])
+	assert.Equal(t, map[string]any{"table": "users"}, rec["query"])
+	assert.Contains(t, rec, "@timestamp")
+	origin, ok := rec["log.origin"].(map[string]any)
+	require.True(t, ok, rec["log.origin"])
+	assert.Contains(t, origin["file"].(map[string]any)["name"], "cloud_test.go")
+
+	assert.Equal(t, "warn", recs[1]["log.level"])
+	assert.Equal(t, "connection reset", recs[1]["error.message"])
+	assert.Equal(t, "*errors.errorString", recs[1]["error.type"])
+	assert.NotContains(t, recs[1], "error")
+}
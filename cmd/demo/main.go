@@ -1,79 +1,151 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
 	"os"
+	"strings"
+	"time"
 
 	"miren.dev/trifle"
+	"miren.dev/trifle/pkg/color"
 )
 
 func main() {
-	// Create a logger with:
-	// - Critical keys (error, panic) highlighted in red
-	// - Important keys (user_id, request_id, status) highlighted in yellow
-	// - Context keys (request_id, session_id) shown before message
-	handler := trifle.New(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelDebug,
-	},
+	var (
+		width   = flag.Int("width", 0, "wrap output at this many columns (0 detects the terminal width)")
+		noColor = flag.Bool("no-color", false, "disable colored output")
+		only    = flag.String("section", "", "only render the named section")
+	)
+	flag.Parse()
+
+	if *noColor {
+		color.NoColor = true
+	}
+
+	options := []trifle.Option{
+		// Critical keys (error, panic) are highlighted in red
 		trifle.WithCriticalKeys("error", "panic"),
+		// Important keys (user_id, request_id, status) are highlighted in yellow
 		trifle.WithImportantKeys("user_id", "request_id", "status"),
+		// Context keys (request_id, session_id, trace_id) are shown before the message
 		trifle.WithContextKey("request_id", "session_id", "trace_id"),
-	)
+	}
+	if *width > 0 {
+		options = append(options, trifle.WithTerminalWidth(*width))
+	}
+	options = append(options, trifle.WithLevel(trifle.Trace))
 
-	logger := slog.New(handler)
+	// Install makes trifle the default logger, writing to stderr, so the
+	// section headings go there too to stay in order with the records.
+	defer trifle.Install(options...)()
+	logger := slog.Default()
 
-	// Demonstrate various log levels with important and normal keys
-	logger.Debug("Starting application",
-		"version", "1.0.0",
-		"environment", "development",
-	)
+	rendered := false
+	for _, s := range sections {
+		if *only != "" && s.name != *only {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "\n── %s ──\n", s.name)
+		s.run(logger)
+		rendered = true
+	}
+
+	if !rendered {
+		fmt.Fprintf(os.Stderr, "demo: unknown section %q\n", *only)
+		os.Exit(2)
+	}
+}
 
+// sections is the gallery of features, in the order they are rendered.
+// Each section is self-contained so it can be rendered on its own with
+// -section, which makes the demo usable as a visual regression fixture.
+var sections = []struct {
+	name string
+	run  func(logger *slog.Logger)
+}{
+	{"levels", levels},
+	{"highlighting", highlighting},
+	{"context", contextDemo},
+	{"modules", modules},
+	{"groups", groups},
+	{"wrapping", wrapping},
+	{"multiline", multiline},
+	{"errors", errorValues},
+}
+
+func levels(logger *slog.Logger) {
+	logger.Log(context.Background(), trifle.Trace, "Tracing request parsing", "bytes", 512)
+	logger.Debug("Starting application", "version", "1.0.0", "environment", "development")
+	logger.Info("Listening", "addr", ":8080")
+	logger.Warn("Rate limit approaching", "requests_made", 95, "limit", 100, "window", "1h")
+	logger.Error("Failed to process payment", "error", "payment gateway timeout", "amount", 99.99)
+}
+
+func highlighting(logger *slog.Logger) {
 	logger.Info("User authentication attempt",
-		"user_id", "12345", // Key highlighted in yellow
+		"user_id", "12345", // Important: yellow
 		"username", "john.doe",
-		"request_id", "req-789", // Key highlighted in yellow and shown as context
-		"session_id", "sess-abc", // Shown as context
 		"ip_address", "192.168.1.1",
 	)
-
-	logger.Warn("Rate limit approaching",
-		"user_id", "12345", // Key highlighted in yellow
-		"requests_made", 95,
-		"limit", 100,
-		"window", "1h",
-	)
-
-	logger.Error("Failed to process payment",
-		"error", "payment gateway timeout", // Key highlighted in yellow
-		"user_id", "12345", // Key highlighted in yellow
-		"amount", 99.99,
-		"currency", "USD",
-		"status", "failed", // Key highlighted in yellow
-	)
-
-	// Demonstrate with groups
-	requestLogger := logger.WithGroup("request").With("request_id", "req-123")
-	requestLogger.Info("Processing request",
-		"method", "POST",
-		"path", "/api/users",
-		"status", "200", // Key highlighted in yellow even in groups
-		"duration_ms", 145,
+	logger.Error("Worker crashed",
+		"panic", "nil pointer dereference", // Critical: red
+		"user_id", "12345",
+		"status", "failed",
 	)
+}
 
-	// Demonstrate module-based logging with multiple context keys
-	logger.Info("\n--- Module-based logging demo ---")
+func contextDemo(logger *slog.Logger) {
+	logger.Info("Missing context values are skipped", "request_id", "req-789")
+	logger.With("request_id", "req-789", "session_id", "sess-abc").
+		Info("Context values appear before the message", "user_id", "12345")
+}
 
-	// Create module loggers with context keys
+func modules(logger *slog.Logger) {
+	// The output format is: time [LEVEL] context module message │ attrs
 	authLogger := logger.With("module", "auth", "request_id", "req-123", "trace_id", "trace-001")
 	dbLogger := logger.With("module", "database", "request_id", "req-123", "trace_id", "trace-002")
-	apiLogger := logger.With("module", "api", "request_id", "req-456", "trace_id", "trace-003")
 
-	// Demonstrate the output format: time [LEVEL] context module message │ attrs
 	authLogger.Info("User login attempt", "user_id", "user-789", "method", "oauth2")
-	dbLogger.Info("Query executed", "query", "SELECT * FROM users", "duration_ms", 12)
-	apiLogger.Error("Request failed", "error", "timeout", "endpoint", "/users")
+	dbLogger.Info("Query executed", "query", "SELECT * FROM users", "duration", 12*time.Millisecond)
+	dbLogger.With("module", "pool").Debug("Connection returned", "idle", 4)
+	logger.Info("Plain log message without module", "status", "ok")
+}
+
+func groups(logger *slog.Logger) {
+	logger.WithGroup("request").With("method", "POST").Info("Processing request",
+		"path", "/api/users",
+		"status", "200", // Important even in groups
+		slog.Group("client", "ip", "10.0.0.7", "agent", "curl/8.5.0"),
+	)
+}
+
+func wrapping(logger *slog.Logger) {
+	logger.Info("A record with many attributes wraps at the terminal width",
+		"alpha", "the first value",
+		"beta", "the second value",
+		"gamma", "the third value",
+		"delta", "the fourth value",
+		"epsilon", "the fifth value",
+		"zeta", "the sixth value",
+	)
+}
+
+func multiline(logger *slog.Logger) {
+	logger.Warn("Configuration reloaded",
+		"diff", strings.Join([]string{
+			"- level: info",
+			"+ level: debug",
+			"  workers: 4",
+		}, "\n"),
+	)
+}
 
-	// Show how it looks without module
-	plainLogger := logger.With("request_id", "req-789")
-	plainLogger.Info("Plain log message", "status", "ok")
+func errorValues(logger *slog.Logger) {
+	err := fmt.Errorf("charge card: %w", errors.New("connection reset by peer"))
+	logger.Error("Payment failed", "error", err, "attempt", 3)
+	logger.Error("Nil error value", "error", error(nil))
 }
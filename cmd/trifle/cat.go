@@ -0,0 +1,196 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"miren.dev/trifle"
+	"miren.dev/trifle/pkg/color"
+	"miren.dev/trifle/pkg/parse"
+)
+
+func cat(args []string) error {
+	fs := flag.NewFlagSet("cat", flag.ContinueOnError)
+	out := registerOutputFlags(fs)
+	var (
+		format  = fs.String("format", "auto", "format of the input: auto, json, logfmt, klog, text or journal")
+		follow  = fs.Bool("f", false, "keep reading the file as it grows, like tail -f")
+		footer  = fs.Duration("footer", 0, "on a terminal, keep a footer counting the records of this last stretch, such as 10s, by level and module")
+		merge   = fs.Bool("merge", false, "interleave the files by time, each shown as its own colored module")
+		skew    = fs.Duration("skew", 0, "with -merge, treat records this close in time as simultaneous")
+		gap     = fs.Duration("gap", 0, "mark stretches this long, such as 5s, between records")
+		offsets = offsetFlag{}
+		marks   exprFlag
+		pins    exprFlag
+		sparkKs keysFlag
+	)
+	fs.Var(offsets, "offset", "with -merge, shift the times of a file, as `name=duration`; repeatable")
+	fs.Var(&marks, "mark", "mark records matching `expr`, such as request_id=abc, in the gutter; repeatable")
+	fs.Var(&pins, "pin", "like -mark, and on a terminal also keep the latest such records below the output; repeatable")
+	fs.Var(&sparkKs, "spark", "on a terminal, keep a sparkline of the latest values of `key`, such as queue_depth, below the output; repeatable")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: trifle cat [flags] [file...]")
+		fmt.Fprintln(fs.Output(), "\nReads standard input when no file, or -, is given.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	f, err := parse.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+	if err := validateFooter(*footer); err != nil {
+		return err
+	}
+
+	names := fs.Args()
+	if len(names) == 0 {
+		names = []string{"-"}
+	}
+	if *follow && (len(names) > 1 || *merge) {
+		return fmt.Errorf("-f follows a single file")
+	}
+
+	var (
+		options []trifle.Option
+		sources []string
+	)
+	if *merge {
+		sources = make([]string, len(names))
+		for i, name := range names {
+			sources[i] = sourceName(name)
+		}
+		options = append(options, trifle.WithModuleColors(color.PaletteDefault, sources...))
+	}
+
+	var (
+		output fdWriter = os.Stdout
+		fw     *footerWriter
+	)
+	if (*footer > 0 || len(pins) > 0 || len(sparkKs) > 0) && stdoutTerminal() {
+		fw = &footerWriter{w: os.Stdout}
+		output = fw
+	}
+
+	if *gap > 0 {
+		options = append(options, trifle.WithGapAnnotations(*gap))
+	}
+
+	var mw *markWriter
+	if len(marks) > 0 || len(pins) > 0 {
+		mw = &markWriter{w: output}
+		if fw != nil {
+			mw.pin = fw.pin
+		}
+		output = mw
+	}
+
+	out.output = output
+	th, err := out.handler(options...)
+	if err != nil {
+		return err
+	}
+	var sp *sparks
+	if fw != nil && len(sparkKs) > 0 {
+		sp = newSparks(sparkKs)
+	}
+	if fw != nil {
+		defer startFooter(fw, th, *footer, sp)()
+	}
+
+	var handler slog.Handler = &sessionHandler{Handler: th, w: output}
+	if mw != nil {
+		handler = &matchHandler{Handler: th, marks: marks, pins: pins, w: mw}
+	}
+	if sp != nil {
+		handler = &sparkHandler{Handler: handler, sparks: sp}
+	}
+
+	if *merge {
+		return mergeFiles(names, handler, f, offsets, parse.MergeOptions{Skew: *skew})
+	}
+
+	open := openInput
+	if *follow {
+		open = openFollow
+	}
+	for _, name := range names {
+		if err := catFile(name, open, handler, f); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func catFile(name string, open func(string) (io.ReadCloser, error), handler slog.Handler, format parse.Format) error {
+	r, err := open(name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return parse.Feed(r, handler, format)
+}
+
+func mergeFiles(names []string, handler slog.Handler, format parse.Format, offsets offsetFlag, opts parse.MergeOptions) error {
+	sources := make([]parse.Source, 0, len(names))
+	for _, name := range names {
+		r, err := openInput(name)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		source := sourceName(name)
+		sources = append(sources, parse.Source{
+			Name:   source,
+			Reader: r,
+			Format: format,
+			Offset: offsets[source],
+		})
+	}
+
+	return parse.Merge(sources, handler, opts)
+}
+
+// sourceName is the module shown for the records of the named file: its
+// base name without extension.
+func sourceName(name string) string {
+	if name == "-" {
+		return "stdin"
+	}
+	base := filepath.Base(name)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// offsetFlag collects -offset name=duration flags.
+type offsetFlag map[string]time.Duration
+
+func (o offsetFlag) String() string {
+	parts := make([]string, 0, len(o))
+	for name, d := range o {
+		parts = append(parts, name+"="+d.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+func (o offsetFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("want name=duration, got %q", s)
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	o[name] = d
+	return nil
+}
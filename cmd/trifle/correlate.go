@@ -0,0 +1,231 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"miren.dev/trifle/pkg/color"
+	"miren.dev/trifle/pkg/expr"
+	"miren.dev/trifle/pkg/parse"
+)
+
+var (
+	correlateTimeColor   = color.New(color.Faint)
+	correlateModuleColor = color.New(color.Bold, color.FgHiCyan)
+	correlateKeyColor    = color.New(color.Faint)
+)
+
+func correlate(args []string) error {
+	fs := flag.NewFlagSet("correlate", flag.ContinueOnError)
+	var (
+		format  = fs.String("format", "auto", "format of the input: auto, json, logfmt, klog, text or journal")
+		noColor = fs.Bool("no-color", false, "disable colored output")
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: trifle correlate [flags] expr [file...]")
+		fmt.Fprintln(fs.Output(), "\nShows the records matching expr, such as request_id=abc, from all the files in")
+		fmt.Fprintln(fs.Output(), "order of time: each with its time since the first, the module it moved to when")
+		fmt.Fprintln(fs.Output(), "that changed, and a summary of how long it all took.")
+		fmt.Fprintln(fs.Output(), "Reads standard input when no file, or -, is given.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *noColor {
+		color.NoColor = true
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no expression given")
+	}
+
+	match, err := expr.Parse(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	f, err := parse.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	names := fs.Args()[1:]
+	if len(names) == 0 {
+		names = []string{"-"}
+	}
+
+	var entries []parse.Entry
+	for _, name := range names {
+		found, err := matchingEntries(name, f, match)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		entries = append(entries, found...)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no records match %s", match)
+	}
+
+	// Files are read one after another, so their records are put in order
+	// of time. Those without a time stay after the record they followed.
+	if len(names) > 1 {
+		sortEntries(entries)
+	}
+
+	return writeCorrelation(os.Stdout, entries)
+}
+
+func matchingEntries(name string, format parse.Format, match *expr.Expr) ([]parse.Entry, error) {
+	r, err := openInput(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return scanMatching(r, format, match)
+}
+
+// scanMatching returns the entries read from r that match.
+func scanMatching(r io.Reader, format parse.Format, match *expr.Expr) ([]parse.Entry, error) {
+	var entries []parse.Entry
+	sc := parse.NewScanner(r, format)
+	for sc.Scan() {
+		e := sc.Entry()
+		if match.Match(&expr.Env{Record: e.Record, Module: e.Module, Group: e.Group}) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, sc.Err()
+}
+
+func sortEntries(entries []parse.Entry) {
+	type timed struct {
+		at time.Time
+		e  parse.Entry
+	}
+
+	var last time.Time
+	ts := make([]timed, len(entries))
+	for i, e := range entries {
+		if t := e.Record.Time; !t.IsZero() {
+			last = t
+		}
+		ts[i] = timed{last, e}
+	}
+	slices.SortStableFunc(ts, func(a, b timed) int {
+		return a.at.Compare(b.at)
+	})
+	for i, t := range ts {
+		entries[i] = t.e
+	}
+}
+
+// writeCorrelation writes entries with their times relative to the first
+// and the modules they move through, followed by a summary:
+//
+//	+0s      api      INFO  GET /users path=/users
+//	+10ms  → handler  INFO  list users
+//	+870ms            WARN  slow query
+//	── 3 records over 870ms: api → handler
+func writeCorrelation(w io.Writer, entries []parse.Entry) error {
+	var start, end time.Time
+	width := 0
+	for _, e := range entries {
+		if t := e.Record.Time; !t.IsZero() {
+			if start.IsZero() {
+				start = t
+			}
+			if t.After(end) {
+				end = t
+			}
+		}
+		width = max(width, len(e.Module))
+	}
+
+	var (
+		b      strings.Builder
+		path   []string
+		module string
+	)
+	for i, e := range entries {
+		at := ""
+		if !e.Record.Time.IsZero() {
+			at = "+" + formatSpanDuration(e.Record.Time.Sub(start))
+		}
+		b.WriteString(correlateTimeColor.Sprintf("%-8s", at))
+
+		switch {
+		case i == 0 || e.Module != module:
+			arrow := "  "
+			if i > 0 {
+				arrow = "→ "
+			}
+			b.WriteString(arrow)
+			b.WriteString(correlateModuleColor.Sprintf("%-*s", width, e.Module))
+			if e.Module != "" {
+				path = append(path, e.Module)
+			}
+			module = e.Module
+		default:
+			b.WriteString(strings.Repeat(" ", width+2))
+		}
+
+		base, level := levelOf(e.Record.Level)
+		level = fmt.Sprintf("%-5s", level)
+		if c, ok := treeLevelColor[base]; ok {
+			level = c.Sprint(level)
+		}
+		fmt.Fprintf(&b, "  %s  %s", level, e.Record.Message)
+
+		e.Record.Attrs(func(a slog.Attr) bool {
+			writeCorrelationAttr(&b, e.Group, a)
+			return true
+		})
+		b.WriteByte('\n')
+	}
+
+	noun := "records"
+	if len(entries) == 1 {
+		noun = "record"
+	}
+	summary := fmt.Sprintf("── %d %s", len(entries), noun)
+	if end.After(start) {
+		summary += " over " + formatSpanDuration(end.Sub(start))
+	}
+	if len(path) > 0 {
+		summary += ": " + strings.Join(path, " → ")
+	}
+	b.WriteString(correlateTimeColor.Sprint(summary))
+	b.WriteByte('\n')
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeCorrelationAttr(b *strings.Builder, group string, a slog.Attr) {
+	key := a.Key
+	if group != "" {
+		key = group + "." + key
+	}
+
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		for _, ga := range v.Group() {
+			writeCorrelationAttr(b, key, ga)
+		}
+		return
+	}
+
+	s := v.String()
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		s = strconv.Quote(s)
+	}
+	b.WriteString(" " + correlateKeyColor.Sprint(key+"=") + s)
+}
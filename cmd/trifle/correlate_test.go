@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle/pkg/color"
+	"miren.dev/trifle/pkg/expr"
+	"miren.dev/trifle/pkg/parse"
+)
+
+// scanEntries returns the entries of the logfmt lines of input that match
+// the expression where.
+func scanEntries(t *testing.T, where, input string) []parse.Entry {
+	t.Helper()
+
+	match, err := expr.Parse(where)
+	require.NoError(t, err)
+	entries, err := scanMatching(strings.NewReader(input), parse.Logfmt, match)
+	require.NoError(t, err)
+	return entries
+}
+
+func TestWriteCorrelation(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	const input = `time=2024-05-01T10:00:00Z level=info msg="GET /users" module=api request_id=abc path=/users
+time=2024-05-01T10:00:00.005Z level=info msg=other module=api request_id=xyz
+time=2024-05-01T10:00:00.010Z level=info msg="list users" module=handler request_id=abc
+time=2024-05-01T10:00:00.870Z level=warn msg="slow query" module=handler request_id=abc q="select 1"
+level=error msg=failed module=api request_id=abc
+`
+
+	tests := []struct {
+		name  string
+		where string
+		want  string
+	}{
+		{
+			name:  "modules",
+			where: "request_id=abc",
+			want: `+0s       api      INFO   GET /users request_id=abc path=/users
++10ms   → handler  INFO   list users request_id=abc
++870ms             WARN   slow query request_id=abc q="select 1"
+        → api      ERROR  failed request_id=abc
+── 4 records over 870ms: api → handler → api
+`,
+		},
+		{
+			name:  "one record",
+			where: "request_id=xyz",
+			want: `+0s       api  INFO   other request_id=xyz
+── 1 record: api
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			require.NoError(t, writeCorrelation(&b, scanEntries(t, tt.where, input)))
+			assert.Equal(t, tt.want, b.String())
+		})
+	}
+}
+
+func TestSortEntries(t *testing.T) {
+	entries := scanEntries(t, "level>=trace", `time=2024-05-01T10:00:02Z level=info msg=c
+level=info msg=after-c
+time=2024-05-01T10:00:01Z level=info msg=b
+time=2024-05-01T10:00:00Z level=info msg=a
+`)
+	sortEntries(entries)
+
+	var msgs []string
+	for _, e := range entries {
+		msgs = append(msgs, e.Record.Message)
+	}
+	// Records without a time stay after the one they followed.
+	assert.Equal(t, []string{"a", "b", "c", "after-c"}, msgs)
+}
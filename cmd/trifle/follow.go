@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// followInterval is how often a followed file is checked for more data.
+const followInterval = 250 * time.Millisecond
+
+// followReader reads a file like tail -f: at its end it waits for more to
+// be written instead of returning io.EOF. A file that shrinks, as when it
+// is truncated by log rotation, is read again from the start.
+type followReader struct {
+	f *os.File
+}
+
+func (r *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.f.Read(p)
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+
+		time.Sleep(followInterval)
+
+		if r.truncated() {
+			if _, err := r.f.Seek(0, io.SeekStart); err != nil {
+				return 0, err
+			}
+		}
+	}
+}
+
+func (r *followReader) Close() error {
+	return r.f.Close()
+}
+
+// truncated reports whether the file is now shorter than the offset read
+// up to.
+func (r *followReader) truncated() bool {
+	offset, err := r.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false
+	}
+	fi, err := r.f.Stat()
+	return err == nil && fi.Size() < offset
+}
+
+// openFollow opens the named file to be followed. Standard input, "-", is
+// returned as it is: reading it already waits for more.
+func openFollow(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return openInput(name)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &followReader{f: f}, nil
+}
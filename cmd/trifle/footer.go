@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"miren.dev/trifle"
+	"miren.dev/trifle/pkg/color"
+)
+
+var (
+	footerColor      = color.New(color.ReverseVideo)
+	footerLevelColor = map[slog.Level]*color.Color{
+		slog.LevelError: color.New(color.ReverseVideo, color.FgRed),
+		slog.LevelWarn:  color.New(color.ReverseVideo, color.FgYellow),
+	}
+)
+
+// footerLevels are the levels the footer counts records by, highest first.
+// Records count toward the highest one they are at or above.
+var footerLevels = []struct {
+	level slog.Level
+	name  string
+}{
+	{slog.LevelError, "ERROR"},
+	{slog.LevelWarn, "WARN"},
+	{slog.LevelInfo, "INFO"},
+	{slog.LevelDebug, "DEBUG"},
+	{trifle.Trace, "TRACE"},
+}
+
+// footerModules is how many of the busiest modules the footer names.
+const footerModules = 3
+
+// pinnedLines is how many pinned records the footer keeps.
+const pinnedLines = 5
+
+// footerWriter writes to a terminal while keeping a footer below the
+// output: the latest pinned records, sparklines of the values of some keys,
+// and a line summarizing the records.
+// The footer is erased before every write and drawn again after it, so
+// records scroll up past it. Writes are expected to end in a newline, as
+// the writes of a trifle handler do.
+type footerWriter struct {
+	mu      sync.Mutex
+	w       fdWriter
+	pinned  []string
+	sparks  []string
+	summary string
+	drawn   int // lines of footer on the terminal
+}
+
+// Fd returns the descriptor of the terminal, so that the handler writing
+// through f measures its width.
+func (f *footerWriter) Fd() uintptr {
+	return f.w.Fd()
+}
+
+func (f *footerWriter) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.drawn == 0 {
+		return f.w.Write(p)
+	}
+
+	var buf bytes.Buffer
+	f.erase(&buf)
+	buf.Write(p)
+	f.draw(&buf)
+
+	if _, err := f.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// set replaces the summary line with line, which "" removes.
+func (f *footerWriter) set(line string) {
+	f.update(func() { f.summary = line })
+}
+
+// pin adds line to the pinned lines, dropping the oldest beyond
+// pinnedLines.
+func (f *footerWriter) pin(line string) {
+	f.update(func() {
+		f.pinned = append(f.pinned, line)
+		if len(f.pinned) > pinnedLines {
+			f.pinned = slices.Delete(f.pinned, 0, len(f.pinned)-pinnedLines)
+		}
+	})
+}
+
+// setSparks replaces the sparklines with lines.
+func (f *footerWriter) setSparks(lines []string) {
+	f.update(func() { f.sparks = lines })
+}
+
+// clear removes the footer.
+func (f *footerWriter) clear() {
+	f.update(func() {
+		f.pinned = nil
+		f.sparks = nil
+		f.summary = ""
+	})
+}
+
+func (f *footerWriter) update(change func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var buf bytes.Buffer
+	f.erase(&buf)
+	change()
+	f.draw(&buf)
+
+	_, _ = f.w.Write(buf.Bytes())
+}
+
+// erase appends to buf what takes the footer off the terminal, leaving the
+// cursor at the start of the line it started on.
+func (f *footerWriter) erase(buf *bytes.Buffer) {
+	if f.drawn == 0 {
+		return
+	}
+	buf.WriteString("\r\x1b[2K")
+	for range f.drawn - 1 {
+		buf.WriteString("\x1b[1A\x1b[2K")
+	}
+	f.drawn = 0
+}
+
+// draw appends the footer to buf, leaving the cursor at the end of its last
+// line. Wrapping is turned off while it is written, so that lines wider
+// than the terminal are cut off rather than taking more lines than erase
+// knows of.
+func (f *footerWriter) draw(buf *bytes.Buffer) {
+	lines := slices.Concat(f.pinned, f.sparks)
+	if f.summary != "" {
+		lines = append(lines, f.summary)
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	buf.WriteString("\x1b[?7l")
+	buf.WriteString(strings.Join(lines, "\n"))
+	buf.WriteString("\x1b[?7h")
+	f.drawn = len(lines)
+}
+
+// footerSummary keeps the rolling counts shown in the footer. It samples
+// the stats of a handler every second, and reports the difference between
+// the latest sample and the one taken a window ago.
+type footerSummary struct {
+	handler *trifle.TextHandler
+	window  time.Duration
+	samples []trifle.Stats // oldest first
+}
+
+func (s *footerSummary) sample() {
+	s.samples = append(s.samples, s.handler.Stats())
+
+	limit := int(s.window/time.Second) + 1
+	if len(s.samples) > limit {
+		s.samples = slices.Delete(s.samples, 0, len(s.samples)-limit)
+	}
+}
+
+// line renders the counts over the window, see footerLine.
+func (s *footerSummary) line() string {
+	return footerLine(s.window, s.samples[0], s.samples[len(s.samples)-1])
+}
+
+// footerLine renders the counts of the records handled between the stats
+// oldest and latest, taken window apart:
+//
+//	last 10s: 42 records  ERROR 1  WARN 3  INFO 38 │ api 30  db 10  worker 2
+func footerLine(window time.Duration, oldest, latest trifle.Stats) string {
+	levels := make(map[slog.Level]uint64)
+	var total uint64
+	for l, n := range latest.Levels {
+		n -= oldest.Levels[l]
+		total += n
+		for _, fl := range footerLevels {
+			if l >= fl.level {
+				levels[fl.level] += n
+				break
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(footerColor.Sprintf(" last %v: %d records ", window, total))
+
+	for _, fl := range footerLevels {
+		if n := levels[fl.level]; n > 0 {
+			c := footerColor
+			if lc, ok := footerLevelColor[fl.level]; ok {
+				c = lc
+			}
+			b.WriteString(c.Sprintf(" %s %d ", fl.name, n))
+		}
+	}
+
+	type moduleCount struct {
+		name string
+		n    uint64
+	}
+	var modules []moduleCount
+	for name, n := range latest.Modules {
+		if n -= oldest.Modules[name]; n > 0 && name != "" {
+			modules = append(modules, moduleCount{name, n})
+		}
+	}
+	slices.SortFunc(modules, func(a, b moduleCount) int {
+		return cmp.Or(cmp.Compare(b.n, a.n), strings.Compare(a.name, b.name))
+	})
+
+	if len(modules) > 0 {
+		b.WriteString(footerColor.Sprint(" │"))
+		for _, m := range modules[:min(len(modules), footerModules)] {
+			b.WriteString(footerColor.Sprintf(" %s %d ", m.name, m.n))
+		}
+	}
+
+	return b.String()
+}
+
+// runFooter redraws the summary line of w with the counts of h over
+// window, if any, and the sparklines of sp, if any, every second until stop
+// is closed.
+func runFooter(w *footerWriter, h *trifle.TextHandler, window time.Duration, sp *sparks, stop <-chan struct{}) {
+	s := &footerSummary{handler: h, window: window}
+
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+
+	for {
+		if sp != nil {
+			w.setSparks(sp.lines())
+		}
+		if window > 0 {
+			s.sample()
+			w.set(s.line())
+		}
+
+		select {
+		case <-tick.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// startFooter keeps the footer of w below the output until the returned
+// function is called or the process is interrupted, and then removes it.
+// With a window, the footer has a line with the counts of h over it, and
+// with sp, the sparklines of its keys.
+func startFooter(w *footerWriter, h *trifle.TextHandler, window time.Duration, sp *sparks) (stop func()) {
+	var (
+		stopc = make(chan struct{})
+		done  = make(chan struct{})
+		once  sync.Once
+	)
+	go func() {
+		if window > 0 || sp != nil {
+			runFooter(w, h, window, sp, stopc)
+		}
+		close(done)
+	}()
+
+	stop = func() {
+		once.Do(func() {
+			close(stopc)
+			<-done
+			w.clear()
+		})
+	}
+
+	// Take the footer away before being killed by Ctrl-C, so that the
+	// shell prompt doesn't land on it.
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		stop()
+		os.Exit(130)
+	}()
+
+	return stop
+}
+
+// stdoutTerminal reports whether stdout is a terminal.
+func stdoutTerminal() bool {
+	fd := os.Stdout.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// validateFooter checks the -footer flag.
+func validateFooter(window time.Duration) error {
+	if window != 0 && window < time.Second {
+		return fmt.Errorf("-footer window must be at least 1s, got %v", window)
+	}
+	return nil
+}
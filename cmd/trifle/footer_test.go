@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"miren.dev/trifle"
+	"miren.dev/trifle/pkg/color"
+)
+
+// fakeTerminal records what is written to it, standing for a terminal.
+type fakeTerminal struct {
+	bytes.Buffer
+}
+
+func (*fakeTerminal) Fd() uintptr { return ^uintptr(0) }
+
+func TestFooterWriter(t *testing.T) {
+	var term fakeTerminal
+	f := &footerWriter{w: &term}
+
+	tests := []struct {
+		name string
+		do   func()
+		want string
+	}{
+		{
+			name: "no footer",
+			do:   func() { f.Write([]byte("one\n")) },
+			want: "one\n",
+		},
+		{
+			name: "summary",
+			do:   func() { f.set("42 records") },
+			want: "\x1b[?7l42 records\x1b[?7h",
+		},
+		{
+			name: "write under the footer",
+			do:   func() { f.Write([]byte("two\n")) },
+			want: "\r\x1b[2Ktwo\n\x1b[?7l42 records\x1b[?7h",
+		},
+		{
+			name: "pin and sparks",
+			do: func() {
+				f.pin("pinned")
+				f.setSparks([]string{"depth ▁█ 9"})
+			},
+			want: "\r\x1b[2K\x1b[?7lpinned\n42 records\x1b[?7h" +
+				"\r\x1b[2K\x1b[1A\x1b[2K\x1b[?7lpinned\ndepth ▁█ 9\n42 records\x1b[?7h",
+		},
+		{
+			name: "clear",
+			do:   f.clear,
+			want: "\r\x1b[2K\x1b[1A\x1b[2K\x1b[1A\x1b[2K",
+		},
+	}
+	for _, tt := range tests {
+		term.Reset()
+		tt.do()
+		assert.Equal(t, tt.want, term.String(), tt.name)
+	}
+}
+
+func TestFooterWriterPinLimit(t *testing.T) {
+	f := &footerWriter{w: &fakeTerminal{}}
+	for _, line := range []string{"1", "2", "3", "4", "5", "6", "7"} {
+		f.pin(line)
+	}
+	assert.Equal(t, []string{"3", "4", "5", "6", "7"}, f.pinned)
+}
+
+func TestFooterLine(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	oldest := trifle.Stats{
+		Levels:  map[slog.Level]uint64{slog.LevelInfo: 10},
+		Modules: map[string]uint64{"api": 10},
+	}
+
+	tests := []struct {
+		name   string
+		latest trifle.Stats
+		want   string
+	}{
+		{
+			name:   "nothing new",
+			latest: oldest,
+			want:   " last 10s: 0 records ",
+		},
+		{
+			name: "levels and modules",
+			latest: trifle.Stats{
+				Levels: map[slog.Level]uint64{
+					slog.LevelError:     1,
+					slog.LevelWarn + 2:  2, // counted as WARN
+					slog.LevelInfo:      13,
+					trifle.Trace:        1,
+					slog.LevelDebug - 1: 1, // counted as TRACE
+				},
+				Modules: map[string]uint64{"api": 12, "db": 5, "worker": 2, "cache": 2, "": 7},
+			},
+			want: " last 10s: 8 records  ERROR 1  WARN 2  INFO 3  TRACE 2  │ db 5  api 2  cache 2 ",
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, footerLine(10*time.Second, oldest, tt.latest), tt.name)
+	}
+}
+
+func TestValidateFooter(t *testing.T) {
+	assert.NoError(t, validateFooter(0))
+	assert.NoError(t, validateFooter(time.Second))
+	assert.EqualError(t, validateFooter(500*time.Millisecond), "-footer window must be at least 1s, got 500ms")
+}
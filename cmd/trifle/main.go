@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"miren.dev/trifle"
+)
+
+const usage = `usage: trifle <command> [arguments]
+
+commands:
+  cat       render JSON, logfmt or klog lines from files or standard input
+  correlate show the records matching an expression, such as request_id=abc, across files
+  doctor    report detected terminal capabilities
+  query     show the records exported to a SQLite file by trifle.SQLSink, filtered by level, module, time or message
+  replay    render a session recorded in the replay format
+  tree      show the operations of records as a tree, from their span and parent ids
+
+With no command and input piped in, trifle runs cat.
+`
+
+func main() {
+	// Piped input without a command, possibly with cat's flags, is
+	// rendered, so that "app | trifle" works.
+	if stdinPiped() && (len(os.Args) < 2 || isFlag(os.Args[1])) {
+		os.Args = slices.Insert(os.Args, 1, "cat")
+	}
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "cat":
+		err = cat(args)
+	case "correlate":
+		err = correlate(args)
+	case "doctor":
+		err = doctor(args)
+	case "query":
+		err = query(args)
+	case "replay":
+		err = replay(args)
+	case "tree":
+		err = tree(args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "trifle: unknown command %q\n\n%s", cmd, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "trifle %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+func stdinPiped() bool {
+	fd := os.Stdin.Fd()
+	return !isatty.IsTerminal(fd) && !isatty.IsCygwinTerminal(fd)
+}
+
+func isFlag(arg string) bool {
+	return strings.HasPrefix(arg, "-") && arg != "-h" && arg != "--help"
+}
+
+func doctor(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("unexpected arguments: %v", args)
+	}
+
+	return trifle.DetectCapabilities(os.Stdout).Report(os.Stdout)
+}
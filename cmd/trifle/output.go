@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"miren.dev/trifle"
+	"miren.dev/trifle/pkg/color"
+)
+
+// outputFlags are the flags shared by the commands that render records.
+type outputFlags struct {
+	width    *int
+	noColor  *bool
+	level    *string
+	where    *string
+	shortIDs *bool
+	redactIP *bool
+	showWS   *bool
+	safe     *bool
+
+	// output is where records are written, stdout unless a command
+	// replaces it.
+	output io.Writer
+}
+
+func registerOutputFlags(fs *flag.FlagSet) *outputFlags {
+	return &outputFlags{
+		width:    fs.Int("width", 0, "wrap output at this many columns (0 detects the terminal width)"),
+		noColor:  fs.Bool("no-color", false, "disable colored output"),
+		level:    fs.String("level", "trace", "only show records at or above this level"),
+		where:    fs.String("where", "", "only show records matching `expr`, such as 'level >= warn && module = db'"),
+		shortIDs: fs.Bool("short-ids", false, "on terminals that support hyperlinks, shorten UUIDs, ULIDs and hashes to 8 characters linking to the full value"),
+		redactIP: fs.Bool("redact-ips", false, "zero the last octet of IP addresses, for sharing output"),
+		showWS:   fs.Bool("show-whitespace", false, "show leading and trailing spaces, tabs and control characters in values"),
+		safe:     fs.Bool("binary-safe", false, "escape control characters everywhere, including messages and keys, for untrusted input"),
+		output:   os.Stdout,
+	}
+}
+
+// handler returns a handler writing to the output as configured by the flags,
+// with options added to those the flags imply.
+func (o *outputFlags) handler(options ...trifle.Option) (*trifle.TextHandler, error) {
+	if *o.noColor {
+		color.NoColor = true
+	}
+
+	lvl, err := o.minLevel()
+	if err != nil {
+		return nil, err
+	}
+
+	options = append([]trifle.Option{trifle.PresetServer}, options...)
+	if *o.where != "" {
+		options = append(options, trifle.WithFilterExpr(*o.where))
+	}
+	if *o.shortIDs {
+		options = append(options, trifle.WithShortIDs())
+	}
+	if *o.redactIP {
+		options = append(options, trifle.WithRedactedIPs())
+	}
+	if *o.showWS {
+		options = append(options, trifle.WithVisibleWhitespace())
+	}
+	if *o.safe {
+		options = append(options, trifle.WithBinarySafe())
+	}
+	if *o.width > 0 {
+		options = append(options, trifle.WithTerminalWidth(*o.width))
+	}
+	return trifle.NewE(o.output, &slog.HandlerOptions{Level: lvl}, options...)
+}
+
+// minLevel returns the level given with -level.
+func (o *outputFlags) minLevel() (slog.Level, error) {
+	var lvl slog.Level
+	if strings.EqualFold(*o.level, "trace") {
+		lvl = trifle.Trace
+	} else if err := lvl.UnmarshalText([]byte(*o.level)); err != nil {
+		return 0, fmt.Errorf("invalid level %q", *o.level)
+	}
+	return lvl, nil
+}
+
+// openInput opens the named file, or returns stdin for "-".
+func openInput(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(name)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle"
+)
+
+func TestMinLevel(t *testing.T) {
+	for arg, want := range map[string]slog.Level{
+		"trace": trifle.Trace,
+		"TRACE": trifle.Trace,
+		"Warn":  slog.LevelWarn,
+		"error": slog.LevelError,
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		out := registerOutputFlags(fs)
+		require.NoError(t, fs.Parse([]string{"-level", arg}))
+
+		lvl, err := out.minLevel()
+		require.NoError(t, err, arg)
+		assert.Equal(t, want, lvl, arg)
+	}
+}
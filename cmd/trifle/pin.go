@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"miren.dev/trifle"
+	"miren.dev/trifle/pkg/color"
+	"miren.dev/trifle/pkg/expr"
+)
+
+// markColor colors the gutter mark of matching records.
+var markColor = color.New(color.FgHiMagenta)
+
+const gutterMark = "▌"
+
+// exprFlag collects repeatable expression flags.
+type exprFlag []*expr.Expr
+
+func (f *exprFlag) String() string {
+	parts := make([]string, len(*f))
+	for i, e := range *f {
+		parts[i] = e.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+func (f *exprFlag) Set(s string) error {
+	e, err := expr.Parse(s)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, e)
+	return nil
+}
+
+// matchHandler marks the records that match any of the expressions of
+// marks or pins as they are passed on to the handler writing to w. Those
+// matching pins are pinned as well.
+type matchHandler struct {
+	slog.Handler
+	marks, pins exprFlag
+	w           *markWriter
+
+	module string
+	group  string      // groups opened so far, joined by dots
+	attrs  []slog.Attr // added with WithAttrs, qualified by their groups
+}
+
+func (h *matchHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.Handler = h.Handler.WithAttrs(attrs)
+	c.attrs = slices.Clip(h.attrs)
+
+	for _, a := range attrs {
+		if a.Key == trifle.ModuleKey && a.Value.Kind() == slog.KindString {
+			if c.module != "" {
+				c.module += "."
+			}
+			c.module += a.Value.String()
+			continue
+		}
+		if h.group != "" {
+			a.Key = h.group + "." + a.Key
+		}
+		c.attrs = append(c.attrs, a)
+	}
+	return &c
+}
+
+func (h *matchHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.Handler = h.Handler.WithGroup(name)
+	if c.group != "" {
+		c.group += "."
+	}
+	c.group += name
+	return &c
+}
+
+func (h *matchHandler) Handle(ctx context.Context, r slog.Record) error {
+	env := &expr.Env{Record: r, Module: h.module, Attrs: h.attrs, Group: h.group}
+
+	h.w.pinned = matchesAny(h.pins, env)
+	h.w.marked = h.w.pinned || matchesAny(h.marks, env)
+	defer func() { h.w.marked, h.w.pinned = false, false }()
+
+	return h.Handler.Handle(ctx, r)
+}
+
+func matchesAny(exprs exprFlag, env *expr.Env) bool {
+	for _, e := range exprs {
+		if e.Match(env) {
+			return true
+		}
+	}
+	return false
+}
+
+// markWriter puts a mark in the gutter of the lines of the records that a
+// matchHandler matched, and passes the first line of those it pinned to
+// pin.
+type markWriter struct {
+	w   fdWriter
+	pin func(line string)
+
+	// marked and pinned are set by the matchHandler while it has the
+	// record written.
+	marked, pinned bool
+}
+
+func (m *markWriter) Write(p []byte) (int, error) {
+	if !m.marked {
+		return m.w.Write(p)
+	}
+
+	marked := markLines(p)
+	if m.pinned && m.pin != nil {
+		first, _, _ := bytes.Cut(marked, []byte("\n"))
+		m.pin(string(bytes.TrimRight(first, "\r")))
+	}
+
+	if _, err := m.w.Write(marked); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Fd returns the descriptor of the file written to, so that the handler
+// writing through m measures its width.
+func (m *markWriter) Fd() uintptr {
+	return m.w.Fd()
+}
+
+// fdWriter is a file, or a writer wrapping one.
+type fdWriter interface {
+	io.Writer
+	Fd() uintptr
+}
+
+// markLines puts the gutter mark at the start of every line of p. Lines
+// rendered by trifle start with a space, leading colors aside, which the
+// mark takes the place of so that nothing shifts.
+func markLines(p []byte) []byte {
+	mark := markColor.Sprint(gutterMark)
+
+	var out bytes.Buffer
+	for len(p) > 0 {
+		line, rest, found := bytes.Cut(p, []byte("\n"))
+		p = rest
+
+		if len(line) > 0 {
+			// The mark's color is reset after it, so the line's own
+			// leading colors go after it too.
+			i := skipEscapes(line)
+			out.WriteString(mark)
+			out.Write(line[:i])
+			if i < len(line) && line[i] == ' ' {
+				i++
+			}
+			out.Write(line[i:])
+		}
+		if found {
+			out.WriteByte('\n')
+		}
+	}
+	return out.Bytes()
+}
+
+// skipEscapes returns the offset of the first character of line that
+// isn't part of a leading CSI escape sequence.
+func skipEscapes(line []byte) int {
+	i := 0
+	for i+1 < len(line) && line[i] == 0x1b && line[i+1] == '[' {
+		j := i + 2
+		for j < len(line) && (line[j] < 0x40 || line[j] > 0x7e) {
+			j++
+		}
+		if j == len(line) {
+			break
+		}
+		i = j + 1
+	}
+	return i
+}
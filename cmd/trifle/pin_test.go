@@ -0,0 +1,75 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestMarkLines(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	tests := []struct {
+		name, in, want string
+	}{
+		{"plain", " line\n", "▌line\n"},
+		{"wrapped", " first\n   second\n", "▌first\n▌  second\n"},
+		{"colors first", "\x1b[2m 12:00\x1b[0m\n", "▌\x1b[2m12:00\x1b[0m\n"},
+		{"no leading space", "line", "▌line"},
+		{"empty lines", "\n\n", "\n\n"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, string(markLines([]byte(tt.in))), tt.name)
+	}
+}
+
+func TestSkipEscapes(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"text", 0},
+		{"\x1b[1mtext", 4},
+		{"\x1b[1;31m\x1b[2mtext", 11},
+		{"\x1b[1", 0}, // unterminated
+		{"\x1b]8;;\x1b\\", 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, skipEscapes([]byte(tt.in)), "%q", tt.in)
+	}
+}
+
+func TestMatchHandler(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	var (
+		term   fakeTerminal
+		pinned []string
+		marks  exprFlag
+		pins   exprFlag
+	)
+	require.NoError(t, marks.Set("module = db"))
+	require.NoError(t, pins.Set("req.user = ada"))
+
+	mw := &markWriter{w: &term, pin: func(line string) { pinned = append(pinned, line) }}
+	th := trifle.New(mw, nil, trifle.WithTerminalWidth(0))
+	log := slog.New(&matchHandler{Handler: th, marks: marks, pins: pins, w: mw})
+
+	log.Info("plain")
+	log.With(trifle.ModuleKey, "db").Info("marked")
+	log.WithGroup("req").With("user", "ada").Info("pinned")
+
+	lines := strings.Split(strings.TrimSuffix(term.String(), "\n"), "\n")
+	require.Len(t, lines, 3)
+	assert.False(t, strings.HasPrefix(lines[0], gutterMark), lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], gutterMark), lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], gutterMark), lines[2])
+	assert.Equal(t, []string{lines[2]}, pinned)
+}
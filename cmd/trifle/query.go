@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"miren.dev/trifle"
+)
+
+func query(args []string) error {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	out := registerOutputFlags(fs)
+	driver := fs.String("driver", "", "the database/sql `driver` to open the file with (default: the one linked into trifle)")
+	module := fs.String("module", "", "only show records of this module and its submodules")
+	contains := fs.String("contains", "", "only show records whose message contains this text")
+	since := fs.String("since", "", "only show records since this time, RFC 3339 or a duration ago such as 15m")
+	until := fs.String("until", "", "only show records until this time, RFC 3339 or a duration ago such as 15m")
+	limit := fs.Int("limit", 0, "only show the most recent `n` records (0 shows all)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: trifle query [flags] file")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("want one database file, got %d", fs.NArg())
+	}
+
+	now := time.Now()
+	q := trifle.SQLQuery{Module: *module, Contains: *contains, Limit: *limit}
+	lvl, err := out.minLevel()
+	if err != nil {
+		return err
+	}
+	q.Level = lvl
+	if q.Since, err = parseTimeBound(*since, now); err != nil {
+		return fmt.Errorf("-since: %w", err)
+	}
+	if q.Until, err = parseTimeBound(*until, now); err != nil {
+		return fmt.Errorf("-until: %w", err)
+	}
+
+	name, err := sqlDriver(*driver, sql.Drivers())
+	if err != nil {
+		return err
+	}
+	handler, err := out.handler()
+	if err != nil {
+		return err
+	}
+
+	return queryFile(name, fs.Arg(0), q, handler)
+}
+
+// queryFile passes the records of the database at path, opened with the
+// named driver, that match q to handler.
+func queryFile(driver, path string, q trifle.SQLQuery, handler slog.Handler) error {
+	// Opening a missing file would create an empty database with some
+	// drivers, so check for it first.
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	db, err := sql.Open(driver, path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return trifle.QuerySQL(context.Background(), db, q, handler)
+}
+
+// parseTimeBound parses the value of -since or -until: an RFC 3339 time, or
+// a duration before now. An empty value is the zero time, for no bound.
+func parseTimeBound(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d.Abs()), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC 3339 time nor a duration", s)
+	}
+	return t, nil
+}
+
+// sqlDriver returns the name of the database/sql driver to open files with:
+// name when it is given, or else the only driver among those registered,
+// the SQLite one of sqlite.go unless more are linked in.
+func sqlDriver(name string, registered []string) (string, error) {
+	switch {
+	case name != "" && slices.Contains(registered, name):
+		return name, nil
+	case name != "":
+		return "", fmt.Errorf("SQL driver %q is not linked into trifle (registered: %s)", name, driverList(registered))
+	case len(registered) == 1:
+		return registered[0], nil
+	case len(registered) == 0:
+		return "", fmt.Errorf("no SQL driver is linked into trifle")
+	}
+	return "", fmt.Errorf("several SQL drivers are linked into trifle, choose one with -driver: %s", driverList(registered))
+}
+
+func driverList(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeBound(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"", time.Time{}},
+		{"15m", now.Add(-15 * time.Minute)},
+		{"-1h", now.Add(-time.Hour)},
+		{"2026-02-28T08:30:00Z", time.Date(2026, 2, 28, 8, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseTimeBound(tt.in, now)
+		require.NoError(t, err, tt.in)
+		assert.True(t, tt.want.Equal(got), "%q: got %v, want %v", tt.in, got, tt.want)
+	}
+
+	_, err := parseTimeBound("yesterday", now)
+	assert.ErrorContains(t, err, "neither an RFC 3339 time nor a duration")
+}
+
+func TestSQLDriver(t *testing.T) {
+	tests := []struct {
+		name       string
+		registered []string
+		want       string
+		err        string
+	}{
+		{"", []string{"sqlite"}, "sqlite", ""},
+		{"sqlite3", []string{"sqlite", "sqlite3"}, "sqlite3", ""},
+		{"", nil, "", "no SQL driver is linked into trifle"},
+		{"", []string{"pgx", "sqlite"}, "", "choose one with -driver: pgx, sqlite"},
+		{"sqlite", []string{"pgx"}, "", `"sqlite" is not linked into trifle (registered: pgx)`},
+	}
+	for _, tt := range tests {
+		got, err := sqlDriver(tt.name, tt.registered)
+		if tt.err != "" {
+			assert.ErrorContains(t, err, tt.err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"miren.dev/trifle"
+)
+
+func replay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	out := registerOutputFlags(fs)
+	seq := fs.Bool("seq", false, "show the number each record was given when it was recorded")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: trifle replay [flags] file...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no replay file given")
+	}
+
+	var options []trifle.Option
+	if *seq {
+		options = append(options, trifle.WithSequenceNumbers())
+	}
+	handler, err := out.handler(options...)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range fs.Args() {
+		if err := replayFile(name, handler); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func replayFile(name string, handler slog.Handler) error {
+	r, err := openInput(name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return trifle.Replay(r, handler)
+}
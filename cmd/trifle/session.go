@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"miren.dev/trifle"
+	"miren.dev/trifle/pkg/color"
+)
+
+var sessionColor = color.New(color.Faint)
+
+// sessionHandler shows the header and trailer lines a trifle.FileSink
+// writes with its Session option as separators between sessions, rather
+// than as records without a message.
+type sessionHandler struct {
+	slog.Handler
+	w      io.Writer
+	module string
+}
+
+func (h *sessionHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.Handler = h.Handler.WithAttrs(attrs)
+	for _, a := range attrs {
+		if a.Key == trifle.ModuleKey && a.Value.Kind() == slog.KindString {
+			c.module = a.Value.String()
+		}
+	}
+	return &c
+}
+
+func (h *sessionHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.Handler = h.Handler.WithGroup(name)
+	return &c
+}
+
+func (h *sessionHandler) Handle(ctx context.Context, r slog.Record) error {
+	line, ok := sessionLine(r)
+	if !ok {
+		return h.Handler.Handle(ctx, r)
+	}
+	if h.module != "" {
+		line = h.module + " " + line
+	}
+	_, err := io.WriteString(h.w, sessionColor.Sprint("── "+line+" ──")+"\n")
+	return err
+}
+
+// sessionLine describes the session header or trailer r was parsed from,
+// and reports whether it was one.
+func sessionLine(r slog.Record) (string, bool) {
+	if r.Message != "" {
+		return "", false
+	}
+
+	attrs := make(map[string]slog.Value)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value
+		return true
+	})
+	str := func(key string) string {
+		if v, ok := attrs[key]; ok {
+			return v.String()
+		}
+		return ""
+	}
+
+	switch str("trifle") {
+	case "header":
+		parts := []string{"session started " + sessionTime(attrs["start"])}
+		if program := str("program"); program != "" {
+			if version := str("version"); version != "" {
+				program += " " + version
+			}
+			parts = append(parts, program)
+		}
+		if host := str("hostname"); host != "" {
+			parts = append(parts, host)
+		}
+		if pid := str("pid"); pid != "" {
+			parts = append(parts, "pid "+pid)
+		}
+		return strings.Join(parts, " · "), true
+	case "trailer":
+		parts := []string{"session ended " + sessionTime(attrs["end"])}
+		if counts := attrs["counts"]; counts.Kind() == slog.KindGroup {
+			var levels []string
+			for _, a := range counts.Group() {
+				levels = append(levels, fmt.Sprintf("%s %s", a.Key, a.Value))
+			}
+			if len(levels) > 0 {
+				parts = append(parts, strings.Join(levels, " "))
+			}
+		}
+		return strings.Join(parts, " · "), true
+	default:
+		return "", false
+	}
+}
+
+// sessionTime shows the start or end time of a session, as written in the
+// file when it can't be parsed.
+func sessionTime(v slog.Value) string {
+	if v.Kind() == slog.KindTime {
+		return v.Time().Local().Format(time.DateTime)
+	}
+	s := v.String()
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.Local().Format(time.DateTime)
+	}
+	return s
+}
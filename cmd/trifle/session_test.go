@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle"
+	"miren.dev/trifle/pkg/color"
+	"miren.dev/trifle/pkg/parse"
+)
+
+func TestSessionHandler(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	path := filepath.Join(t.TempDir(), "app.jsonl")
+	sink, err := trifle.NewFileSink(path, &trifle.FileSinkOptions{Session: true})
+	require.NoError(t, err)
+	slog.New(sink).Error("failed", "job", "backup")
+	require.NoError(t, sink.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var buf bytes.Buffer
+	th := trifle.New(&buf, nil, trifle.WithTerminalWidth(0))
+	require.NoError(t, parse.Feed(f, &sessionHandler{Handler: th, w: &buf}, parse.Auto))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3, buf.String())
+	assert.Regexp(t, `^── session started \d{4}-\d\d-\d\d \d\d:\d\d:\d\d · .*pid \d+ ──$`, lines[0])
+	assert.Contains(t, lines[1], "failed │ job: backup")
+	assert.Regexp(t, `^── session ended \d{4}-\d\d-\d\d \d\d:\d\d:\d\d · ERROR 1 ──$`, lines[2])
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+var sparkKeyColor = color.New(color.Faint)
+
+// sparkPoints is how many of the latest values a sparkline shows.
+const sparkPoints = 40
+
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// keysFlag collects repeatable key flags.
+type keysFlag []string
+
+func (f *keysFlag) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *keysFlag) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
+
+// sparks keeps the latest values of some keys, to show them as sparklines
+// in the footer.
+type sparks struct {
+	keys []string
+
+	mu       sync.Mutex
+	values   map[string][]float64 // oldest first, up to sparkPoints
+	duration map[string]bool      // the values of the key are durations
+}
+
+func newSparks(keys []string) *sparks {
+	return &sparks{keys: keys, values: make(map[string][]float64), duration: make(map[string]bool)}
+}
+
+// add records the values of r for the keys of s, with group being the
+// groups opened for r.
+func (s *sparks) add(group string, r slog.Record) {
+	for _, key := range s.keys {
+		var found string
+		r.Attrs(func(a slog.Attr) bool {
+			found = findAttrValue(key, group, a)
+			return found == ""
+		})
+		if found == "" {
+			continue
+		}
+
+		n, err := strconv.ParseFloat(found, 64)
+		isDuration := false
+		if err != nil {
+			d, err := time.ParseDuration(found)
+			if err != nil {
+				continue
+			}
+			n, isDuration = float64(d), true
+		}
+
+		s.mu.Lock()
+		vs := append(s.values[key], n)
+		if len(vs) > sparkPoints {
+			vs = vs[len(vs)-sparkPoints:]
+		}
+		s.values[key] = vs
+		s.duration[key] = isDuration
+		s.mu.Unlock()
+	}
+}
+
+// lines renders a sparkline for each key with values, scaled from the
+// lowest to the highest of them and followed by the latest:
+//
+//	queue_depth ▁▁▂▃▅▇█▆▄ 42
+//	memory_mb   ▃▃▄▄▄▅▅▆▆ 512
+func (s *sparks) lines() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	width := 0
+	for _, key := range s.keys {
+		width = max(width, len(key))
+	}
+
+	var lines []string
+	for _, key := range s.keys {
+		vs := s.values[key]
+		if len(vs) == 0 {
+			continue
+		}
+
+		lo, hi := vs[0], vs[0]
+		for _, v := range vs {
+			lo, hi = min(lo, v), max(hi, v)
+		}
+
+		var b strings.Builder
+		b.WriteString(sparkKeyColor.Sprintf("%-*s ", width, key))
+		for _, v := range vs {
+			i := 0
+			if hi > lo {
+				i = int((v - lo) / (hi - lo) * float64(len(sparkBars)-1))
+			}
+			b.WriteRune(sparkBars[i])
+		}
+
+		latest := vs[len(vs)-1]
+		if s.duration[key] {
+			b.WriteString(" " + formatSpanDuration(time.Duration(latest)))
+		} else {
+			b.WriteString(" " + strconv.FormatFloat(latest, 'g', -1, 64))
+		}
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+// sparkHandler passes the records to the handler after adding their values
+// to the sparklines.
+type sparkHandler struct {
+	slog.Handler
+	sparks *sparks
+	group  string // groups opened so far, joined by dots
+}
+
+func (h *sparkHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.Handler = h.Handler.WithAttrs(attrs)
+	return &c
+}
+
+func (h *sparkHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.Handler = h.Handler.WithGroup(name)
+	if c.group != "" {
+		c.group += "."
+	}
+	c.group += name
+	return &c
+}
+
+func (h *sparkHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.sparks.add(h.group, r)
+	return h.Handler.Handle(ctx, r)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestSparks(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	tests := []struct {
+		name   string
+		keys   []string
+		values []slog.Attr
+		want   []string
+	}{
+		{
+			name:   "numbers",
+			keys:   []string{"depth"},
+			values: []slog.Attr{slog.Int("depth", 0), slog.Int("depth", 7), slog.Int("depth", 14), slog.Int("depth", 7)},
+			want:   []string{"depth ▁▄█▄ 7"},
+		},
+		{
+			name:   "flat",
+			keys:   []string{"depth"},
+			values: []slog.Attr{slog.Float64("depth", 1.5), slog.Float64("depth", 1.5)},
+			want:   []string{"depth ▁▁ 1.5"},
+		},
+		{
+			name:   "durations",
+			keys:   []string{"took"},
+			values: []slog.Attr{slog.Duration("took", time.Millisecond), slog.String("took", "3ms")},
+			want:   []string{"took ▁█ 3ms"},
+		},
+		{
+			name:   "aligned keys",
+			keys:   []string{"depth", "cpu_percent", "missing"},
+			values: []slog.Attr{slog.Int("depth", 3), slog.Int("cpu_percent", 50), slog.String("depth", "n/a")},
+			want:   []string{"depth       ▁ 3", "cpu_percent ▁ 50"},
+		},
+	}
+	for _, tt := range tests {
+		sp := newSparks(tt.keys)
+		for _, a := range tt.values {
+			r := slog.NewRecord(time.Time{}, slog.LevelInfo, "m", 0)
+			r.AddAttrs(a)
+			sp.add("", r)
+		}
+		assert.Equal(t, tt.want, sp.lines(), tt.name)
+	}
+}
+
+func TestSparksKeepLatest(t *testing.T) {
+	sp := newSparks([]string{"n"})
+	for i := range sparkPoints + 5 {
+		r := slog.NewRecord(time.Time{}, slog.LevelInfo, "m", 0)
+		r.AddAttrs(slog.Int("n", i))
+		sp.add("", r)
+	}
+	assert.Len(t, sp.values["n"], sparkPoints)
+	assert.Equal(t, 5.0, sp.values["n"][0])
+}
+
+func TestSparkHandlerGroups(t *testing.T) {
+	sp := newSparks([]string{"pool.idle"})
+	log := slog.New(&sparkHandler{Handler: slog.NewTextHandler(io.Discard, nil), sparks: sp})
+
+	log.WithGroup("pool").Info("stats", "idle", 4)
+	log.Info("stats", slog.Group("pool", "idle", 6))
+	log.Info("stats", "idle", 9) // not in the group
+
+	assert.Equal(t, []float64{4, 6}, sp.values["pool.idle"])
+}
@@ -0,0 +1,5 @@
+package main
+
+// The SQLite driver of trifle query, registered as "sqlite3". It needs
+// cgo; a build without it fails to open databases, saying so.
+import _ "github.com/mattn/go-sqlite3"
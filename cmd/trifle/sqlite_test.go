@@ -0,0 +1,75 @@
+//go:build cgo
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestQueryFile(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	path := filepath.Join(t.TempDir(), "run.db")
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	sink, err := trifle.NewSQLSink(context.Background(), db, nil)
+	require.NoError(t, err)
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	log := slog.New(sink)
+	for i, rec := range []struct {
+		module, msg string
+		level       slog.Level
+	}{
+		{"api", "served", slog.LevelInfo},
+		{"db", "slow query", slog.LevelWarn},
+		{"db.pool", "slow dial", slog.LevelWarn},
+		{"dbx", "slow other", slog.LevelWarn},
+		{"db", "fast query", slog.LevelDebug},
+	} {
+		r := slog.NewRecord(start.Add(time.Duration(i)*time.Second), rec.level, rec.msg, 0)
+		r.AddAttrs(slog.Int("n", i))
+		require.NoError(t, log.With(trifle.ModuleKey, rec.module).Handler().Handle(context.Background(), r))
+	}
+	require.NoError(t, sink.Close())
+	require.NoError(t, db.Close())
+
+	tests := []struct {
+		name string
+		q    trifle.SQLQuery
+		want []string
+	}{
+		{"all", trifle.SQLQuery{}, []string{"served", "slow query", "slow dial", "slow other", "fast query"}},
+		{"module", trifle.SQLQuery{Module: "db", Level: slog.LevelInfo}, []string{"slow query", "slow dial"}},
+		{"contains", trifle.SQLQuery{Contains: "slow", Since: start.Add(2 * time.Second)}, []string{"slow dial", "slow other"}},
+		{"limit", trifle.SQLQuery{Limit: 2}, []string{"slow other", "fast query"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := trifle.New(&buf, &slog.HandlerOptions{Level: trifle.Trace}, trifle.WithTerminalWidth(0))
+			require.NoError(t, queryFile("sqlite3", path, tt.q, h))
+
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			require.Len(t, lines, len(tt.want), buf.String())
+			for i, msg := range tt.want {
+				assert.Contains(t, lines[i], msg)
+			}
+		})
+	}
+
+	assert.Error(t, queryFile("sqlite3", filepath.Join(t.TempDir(), "missing.db"), trifle.SQLQuery{}, trifle.New(&bytes.Buffer{}, nil)))
+}
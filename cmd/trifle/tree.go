@@ -0,0 +1,376 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"miren.dev/trifle/pkg/color"
+	"miren.dev/trifle/pkg/parse"
+)
+
+var (
+	treeLineColor   = color.New(color.Faint)
+	treeModuleColor = color.New(color.Faint)
+	treeLevelColor  = map[slog.Level]*color.Color{
+		slog.LevelError: color.New(color.FgHiRed),
+		slog.LevelWarn:  color.New(color.FgHiYellow),
+	}
+)
+
+func tree(args []string) error {
+	fs := flag.NewFlagSet("tree", flag.ContinueOnError)
+	var (
+		format  = fs.String("format", "auto", "format of the input: auto, json, logfmt, klog, text or journal")
+		spanKey = fs.String("span", "span_id", "the `key` identifying the operation a record belongs to")
+		parent  = fs.String("parent", "parent_id", "the `key` identifying the operation that started it")
+		depth   = fs.Int("depth", 0, "collapse operations nested deeper than this, 0 for none")
+		records = fs.Bool("records", false, "list the records of every operation below it")
+		noColor = fs.Bool("no-color", false, "disable colored output")
+	)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: trifle tree [flags] [file...]")
+		fmt.Fprintln(fs.Output(), "\nShows the operations of the records as a tree, each with how long it took,")
+		fmt.Fprintln(fs.Output(), "from the ids of the operations they belong to and of their parents.")
+		fmt.Fprintln(fs.Output(), "Reads standard input when no file, or -, is given.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *noColor {
+		color.NoColor = true
+	}
+
+	f, err := parse.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	names := fs.Args()
+	if len(names) == 0 {
+		names = []string{"-"}
+	}
+
+	t := newSpanTree(*spanKey, *parent)
+	for _, name := range names {
+		if err := t.read(name, f); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return t.write(os.Stdout, *depth, *records)
+}
+
+// span is an operation: the records carrying its id, and the operations
+// started by it.
+type span struct {
+	id, parent string
+	records    []parse.Entry
+	children   []*span
+
+	start, end time.Time // of its records and those of its descendants
+	level      slog.Level
+}
+
+// label describes s by the module and message of its first record.
+func (s *span) label() (module, msg string) {
+	if len(s.records) == 0 {
+		return "", ""
+	}
+	return s.records[0].Module, s.records[0].Record.Message
+}
+
+// spanTree collects the operations of records by their ids.
+type spanTree struct {
+	spanKey, parentKey string
+
+	spans     map[string]*span
+	order     []*span // in the order they were first seen
+	unrelated int     // records with no span id
+}
+
+func newSpanTree(spanKey, parentKey string) *spanTree {
+	return &spanTree{spanKey: spanKey, parentKey: parentKey, spans: make(map[string]*span)}
+}
+
+func (t *spanTree) read(name string, format parse.Format) error {
+	r, err := openInput(name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return t.readFrom(r, format)
+}
+
+func (t *spanTree) readFrom(r io.Reader, format parse.Format) error {
+	sc := parse.NewScanner(r, format)
+	for sc.Scan() {
+		t.add(sc.Entry())
+	}
+	return sc.Err()
+}
+
+func (t *spanTree) add(e parse.Entry) {
+	id := entryAttr(e, t.spanKey)
+	if id == "" {
+		t.unrelated++
+		return
+	}
+
+	s, ok := t.spans[id]
+	if !ok {
+		s = &span{id: id, level: e.Record.Level}
+		t.spans[id] = s
+		t.order = append(t.order, s)
+	}
+	if s.parent == "" {
+		if parent := entryAttr(e, t.parentKey); parent != id {
+			s.parent = parent
+		}
+	}
+	s.records = append(s.records, e)
+	s.level = max(s.level, e.Record.Level)
+	s.extend(e.Record.Time)
+}
+
+func (s *span) extend(at time.Time) {
+	if at.IsZero() {
+		return
+	}
+	if s.start.IsZero() || at.Before(s.start) {
+		s.start = at
+	}
+	if at.After(s.end) {
+		s.end = at
+	}
+}
+
+// entryAttr returns the value of the attribute of e named key, either by
+// its own key or qualified by its groups, or "" if e has none.
+func entryAttr(e parse.Entry, key string) string {
+	var found string
+	e.Record.Attrs(func(a slog.Attr) bool {
+		found = findAttrValue(key, e.Group, a)
+		return found == ""
+	})
+	return found
+}
+
+func findAttrValue(key, group string, a slog.Attr) string {
+	qualified := a.Key
+	if group != "" {
+		qualified = group + "." + a.Key
+	}
+
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		for _, ga := range v.Group() {
+			if found := findAttrValue(key, qualified, ga); found != "" {
+				return found
+			}
+		}
+		return ""
+	}
+	if a.Key == key || qualified == key {
+		return v.String()
+	}
+	return ""
+}
+
+// roots links the spans to their parents and returns those without one,
+// ordered by when they started. Spans whose parent never logged anything
+// are roots, and so is one span of every cycle of parents.
+func (t *spanTree) roots() []*span {
+	var roots []*span
+	for _, s := range t.order {
+		if p, ok := t.spans[s.parent]; ok && p != s {
+			p.children = append(p.children, s)
+		} else {
+			roots = append(roots, s)
+		}
+	}
+
+	visited := make(map[*span]bool)
+	var visit func(s *span)
+	visit = func(s *span) {
+		visited[s] = true
+		for _, c := range s.children {
+			if !visited[c] {
+				visit(c)
+			}
+		}
+	}
+	for _, s := range roots {
+		visit(s)
+	}
+	for _, s := range t.order {
+		if !visited[s] {
+			roots = append(roots, s)
+			visit(s)
+		}
+	}
+
+	for _, s := range roots {
+		s.settle(make(map[*span]bool))
+	}
+	sortSpans(roots)
+	return roots
+}
+
+// settle extends the times of s and its descendants to cover those of
+// their descendants, and orders their children by start.
+func (s *span) settle(seen map[*span]bool) {
+	seen[s] = true
+	s.children = slices.DeleteFunc(s.children, func(c *span) bool { return seen[c] })
+	for _, c := range s.children {
+		c.settle(seen)
+		s.extend(c.start)
+		s.extend(c.end)
+	}
+	sortSpans(s.children)
+}
+
+func sortSpans(spans []*span) {
+	slices.SortStableFunc(spans, func(a, b *span) int {
+		return a.start.Compare(b.start)
+	})
+}
+
+// write draws the tree of operations to w:
+//
+//	GET /users  api  1.204s
+//	├─ list users  handler  1.1s
+//	│  └─ query  db  850ms
+//	└─ render  api  12ms
+func (t *spanTree) write(w io.Writer, maxDepth int, records bool) error {
+	roots := t.roots()
+	if len(roots) == 0 {
+		return fmt.Errorf("no records carry a %q attribute", t.spanKey)
+	}
+
+	tw := &treeWriter{w: w, maxDepth: maxDepth, records: records}
+	for _, s := range roots {
+		tw.span(s, "", "", 1)
+	}
+	if t.unrelated > 0 {
+		noun := "records"
+		if t.unrelated == 1 {
+			noun = "record"
+		}
+		tw.printf("%s\n", treeLineColor.Sprintf("(%d %s without a %s)", t.unrelated, noun, t.spanKey))
+	}
+	return tw.err
+}
+
+type treeWriter struct {
+	w        io.Writer
+	maxDepth int
+	records  bool
+	err      error
+}
+
+func (tw *treeWriter) printf(format string, args ...any) {
+	if tw.err == nil {
+		_, tw.err = fmt.Fprintf(tw.w, format, args...)
+	}
+}
+
+// span writes s after lead, and its records and children after indent.
+func (tw *treeWriter) span(s *span, lead, indent string, depth int) {
+	module, msg := s.label()
+
+	line := msg
+	if base, _ := levelOf(s.level); treeLevelColor[base] != nil {
+		line = treeLevelColor[base].Sprint(msg)
+	}
+	if module != "" {
+		line += "  " + treeModuleColor.Sprint(module)
+	}
+	if d := s.end.Sub(s.start); d > 0 {
+		line += "  " + formatSpanDuration(d)
+	}
+	if lead != "" {
+		lead = treeLineColor.Sprint(lead)
+	}
+	tw.printf("%s%s\n", lead, line)
+
+	if tw.records {
+		guide := indent + "   "
+		if len(s.children) > 0 {
+			guide = indent + "│  "
+		}
+		for _, e := range s.records {
+			tw.printf("%s%s\n", treeLineColor.Sprint(guide), recordLine(e, s.start))
+		}
+	}
+
+	if len(s.children) == 0 {
+		return
+	}
+	if tw.maxDepth > 0 && depth >= tw.maxDepth {
+		tw.printf("%s\n", treeLineColor.Sprintf("%s└─ … %d more", indent, countSpans(s.children)))
+		return
+	}
+	for i, c := range s.children {
+		if i == len(s.children)-1 {
+			tw.span(c, indent+"└─ ", indent+"   ", depth+1)
+		} else {
+			tw.span(c, indent+"├─ ", indent+"│  ", depth+1)
+		}
+	}
+}
+
+// recordLine renders e briefly, with its time relative to start.
+func recordLine(e parse.Entry, start time.Time) string {
+	at := "       "
+	if !e.Record.Time.IsZero() && !start.IsZero() {
+		at = fmt.Sprintf("+%-6s", formatSpanDuration(e.Record.Time.Sub(start)))
+	}
+	base, level := levelOf(e.Record.Level)
+	level = fmt.Sprintf("%-5s", level)
+	if c, ok := treeLevelColor[base]; ok {
+		level = c.Sprint(level)
+	}
+	return treeLineColor.Sprint(at) + " " + level + " " + e.Record.Message
+}
+
+func countSpans(spans []*span) int {
+	n := len(spans)
+	for _, s := range spans {
+		n += countSpans(s.children)
+	}
+	return n
+}
+
+// formatSpanDuration rounds d to three significant digits or so, which
+// is all a tree of operations needs.
+func formatSpanDuration(d time.Duration) string {
+	switch {
+	case d >= 10*time.Second:
+		return d.Round(100 * time.Millisecond).String()
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.String()
+	}
+}
+
+// levelOf returns the level of footerLevels that l is at or above, the
+// lowest one if none.
+func levelOf(l slog.Level) (base slog.Level, name string) {
+	for _, fl := range footerLevels {
+		if l >= fl.level {
+			return fl.level, fl.name
+		}
+	}
+	last := footerLevels[len(footerLevels)-1]
+	return last.level, last.name
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle/pkg/color"
+	"miren.dev/trifle/pkg/parse"
+)
+
+func TestSpanTree(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	const input = `time=2024-05-01T10:00:00Z level=info msg="GET /users" module=api span_id=a
+time=2024-05-01T10:00:00.010Z level=info msg="list users" module=handler span_id=b parent_id=a
+time=2024-05-01T10:00:00.020Z level=warn msg=query module=db span_id=c parent_id=b
+time=2024-05-01T10:00:00.870Z level=info msg=done module=db span_id=c
+time=2024-05-01T10:00:01.100Z level=info msg=render module=api span_id=d parent_id=a
+time=2024-05-01T10:00:01.112Z level=info msg=rendered module=api span_id=d
+time=2024-05-01T10:00:01.204Z level=info msg=served module=api span_id=a
+level=info msg="no span"
+`
+
+	tests := []struct {
+		name    string
+		depth   int
+		records bool
+		want    string
+	}{
+		{
+			name: "tree",
+			want: `GET /users  api  1.204s
+├─ list users  handler  860ms
+│  └─ query  db  850ms
+└─ render  api  12ms
+(1 record without a span_id)
+`,
+		},
+		{
+			name:  "depth",
+			depth: 1,
+			want: `GET /users  api  1.204s
+└─ … 3 more
+(1 record without a span_id)
+`,
+		},
+		{
+			name:    "records",
+			depth:   2,
+			records: true,
+			want: `GET /users  api  1.204s
+│  +0s     INFO  GET /users
+│  +1.204s INFO  served
+├─ list users  handler  860ms
+│  │  +0s     INFO  list users
+│  └─ … 1 more
+└─ render  api  12ms
+      +0s     INFO  render
+      +12ms   INFO  rendered
+(1 record without a span_id)
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree := newSpanTree("span_id", "parent_id")
+			require.NoError(t, tree.readFrom(strings.NewReader(input), parse.Logfmt))
+
+			var b strings.Builder
+			require.NoError(t, tree.write(&b, tt.depth, tt.records))
+			assert.Equal(t, tt.want, b.String())
+		})
+	}
+}
+
+func TestSpanTreeCycle(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	const input = `level=info msg=first span_id=a parent_id=b
+level=info msg=second span_id=b parent_id=a
+level=info msg=self span_id=c parent_id=c
+`
+	tree := newSpanTree("span_id", "parent_id")
+	require.NoError(t, tree.readFrom(strings.NewReader(input), parse.Logfmt))
+
+	var b strings.Builder
+	require.NoError(t, tree.write(&b, 0, false))
+	// Without times, the spans stay in the order they were found as roots.
+	assert.Equal(t, "self\nfirst\n└─ second\n", b.String())
+}
+
+func TestSpanTreeNoSpans(t *testing.T) {
+	tree := newSpanTree("span_id", "parent_id")
+	require.NoError(t, tree.readFrom(strings.NewReader("level=info msg=hi\n"), parse.Logfmt))
+
+	err := tree.write(&strings.Builder{}, 0, false)
+	assert.EqualError(t, err, `no records carry a "span_id" attribute`)
+}
+
+func TestFormatSpanDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"12.345678s", "12.3s"},
+		{"1.2345678s", "1.235s"},
+		{"12.345678ms", "12.35ms"},
+		{"123µs", "123µs"},
+	}
+	for _, tt := range tests {
+		d, err := time.ParseDuration(tt.in)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, formatSpanDuration(d), tt.in)
+	}
+}
@@ -0,0 +1,172 @@
+package trifle
+
+import (
+	"compress/gzip"
+	"io"
+	"sync"
+	"time"
+)
+
+// Compressor is a writer compressing what is written to it, such as a
+// [gzip.Writer]. Flush writes out the output for everything written so far
+// in a form that can be decompressed, and Close ends the stream.
+type Compressor interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// Codec returns a Compressor writing to w. Codecs for encodings outside the
+// standard library adapt their writers; for zstd with
+// github.com/klauspost/compress:
+//
+//	zstdCodec := func(w io.Writer) (trifle.Compressor, error) {
+//		return zstd.NewWriter(w)
+//	}
+type Codec func(w io.Writer) (Compressor, error)
+
+// Gzip is the [Codec] of gzip, at the default compression level.
+func Gzip(w io.Writer) (Compressor, error) {
+	return gzip.NewWriter(w), nil
+}
+
+// CompressedWriter compresses what is written to it before passing it to
+// the underlying writer, for sinks that keep or ship large amounts of
+// output.
+//
+// A compressor holds on to its input until it has enough to compress well,
+// which would lose the most recent records if the program crashed. So the
+// CompressedWriter flushes the compressor once MaxBytes have been written
+// since the last flush, MaxDelay after the first write since then, and on
+// Flush or Close: what reached the underlying writer can always be
+// decompressed, up to the last flush, even if the stream was never ended.
+// An error from the compressor or the underlying writer is returned by the
+// next call to Write, Flush or Close.
+type CompressedWriter struct {
+	w        io.Writer
+	zw       Compressor
+	maxBytes int
+	maxDelay time.Duration
+
+	mu      sync.Mutex
+	pending int // bytes written since the last flush
+	timer   *time.Timer
+	err     error
+}
+
+// NewCompressedWriter returns a [CompressedWriter] that compresses to w with
+// codec. The options are those of a [BatchWriter], whose defaults they
+// share.
+func NewCompressedWriter(w io.Writer, codec Codec, opts BatchOptions) (*CompressedWriter, error) {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultBatchSize
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = DefaultBatchDelay
+	}
+
+	zw, err := codec(w)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CompressedWriter{
+		w:        w,
+		zw:       zw,
+		maxBytes: opts.MaxBytes,
+		maxDelay: opts.MaxDelay,
+	}, nil
+}
+
+// Write compresses p.
+func (c *CompressedWriter) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.takeErr(); err != nil {
+		return 0, err
+	}
+
+	n, err := c.zw.Write(p)
+	if err != nil {
+		return n, err
+	}
+	c.pending += n
+
+	if c.pending >= c.maxBytes {
+		if err := c.flushLocked(); err != nil {
+			return n, c.takeErr()
+		}
+		return n, nil
+	}
+
+	if c.timer == nil {
+		c.timer = time.AfterFunc(c.maxDelay, c.timedFlush)
+	}
+
+	return n, nil
+}
+
+// Flush writes out the compressed output for everything written so far.
+func (c *CompressedWriter) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.flushLocked()
+	return c.takeErr()
+}
+
+// Close ends the compressed stream and, if the underlying writer is an
+// [io.Closer], closes it.
+func (c *CompressedWriter) Close() error {
+	c.mu.Lock()
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	c.pending = 0
+	err := c.takeErr()
+	if zerr := c.zw.Close(); err == nil {
+		err = zerr
+	}
+	c.mu.Unlock()
+
+	if cl, ok := c.w.(io.Closer); ok {
+		if cerr := cl.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func (c *CompressedWriter) timedFlush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.flushLocked()
+}
+
+// flushLocked flushes the compressor. A failure is remembered in c.err so
+// that it can be reported to the next caller.
+func (c *CompressedWriter) flushLocked() error {
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+
+	if c.pending == 0 {
+		return nil
+	}
+
+	err := c.zw.Flush()
+	c.pending = 0
+	if err != nil && c.err == nil {
+		c.err = err
+	}
+	return err
+}
+
+func (c *CompressedWriter) takeErr() error {
+	err := c.err
+	c.err = nil
+	return err
+}
@@ -0,0 +1,122 @@
+package trifle
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gunzip returns what data decompresses to, up to where it ends, and
+// whether the stream was ended.
+func gunzip(t *testing.T, data []byte) (string, bool) {
+	t.Helper()
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	}
+	return string(out), err == nil
+}
+
+func TestCompressedWriterRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+
+	cw, err := NewCompressedWriter(&buf, Gzip, BatchOptions{MaxDelay: time.Hour})
+	require.NoError(t, err)
+
+	logger := slog.New(New(cw, nil))
+	for i := 0; i < 100; i++ {
+		logger.Info("record", "n", i)
+	}
+	require.NoError(t, cw.Close())
+
+	out, ended := gunzip(t, buf.Bytes())
+	assert.True(t, ended)
+	assert.Equal(t, 100, bytes.Count([]byte(out), []byte("record")))
+	assert.Less(t, buf.Len(), len(out))
+}
+
+func TestCompressedWriterFlushes(t *testing.T) {
+	var cw countingWriter
+
+	zw, err := NewCompressedWriter(&cw, Gzip, BatchOptions{MaxBytes: 10, MaxDelay: time.Hour})
+	require.NoError(t, err)
+	_, err = zw.Write([]byte("0123456789"))
+	require.NoError(t, err)
+
+	output, _ := cw.state()
+	out, ended := gunzip(t, []byte(output))
+	assert.False(t, ended)
+	assert.Equal(t, "0123456789", out, "a full buffer is flushed")
+
+	cw = countingWriter{}
+	zw, err = NewCompressedWriter(&cw, Gzip, BatchOptions{MaxDelay: time.Millisecond})
+	require.NoError(t, err)
+	_, err = zw.Write([]byte("delayed"))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		output, _ := cw.state()
+		if output == "" {
+			return false
+		}
+		out, _ := gunzip(t, []byte(output))
+		return out == "delayed"
+	}, time.Second, time.Millisecond)
+}
+
+func TestCompressedWriterReportsErrors(t *testing.T) {
+	cw := countingWriter{err: errors.New("disk full")}
+
+	// unlike gzip, flate writes nothing before it is flushed
+	codec := func(w io.Writer) (Compressor, error) {
+		return flate.NewWriter(w, flate.DefaultCompression)
+	}
+	zw, err := NewCompressedWriter(&cw, codec, BatchOptions{MaxDelay: time.Millisecond})
+	require.NoError(t, err)
+	_, err = zw.Write([]byte("lost"))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, writes := cw.state()
+		return writes > 0
+	}, time.Second, time.Millisecond)
+
+	_, err = zw.Write([]byte("more"))
+	assert.EqualError(t, err, "disk full")
+}
+
+func TestCompressedWriterCodec(t *testing.T) {
+	var buf bytes.Buffer
+
+	codec := func(w io.Writer) (Compressor, error) {
+		return zlib.NewWriterLevel(w, zlib.BestSpeed)
+	}
+	zw, err := NewCompressedWriter(&buf, codec, BatchOptions{})
+	require.NoError(t, err)
+	_, err = zw.Write([]byte("through zlib"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	zr, err := zlib.NewReader(&buf)
+	require.NoError(t, err)
+	out, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, "through zlib", string(out))
+
+	_, err = NewCompressedWriter(&buf, func(io.Writer) (Compressor, error) {
+		return nil, errors.New("no such level")
+	}, BatchOptions{})
+	assert.EqualError(t, err, "no such level")
+}
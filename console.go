@@ -0,0 +1,207 @@
+package trifle
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// consoleMethod returns the method of the browser console that shows
+// records at level.
+func consoleMethod(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return "debug"
+	case level < slog.LevelWarn:
+		return "info"
+	case level < slog.LevelError:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+// consoleColors approximates the 16 colors of a terminal theme, readable
+// on the light and dark themes of browser developer tools.
+var consoleColors = [16]string{
+	"#000000", "#cd3131", "#0dbc79", "#e5e510", "#2472c8", "#bc3fbc", "#11a8cd", "#e5e5e5",
+	"#666666", "#f14c4c", "#23d18b", "#f5f543", "#3b8eea", "#d670d6", "#29b8db", "#e5e5e5",
+}
+
+// consoleStyle is the state of SGR attributes, as CSS.
+type consoleStyle struct {
+	fg, bg                         string
+	bold, faint, italic, underline bool
+}
+
+func (s consoleStyle) css() string {
+	var props []string
+	fg := s.fg
+	if s.faint && fg == "" {
+		fg = "gray"
+	}
+	if fg != "" {
+		props = append(props, "color: "+fg)
+	}
+	if s.bg != "" {
+		props = append(props, "background: "+s.bg)
+	}
+	if s.bold {
+		props = append(props, "font-weight: bold")
+	}
+	if s.faint {
+		props = append(props, "opacity: 0.7")
+	}
+	if s.italic {
+		props = append(props, "font-style: italic")
+	}
+	if s.underline {
+		props = append(props, "text-decoration: underline")
+	}
+	return strings.Join(props, "; ")
+}
+
+// apply updates s with the parameters of an SGR sequence.
+func (s *consoleStyle) apply(params []int) {
+	if len(params) == 0 {
+		params = []int{0}
+	}
+	for i := 0; i < len(params); i++ {
+		switch p := params[i]; {
+		case p == 0:
+			*s = consoleStyle{}
+		case p == 1:
+			s.bold = true
+		case p == 2:
+			s.faint = true
+		case p == 3:
+			s.italic = true
+		case p == 4:
+			s.underline = true
+		case p == 22:
+			s.bold, s.faint = false, false
+		case p == 23:
+			s.italic = false
+		case p == 24:
+			s.underline = false
+		case p >= 30 && p <= 37:
+			s.fg = consoleColors[p-30]
+		case p >= 90 && p <= 97:
+			s.fg = consoleColors[p-90+8]
+		case p == 39:
+			s.fg = ""
+		case p >= 40 && p <= 47:
+			s.bg = consoleColors[p-40]
+		case p >= 100 && p <= 107:
+			s.bg = consoleColors[p-100+8]
+		case p == 49:
+			s.bg = ""
+		case p == 38 || p == 48:
+			color, n := extendedColor(params[i+1:])
+			i += n
+			if p == 38 {
+				s.fg = color
+			} else {
+				s.bg = color
+			}
+		}
+	}
+}
+
+// extendedColor returns the CSS color of the parameters following 38 or
+// 48, in the 256-color or the RGB form, and how many of them it used.
+func extendedColor(params []int) (string, int) {
+	switch {
+	case len(params) >= 2 && params[0] == 5:
+		n := params[1]
+		switch {
+		case n < 0 || n > 255:
+			return "", 2
+		case n < 16:
+			return consoleColors[n], 2
+		case n < 232:
+			n -= 16
+			level := func(v int) int {
+				if v == 0 {
+					return 0
+				}
+				return 55 + v*40
+			}
+			return fmt.Sprintf("#%02x%02x%02x", level(n/36), level(n/6%6), level(n%6)), 2
+		default:
+			v := 8 + (n-232)*10
+			return fmt.Sprintf("#%02x%02x%02x", v, v, v), 2
+		}
+	case len(params) >= 4 && params[0] == 2:
+		return fmt.Sprintf("rgb(%d, %d, %d)", params[1], params[2], params[3]), 4
+	}
+	return "", len(params)
+}
+
+// consoleFormat turns s, styled with SGR sequences, into a format string
+// for the browser console and its arguments: for every run of text, a CSS
+// style for a %c directive and the text for a %s one, so that no text is
+// taken as a directive. Other escape sequences are removed.
+func consoleFormat(s string) (string, []any) {
+	var (
+		format strings.Builder
+		args   []any
+		style  consoleStyle
+		text   strings.Builder
+	)
+	flush := func() {
+		if text.Len() == 0 {
+			return
+		}
+		format.WriteString("%c%s")
+		args = append(args, style.css(), text.String())
+		text.Reset()
+	}
+
+	for i := 0; i < len(s); {
+		if s[i] != 0x1b {
+			text.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		// A CSI sequence ends with a byte from 0x40 to 0x7e.
+		if i+1 >= len(s) || s[i+1] != '[' {
+			i++
+			continue
+		}
+		end := i + 2
+		for end < len(s) && (s[end] < 0x40 || s[end] > 0x7e) {
+			end++
+		}
+		if end >= len(s) {
+			break
+		}
+		if s[end] == 'm' {
+			flush()
+			style.apply(sgrParams(s[i+2 : end]))
+		}
+		i = end + 1
+	}
+	flush()
+
+	return format.String(), args
+}
+
+// sgrParams parses the parameters of an SGR sequence, such as "1;38;5;42".
+func sgrParams(s string) []int {
+	if s == "" {
+		return nil
+	}
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == ':' })
+	params := make([]int, 0, len(fields))
+	for _, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			n = -1
+		}
+		params = append(params, n)
+	}
+	return params
+}
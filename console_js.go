@@ -0,0 +1,78 @@
+//go:build js && wasm
+
+package trifle
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"syscall/js"
+)
+
+// BrowserConsole is a [TextHandler] writing to the console of the browser
+// running the program, for Go compiled to WebAssembly. Records go to
+// console.debug, console.info, console.warn or console.error by their
+// level, so the developer tools can filter them, and their colors and
+// text attributes are shown through %c styles approximating the terminal
+// theme.
+type BrowserConsole struct {
+	*TextHandler
+	out *browserConsoleWriter
+}
+
+// browserConsoleWriter passes what a TextHandler writes to the console,
+// with the method of the level of the record being handled.
+type browserConsoleWriter struct {
+	mu    sync.Mutex // held while a record is handled
+	level slog.Level
+}
+
+// NewBrowserConsole returns a BrowserConsole configured like [New]. The
+// console doesn't wrap lines to a width, so neither does the handler.
+func NewBrowserConsole(opts *slog.HandlerOptions, options ...Option) *BrowserConsole {
+	out := &browserConsoleWriter{level: slog.LevelInfo}
+	options = append([]Option{WithTerminalWidth(0)}, options...)
+	return &BrowserConsole{TextHandler: New(out, opts, options...), out: out}
+}
+
+// Handle writes r to the console method for its level.
+func (c *BrowserConsole) Handle(ctx context.Context, r slog.Record) error {
+	c.out.mu.Lock()
+	defer c.out.mu.Unlock()
+
+	c.out.level = r.Level
+	defer func() { c.out.level = slog.LevelInfo }()
+	return c.TextHandler.Handle(ctx, r)
+}
+
+// HandleBatch handles the records one at a time, so each goes to the
+// console method for its level.
+func (c *BrowserConsole) HandleBatch(ctx context.Context, records []slog.Record) error {
+	for _, r := range records {
+		if !c.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := c.Handle(ctx, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithAttrs returns a BrowserConsole whose records include attrs.
+func (c *BrowserConsole) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &BrowserConsole{TextHandler: c.TextHandler.WithAttrs(attrs).(*TextHandler), out: c.out}
+}
+
+// WithGroup returns a BrowserConsole nesting later attributes in group
+// name.
+func (c *BrowserConsole) WithGroup(name string) slog.Handler {
+	return &BrowserConsole{TextHandler: c.TextHandler.WithGroup(name).(*TextHandler), out: c.out}
+}
+
+func (w *browserConsoleWriter) Write(p []byte) (int, error) {
+	format, args := consoleFormat(strings.TrimSuffix(string(p), "\n"))
+	js.Global().Get("console").Call(consoleMethod(w.level), append([]any{format}, args...)...)
+	return len(p), nil
+}
@@ -0,0 +1,55 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestConsoleFormat(t *testing.T) {
+	format, args := consoleFormat("\x1b[1;31mfailed\x1b[0m at 100% \x1b[38;5;46mok\x1b[22m\x1b[2mdim\x1b[0m\x1b[2Jrest")
+
+	assert.Equal(t, "%c%s%c%s%c%s%c%s%c%s", format)
+	assert.Equal(t, []any{
+		"color: #cd3131; font-weight: bold", "failed",
+		"", " at 100% ",
+		"color: #00ff00", "ok",
+		"color: #00ff00; opacity: 0.7", "dim",
+		"", "rest",
+	}, args)
+}
+
+func TestConsoleFormatHandlerOutput(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	var buf bytes.Buffer
+	logger := slog.New(New(&buf, nil, WithTerminalWidth(0)))
+	logger.Warn("disk almost full", "free", "3%")
+
+	format, args := consoleFormat(strings.TrimSuffix(buf.String(), "\n"))
+	assert.Equal(t, strings.Count(format, "%c%s")*2, len(args))
+
+	var text strings.Builder
+	for i := 1; i < len(args); i += 2 {
+		text.WriteString(args[i].(string))
+	}
+	assert.Equal(t, Plain(buf.String()), text.String()+"\n")
+}
+
+func TestConsoleMethod(t *testing.T) {
+	for level, want := range map[slog.Level]string{
+		Trace:               "debug",
+		slog.LevelDebug:     "debug",
+		slog.LevelInfo:      "info",
+		slog.LevelWarn:      "warn",
+		slog.LevelError:     "error",
+		slog.LevelError + 4: "error",
+	} {
+		assert.Equal(t, want, consoleMethod(level), level.String())
+	}
+}
@@ -0,0 +1,23 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := slog.New(New(&buf, nil, WithContextKey("request_id"))).With("request_id", "req-ctx")
+	ctx := NewContext(context.Background(), logger)
+
+	FromContext(ctx).Info("from context")
+	assert.Regexp(t, `req-ctx.*from context`, buf.String())
+
+	assert.Same(t, slog.Default(), FromContext(context.Background()))
+	assert.Same(t, slog.Default(), FromContext(NewContext(context.Background(), nil)))
+}
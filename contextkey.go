@@ -0,0 +1,95 @@
+package trifle
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// OverrideContext returns an Attr that replaces the value of a context key
+// set by [WithContextKey] on a parent logger, rather than colliding with it:
+//
+//	logger := base.With("request_id", "req-1")
+//	logger.With("request_id", "req-2").Info("retry")                    // req-1→req-2 retry
+//	logger.With(trifle.OverrideContext("request_id", "req-2")).Info("retry") // req-2 retry
+//
+// Setting a context key that already has a different value shows both in
+// the prefix, the stale one first, since only one of them can be right.
+// Other handlers see the Attr as key and value.
+func OverrideContext(key string, value any) slog.Attr {
+	return slog.Any(key, contextOverride{value})
+}
+
+// contextOverride marks the value of an Attr made by OverrideContext.
+type contextOverride struct {
+	value any
+}
+
+func (o contextOverride) LogValue() slog.Value {
+	return slog.AnyValue(o.value)
+}
+
+// contextString returns the text of the value of a context key and whether
+// it was given by OverrideContext.
+func contextString(v slog.Value) (s string, override bool) {
+	if o, ok := v.Any().(contextOverride); ok {
+		return fmt.Sprint(o.value), true
+	}
+	return fmt.Sprint(v.Any()), false
+}
+
+// contextMap holds the values of context keys set on a handler.
+type contextMap map[string]contextValue
+
+// contextValue is the value of a context key set on a handler.
+type contextValue struct {
+	first string // the value it was set to first
+	last  string // a different value it was set to later, "" if none
+}
+
+// set returns v after setting the key to s.
+func (v contextValue) set(s string, override bool) contextValue {
+	switch {
+	case override || v.first == "":
+		return contextValue{first: s}
+	case s == v.first:
+		return contextValue{first: v.first}
+	default:
+		return contextValue{first: v.first, last: s}
+	}
+}
+
+// current returns the value the key was set to last.
+func (v contextValue) current() string {
+	if v.last != "" {
+		return v.last
+	}
+	return v.first
+}
+
+// contextValuesOf returns the values of the context keys for r, in the
+// order of the keys: those set on the handler, updated by the attributes
+// of r. A key without a value has the zero contextValue.
+func (h *commonHandler) contextValuesOf(r slog.Record) []contextValue {
+	vals := make([]contextValue, len(h.contextKeys))
+	for i, key := range h.contextKeys {
+		vals[i] = h.contextValues[key]
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		for i, key := range h.contextKeys {
+			if a.Key == key {
+				vals[i] = vals[i].set(contextString(a.Value))
+			}
+		}
+		return true
+	})
+	return vals
+}
+
+// String returns the value for the prefix, annotated as "first→last" when
+// it was set to something else.
+func (v contextValue) String() string {
+	if v.last != "" {
+		return v.first + "→" + v.last
+	}
+	return v.first
+}
@@ -0,0 +1,39 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextKeyCollision(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithContextKey("request_id"))
+
+	base := slog.New(h).With("request_id", "req-1")
+	base.Info("first")
+	base.With("request_id", "req-2").Info("derived")
+	base.With("request_id", "req-1").Info("same")
+	base.Info("record", "request_id", "req-3")
+	base.With(OverrideContext("request_id", "req-4")).Info("override")
+	base.Info("record override", OverrideContext("request_id", "req-5"))
+
+	out := Plain(buf.String())
+	assert.True(t, MatchesLine(out, `\[INFO\]  req-1 first$`), out)
+	assert.True(t, MatchesLine(out, `\[INFO\]  req-1→req-2 derived$`), out)
+	assert.True(t, MatchesLine(out, `\[INFO\]  req-1 same$`), out)
+	assert.True(t, MatchesLine(out, `\[INFO\]  req-1→req-3 record `), out)
+	assert.True(t, MatchesLine(out, `\[INFO\]  req-4 override$`), out)
+	assert.True(t, MatchesLine(out, `\[INFO\]  req-5 record override `), out)
+}
+
+func TestOverrideContextOtherKeys(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithContextKey("user"))
+
+	slog.New(h).Info("served", OverrideContext("request_id", "req-1"))
+
+	assert.True(t, ContainsAttr(buf.String(), "request_id", "req-1"))
+}
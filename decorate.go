@@ -0,0 +1,145 @@
+package trifle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// StackKey is the attribute key used by [DecorateStack].
+const StackKey = "stack"
+
+// BuildKey is the attribute key used by [DecorateBuild].
+const BuildKey = "build"
+
+// maxStackFrames limits the frames DecorateStack captures.
+const maxStackFrames = 32
+
+// Decorator returns attributes to add to a record, see [WithLevelDecorator].
+type Decorator func(ctx context.Context, r slog.Record) []slog.Attr
+
+// WithLevelDecorator returns an Option that adds the attributes returned
+// by fn to the records at level or above, so that errors carry what it
+// takes to investigate them while the rest of the output stays lean:
+//
+//	trifle.New(os.Stderr, nil,
+//		trifle.WithLevelDecorator(slog.LevelError, trifle.DecorateSource),
+//		trifle.WithLevelDecorator(slog.LevelError, trifle.DecorateStack),
+//		trifle.WithLevelDecorator(slog.LevelWarn, trifle.DecorateBuild))
+//
+// Decorators run in the order they were added, after the filters, so only
+// records that are written pay for them. See [WithTransformer] for the
+// order of the pipeline.
+func WithLevelDecorator(level slog.Level, fn Decorator) Option {
+	return func(h *TextHandler) {
+		h.decorators = append(h.decorators, levelDecorator{level: level, fn: fn})
+	}
+}
+
+type levelDecorator struct {
+	level slog.Level
+	fn    Decorator
+}
+
+func (h *TextHandler) decorateStage(ctx context.Context, r slog.Record, _ bool) (slog.Record, bool) {
+	for _, d := range h.decorators {
+		if r.Level < d.level {
+			continue
+		}
+		if attrs := d.fn(ctx, r); len(attrs) > 0 {
+			r = CloneWithAttrs(r, attrs...)
+		}
+	}
+	return r, true
+}
+
+func (h *commonHandler) validateDecorators() []error {
+	for _, d := range h.decorators {
+		if d.fn == nil {
+			return []error{errors.New("decorator must not be nil")}
+		}
+	}
+	return nil
+}
+
+// DecorateSource is a [Decorator] that adds the source position of the log
+// call, shown as FILE:LINE, like the AddSource option does for all records.
+func DecorateSource(_ context.Context, r slog.Record) []slog.Attr {
+	if r.PC == 0 {
+		return nil
+	}
+	return []slog.Attr{slog.Any(slog.SourceKey, recordSource(r))}
+}
+
+// DecorateStack is a [Decorator] that adds the stack of the log call, one
+// frame per line, starting with the function that logged the record. It
+// adds nothing when the record is handled away from the goroutine that
+// logged it, as in a batch.
+func DecorateStack(_ context.Context, r slog.Record) []slog.Attr {
+	if r.PC == 0 {
+		return nil
+	}
+	src := recordSource(r)
+
+	var pcs [64]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+
+	var (
+		b     strings.Builder
+		n     int
+		found bool
+	)
+	for n < maxStackFrames {
+		f, more := frames.Next()
+		if !found {
+			found = f.Function == src.Function
+		}
+		if found {
+			if n > 0 {
+				b.WriteByte('\n')
+			}
+			fmt.Fprintf(&b, "%s\n\t%s:%d", f.Function, f.File, f.Line)
+			n++
+		}
+		if !more {
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+	return []slog.Attr{slog.String(StackKey, b.String())}
+}
+
+// DecorateBuild is a [Decorator] that adds a group describing the running
+// binary: its module version, VCS revision and Go version, as far as they
+// were recorded when it was built.
+func DecorateBuild(context.Context, slog.Record) []slog.Attr {
+	if attrs := buildAttrs(); len(attrs) > 0 {
+		return []slog.Attr{slog.Attr{Key: BuildKey, Value: slog.GroupValue(attrs...)}}
+	}
+	return nil
+}
+
+var buildAttrs = sync.OnceValue(func() []slog.Attr {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	var attrs []slog.Attr
+	if v := bi.Main.Version; v != "" && v != "(devel)" {
+		attrs = append(attrs, slog.String("version", v))
+	}
+	for _, s := range bi.Settings {
+		if s.Key == "vcs.revision" {
+			attrs = append(attrs, slog.String("revision", s.Value))
+		}
+	}
+	return append(attrs, slog.String("go", bi.GoVersion))
+})
@@ -0,0 +1,58 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelDecorator(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := NewE(&buf, nil, WithTerminalWidth(0),
+		WithLevelDecorator(slog.LevelError, DecorateSource),
+		WithLevelDecorator(slog.LevelError, DecorateStack),
+		WithLevelDecorator(slog.LevelWarn, DecorateBuild))
+	require.NoError(t, err)
+
+	log := slog.New(h)
+	log.Info("listening")
+	log.Warn("slow")
+	log.Error("failed")
+
+	lines := strings.Split(Plain(buf.String()), "\n")
+	assert.NotContains(t, lines[0], "source")
+	assert.NotContains(t, lines[0], "build")
+
+	assert.Contains(t, lines[1], "build.go: go")
+	assert.NotContains(t, lines[1], "source")
+
+	out := Plain(buf.String())
+	assert.Regexp(t, `failed │ .*source: \S+decorate_test\.go:\d+`, out)
+	assert.Contains(t, out, "miren.dev/trifle.TestLevelDecorator")
+}
+
+func TestDecorateStackElsewhere(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, DecorateStack(ctx, slog.Record{}))
+
+	var pcs [1]uintptr
+	runtime.Callers(1, pcs[:])
+	r := slog.NewRecord(time.Now(), slog.LevelError, "failed", pcs[0])
+	assert.NotEmpty(t, DecorateStack(ctx, r))
+
+	done := make(chan []slog.Attr)
+	go func() { done <- DecorateStack(ctx, r) }()
+	assert.Empty(t, <-done)
+}
+
+func TestLevelDecoratorValidation(t *testing.T) {
+	_, err := NewE(&bytes.Buffer{}, nil, WithLevelDecorator(slog.LevelError, nil))
+	assert.ErrorContains(t, err, "decorator must not be nil")
+}
@@ -0,0 +1,182 @@
+package trifle
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDedupWindow is the window used by [WithDedupKey] unless
+// [WithDedupWindow] sets another.
+const DefaultDedupWindow = time.Second
+
+// WithDedupKey returns an Option that suppresses a record when another one
+// with the same level, module, message, logger attributes and values of
+// keys was written within the dedup window. Once the window of the first
+// record closes, the last suppressed one is written with the number of
+// records suppressed, so a reconnect loop logging the same peer every
+// 100ms shows up once per window rather than filling the terminal.
+//
+// Without keys, records are compared by message alone. Suppressed records
+// are counted as dropped in [Stats]. [TextHandler.Close] writes the
+// summaries of the windows still open rather than waiting for them.
+func WithDedupKey(keys ...string) Option {
+	return func(h *TextHandler) {
+		if h.dedup == nil {
+			h.dedup = newDeduper(DefaultDedupWindow)
+		}
+		h.dedup.keys = keys
+	}
+}
+
+// WithDedupWindow returns an Option that sets the window of [WithDedupKey].
+// Used on its own, it deduplicates records by message.
+func WithDedupWindow(window time.Duration) Option {
+	return func(h *TextHandler) {
+		if h.dedup == nil {
+			h.dedup = newDeduper(window)
+		}
+		h.dedup.window = window
+	}
+}
+
+// deduper remembers the records written recently. It is shared by all
+// clones of a handler.
+type deduper struct {
+	keys   []string
+	window time.Duration
+
+	mu     sync.Mutex
+	seen   map[string]*dedupEntry
+	closed bool           // records are no longer deduplicated, see close
+	timers sync.WaitGroup // timers that may still write a summary
+}
+
+type dedupEntry struct {
+	h          *TextHandler // handler that wrote the first record
+	raw        bool
+	last       slog.Record
+	suppressed int
+	timer      *time.Timer // closes the window
+}
+
+func newDeduper(window time.Duration) *deduper {
+	return &deduper{window: window, seen: make(map[string]*dedupEntry)}
+}
+
+// suppress reports whether r repeats a record written within the window.
+// If it doesn't, r opens a new window.
+func (d *deduper) suppress(h *TextHandler, r slog.Record, raw bool) bool {
+	key := d.key(h, r)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return false
+	}
+	if e, ok := d.seen[key]; ok {
+		e.suppressed++
+		e.last = r.Clone()
+		return true
+	}
+
+	e := &dedupEntry{h: h, raw: raw}
+	d.seen[key] = e
+	d.timers.Add(1)
+	e.timer = time.AfterFunc(d.window, func() {
+		defer d.timers.Done()
+		d.expire(key)
+	})
+	return false
+}
+
+// expire closes the window for key, writing a summary of the records it
+// suppressed.
+func (d *deduper) expire(key string) {
+	d.mu.Lock()
+	e := d.seen[key]
+	delete(d.seen, key)
+	d.mu.Unlock()
+
+	if e != nil {
+		d.summarize(e)
+	}
+}
+
+// close stops the timers of the open windows and writes their summaries
+// at once, so that none is lost at shutdown or written after it. Records
+// are no longer deduplicated afterwards.
+func (d *deduper) close() {
+	d.mu.Lock()
+	d.closed = true
+	var open []*dedupEntry
+	for key, e := range d.seen {
+		// A timer that can't be stopped has fired, and expire writes its
+		// summary.
+		if e.timer.Stop() {
+			delete(d.seen, key)
+			d.timers.Done()
+			open = append(open, e)
+		}
+	}
+	d.mu.Unlock()
+
+	slices.SortFunc(open, func(a, b *dedupEntry) int { return a.last.Time.Compare(b.last.Time) })
+	for _, e := range open {
+		d.summarize(e)
+	}
+	d.timers.Wait()
+}
+
+// summarize writes the last record suppressed in the window of e with the
+// number of records suppressed, if any were.
+func (d *deduper) summarize(e *dedupEntry) {
+	if e.suppressed == 0 {
+		return
+	}
+
+	r := slog.NewRecord(time.Now(), e.last.Level, e.last.Message, e.last.PC)
+	e.last.Attrs(func(a slog.Attr) bool {
+		r.AddAttrs(a)
+		return true
+	})
+	r.AddAttrs(slog.Int("suppressed", e.suppressed), slog.Duration("within", d.window))
+
+	_ = e.h.handle(context.Background(), r, e.h.module, e.raw)
+}
+
+// key identifies the records that count as repeats of r.
+func (d *deduper) key(h *TextHandler, r slog.Record) string {
+	var sb strings.Builder
+
+	sb.WriteString(r.Level.String())
+	sb.WriteByte(0)
+	sb.WriteString(h.module)
+	sb.WriteByte(0)
+	sb.WriteString(r.Message)
+	sb.WriteByte(0)
+	sb.Write(h.preformattedAttrs)
+	sb.WriteString(h.groupPrefix)
+
+	if len(d.keys) > 0 {
+		values := make([]string, len(d.keys))
+		r.Attrs(func(a slog.Attr) bool {
+			for i, k := range d.keys {
+				if a.Key == k {
+					values[i] = formatValueAsString(a.Value.Resolve())
+				}
+			}
+			return true
+		})
+		for _, v := range values {
+			sb.WriteByte(0)
+			sb.WriteString(v)
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,96 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle/pkg/color"
+)
+
+// syncBuffer is a bytes.Buffer safe for use by the dedup timers.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// String returns the output without colors.
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return color.Strip(b.buf.String())
+}
+
+func TestDedupKey(t *testing.T) {
+	var buf syncBuffer
+
+	handler := New(&buf, nil, PresetTest, WithDedupKey("peer"), WithDedupWindow(50*time.Millisecond))
+	defer handler.Close()
+	logger := slog.New(handler).With("module", "net")
+
+	for i := range 5 {
+		logger.Warn("reconnecting", "peer", "10.0.0.1", "attempt", i)
+	}
+	logger.Warn("reconnecting", "peer", "10.0.0.2", "attempt", 0)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "peer: 10.0.0.1")
+	assert.Contains(t, lines[1], "peer: 10.0.0.2")
+	assert.Equal(t, uint64(4), handler.Stats().Dropped)
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(buf.String(), "suppressed")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Regexp(t, `net reconnecting │ peer: 10.0.0.1 attempt: 4 suppressed: 4 within: 50ms`, lines[2])
+
+	// A new window starts once the previous one closed.
+	logger.Warn("reconnecting", "peer", "10.0.0.1", "attempt", 5)
+	assert.Contains(t, buf.String(), "attempt: 5")
+}
+
+func TestDedupCloseWritesSummaries(t *testing.T) {
+	var buf syncBuffer
+
+	handler := New(&buf, nil, PresetTest, WithDedupKey("peer"), WithDedupWindow(time.Hour))
+	logger := slog.New(handler)
+
+	for i := range 3 {
+		logger.Warn("reconnecting", "peer", "10.0.0.1", "attempt", i)
+	}
+	logger.Warn("reconnecting", "peer", "10.0.0.2", "attempt", 0)
+	for i := range 2 {
+		logger.Info("polling", "attempt", i)
+	}
+
+	require.NoError(t, handler.Close())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 5, buf.String())
+	assert.Regexp(t, `reconnecting │ peer: 10.0.0.1 attempt: 2 suppressed: 2 within: 1h0m0s`, lines[3])
+	assert.Regexp(t, `polling │ attempt: 1 suppressed: 1 within: 1h0m0s`, lines[4])
+
+	// Records are written as they come once the handler is closed.
+	logger.Info("polling", "attempt", 2)
+	logger.Info("polling", "attempt", 3)
+	assert.Contains(t, buf.String(), "attempt: 3")
+}
+
+func TestDedupWindowValidation(t *testing.T) {
+	_, err := NewE(&bytes.Buffer{}, nil, WithDedupKey("peer"), WithDedupWindow(0))
+	assert.ErrorContains(t, err, "dedup window")
+}
@@ -0,0 +1,163 @@
+package trifle
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+// DiffKey is the attribute key used by [Diff].
+const DiffKey = "diff"
+
+var diffAddColor = color.New(color.FgHiGreen)
+
+// diffContext is how many unchanged lines are kept around a change.
+const diffContext = 2
+
+// maxDiffCells bounds the table used to line up the lines of a diff. Longer
+// texts are shown as entirely removed and added.
+const maxDiffCells = 1 << 22
+
+// Diff returns an Attr showing how got differs from want, line by line. The
+// TextHandler shows it under the record, with the lines only in want marked
+// "-" in the color of critical keys, the lines only in got marked "+" in
+// green, and a few unchanged lines around each change dimmed:
+//
+//	logger.Error("config differs", trifle.Diff(want, got))
+//
+//	12:00:00.000 [ERROR] config differs
+//	    - level: info
+//	    + level: debug
+//	      workers: 4
+//
+// Longer runs of unchanged lines are left out. Other handlers see the diff
+// as a string with the key "diff".
+func Diff(want, got string) slog.Attr {
+	return slog.Any(DiffKey, diffLines(want, got))
+}
+
+// diffLine is a line of a diff. Its op is '-' or '+' for a removed or added
+// line, ' ' for an unchanged one, and 0 for unchanged lines left out.
+type diffLine struct {
+	op   byte
+	text string
+}
+
+type diff []diffLine
+
+func (d diff) LogValue() slog.Value {
+	lines := make([]string, len(d))
+	for i, l := range d {
+		lines[i] = l.String()
+	}
+	return slog.StringValue(strings.Join(lines, "\n"))
+}
+
+func (l diffLine) String() string {
+	if l.op == 0 {
+		return l.text
+	}
+	return string(l.op) + " " + l.text
+}
+
+// diffLines returns the diff turning want into got.
+func diffLines(want, got string) diff {
+	a, b := strings.Split(want, "\n"), strings.Split(got, "\n")
+
+	var d diff
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			d = append(d, diffLine{'-', line})
+		}
+		for _, line := range b {
+			d = append(d, diffLine{'+', line})
+		}
+		return d
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			d = append(d, diffLine{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			d = append(d, diffLine{'-', a[i]})
+			i++
+		default:
+			d = append(d, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	return d.trim()
+}
+
+// trim replaces the runs of unchanged lines further than diffContext from
+// any change with a line saying how many were left out.
+func (d diff) trim() diff {
+	keep := make([]bool, len(d))
+	for i, l := range d {
+		if l.op == ' ' {
+			continue
+		}
+		for k := max(i-diffContext, 0); k <= min(i+diffContext, len(d)-1); k++ {
+			keep[k] = true
+		}
+	}
+
+	var out diff
+	for i := 0; i < len(d); {
+		if keep[i] {
+			out = append(out, d[i])
+			i++
+			continue
+		}
+		end := i
+		for end < len(d) && !keep[end] {
+			end++
+		}
+		if n := end - i; n == 1 {
+			out = append(out, d[i])
+		} else {
+			out = append(out, diffLine{text: fmt.Sprintf("⋯ %d unchanged lines", n)})
+		}
+		i = end
+	}
+	return out
+}
+
+// diffFootnote returns the lines to write under the record for d.
+func (s *handleState) diffFootnote(d diff) []string {
+	lines := make([]string, len(d))
+	for i, l := range d {
+		l.text = s.h.safe(l.text)
+		switch l.op {
+		case '-':
+			lines[i] = footnoteIndent + criticalKeyColor.Sprint(l.String())
+		case '+':
+			lines[i] = footnoteIndent + diffAddColor.Sprint(l.String())
+		default:
+			lines[i] = footnoteIndent + footnoteColor.Sprint(l.String())
+		}
+	}
+	return lines
+}
@@ -0,0 +1,57 @@
+package trifle
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(New(&buf, nil, WithTerminalWidth(0)))
+
+	log.Error("config differs", Diff("level: info\nworkers: 4", "level: debug\nworkers: 4"))
+
+	assert.Equal(t, ` [ERROR] config differs
+    - level: info
+    + level: debug
+      workers: 4
+`, Plain(buf.String())[12:])
+}
+
+func TestDiffLeavesOutUnchangedLines(t *testing.T) {
+	var want []string
+	for i := range 20 {
+		want = append(want, fmt.Sprint("line ", i))
+	}
+	got := slices.Clone(want)
+	got[6] = "changed"
+	got = append(got, "added")
+
+	d := diffLines(strings.Join(want, "\n"), strings.Join(got, "\n"))
+	assert.Equal(t, strings.Join([]string{
+		"⋯ 4 unchanged lines",
+		"  line 4",
+		"  line 5",
+		"- line 6",
+		"+ changed",
+		"  line 7",
+		"  line 8",
+		"⋯ 9 unchanged lines",
+		"  line 18",
+		"  line 19",
+		"+ added",
+	}, "\n"), d.LogValue().String())
+}
+
+func TestDiffOtherHandlers(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("x", Diff("a\nb", "a\nc"))
+
+	assert.Contains(t, buf.String(), `"diff":"  a\n- b\n+ c"`)
+}
@@ -0,0 +1,171 @@
+package trifle
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/muesli/termenv"
+	"miren.dev/trifle/pkg/color"
+)
+
+// Capabilities describes what trifle detected about a terminal. It is meant
+// for debugging why colors or wrapping look wrong in an environment.
+type Capabilities struct {
+	// Terminal reports whether the file is attached to a terminal.
+	Terminal bool
+
+	// Width is the terminal width used for wrapping, or 0 if unknown.
+	Width int
+
+	// ColorProfile is the color support advertised by the environment:
+	// TrueColor, ANSI256, ANSI or Ascii.
+	ColorProfile string
+
+	// ColorEnabled reports whether trifle emits color at all, which is
+	// false when NO_COLOR is set or TERM is "dumb".
+	ColorEnabled bool
+
+	// Background is the terminal background color as reported by the
+	// terminal itself, in #rrggbb form, or empty if it didn't answer.
+	Background string
+
+	// Hyperlinks reports whether the terminal is known to support OSC 8
+	// hyperlinks.
+	Hyperlinks bool
+
+	// UTF8 reports whether the locale selects UTF-8, which the box drawing
+	// characters used by trifle rely on.
+	UTF8 bool
+
+	// UnicodeWidths holds how many columns trifle expects the samples of
+	// unicodeSamples to take, by sample. Wrapping and alignment go wrong on
+	// a terminal that draws them at other widths.
+	UnicodeWidths map[string]int
+
+	// Env holds the environment variables that influenced detection.
+	Env map[string]string
+}
+
+// doctorEnv lists the environment variables reported by DetectCapabilities.
+var doctorEnv = []string{
+	"TERM", "COLORTERM", "TERM_PROGRAM", "NO_COLOR", "CLICOLOR_FORCE",
+	"TMUX", "STY", "LANG", "LC_ALL", "LC_CTYPE",
+}
+
+// unicodeSamples are characters whose width terminals disagree on: CJK
+// ideographs, emoji and a letter with a combining accent.
+var unicodeSamples = []string{"日本語", "🙂", "e\u0301"}
+
+// unicodeWidths measures the widths of unicodeSamples.
+func unicodeWidths() map[string]int {
+	widths := make(map[string]int, len(unicodeSamples))
+	for _, s := range unicodeSamples {
+		widths[s] = color.StringWidth(s)
+	}
+	return widths
+}
+
+// DetectCapabilities inspects f and the environment. Querying the
+// background color talks to the terminal on os.Stdout, so it should not be
+// called while other goroutines are reading from the terminal.
+func DetectCapabilities(f *os.File) Capabilities {
+	c := Capabilities{
+		Terminal:     isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()),
+		Width:        getTerminalWidth(f),
+		ColorProfile: termenv.NewOutput(f).EnvColorProfile().Name(),
+		ColorEnabled: !color.NoColor,
+		Hyperlinks:   supportsHyperlinks(),
+		UTF8:         localeIsUTF8(),
+		Env:          make(map[string]string),
+
+		UnicodeWidths: unicodeWidths(),
+	}
+
+	if c.Terminal {
+		c.Background = color.Background()
+	}
+
+	for _, key := range doctorEnv {
+		if val, ok := os.LookupEnv(key); ok {
+			c.Env[key] = val
+		}
+	}
+
+	return c
+}
+
+// Report writes a human readable summary of c to w.
+func (c Capabilities) Report(w io.Writer) error {
+	background := c.Background
+	if background == "" {
+		background = "unknown"
+	}
+
+	width := "unknown (wrapping disabled)"
+	if c.Width > 0 {
+		width = strconv.Itoa(c.Width)
+	}
+
+	_, err := fmt.Fprintf(w,
+		"terminal:      %t\nwidth:         %s\ncolor profile: %s\ncolor enabled: %t\nbackground:    %s\nhyperlinks:    %t\nutf-8 locale:  %t\n",
+		c.Terminal, width, c.ColorProfile, c.ColorEnabled, background, c.Hyperlinks, c.UTF8)
+	if err != nil {
+		return err
+	}
+
+	if len(c.UnicodeWidths) > 0 {
+		var widths []string
+		for _, s := range unicodeSamples {
+			if n, ok := c.UnicodeWidths[s]; ok {
+				widths = append(widths, fmt.Sprintf("%s=%d", s, n))
+			}
+		}
+		if _, err := fmt.Fprintf(w, "unicode width: %s (columns expected)\n", strings.Join(widths, " ")); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range doctorEnv {
+		if val, ok := c.Env[key]; ok {
+			if _, err := fmt.Fprintf(w, "env %s=%q\n", key, val); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// supportsHyperlinks guesses OSC 8 support from the environment, since
+// terminals offer no way to query it.
+func supportsHyperlinks() bool {
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty":
+		return true
+	}
+
+	if os.Getenv("WT_SESSION") != "" || os.Getenv("KITTY_WINDOW_ID") != "" {
+		return true
+	}
+
+	// VTE based terminals (GNOME Terminal, Tilix, ...) gained support in 0.50.
+	if v, err := strconv.Atoi(os.Getenv("VTE_VERSION")); err == nil && v >= 5000 {
+		return true
+	}
+
+	return false
+}
+
+func localeIsUTF8() bool {
+	for _, key := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if val := os.Getenv(key); val != "" {
+			val = strings.ToUpper(val)
+			return strings.Contains(val, "UTF-8") || strings.Contains(val, "UTF8")
+		}
+	}
+	return false
+}
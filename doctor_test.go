@@ -0,0 +1,58 @@
+package trifle
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilitiesReport(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := Capabilities{
+		Width:        80,
+		ColorProfile: "ANSI256",
+		ColorEnabled: true,
+		Env:          map[string]string{"TERM": "xterm-256color"},
+
+		UnicodeWidths: unicodeWidths(),
+	}
+	require.NoError(t, c.Report(&buf))
+
+	output := buf.String()
+	assert.Contains(t, output, "width:         80\n")
+	assert.Contains(t, output, "color profile: ANSI256\n")
+	assert.Contains(t, output, "background:    unknown\n")
+	assert.Contains(t, output, `env TERM="xterm-256color"`)
+	assert.Contains(t, output, "unicode width: 日本語=6 🙂=2 e\u0301=1 (columns expected)\n")
+}
+
+func TestUnicodeWidths(t *testing.T) {
+	// Wide characters take two columns, whatever their length in bytes
+	// or runes, and combining marks none.
+	widths := unicodeWidths()
+	assert.Equal(t, 6, widths["日本語"])
+	assert.Equal(t, 2, widths["🙂"])
+	assert.Equal(t, 1, widths["e\u0301"])
+}
+
+func TestHyperlinkAndLocaleDetection(t *testing.T) {
+	t.Setenv("TERM_PROGRAM", "")
+	t.Setenv("WT_SESSION", "")
+	t.Setenv("KITTY_WINDOW_ID", "")
+	t.Setenv("VTE_VERSION", "6003")
+	assert.True(t, supportsHyperlinks())
+
+	t.Setenv("VTE_VERSION", "")
+	assert.False(t, supportsHyperlinks())
+
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_CTYPE", "")
+	t.Setenv("LANG", "en_US.utf8")
+	assert.True(t, localeIsUTF8())
+
+	t.Setenv("LC_ALL", "C")
+	assert.False(t, localeIsUTF8())
+}
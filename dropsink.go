@@ -0,0 +1,50 @@
+package trifle
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithDropSink returns an Option that passes the records the handler drops
+// to sink instead of discarding them: those rejected by [WithFilter] and
+// [WithFilterExpr], and the duplicates suppressed by [WithDedupKey]. With
+// a [Ring] or a [FileSink] as the sink, what was filtered out of the
+// console can still be looked at during an incident:
+//
+//	dropped := trifle.NewRing(1000)
+//	h := trifle.New(os.Stderr, nil,
+//		trifle.WithFilterExpr(`level >= info`),
+//		trifle.WithDropSink(dropped))
+//
+// The sink gets the attributes added with WithAttrs and WithGroup along
+// with the handler, and only the records it is enabled for. Errors from
+// it are ignored. Records below the handler's level never reach it, as
+// slog doesn't pass them to the handler at all. A nil sink discards dropped
+// records again.
+func WithDropSink(sink slog.Handler) Option {
+	return func(h *TextHandler) {
+		h.dropSink = sink
+	}
+}
+
+// drop counts r as dropped and passes it to the drop sink, if any.
+func (h *TextHandler) drop(ctx context.Context, r slog.Record) {
+	h.stats.drop()
+
+	if h.dropSink != nil && h.dropSink.Enabled(ctx, r.Level) {
+		_ = h.dropSink.Handle(ctx, r)
+	}
+}
+
+// deriveDropSink gives c, derived from h, the drop sink of h derived the
+// same way.
+func (h *TextHandler) deriveDropSink(c *commonHandler, derive func(slog.Handler) slog.Handler) *commonHandler {
+	if h.dropSink == nil {
+		return c
+	}
+	if c == h.commonHandler {
+		c = c.clone()
+	}
+	c.dropSink = derive(h.dropSink)
+	return c
+}
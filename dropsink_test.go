@@ -0,0 +1,52 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDropSinkGetsFilteredRecords(t *testing.T) {
+	var buf bytes.Buffer
+	ring := NewRing(10)
+	h := New(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}, WithTerminalWidth(0),
+		WithFilterExpr(`level >= info`),
+		WithDropSink(ring))
+
+	log := slog.New(h).With(ModuleKey, "api", "host", "a")
+	log.Debug("cache miss", "key", "u1")
+	log.Info("request")
+
+	assert.NotContains(t, buf.String(), "cache miss")
+	assert.Equal(t, uint64(1), h.Stats().Dropped)
+
+	records := ring.Records()
+	require.Len(t, records, 1)
+	assert.Equal(t, "cache miss", records[0].Message)
+	assert.Equal(t, map[string]string{"host": "a", "key": "u1", ModuleKey: "api"}, recordAttrs(records[0]))
+}
+
+func TestDropSinkGetsDuplicates(t *testing.T) {
+	ring := NewRing(10)
+	h := New(&bytes.Buffer{}, nil, WithDedupKey(), WithDropSink(ring))
+	defer h.Close()
+	log := slog.New(h)
+
+	for range 3 {
+		log.Info("reconnecting")
+	}
+
+	assert.Equal(t, 2, ring.Len())
+}
+
+func recordAttrs(r slog.Record) map[string]string {
+	m := make(map[string]string)
+	r.Attrs(func(a slog.Attr) bool {
+		m[a.Key] = a.Value.String()
+		return true
+	})
+	return m
+}
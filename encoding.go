@@ -0,0 +1,511 @@
+package trifle
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// Encoding is how a [Recorder] encodes the frames of the replay format.
+// The binary encodings use the same fields as JSON, with values in their
+// native types, and are smaller and cheaper to produce: they suit records
+// shipped over constrained links, or high-volume captures kept for later.
+// [Replay] reads all of them.
+type Encoding int
+
+const (
+	// EncodingJSON encodes frames as JSON, readable with common tools.
+	EncodingJSON Encoding = iota
+
+	// EncodingMessagePack encodes frames as MessagePack.
+	EncodingMessagePack
+
+	// EncodingCBOR encodes frames as CBOR, as defined by RFC 8949.
+	EncodingCBOR
+)
+
+// String returns the name of e.
+func (e Encoding) String() string {
+	switch e {
+	case EncodingJSON:
+		return "json"
+	case EncodingMessagePack:
+		return "msgpack"
+	case EncodingCBOR:
+		return "cbor"
+	}
+	return fmt.Sprintf("Encoding(%d)", int(e))
+}
+
+// codec returns the binary codec of e, or nil for JSON.
+func (e Encoding) codec() binaryCodec {
+	switch e {
+	case EncodingMessagePack:
+		return msgpackCodec{}
+	case EncodingCBOR:
+		return cborCodec{}
+	}
+	return nil
+}
+
+// frameEncoding returns the encoding of a replay frame, told apart by its
+// first byte: every frame is a map, which starts differently in each.
+func frameEncoding(frame []byte) Encoding {
+	if len(frame) == 0 {
+		return EncodingJSON
+	}
+	switch b := frame[0]; {
+	case b&0xf0 == 0x80, b == 0xde, b == 0xdf:
+		return EncodingMessagePack
+	case b >= 0xa0 && b <= 0xbb:
+		return EncodingCBOR
+	}
+	return EncodingJSON
+}
+
+// binaryCodec writes and reads the values of a binary encoding.
+type binaryCodec interface {
+	appendMap(b []byte, n int) []byte
+	appendArray(b []byte, n int) []byte
+	appendString(b []byte, s string) []byte
+	appendBytes(b []byte, bs []byte) []byte
+	appendInt(b []byte, i int64) []byte
+	appendUint(b []byte, u uint64) []byte
+	appendFloat(b []byte, f float64) []byte
+	appendBool(b []byte, v bool) []byte
+
+	// decode returns the value at the start of data, and the bytes after
+	// it. Maps are returned as map[string]any, arrays as []any and
+	// integers as int64 or uint64.
+	decode(data []byte, depth int) (any, []byte, error)
+}
+
+// maxBinaryDepth bounds the nesting of decoded values, so a corrupt frame
+// can't exhaust the stack.
+const maxBinaryDepth = 10000
+
+var errBinaryDepth = errors.New("values nested too deeply")
+
+func appendBinaryHeader(c binaryCodec, b []byte, h replayHeader) []byte {
+	b = c.appendMap(b, 3)
+	b = c.appendString(b, "format")
+	b = c.appendString(b, h.Format)
+	b = c.appendString(b, "version")
+	b = c.appendInt(b, int64(h.Version))
+	b = c.appendString(b, "created")
+	return c.appendString(b, h.Created.Format(time.RFC3339Nano))
+}
+
+// appendBinaryRecord appends rec with attrs, leaving out the fields JSON
+// omits when empty.
+func appendBinaryRecord(c binaryCodec, b []byte, rec replayRecord, attrs []slog.Attr) []byte {
+	attrs = replayableAttrs(attrs)
+
+	n := 3
+	for _, set := range []bool{rec.Seq != 0, rec.Module != "", rec.Raw, len(attrs) > 0} {
+		if set {
+			n++
+		}
+	}
+
+	b = c.appendMap(b, n)
+	if rec.Seq != 0 {
+		b = c.appendString(b, "n")
+		b = c.appendUint(b, rec.Seq)
+	}
+	b = c.appendString(b, "t")
+	b = c.appendString(b, rec.Time.Format(time.RFC3339Nano))
+	b = c.appendString(b, "l")
+	b = c.appendInt(b, int64(rec.Level))
+	b = c.appendString(b, "m")
+	b = c.appendString(b, rec.Msg)
+	if rec.Module != "" {
+		b = c.appendString(b, "mod")
+		b = c.appendString(b, rec.Module)
+	}
+	if rec.Raw {
+		b = c.appendString(b, "raw")
+		b = c.appendBool(b, true)
+	}
+	if len(attrs) > 0 {
+		b = c.appendString(b, "a")
+		b = appendBinaryAttrs(c, b, attrs)
+	}
+	return b
+}
+
+// replayableAttrs returns as resolved, without the empty attributes.
+func replayableAttrs(as []slog.Attr) []slog.Attr {
+	out := make([]slog.Attr, 0, len(as))
+	for _, a := range as {
+		a.Value = a.Value.Resolve()
+		if a.Equal(slog.Attr{}) {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// appendBinaryAttrs appends resolved attributes as an array of maps with
+// the fields of a replayAttr.
+func appendBinaryAttrs(c binaryCodec, b []byte, as []slog.Attr) []byte {
+	b = c.appendArray(b, len(as))
+	for _, a := range as {
+		b = c.appendMap(b, 3)
+		b = c.appendString(b, "k")
+		b = c.appendString(b, a.Key)
+		b = c.appendString(b, "t")
+
+		v := a.Value
+		switch v.Kind() {
+		case slog.KindString:
+			b = c.appendString(b, replayString)
+			b = c.appendString(b, "v")
+			b = c.appendString(b, v.String())
+		case slog.KindInt64:
+			b = c.appendString(b, replayInt)
+			b = c.appendString(b, "v")
+			b = c.appendInt(b, v.Int64())
+		case slog.KindUint64:
+			b = c.appendString(b, replayUint)
+			b = c.appendString(b, "v")
+			b = c.appendUint(b, v.Uint64())
+		case slog.KindFloat64:
+			b = c.appendString(b, replayFloat)
+			b = c.appendString(b, "v")
+			b = c.appendFloat(b, v.Float64())
+		case slog.KindBool:
+			b = c.appendString(b, replayBool)
+			b = c.appendString(b, "v")
+			b = c.appendBool(b, v.Bool())
+		case slog.KindDuration:
+			b = c.appendString(b, replayDuration)
+			b = c.appendString(b, "v")
+			b = c.appendInt(b, int64(v.Duration()))
+		case slog.KindTime:
+			b = c.appendString(b, replayTime)
+			b = c.appendString(b, "v")
+			b = c.appendString(b, v.Time().Format(time.RFC3339Nano))
+		case slog.KindGroup:
+			b = c.appendString(b, replayGroup)
+			b = c.appendString(b, "v")
+			b = appendBinaryAttrs(c, b, replayableAttrs(v.Group()))
+		default:
+			if bs, ok := byteSlice(v.Any()); ok {
+				b = c.appendString(b, replayBytes)
+				b = c.appendString(b, "v")
+				b = c.appendBytes(b, bs)
+			} else {
+				b = c.appendString(b, replayString)
+				b = c.appendString(b, "v")
+				b = c.appendString(b, anyText(v.Any()))
+			}
+		}
+	}
+	return b
+}
+
+func decodeBinaryHeader(c binaryCodec, frame []byte) (replayHeader, error) {
+	var h replayHeader
+
+	fields, err := decodeBinaryFrame(c, frame)
+	if err != nil {
+		return h, err
+	}
+	for key, v := range fields {
+		switch key {
+		case "format":
+			h.Format, err = binaryString(v)
+		case "version":
+			var i int64
+			i, err = binaryInt(v)
+			h.Version = int(i)
+		case "created":
+			h.Created, err = binaryTime(v)
+		}
+		if err != nil {
+			return h, fmt.Errorf("field %q: %w", key, err)
+		}
+	}
+	return h, nil
+}
+
+func decodeBinaryRecord(c binaryCodec, frame []byte) (replayRecord, []slog.Attr, error) {
+	var (
+		rec   replayRecord
+		attrs []slog.Attr
+	)
+
+	fields, err := decodeBinaryFrame(c, frame)
+	if err != nil {
+		return rec, nil, err
+	}
+	for key, v := range fields {
+		switch key {
+		case "n":
+			rec.Seq, err = binaryUint(v)
+		case "t":
+			rec.Time, err = binaryTime(v)
+		case "l":
+			var i int64
+			i, err = binaryInt(v)
+			rec.Level = slog.Level(i)
+		case "m":
+			rec.Msg, err = binaryString(v)
+		case "mod":
+			rec.Module, err = binaryString(v)
+		case "raw":
+			rec.Raw, err = binaryBool(v)
+		case "a":
+			attrs, err = decodeBinaryAttrs(v)
+		}
+		if err != nil {
+			return rec, nil, fmt.Errorf("field %q: %w", key, err)
+		}
+	}
+	return rec, attrs, nil
+}
+
+// decodeBinaryFrame returns the fields of the map frame holds.
+func decodeBinaryFrame(c binaryCodec, frame []byte) (map[string]any, error) {
+	v, rest, err := c.decode(frame, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%d bytes after the frame's value", len(rest))
+	}
+	fields, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("frame holds %s, not a map", binaryType(v))
+	}
+	return fields, nil
+}
+
+func decodeBinaryAttrs(v any) ([]slog.Attr, error) {
+	list, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an array, got %s", binaryType(v))
+	}
+
+	attrs := make([]slog.Attr, 0, len(list))
+	for _, item := range list {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expected an attribute, got %s", binaryType(item))
+		}
+		key, err := binaryString(fields["k"])
+		if err != nil {
+			return nil, fmt.Errorf("attribute key: %w", err)
+		}
+		kind, err := binaryString(fields["t"])
+		if err != nil {
+			return nil, fmt.Errorf("attribute %q: %w", key, err)
+		}
+		value, err := decodeBinaryValue(kind, fields["v"])
+		if err != nil {
+			return nil, fmt.Errorf("attribute %q: %w", key, err)
+		}
+		attrs = append(attrs, slog.Attr{Key: key, Value: value})
+	}
+	return attrs, nil
+}
+
+func decodeBinaryValue(kind string, v any) (slog.Value, error) {
+	switch kind {
+	case replayString:
+		s, err := binaryString(v)
+		return slog.StringValue(s), err
+	case replayInt:
+		i, err := binaryInt(v)
+		return slog.Int64Value(i), err
+	case replayUint:
+		u, err := binaryUint(v)
+		return slog.Uint64Value(u), err
+	case replayFloat:
+		f, err := binaryFloat(v)
+		return slog.Float64Value(f), err
+	case replayBool:
+		b, err := binaryBool(v)
+		return slog.BoolValue(b), err
+	case replayDuration:
+		d, err := binaryInt(v)
+		return slog.DurationValue(time.Duration(d)), err
+	case replayTime:
+		t, err := binaryTime(v)
+		return slog.TimeValue(t), err
+	case replayBytes:
+		bs, ok := v.([]byte)
+		if !ok {
+			return slog.Value{}, fmt.Errorf("expected bytes, got %s", binaryType(v))
+		}
+		return slog.AnyValue(bs), nil
+	case replayGroup:
+		attrs, err := decodeBinaryAttrs(v)
+		return slog.GroupValue(attrs...), err
+	default:
+		return slog.Value{}, fmt.Errorf("unknown kind %q", kind)
+	}
+}
+
+func binaryString(v any) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("expected a string, got %s", binaryType(v))
+	}
+	return s, nil
+}
+
+func binaryBool(v any) (bool, error) {
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expected a bool, got %s", binaryType(v))
+	}
+	return b, nil
+}
+
+func binaryInt(v any) (int64, error) {
+	switch v := v.(type) {
+	case int64:
+		return v, nil
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v), nil
+		}
+		return 0, fmt.Errorf("integer %d out of range", v)
+	}
+	return 0, fmt.Errorf("expected an integer, got %s", binaryType(v))
+}
+
+func binaryUint(v any) (uint64, error) {
+	switch v := v.(type) {
+	case uint64:
+		return v, nil
+	case int64:
+		if v >= 0 {
+			return uint64(v), nil
+		}
+		return 0, fmt.Errorf("integer %d out of range", v)
+	}
+	return 0, fmt.Errorf("expected an integer, got %s", binaryType(v))
+}
+
+func binaryFloat(v any) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	}
+	return 0, fmt.Errorf("expected a number, got %s", binaryType(v))
+}
+
+func binaryTime(v any) (time.Time, error) {
+	s, err := binaryString(v)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// binaryType names the type of a decoded value in errors.
+func binaryType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "nil"
+	case map[string]any:
+		return "a map"
+	case []any:
+		return "an array"
+	case []byte:
+		return "bytes"
+	case string:
+		return "a string"
+	case int64, uint64:
+		return "an integer"
+	case float64:
+		return "a float"
+	case bool:
+		return "a bool"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// binaryNumber reads a big-endian unsigned integer of size bytes.
+func binaryNumber(data []byte, size int) (uint64, []byte, error) {
+	if len(data) < size {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	var n uint64
+	switch size {
+	case 1:
+		n = uint64(data[0])
+	case 2:
+		n = uint64(binary.BigEndian.Uint16(data))
+	case 4:
+		n = uint64(binary.BigEndian.Uint32(data))
+	case 8:
+		n = binary.BigEndian.Uint64(data)
+	}
+	return n, data[size:], nil
+}
+
+// binaryTake splits the first n bytes off data.
+func binaryTake(data []byte, n uint64) ([]byte, []byte, error) {
+	if n > uint64(len(data)) {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	return data[:n], data[n:], nil
+}
+
+// decodeBinaryArray decodes n values from data. Every value takes at least
+// a byte, which bounds n before anything is allocated.
+func decodeBinaryArray(c binaryCodec, data []byte, n uint64, depth int) ([]any, []byte, error) {
+	if n > uint64(len(data)) {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+
+	list := make([]any, 0, n)
+	for range n {
+		v, rest, err := c.decode(data, depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		list = append(list, v)
+		data = rest
+	}
+	return list, data, nil
+}
+
+// decodeBinaryMap decodes n pairs of string keys and values from data.
+func decodeBinaryMap(c binaryCodec, data []byte, n uint64, depth int) (map[string]any, []byte, error) {
+	if n > uint64(len(data))/2 {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+
+	m := make(map[string]any, n)
+	for range n {
+		k, rest, err := c.decode(data, depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("map key is %s, not a string", binaryType(k))
+		}
+		v, rest, err := c.decode(rest, depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		m[key] = v
+		data = rest
+	}
+	return m, data, nil
+}
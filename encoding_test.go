@@ -0,0 +1,209 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodedRecorderRoundTrip(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	ctx := ContextWithRequestID(context.Background(), "req-42")
+	options := []Option{WithTerminalWidth(0), PresetServer}
+
+	record := func(h slog.Handler) {
+		h = h.WithAttrs([]slog.Attr{slog.String("module", "api")})
+
+		r := slog.NewRecord(start, slog.LevelWarn, "request handled", 0)
+		r.AddAttrs(
+			slog.Int("status", -200),
+			slog.Uint64("big", math.MaxUint64),
+			slog.Duration("took", 12*time.Millisecond),
+			slog.Float64("ratio", math.NaN()),
+			slog.Any("error", errors.New("boom")),
+			slog.Any("body", []byte("hi")),
+			slog.String("long", string(bytes.Repeat([]byte("x"), 300))),
+			slog.Group("client", slog.String("ip", "10.0.0.7"), slog.Bool("tls", true)),
+		)
+		require.NoError(t, h.Handle(ctx, r))
+
+		r = slog.NewRecord(start.Add(time.Second), Trace, "query", 0)
+		r.AddAttrs(slog.Time("at", start))
+		require.NoError(t, h.WithGroup("db").Handle(ctx, r))
+	}
+
+	var direct bytes.Buffer
+	record(New(&direct, &slog.HandlerOptions{Level: Trace}, options...))
+
+	for _, enc := range []Encoding{EncodingJSON, EncodingMessagePack, EncodingCBOR} {
+		t.Run(enc.String(), func(t *testing.T) {
+			var recorded bytes.Buffer
+			record(NewEncodedRecorder(&recorded, enc, nil))
+
+			var replayed bytes.Buffer
+			err := Replay(&recorded, New(&replayed, &slog.HandlerOptions{Level: Trace}, options...))
+			require.NoError(t, err)
+
+			assert.Equal(t, direct.String(), replayed.String())
+		})
+	}
+}
+
+func TestEncodedRecorderIsSmaller(t *testing.T) {
+	sizes := make(map[Encoding]int)
+	for _, enc := range []Encoding{EncodingJSON, EncodingMessagePack, EncodingCBOR} {
+		var recorded bytes.Buffer
+		logger := slog.New(NewEncodedRecorder(&recorded, enc, nil))
+		for i := range 100 {
+			logger.Info("request handled", "status", 200, "took", time.Duration(i)*time.Millisecond)
+		}
+		sizes[enc] = recorded.Len()
+	}
+
+	assert.Less(t, sizes[EncodingMessagePack], sizes[EncodingJSON])
+	assert.Less(t, sizes[EncodingCBOR], sizes[EncodingJSON])
+}
+
+func TestEncodedRecorderUnknownEncoding(t *testing.T) {
+	var recorded bytes.Buffer
+	r := NewEncodedRecorder(&recorded, Encoding(7), nil)
+
+	err := r.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "lost", 0))
+	assert.ErrorContains(t, err, "unknown encoding Encoding(7)")
+	assert.Zero(t, recorded.Len())
+}
+
+func TestBinaryCodecs(t *testing.T) {
+	// Examples from the MessagePack specification and appendix A of
+	// RFC 8949.
+	tests := []struct {
+		name          string
+		value         any
+		msgpack, cbor string
+	}{
+		{name: "small", value: uint64(10), msgpack: "0a", cbor: "0a"},
+		{name: "byte", value: uint64(100), msgpack: "64", cbor: "1864"},
+		{name: "uint16", value: uint64(1000), msgpack: "cd03e8", cbor: "1903e8"},
+		{name: "uint64", value: uint64(math.MaxUint64), msgpack: "cfffffffffffffffff", cbor: "1bffffffffffffffff"},
+		{name: "negative", value: int64(-10), msgpack: "f6", cbor: "29"},
+		{name: "negative16", value: int64(-1000), msgpack: "d1fc18", cbor: "3903e7"},
+		{name: "float", value: 1.1, msgpack: "cb3ff199999999999a", cbor: "fb3ff199999999999a"},
+		{name: "true", value: true, msgpack: "c3", cbor: "f5"},
+		{name: "string", value: "IETF", msgpack: "a449455446", cbor: "6449455446"},
+		{name: "bytes", value: []byte{1, 2, 3, 4}, msgpack: "c40401020304", cbor: "4401020304"},
+	}
+
+	appendValue := func(c binaryCodec, b []byte, v any) []byte {
+		switch v := v.(type) {
+		case uint64:
+			return c.appendUint(b, v)
+		case int64:
+			return c.appendInt(b, v)
+		case float64:
+			return c.appendFloat(b, v)
+		case bool:
+			return c.appendBool(b, v)
+		case string:
+			return c.appendString(b, v)
+		case []byte:
+			return c.appendBytes(b, v)
+		}
+		panic("unexpected type")
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for c, want := range map[binaryCodec]string{msgpackCodec{}: tt.msgpack, cborCodec{}: tt.cbor} {
+				data := appendValue(c, nil, tt.value)
+				assert.Equal(t, want, hex.EncodeToString(data))
+
+				v, rest, err := c.decode(data, 0)
+				require.NoError(t, err)
+				assert.Empty(t, rest)
+				assert.Equal(t, tt.value, v)
+			}
+		})
+	}
+}
+
+func TestBinaryCodecsDecodeOtherForms(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec binaryCodec
+		data  string
+		value any
+	}{
+		{"msgpack float32", msgpackCodec{}, "ca3fc00000", 1.5},
+		{"msgpack int8", msgpackCodec{}, "d005", int64(5)},
+		{"msgpack nil", msgpackCodec{}, "c0", nil},
+		{"msgpack str8", msgpackCodec{}, "d90161", "a"},
+		{"cbor half", cborCodec{}, "f93e00", 1.5},
+		{"cbor half infinity", cborCodec{}, "f97c00", math.Inf(1)},
+		{"cbor half negative", cborCodec{}, "f9c400", -4.0},
+		{"cbor float32", cborCodec{}, "fa47c35000", 100000.0},
+		{"cbor tag", cborCodec{}, "c11a514b67b0", uint64(1363896240)},
+		{"cbor map", cborCodec{}, "a161610a", map[string]any{"a": uint64(10)}},
+		{"cbor array", cborCodec{}, "83010203", []any{uint64(1), uint64(2), uint64(3)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := hex.DecodeString(tt.data)
+			require.NoError(t, err)
+
+			v, rest, err := tt.codec.decode(data, 0)
+			require.NoError(t, err)
+			assert.Empty(t, rest)
+			assert.Equal(t, tt.value, v)
+		})
+	}
+}
+
+func TestBinaryCodecsRejectCorruptInput(t *testing.T) {
+	for _, c := range []binaryCodec{msgpackCodec{}, cborCodec{}} {
+		// a string claiming more bytes than there are
+		data := c.appendString(nil, "truncated")
+		_, _, err := c.decode(data[:len(data)-1], 0)
+		assert.Error(t, err)
+
+		// an array claiming more elements than there are bytes
+		data = c.appendArray(nil, 1<<30)
+		_, _, err = c.decode(data, 0)
+		assert.Error(t, err)
+
+		// arrays nested beyond the limit
+		data = nil
+		for range maxBinaryDepth + 1 {
+			data = c.appendArray(data, 1)
+		}
+		data = c.appendBool(data, true)
+		_, _, err = c.decode(data, 0)
+		assert.ErrorIs(t, err, errBinaryDepth)
+	}
+}
+
+func TestReplayRejectsCorruptBinaryRecord(t *testing.T) {
+	var recorded bytes.Buffer
+	slog.New(NewEncodedRecorder(&recorded, EncodingCBOR, nil)).Info("fine")
+
+	c := cborCodec{}
+	frame := c.appendMap(nil, 1)
+	frame = c.appendString(frame, "m")
+	frame = c.appendInt(frame, 42)
+	recorded.Write(binary.AppendUvarint(nil, uint64(len(frame))))
+	recorded.Write(frame)
+
+	var out bytes.Buffer
+	err := Replay(&recorded, New(&out, nil))
+	assert.ErrorContains(t, err, `field "m": expected a string, got an integer`)
+	assert.Contains(t, out.String(), "fine")
+}
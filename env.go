@@ -0,0 +1,76 @@
+package trifle
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+)
+
+// ContextEnv is the environment variable that carries a logger's module
+// and attributes, and the request and trace ids of a context, to child
+// processes. [Quick], and so [Install], pick it up.
+const ContextEnv = "TRIFLE_CONTEXT"
+
+// Environ returns a "TRIFLE_CONTEXT=..." entry for the environment of a
+// child process, so that the child's logger continues where the logger of
+// ctx, as returned by [FromContext], left off:
+//
+//	cmd := exec.CommandContext(ctx, "worker")
+//	cmd.Env = append(os.Environ(), trifle.Environ(ctx))
+//
+// It carries the module and attributes of the logger, if its handler is a
+// [TextHandler], and the request id and trace context of ctx.
+func Environ(ctx context.Context) string {
+	var snap Snapshot
+	if h, ok := FromContext(ctx).Handler().(*TextHandler); ok {
+		snap = h.Snapshot()
+	}
+	snap = snap.withContext(ctx)
+
+	data, err := json.Marshal(snap)
+	if err != nil {
+		data = []byte("{}")
+	}
+	return ContextEnv + "=" + string(data)
+}
+
+// withContext returns s with the values carried by ctx added ahead of its
+// own attributes, unless s has them already.
+func (s Snapshot) withContext(ctx context.Context) Snapshot {
+	present := make(map[string]bool)
+	for _, goa := range s.goas {
+		if goa.group != "" {
+			break
+		}
+		for _, a := range goa.attrs {
+			present[a.Key] = true
+		}
+	}
+
+	var attrs []slog.Attr
+	for _, a := range contextAttrs(ctx) {
+		if !present[a.Key] {
+			attrs = append(attrs, a)
+		}
+	}
+	if len(attrs) > 0 {
+		s.goas = append([]groupOrAttrs{{attrs: attrs}}, s.goas...)
+	}
+	return s
+}
+
+// SnapshotFromEnv returns the snapshot passed down by a parent process
+// through [ContextEnv], and false if there is none or it can't be read.
+func SnapshotFromEnv() (Snapshot, bool) {
+	data := os.Getenv(ContextEnv)
+	if data == "" {
+		return Snapshot{}, false
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal([]byte(data), &snap); err != nil {
+		return Snapshot{}, false
+	}
+	return snap, true
+}
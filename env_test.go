@@ -0,0 +1,45 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvironRoundTrip(t *testing.T) {
+	parent := slog.New(New(&bytes.Buffer{}, nil)).With(ModuleKey, "build", "target", "linux")
+	ctx := NewContext(ContextWithRequestID(context.Background(), "req-1"), parent)
+
+	entry := Environ(ctx)
+	name, value, ok := strings.Cut(entry, "=")
+	require.True(t, ok)
+	assert.Equal(t, ContextEnv, name)
+
+	t.Setenv(ContextEnv, value)
+	snap, ok := SnapshotFromEnv()
+	require.True(t, ok)
+
+	var buf bytes.Buffer
+	child := slog.New(snap.Apply(New(&buf, nil, PresetServer, WithTerminalWidth(0))))
+	child.Info("compiling")
+
+	out := Plain(buf.String())
+	assert.True(t, MatchesLine(out, `req-1 build compiling`), out)
+	assert.True(t, ContainsAttr(buf.String(), "target", "linux"), out)
+}
+
+func TestQuickPicksUpEnv(t *testing.T) {
+	t.Setenv(ContextEnv, `{"module":"worker","steps":[{"attrs":[["job",7]]}]}`)
+
+	snap := Quick().Snapshot()
+	assert.Equal(t, "worker", snap.Module)
+	assert.Equal(t, "job=7", snap.Attrs()[0].String())
+
+	t.Setenv(ContextEnv, `not json`)
+	assert.True(t, Quick().Snapshot().IsZero())
+}
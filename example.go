@@ -0,0 +1,56 @@
+package trifle
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+// NewExample returns a TextHandler for Example functions, whose output go
+// test compares with their Output comments. Records are written without
+// their time, colors or other escape sequences, and without wrapping, so
+// the output is the same wherever the example runs:
+//
+//	func ExampleServer() {
+//		logger := slog.New(trifle.NewExample(os.Stdout))
+//		logger.Info("listening", "addr", ":8080")
+//		// Output:
+//		// [INFO]  listening │ addr: :8080
+//	}
+//
+// Records at Info and above are written. The options are applied after
+// those of the example mode.
+func NewExample(w io.Writer, options ...Option) *TextHandler {
+	options = append([]Option{WithTerminalWidth(0), WithTransformer(withoutTime)}, options...)
+	return New(exampleWriter{w}, nil, options...)
+}
+
+// withoutTime removes the time of r, so the handler leaves it out.
+func withoutTime(_ context.Context, r slog.Record) (slog.Record, bool) {
+	r.Time = time.Time{}
+	return r, true
+}
+
+// exampleWriter removes the escape sequences of what is written to it, and
+// the space the level of a record starts with when there's no time before
+// it.
+type exampleWriter struct {
+	w io.Writer
+}
+
+func (e exampleWriter) Write(p []byte) (int, error) {
+	lines := strings.Split(color.Strip(string(p)), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, " [") {
+			lines[i] = line[1:]
+		}
+	}
+	if _, err := io.WriteString(e.w, strings.Join(lines, "\n")); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -31,9 +31,7 @@ func ExampleWithImportantKeys() {
 
 func ExampleWithContextKey() {
 	// Create a logger with a context key that appears before each message
-	handler := trifle.New(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}, trifle.WithContextKey("request_id"))
+	handler := trifle.NewExample(os.Stdout, trifle.WithContextKey("request_id"))
 
 	logger := slog.New(handler)
 
@@ -46,13 +44,16 @@ func ExampleWithContextKey() {
 	requestLogger.Error("Login failed", "error", "invalid token")
 
 	// request_id won't appear in the regular attributes section
+
+	// Output:
+	// [INFO]  req-123 Processing user login │ user_id: user-456
+	// [INFO]  req-123 Validating credentials │ method: oauth2
+	// [ERROR] req-123 Login failed │ error: "invalid token"
 }
 
 func ExampleWithContextKey_multiple() {
 	// Create a logger with multiple context keys
-	handler := trifle.New(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}, trifle.WithContextKey("request_id", "user_id", "session_id"))
+	handler := trifle.NewExample(os.Stdout, trifle.WithContextKey("request_id", "user_id", "session_id"))
 
 	logger := slog.New(handler)
 
@@ -65,6 +66,11 @@ func ExampleWithContextKey_multiple() {
 
 	// If a context key is missing, it's skipped
 	logger.Info("Session expired") // Only req-789 and sess-xyz appear
+
+	// Output:
+	// [INFO]  req-789 user-123 sess-xyz User authenticated │ method: oauth2
+	// [INFO]  req-789 sess-xyz Profile updated │ fields: "[email name]"
+	// [INFO]  req-789 sess-xyz Session expired
 }
 
 func ExampleWithCriticalKeys() {
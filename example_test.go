@@ -0,0 +1,39 @@
+package trifle_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"miren.dev/trifle"
+	"miren.dev/trifle/pkg/color"
+)
+
+func ExampleNewExample() {
+	logger := slog.New(trifle.NewExample(os.Stdout))
+
+	logger.Debug("not shown")
+	logger.Info("listening", "addr", ":8080")
+	logger.With("module", "db").Error("query failed",
+		"error", errors.New("connection reset"),
+		trifle.Hint("check that the database is running"),
+	)
+	// Output:
+	// [INFO]  listening │ addr: :8080
+	// [ERROR] db query failed │ error: "connection reset"
+	//     ↳ check that the database is running
+}
+
+func TestNewExampleIgnoresColorSetting(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	var buf bytes.Buffer
+	logger := slog.New(trifle.NewExample(&buf, trifle.WithCriticalKeys("error")))
+	logger.Warn("disk almost full", "error", "97% used", "mount", "/var/lib/data/with/a/rather/long/path/that/would/wrap")
+
+	assert.Equal(t, "[WARN]  disk almost full │ error: \"97% used\" mount: /var/lib/data/with/a/rather/long/path/that/would/wrap\n", buf.String())
+}
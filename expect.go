@@ -0,0 +1,92 @@
+package trifle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"time"
+
+	testing "github.com/mitchellh/go-testing-interface"
+)
+
+// TestLog is a logger writing to the log of a test, as [NewTest] does, that
+// also checks the expectations of the test, so that its failures read like
+// the rest of its output.
+type TestLog struct {
+	*slog.Logger
+	t testing.T
+	h *testHandler
+}
+
+// TestLogger returns a TestLog for t, configured with [PresetTest] and
+// options.
+func TestLogger(t testing.T, options ...Option) *TestLog {
+	h := newTestHandler(t, nil, append([]Option{PresetTest}, options...)...)
+	return &TestLog{Logger: slog.New(h), t: t, h: h}
+}
+
+// Expect reports whether got is equal to want, as [reflect.DeepEqual]
+// decides. If it isn't, the test fails with an Error record of msg and
+// args, followed by a [Diff] of want and got:
+//
+//	log := trifle.TestLogger(t)
+//	log.Expect("user loaded", want, got, "id", 7)
+//
+//	    user_test.go:24: 12:00:00.000 [ERROR] TestLoad user loaded │ id: 7
+//	            {
+//	        -     "Name": "ada",
+//	        +     "Name": "grace",
+//	              "Admin": false
+//	            }
+//
+// Values are compared as text: strings and byte slices as they are,
+// anything else as indented JSON, or in Go syntax if its JSON is the same
+// for want and got, with their types if that is the same too. The record
+// is shown whatever the level of the logger.
+func (l *TestLog) Expect(msg string, want, got any, args ...any) bool {
+	l.t.Helper()
+	if reflect.DeepEqual(want, got) {
+		return true
+	}
+
+	wantText, gotText := expectText(want), expectText(got)
+	if wantText == gotText {
+		wantText, gotText = fmt.Sprintf("%#v", want), fmt.Sprintf("%#v", got)
+	}
+	if wantText == gotText {
+		wantText, gotText = fmt.Sprintf("%#v (%T)", want, want), fmt.Sprintf("%#v (%T)", got, got)
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:])
+	r := slog.NewRecord(time.Now(), slog.LevelError, msg, pcs[0])
+	r.Add(args...)
+	r.AddAttrs(Diff(wantText, gotText))
+
+	l.h.mu.Lock()
+	output, err := l.h.render(context.Background(), r)
+	l.h.mu.Unlock()
+	if err != nil {
+		l.t.Errorf("%s: %v", msg, err)
+		return false
+	}
+	l.t.Error(string(output))
+	return false
+}
+
+// expectText returns v as text to compare in [TestLog.Expect].
+func expectText(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	if data, err := json.MarshalIndent(v, "", "  "); err == nil {
+		return string(data)
+	}
+	return fmt.Sprintf("%#v", v)
+}
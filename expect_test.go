@@ -0,0 +1,36 @@
+package trifle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestLoggerExpect(t *testing.T) {
+	rt := &recordingT{name: "TestLoad"}
+	log := TestLogger(rt)
+
+	type user struct {
+		Name  string
+		Admin bool
+	}
+	assert.True(t, log.Expect("user loaded", user{"ada", false}, user{"ada", false}))
+	assert.Empty(t, rt.errors)
+
+	assert.False(t, log.Expect("user loaded", user{"ada", false}, user{"grace", false}, "id", 7))
+	require.Len(t, rt.errors, 1)
+	assert.Contains(t, rt.errors[0], "[ERROR] TestLoad user loaded │ id: 7\n")
+	assert.Contains(t, rt.errors[0], `
+      {
+    -   "Name": "ada",
+    +   "Name": "grace",
+        "Admin": false
+      }`)
+	assert.Empty(t, rt.logs, "failed expectations are errors, not logs")
+
+	// Values that read the same are told apart by their types.
+	log.Expect("count", int64(1), 1)
+	require.Len(t, rt.errors, 2)
+	assert.Contains(t, rt.errors[1], "    - 1 (int64)\n    + 1 (int)")
+}
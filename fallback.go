@@ -0,0 +1,143 @@
+package trifle
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// fallbackWriter receives the records that could not be written to the
+// handler's writer before their context was done.
+type fallbackWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+
+	// torn tells, for the handler's writer and its error writer, whether
+	// the last record written to it was cut short, leaving a line the next
+	// record must end first. It is guarded by the handler's writeLock.
+	torn [2]bool
+}
+
+// WithFallback returns an Option that bounds how long Handle blocks on a
+// slow writer, such as a network connection to a collector. When the
+// context passed to Handle is canceled or reaches its deadline while the
+// record waits for the writer, or while it is being written, the record
+// is written to w instead and Handle returns. A record interrupted partway
+// through has the rest of it written to w, and the line it left unfinished
+// is ended before the next record.
+//
+// Waiting for another record to finish writing is always interruptible.
+// The write itself is interrupted only when the writer has a
+// SetWriteDeadline method, as net.Conn does; other writers are given the
+// record and waited for.
+//
+// Records handled with a context that is never done, such as
+// context.Background, always wait for the writer.
+func WithFallback(w io.Writer) Option {
+	return func(h *TextHandler) {
+		h.fallback = &fallbackWriter{w: w}
+	}
+}
+
+func (f *fallbackWriter) write(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err := f.w.Write(b)
+	return err
+}
+
+// writePrimary writes b to the handler's writer w, or its error writer
+// when toErr is set, as writeContext does. It ends the line of a record cut
+// short on w first. The caller holds the handler's writeLock.
+func (f *fallbackWriter) writePrimary(ctx context.Context, w io.Writer, toErr bool, b []byte) (int, error) {
+	i := 0
+	if toErr {
+		i = 1
+	}
+
+	if f.torn[i] {
+		if _, err := writeContext(ctx, w, []byte{'\n'}); err != nil {
+			return 0, err
+		}
+		f.torn[i] = false
+	}
+
+	n, err := writeContext(ctx, w, b)
+	if n > 0 && n < len(b) {
+		f.torn[i] = b[n-1] != '\n'
+	}
+	return n, err
+}
+
+// writeLock serializes the writes of a handler and its clones. It is a
+// semaphore of one rather than a sync.Mutex so that waiting for it can be
+// given up, see lockContext.
+type writeLock chan struct{}
+
+func newWriteLock() writeLock {
+	return make(writeLock, 1)
+}
+
+func (l writeLock) Lock() {
+	l <- struct{}{}
+}
+
+func (l writeLock) Unlock() {
+	<-l
+}
+
+// lockContext acquires l, giving up when ctx is done first. It reports
+// whether l is held.
+func (l writeLock) lockContext(ctx context.Context) bool {
+	select {
+	case l <- struct{}{}:
+		return true
+	default:
+	}
+
+	select {
+	case l <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// deadlineWriter is implemented by writers whose writes can be
+// interrupted, such as net.Conn.
+type deadlineWriter interface {
+	SetWriteDeadline(t time.Time) error
+}
+
+// writeContext writes b to w, interrupting the write when ctx is done if w
+// supports write deadlines.
+func writeContext(ctx context.Context, w io.Writer, b []byte) (int, error) {
+	dw, ok := w.(deadlineWriter)
+	if !ok || ctx.Done() == nil {
+		return w.Write(b)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = dw.SetWriteDeadline(deadline)
+	}
+	interrupted := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		// A deadline in the past makes a blocked write return at once.
+		_ = dw.SetWriteDeadline(time.Unix(1, 0))
+		close(interrupted)
+	})
+
+	n, err := w.Write(b)
+
+	if !stop() {
+		// Let the interruption finish so it can't outlast the reset.
+		<-interrupted
+	}
+	_ = dw.SetWriteDeadline(time.Time{})
+	return n, err
+}
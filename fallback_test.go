@@ -0,0 +1,166 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingWriter blocks every write until release is closed.
+type blockingWriter struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (b *blockingWriter) Write(p []byte) (int, error) {
+	b.once.Do(func() { close(b.started) })
+	<-b.release
+	return len(p), nil
+}
+
+func TestFallbackWhenContextAlreadyDone(t *testing.T) {
+	var primary, fallback syncBuffer
+
+	logger := slog.New(New(&primary, nil, WithTerminalWidth(0), WithFallback(&fallback)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	logger.InfoContext(ctx, "late")
+	logger.Info("on time")
+
+	assert.Contains(t, fallback.String(), "late")
+	assert.NotContains(t, primary.String(), "late")
+	assert.Contains(t, primary.String(), "on time")
+}
+
+func TestFallbackWhileWriterIsBusy(t *testing.T) {
+	var fallback syncBuffer
+	bw := &blockingWriter{started: make(chan struct{}), release: make(chan struct{})}
+
+	h := New(bw, nil, WithTerminalWidth(0), WithFallback(&fallback))
+	logger := slog.New(h)
+
+	go logger.Info("stuck")
+	<-bw.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	logger.InfoContext(ctx, "impatient")
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Contains(t, fallback.String(), "impatient")
+
+	// Once the writer recovers, the lock given up on is released again.
+	close(bw.release)
+	logger.Info("after")
+	assert.Equal(t, uint64(1), h.Stats().Dropped)
+}
+
+func TestFallbackLeavesNoWaiters(t *testing.T) {
+	var fallback syncBuffer
+	bw := &blockingWriter{started: make(chan struct{}), release: make(chan struct{})}
+
+	logger := slog.New(New(bw, nil, WithTerminalWidth(0), WithFallback(&fallback)))
+	go logger.Info("stuck")
+	<-bw.started
+
+	before := runtime.NumGoroutine()
+	for range 100 {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		logger.InfoContext(ctx, "impatient")
+		cancel()
+	}
+	// Nothing is left waiting for the writer on behalf of the records
+	// given up on.
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+	assert.Equal(t, 100, strings.Count(fallback.String(), "impatient"))
+
+	close(bw.release)
+	logger.Info("after")
+}
+
+func TestFallbackInterruptsConnectionWrite(t *testing.T) {
+	var fallback syncBuffer
+
+	// Nothing reads from the other end, so writes block once the pipe
+	// has no room.
+	client, server := net.Pipe()
+	defer server.Close()
+	defer client.Close()
+
+	logger := slog.New(New(client, nil, WithTerminalWidth(0), WithFallback(&fallback)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	logger.InfoContext(ctx, "unread")
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Contains(t, fallback.String(), "unread")
+
+	// The deadline is cleared for the records that follow.
+	done := make(chan []byte)
+	go func() {
+		buf := make([]byte, 256)
+		n, _ := server.Read(buf)
+		done <- buf[:n]
+	}()
+	logger.Info("read")
+	assert.Contains(t, string(<-done), "read")
+}
+
+// tornWriter accepts the first limit bytes written to it and fails the
+// write that goes past them as if its deadline had passed.
+type tornWriter struct {
+	bytes.Buffer
+	limit int
+}
+
+func (w *tornWriter) Write(p []byte) (int, error) {
+	if w.limit >= 0 && len(p) > w.limit {
+		n, _ := w.Buffer.Write(p[:w.limit])
+		w.limit = -1
+		return n, os.ErrDeadlineExceeded
+	}
+	return w.Buffer.Write(p)
+}
+
+func (w *tornWriter) SetWriteDeadline(time.Time) error { return nil }
+
+func TestFallbackGetsRestOfTornRecord(t *testing.T) {
+	var fallback syncBuffer
+	w := &tornWriter{limit: 10}
+	logger := slog.New(New(w, nil, WithTerminalWidth(0), WithFallback(&fallback)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger.InfoContext(ctx, "cut short")
+	logger.Info("next")
+
+	lines := strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n")
+	require.Len(t, lines, 2, w.String())
+	assert.Len(t, lines[0], 10)
+	assert.Contains(t, lines[1], "next")
+
+	// Together they make up the whole record, once.
+	assert.Contains(t, lines[0]+fallback.String(), "[INFO]  cut short\n")
+	assert.NotContains(t, fallback.String(), lines[0])
+}
+
+func TestFallbackMustNotBeNil(t *testing.T) {
+	_, err := NewE(&bytes.Buffer{}, nil, WithFallback(nil))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "fallback writer")
+}
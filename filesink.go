@@ -0,0 +1,247 @@
+package trifle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// FileSinkOptions configures [NewFileSink]. The zero value keeps a single
+// uncompressed file that grows without bound, recording Info and above.
+type FileSinkOptions struct {
+	// Level is the minimum level recorded. Nil means Info.
+	Level slog.Leveler
+
+	// AddSource adds the source position of the log call to each record.
+	AddSource bool
+
+	// NumericLevel writes the level of each record as a number too, by a
+	// convention such as [SeverityOTLP]. The zero value writes only the
+	// name of the level.
+	NumericLevel Severity
+
+	// MaxBytes, MaxFiles and Compress control rotation, see
+	// [RotateOptions].
+	MaxBytes int64
+	MaxFiles int
+	Compress bool
+
+	// FlushDelay is the longest a record stays buffered before it is
+	// written. Zero means [DefaultBatchDelay].
+	FlushDelay time.Duration
+
+	// Session writes a [SessionHeader] line when the sink is created and
+	// a [SessionTrailer] line when it is closed, so tools can present the
+	// file with its metadata. With rotation, only the files the sink
+	// started and ended in carry them.
+	Session bool
+}
+
+// SessionHeader is the first line of a file written by a [FileSink] with
+// the Session option. Its "trifle" field is "header", which tells it apart
+// from records.
+type SessionHeader struct {
+	Trifle   string           `json:"trifle"`
+	Start    time.Time        `json:"start"`
+	Hostname string           `json:"hostname,omitempty"`
+	PID      int              `json:"pid"`
+	Program  string           `json:"program,omitempty"`
+	Version  string           `json:"version,omitempty"`
+	Config   FileSinkSettings `json:"config"`
+}
+
+// FileSinkSettings is the configuration recorded in a [SessionHeader].
+type FileSinkSettings struct {
+	Level    string `json:"level"`
+	MaxBytes int64  `json:"max_bytes,omitempty"`
+	MaxFiles int    `json:"max_files,omitempty"`
+	Compress bool   `json:"compress,omitempty"`
+}
+
+// SessionTrailer is the last line of a file written by a [FileSink] with
+// the Session option, written by Close. Its "trifle" field is "trailer".
+type SessionTrailer struct {
+	Trifle string            `json:"trifle"`
+	End    time.Time         `json:"end"`
+	Counts map[string]uint64 `json:"counts"`
+}
+
+// FileSink is a [slog.Handler] writing records as JSON Lines to a file,
+// buffered and optionally rotated and compressed. Close it before the
+// program exits to flush the buffer.
+type FileSink struct {
+	handler slog.Handler
+	state   *fileSinkState
+}
+
+// fileSinkState is shared by a FileSink and the handlers derived from it.
+type fileSinkState struct {
+	bw      *BatchWriter
+	session bool
+
+	mu     sync.Mutex
+	counts map[slog.Level]uint64
+	closed bool
+}
+
+// NewFileSink returns a FileSink appending to the file at path. Pair it
+// with the console using [Multi]:
+//
+//	sink, err := trifle.NewFileSink("app.jsonl", &trifle.FileSinkOptions{
+//		MaxBytes: 10 << 20, MaxFiles: 5, Compress: true,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer sink.Close()
+//	slog.SetDefault(slog.New(trifle.Multi(trifle.Quick(), sink)))
+func NewFileSink(path string, opts *FileSinkOptions) (*FileSink, error) {
+	if opts == nil {
+		opts = &FileSinkOptions{}
+	}
+
+	rf, err := OpenRotatingFile(path, RotateOptions{
+		MaxBytes: opts.MaxBytes,
+		MaxFiles: opts.MaxFiles,
+		Compress: opts.Compress,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the batches below the rotation size, so rotation stays close to
+	// the requested one.
+	batch := BatchOptions{MaxDelay: opts.FlushDelay}
+	if opts.MaxBytes > 0 && opts.MaxBytes < DefaultBatchSize {
+		batch.MaxBytes = int(opts.MaxBytes)
+	}
+	bw := NewBatchWriter(rf, batch)
+
+	hopts := &slog.HandlerOptions{
+		Level:     opts.Level,
+		AddSource: opts.AddSource,
+	}
+	if opts.NumericLevel.Number != nil {
+		hopts.ReplaceAttr = opts.NumericLevel.replaceLevel
+	}
+
+	s := &FileSink{
+		handler: slog.NewJSONHandler(bw, hopts),
+		state: &fileSinkState{
+			bw:      bw,
+			session: opts.Session,
+			counts:  make(map[slog.Level]uint64),
+		},
+	}
+
+	if opts.Session {
+		if err := s.state.writeLine(newSessionHeader(opts)); err != nil {
+			bw.Close()
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func newSessionHeader(opts *FileSinkOptions) SessionHeader {
+	level := slog.LevelInfo
+	if opts.Level != nil {
+		level = opts.Level.Level()
+	}
+
+	h := SessionHeader{
+		Trifle: "header",
+		Start:  time.Now(),
+		PID:    os.Getpid(),
+		Config: FileSinkSettings{
+			Level:    level.String(),
+			MaxBytes: opts.MaxBytes,
+			MaxFiles: opts.MaxFiles,
+			Compress: opts.Compress,
+		},
+	}
+
+	h.Hostname, _ = os.Hostname()
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		h.Program = bi.Path
+		h.Version = bi.Main.Version
+	}
+
+	return h
+}
+
+func (s *fileSinkState) writeLine(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = s.bw.Write(append(data, '\n'))
+	return err
+}
+
+// Enabled reports whether the sink records messages at level.
+func (s *FileSink) Enabled(ctx context.Context, level slog.Level) bool {
+	return s.handler.Enabled(ctx, level)
+}
+
+// Handle writes r as one JSON line.
+func (s *FileSink) Handle(ctx context.Context, r slog.Record) error {
+	if s.state.session {
+		s.state.mu.Lock()
+		s.state.counts[r.Level]++
+		s.state.mu.Unlock()
+	}
+	return s.handler.Handle(ctx, r)
+}
+
+// WithAttrs returns a sink that adds attrs to every record, writing to the
+// same file.
+func (s *FileSink) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &FileSink{handler: s.handler.WithAttrs(attrs), state: s.state}
+}
+
+// WithGroup returns a sink that nests later attributes in group name,
+// writing to the same file.
+func (s *FileSink) WithGroup(name string) slog.Handler {
+	return &FileSink{handler: s.handler.WithGroup(name), state: s.state}
+}
+
+// Flush writes buffered records to the file.
+func (s *FileSink) Flush() error {
+	return s.state.bw.Flush()
+}
+
+// Close writes the session trailer, if enabled, flushes buffered records
+// and closes the file. It is shared by every handler derived from s, which
+// must not be used afterwards.
+func (s *FileSink) Close() error {
+	st := s.state
+
+	st.mu.Lock()
+	if st.closed {
+		st.mu.Unlock()
+		return nil
+	}
+	st.closed = true
+
+	var trailer *SessionTrailer
+	if st.session {
+		trailer = &SessionTrailer{Trifle: "trailer", End: time.Now(), Counts: make(map[string]uint64)}
+		for level, n := range st.counts {
+			trailer.Counts[level.String()] += n
+		}
+	}
+	st.mu.Unlock()
+
+	var err error
+	if trailer != nil {
+		err = st.writeLine(trailer)
+	}
+	return errors.Join(err, st.bw.Close())
+}
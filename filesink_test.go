@@ -0,0 +1,132 @@
+package trifle
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSinkWithMulti(t *testing.T) {
+	var console bytes.Buffer
+
+	path := filepath.Join(t.TempDir(), "app.jsonl")
+	sink, err := NewFileSink(path, &FileSinkOptions{Level: slog.LevelDebug})
+	require.NoError(t, err)
+
+	logger := slog.New(Multi(New(&console, nil, PresetTest), sink))
+	logger.Debug("file only", "n", 1)
+	logger.Info("both", "user", "u-1")
+
+	require.NoError(t, sink.Close())
+
+	assert.NotContains(t, console.String(), "file only")
+	assert.Contains(t, console.String(), "both")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var rec map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &rec))
+	assert.Equal(t, "both", rec["msg"])
+	assert.Equal(t, "u-1", rec["user"])
+}
+
+func TestFileSinkNumericLevel(t *testing.T) {
+	for _, tt := range []struct {
+		severity Severity
+		want     map[string]any
+	}{
+		{SeverityOTLP, map[string]any{"level": "WARN", "severity_number": 13.0}},
+		{SeverityGCP, map[string]any{"level": "WARN", "severity": 400.0}},
+		{SeveritySlog, map[string]any{"level": 4.0}},
+	} {
+		t.Run(tt.severity.Key, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.jsonl")
+			sink, err := NewFileSink(path, &FileSinkOptions{NumericLevel: tt.severity})
+			require.NoError(t, err)
+
+			slog.New(sink).WithGroup("req").Warn("slow", "level", "not the level")
+			require.NoError(t, sink.Close())
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			var rec map[string]any
+			require.NoError(t, json.Unmarshal(data, &rec))
+
+			for k, v := range tt.want {
+				assert.Equal(t, v, rec[k], k)
+			}
+			assert.Equal(t, map[string]any{"level": "not the level"}, rec["req"])
+		})
+	}
+}
+
+func TestFileSinkRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.jsonl")
+	sink, err := NewFileSink(path, &FileSinkOptions{MaxBytes: 200, MaxFiles: 3, Compress: true})
+	require.NoError(t, err)
+
+	logger := slog.New(sink)
+	for i := range 20 {
+		logger.Info("a record long enough to fill the file quickly", "i", i)
+	}
+	require.NoError(t, sink.Close())
+
+	assert.FileExists(t, path)
+	assert.FileExists(t, path+".1.gz")
+	assert.FileExists(t, path+".3.gz")
+	assert.NoFileExists(t, path+".4.gz")
+}
+
+func TestMultiEnabled(t *testing.T) {
+	debug := New(&bytes.Buffer{}, &slog.HandlerOptions{Level: slog.LevelDebug})
+	warn := New(&bytes.Buffer{}, &slog.HandlerOptions{Level: slog.LevelWarn})
+
+	m := Multi(warn, debug)
+	assert.True(t, m.Enabled(nil, slog.LevelDebug))
+	assert.False(t, Multi(warn).Enabled(nil, slog.LevelInfo))
+}
+
+func TestFileSinkSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.jsonl")
+	sink, err := NewFileSink(path, &FileSinkOptions{Session: true, Level: slog.LevelDebug, MaxBytes: 1 << 20})
+	require.NoError(t, err)
+
+	logger := slog.New(sink).With("module", "jobs")
+	logger.Debug("one")
+	logger.Error("two")
+	logger.Error("three")
+
+	require.NoError(t, sink.Close())
+	require.NoError(t, sink.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 5)
+
+	var header SessionHeader
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &header))
+	assert.Equal(t, "header", header.Trifle)
+	assert.Equal(t, os.Getpid(), header.PID)
+	assert.Equal(t, "DEBUG", header.Config.Level)
+	assert.Equal(t, int64(1<<20), header.Config.MaxBytes)
+	assert.False(t, header.Start.IsZero())
+
+	var trailer SessionTrailer
+	require.NoError(t, json.Unmarshal([]byte(lines[4]), &trailer))
+	assert.Equal(t, "trailer", trailer.Trifle)
+	assert.Equal(t, map[string]uint64{"DEBUG": 1, "ERROR": 2}, trailer.Counts)
+	assert.False(t, trailer.End.Before(header.Start))
+}
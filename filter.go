@@ -0,0 +1,116 @@
+package trifle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"miren.dev/trifle/pkg/expr"
+)
+
+// WithFilterExpr returns an Option that drops the records that don't
+// match src, an expression in the language of package
+// [miren.dev/trifle/pkg/expr]:
+//
+//	trifle.WithFilterExpr(`level >= warn || module = db`)
+//
+// The expression sees the record's attributes, including those added from
+// its context, and those added to the logger with WithAttrs. Dropped
+// records are counted in [Stats]. With several filters, a record must
+// match them all. An expression that doesn't parse is reported by [NewE].
+func WithFilterExpr(src string) Option {
+	return func(h *TextHandler) {
+		e, err := expr.Parse(src)
+		h.filters = append(h.filters, &recordFilter{expr: e, err: err})
+	}
+}
+
+// WithFilter returns an Option that drops the records for which fn returns
+// false, for suppression that depends on more than the level:
+//
+//	trifle.WithFilter(func(ctx context.Context, r slog.Record) bool {
+//		keep := true
+//		r.Attrs(func(a slog.Attr) bool {
+//			keep = a.Key != "path" || a.Value.String() != "/healthz"
+//			return keep
+//		})
+//		return keep
+//	})
+//
+// fn is called after Enabled, for records that are enabled, and sees the
+// attributes of the log call and those added from the context, but not
+// those added to the logger with WithAttrs. Use [WithFilterExpr] to match
+// those. Dropped records are counted in [Stats]. With several filters, a
+// record must pass them all.
+func WithFilter(fn func(ctx context.Context, r slog.Record) bool) Option {
+	return func(h *TextHandler) {
+		f := &recordFilter{fn: fn}
+		if fn == nil {
+			f.err = errors.New("function must not be nil")
+		}
+		h.filters = append(h.filters, f)
+	}
+}
+
+// recordFilter decides which records are written, with an expression or
+// a function.
+type recordFilter struct {
+	expr *expr.Expr
+	fn   func(ctx context.Context, r slog.Record) bool
+	err  error // from parsing the expression
+}
+
+// keep reports whether r passes all the filters of h.
+func (h *TextHandler) keep(ctx context.Context, r slog.Record) bool {
+	var env *expr.Env
+
+	for _, f := range h.filters {
+		if f.fn != nil {
+			if !f.fn(ctx, r) {
+				return false
+			}
+			continue
+		}
+
+		if env == nil {
+			env = &expr.Env{
+				Record: r,
+				Module: h.module,
+				Attrs:  h.filterAttrs,
+				Group:  strings.Join(h.groups, "."),
+			}
+		}
+		if !f.expr.Match(env) {
+			return false
+		}
+	}
+	return true
+}
+
+// addFilterAttrs remembers as, added with WithAttrs, for the filters to
+// see. They are qualified by the groups open at the time.
+func (h *commonHandler) addFilterAttrs(as []slog.Attr) {
+	prefix := strings.Join(h.groups, ".")
+
+	attrs := slices.Clip(h.filterAttrs)
+	for _, a := range as {
+		if prefix != "" {
+			a.Key = prefix + "." + a.Key
+		}
+		attrs = append(attrs, a)
+	}
+	h.filterAttrs = attrs
+}
+
+func (h *commonHandler) validateFilters() []error {
+	var errs []error
+	for _, f := range h.filters {
+		if f.err != nil {
+			errs = append(errs, fmt.Errorf("filter: %w", f.err))
+		}
+	}
+	return errs
+}
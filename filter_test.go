@@ -0,0 +1,106 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterExpr(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := NewE(&buf, &slog.HandlerOptions{Level: Trace}, WithTerminalWidth(0),
+		WithFilterExpr(`level >= warn || module = db`),
+		WithFilterExpr(`path != /healthz`))
+	require.NoError(t, err)
+
+	log := slog.New(h)
+	log.Info("listening")
+	log.Warn("slow", "path", "/api")
+	log.Warn("probe failed", "path", "/healthz")
+	log.With(ModuleKey, "db").Debug("query")
+
+	out := Plain(buf.String())
+	assert.NotContains(t, out, "listening")
+	assert.Contains(t, out, "slow")
+	assert.NotContains(t, out, "probe failed")
+	assert.Contains(t, out, "query")
+
+	assert.Equal(t, uint64(2), h.Stats().Dropped)
+}
+
+func TestFilterExprSeesLoggerAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithFilterExpr(`job.tenant = acme`))
+
+	log := slog.New(h)
+	log.WithGroup("job").With("tenant", "acme").Info("kept")
+	log.WithGroup("job").With("tenant", "other").Info("dropped")
+	log.Info("also dropped")
+
+	out := Plain(buf.String())
+	assert.Contains(t, out, "kept")
+	assert.NotContains(t, out, "dropped")
+}
+
+func TestFilterExprSeesContextAttrs(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithFilterExpr(`request_id = r1`))
+
+	log := slog.New(h)
+	log.InfoContext(ContextWithRequestID(context.Background(), "r1"), "kept")
+	log.InfoContext(ContextWithRequestID(context.Background(), "r2"), "dropped")
+
+	out := Plain(buf.String())
+	assert.Contains(t, out, "kept")
+	assert.NotContains(t, out, "dropped")
+}
+
+func TestFilterExprInvalid(t *testing.T) {
+	_, err := NewE(&bytes.Buffer{}, nil, WithFilterExpr(`level >=`))
+	assert.ErrorContains(t, err, "filter: expr:")
+}
+
+func TestFilter(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithFilter(func(ctx context.Context, r slog.Record) bool {
+		keep := true
+		r.Attrs(func(a slog.Attr) bool {
+			keep = a.Key != "path" || a.Value.String() != "/healthz"
+			return keep
+		})
+		return keep
+	}))
+
+	log := slog.New(h)
+	log.Info("request", "path", "/healthz")
+	log.Info("request", "path", "/api")
+	log.Debug("not enabled", "path", "/api")
+
+	assert.Equal(t, 1, strings.Count(Plain(buf.String()), "request"))
+	assert.True(t, ContainsAttr(buf.String(), "path", "/api"))
+	assert.Equal(t, uint64(1), h.Stats().Dropped)
+}
+
+func TestFilterRunsBeforeAlerts(t *testing.T) {
+	alerted := make(chan slog.Record, 2)
+	h := New(&bytes.Buffer{}, nil,
+		WithFilter(func(ctx context.Context, r slog.Record) bool { return r.Message != "ignored" }),
+		WithAlertHook(slog.LevelError, func(ctx context.Context, r slog.Record) { alerted <- r }))
+
+	log := slog.New(h)
+	log.Error("ignored")
+	log.Error("failed")
+
+	r := <-alerted
+	assert.Equal(t, "failed", r.Message)
+}
+
+func TestFilterNil(t *testing.T) {
+	_, err := NewE(&bytes.Buffer{}, nil, WithFilter(nil))
+	assert.ErrorContains(t, err, "filter: function must not be nil")
+}
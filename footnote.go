@@ -0,0 +1,150 @@
+package trifle
+
+import (
+	"log/slog"
+	"strings"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+// HintKey is the attribute key used by [Hint].
+const HintKey = "hint"
+
+// DetailsKey is the attribute key used by [Details].
+const DetailsKey = "details"
+
+var footnoteColor = color.New(color.Faint)
+
+// Hint returns an Attr that tells the reader what to do about a record. The
+// TextHandler shows it as a dim footnote under the record rather than with
+// the other attributes, so that errors can say how to fix them through the
+// standard logging path:
+//
+//	logger.Error("config is out of date", trifle.Hint("run `app migrate --fix` to resolve"))
+//
+//	12:00:00.000 [ERROR] config is out of date
+//	    ↳ run `app migrate --fix` to resolve
+//
+// Other handlers see a string attribute with the key "hint".
+func Hint(text string) slog.Attr {
+	return slog.Any(HintKey, hint(text))
+}
+
+type hint string
+
+func (h hint) LogValue() slog.Value {
+	return slog.StringValue(string(h))
+}
+
+// Details returns an Attr for diagnostics too verbose for the line of the
+// record. Its arguments are attributes or alternating keys and values, as
+// for [slog.Group]. The TextHandler shows them as an aligned block of keys
+// and values under the record, like the output of a failed test:
+//
+//	logger.Error("response differs", "path", "/users", trifle.Details(
+//		"expected", `{"id": 1}`,
+//		"got", `{"id": 2}`,
+//	))
+//
+//	12:00:00.000 [ERROR] response differs │ path: /users
+//	    expected: {"id": 1}
+//	    got:      {"id": 2}
+//
+// Other handlers see a group with the key "details".
+func Details(args ...any) slog.Attr {
+	return slog.Any(DetailsKey, details(slog.Group(DetailsKey, args...).Value.Group()))
+}
+
+type details []slog.Attr
+
+func (d details) LogValue() slog.Value {
+	return slog.GroupValue(d...)
+}
+
+// footnote returns the lines to write under the record for v, and whether v
+// is shown that way at all.
+func (s *handleState) footnote(v slog.Value) ([]string, bool) {
+	if v.Kind() != slog.KindLogValuer {
+		return nil, false
+	}
+	switch v := v.LogValuer().(type) {
+	case hint:
+		lines := strings.Split(s.h.safe(string(v)), "\n")
+		for i, line := range lines {
+			lead := "  "
+			if i == 0 {
+				lead = "↳ "
+			}
+			lines[i] = footnoteColor.Sprint(footnoteIndent + lead + line)
+		}
+		return lines, true
+	case details:
+		return s.detailLines(v), true
+	case diff:
+		return s.diffFootnote(v), true
+	}
+	return nil, false
+}
+
+// detailLines renders the attributes of d, flattened into keys qualified by
+// their groups, with the values lined up.
+func (s *handleState) detailLines(d details) []string {
+	type detail struct{ key, value string }
+	var (
+		flat    []detail
+		flatten func(prefix string, as []slog.Attr)
+	)
+	flatten = func(prefix string, as []slog.Attr) {
+		for _, a := range as {
+			key := prefix + s.h.safe(a.Key)
+			v := a.Value.Resolve()
+			if v.Kind() == slog.KindGroup {
+				flatten(key+".", v.Group())
+				continue
+			}
+			flat = append(flat, detail{key, s.h.safe(v.String())})
+		}
+	}
+	flatten("", d)
+
+	width := 0
+	for _, d := range flat {
+		width = max(width, color.StringWidth(d.key))
+	}
+
+	var lines []string
+	for _, d := range flat {
+		pad := strings.Repeat(" ", width-color.StringWidth(d.key)+1)
+		for i, line := range strings.Split(d.value, "\n") {
+			lead := footnoteIndent + footnoteColor.Sprint(d.key+":") + pad
+			if i > 0 {
+				lead = footnoteIndent + strings.Repeat(" ", width+2)
+			}
+			lines = append(lines, lead+line)
+		}
+	}
+	return lines
+}
+
+// isFootnote reports whether a is shown under the record rather than with
+// the other attributes.
+func isFootnote(a slog.Attr) bool {
+	if a.Value.Kind() != slog.KindLogValuer {
+		return false
+	}
+	switch a.Value.LogValuer().(type) {
+	case hint, details, diff:
+		return true
+	}
+	return false
+}
+
+// inlineAttrs reports whether r has attributes shown with the message.
+func inlineAttrs(r slog.Record) bool {
+	inline := false
+	r.Attrs(func(a slog.Attr) bool {
+		inline = !isFootnote(a)
+		return !inline
+	})
+	return inline
+}
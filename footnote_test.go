@@ -0,0 +1,61 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHint(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(New(&buf, nil, WithTerminalWidth(0)))
+
+	log.Error("config is out of date", Hint("run `app migrate --fix` to resolve"))
+	log.With(Hint("check the network\nor try again later")).Warn("fetch failed", "url", "/users")
+
+	lines := strings.Split(strings.TrimSuffix(Plain(buf.String()), "\n"), "\n")
+	require.Len(t, lines, 5, buf.String())
+	assert.True(t, strings.HasSuffix(lines[0], "[ERROR] config is out of date"), lines[0])
+	assert.Equal(t, "    ↳ run `app migrate --fix` to resolve", lines[1])
+	assert.True(t, strings.HasSuffix(lines[2], "fetch failed │ url: /users"), lines[2])
+	assert.Equal(t, "    ↳ check the network", lines[3])
+	assert.Equal(t, "      or try again later", lines[4])
+}
+
+func TestHintOtherHandlers(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("x", Hint("do this"))
+
+	assert.Contains(t, buf.String(), `"hint":"do this"`)
+}
+
+func TestDetails(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(New(&buf, nil, WithTerminalWidth(0)))
+
+	log.Error("response differs", "path", "/users", Details(
+		"expected", `{"id": 1}`,
+		"got", "{\n  \"id\": 2\n}",
+		slog.Group("request", "method", "GET"),
+	), Hint("rerun with -update"))
+
+	assert.Equal(t, ` [ERROR] response differs │ path: /users
+    expected:       {"id": 1}
+    got:            {
+                      "id": 2
+                    }
+    request.method: GET
+    ↳ rerun with -update
+`, Plain(buf.String())[12:])
+}
+
+func TestDetailsOtherHandlers(t *testing.T) {
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("x", Details("a", 1, "b", "two"))
+
+	assert.Contains(t, buf.String(), `"details":{"a":1,"b":"two"}`)
+}
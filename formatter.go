@@ -0,0 +1,61 @@
+package trifle
+
+import (
+	"io"
+	"log/slog"
+)
+
+// Formatter renders records exactly as a [TextHandler] configured with the
+// same options would, but returns the bytes instead of writing them. It
+// lets other programs, such as TUIs or test frameworks, embed trifle's
+// rendering while managing output themselves.
+//
+// A Formatter is safe for concurrent use.
+type Formatter struct {
+	h *TextHandler
+}
+
+// NewFormatter creates a [Formatter] using the given options.
+// If opts is nil, the default options are used.
+//
+// Like [New], NewFormatter panics if the options conflict with each other.
+func NewFormatter(opts *slog.HandlerOptions, options ...Option) *Formatter {
+	return &Formatter{h: New(io.Discard, opts, options...)}
+}
+
+// Formatter returns a [Formatter] that renders records with h's options,
+// attributes, groups and module.
+func (h *TextHandler) Formatter() *Formatter {
+	return &Formatter{h: h}
+}
+
+// Format returns the rendered form of r, including the trailing newline.
+func (f *Formatter) Format(r slog.Record) []byte {
+	return f.AppendFormat(nil, r)
+}
+
+// FormatBuffer renders r, including the trailing newline, into a [Buffer]
+// taken from the pool. The caller must Free the returned Buffer.
+func (f *Formatter) FormatBuffer(r slog.Record) *Buffer {
+	return f.h.format(r, f.h.module, false)
+}
+
+// AppendFormat appends the rendered form of r, including the trailing
+// newline, to dst and returns the extended slice.
+func (f *Formatter) AppendFormat(dst []byte, r slog.Record) []byte {
+	buf := f.FormatBuffer(r)
+	defer buf.Free()
+	return append(dst, *buf...)
+}
+
+// WithAttrs returns a Formatter whose output includes attrs, as with
+// [TextHandler.WithAttrs].
+func (f *Formatter) WithAttrs(attrs []slog.Attr) *Formatter {
+	return &Formatter{h: f.h.WithAttrs(attrs).(*TextHandler)}
+}
+
+// WithGroup returns a Formatter that qualifies later attributes with name,
+// as with [TextHandler.WithGroup].
+func (f *Formatter) WithGroup(name string) *Formatter {
+	return &Formatter{h: f.h.WithGroup(name).(*TextHandler)}
+}
@@ -0,0 +1,41 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatterMatchesHandler(t *testing.T) {
+	var buf bytes.Buffer
+
+	options := []Option{WithContextKey("request_id"), WithImportantKeys("user_id")}
+	handler := New(&buf, nil, options...)
+	formatter := NewFormatter(nil, options...)
+
+	attrs := []slog.Attr{slog.String("module", "auth"), slog.String("request_id", "req-1")}
+	h := handler.WithAttrs(attrs).WithGroup("user")
+	f := formatter.WithAttrs(attrs).WithGroup("user")
+
+	r := slog.NewRecord(time.Time{}, slog.LevelInfo, "logged in", 0)
+	r.AddAttrs(slog.String("user_id", "u-1"))
+
+	require.NoError(t, h.Handle(context.Background(), r))
+	assert.Equal(t, buf.String(), string(f.Format(r)))
+	assert.Equal(t, "prefix:"+buf.String(), string(f.AppendFormat([]byte("prefix:"), r)))
+	assert.Equal(t, buf.String(), string(h.(*TextHandler).Formatter().Format(r)))
+}
+
+func TestFormatterBuffer(t *testing.T) {
+	f := NewFormatter(nil)
+	r := slog.NewRecord(time.Time{}, slog.LevelWarn, "pooled", 0)
+
+	buf := f.FormatBuffer(r)
+	defer buf.Free()
+	assert.Equal(t, string(f.Format(r)), buf.String())
+}
@@ -0,0 +1,55 @@
+package trifle
+
+import (
+	"sync/atomic"
+	"time"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+var gapColor = color.New(color.Faint)
+
+// WithGapAnnotations returns an Option that writes a dim line before a
+// record logged at least threshold after the record before it, stating how
+// long the output was quiet:
+//
+//	12:00:00.000 [INFO]  waiting for lock
+//	… 4.2s with no output …
+//	12:00:04.200 [INFO]  lock acquired
+//
+// so that stalls stand out when reading the log later. Records are compared
+// by their times, across all handlers derived from this one.
+func WithGapAnnotations(threshold time.Duration) Option {
+	return func(h *TextHandler) {
+		h.gaps = &gapMarker{threshold: threshold}
+	}
+}
+
+// gapMarker notices gaps between records. It is shared among clones.
+type gapMarker struct {
+	threshold time.Duration
+	last      atomic.Int64 // UnixNano of the latest record, 0 before the first
+}
+
+// mark returns the annotation to write before a record logged at t, or ""
+// if it doesn't follow a gap.
+func (g *gapMarker) mark(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	now := t.UnixNano()
+	prev := g.last.Load()
+	for now > prev && !g.last.CompareAndSwap(prev, now) {
+		prev = g.last.Load()
+	}
+	if prev == 0 {
+		return ""
+	}
+
+	d := time.Duration(now - prev)
+	if d < g.threshold {
+		return ""
+	}
+	return gapColor.Sprintf("… %v with no output …", summaryDuration(d)) + "\n"
+}
@@ -0,0 +1,55 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGapAnnotations(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithGapAnnotations(time.Second))
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	logAt := func(h slog.Handler, at time.Duration, msg string) {
+		require.NoError(t, h.Handle(context.Background(), slog.NewRecord(start.Add(at), slog.LevelInfo, msg, 0)))
+	}
+
+	logAt(h, 0, "waiting for lock")
+	logAt(h, 500*time.Millisecond, "still waiting")
+	logAt(h.WithAttrs([]slog.Attr{slog.String("module", "db")}), 4700*time.Millisecond, "lock acquired")
+	logAt(h, 4800*time.Millisecond, "done")
+
+	lines := strings.Split(strings.TrimSpace(Plain(buf.String())), "\n")
+	require.Len(t, lines, 5)
+	assert.Contains(t, lines[1], "still waiting")
+	assert.Equal(t, "… 4.2s with no output …", lines[2])
+	assert.Contains(t, lines[3], "lock acquired")
+}
+
+func TestGapAnnotationsBatch(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithGapAnnotations(time.Second))
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, h.HandleBatch(context.Background(), []slog.Record{
+		slog.NewRecord(start, slog.LevelInfo, "waiting for lock", 0),
+		slog.NewRecord(start.Add(3*time.Second), slog.LevelInfo, "lock acquired", 0),
+	}))
+
+	lines := strings.Split(strings.TrimSpace(Plain(buf.String())), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "… 3s with no output …", lines[1])
+	assert.Contains(t, lines[2], "lock acquired")
+}
+
+func TestGapAnnotationsValidation(t *testing.T) {
+	_, err := NewE(&bytes.Buffer{}, nil, WithGapAnnotations(0))
+	assert.ErrorContains(t, err, "gap threshold")
+}
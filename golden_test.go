@@ -0,0 +1,129 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle/pkg/color"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// goldenCase renders a fixed set of records through a handler. Records are
+// created with a zero time so the output doesn't depend on the clock.
+type goldenCase struct {
+	name    string
+	options []Option
+	log     func(h slog.Handler)
+}
+
+func goldenRecord(level slog.Level, msg string, args ...any) slog.Record {
+	r := slog.NewRecord(time.Time{}, level, msg, 0)
+	r.Add(args...)
+	return r
+}
+
+var goldenCases = []goldenCase{
+	{
+		name: "levels",
+		log: func(h slog.Handler) {
+			for _, level := range []slog.Level{Trace, Debug, Info, Warn, Error, Error + 2} {
+				h.Handle(context.Background(), goldenRecord(level, "level "+level.String(), "n", 1))
+			}
+		},
+	},
+	{
+		name:    "highlighting",
+		options: []Option{WithCriticalKeys("error"), WithImportantKeys("user_id")},
+		log: func(h slog.Handler) {
+			h.Handle(context.Background(), goldenRecord(Error, "payment failed",
+				"error", errors.New("card declined"), "user_id", "u-1", "amount", 12.5))
+		},
+	},
+	{
+		name:    "context and modules",
+		options: []Option{WithContextKey("request_id", "trace_id")},
+		log: func(h slog.Handler) {
+			h = h.WithAttrs([]slog.Attr{slog.String("module", "api"), slog.String("request_id", "req-1")})
+			h.Handle(context.Background(), goldenRecord(Info, "handled", "status", 200))
+			h = h.WithAttrs([]slog.Attr{slog.String("module", "db")})
+			h.Handle(context.Background(), goldenRecord(Info, "queried", "trace_id", "t-9", "rows", 3))
+		},
+	},
+	{
+		name: "groups",
+		log: func(h slog.Handler) {
+			h = h.WithGroup("request").WithAttrs([]slog.Attr{slog.String("method", "GET")})
+			h.Handle(context.Background(), goldenRecord(Info, "grouped",
+				"path", "/", slog.Group("client", "ip", "10.0.0.1")))
+		},
+	},
+	{
+		name: "wrapping",
+		log: func(h slog.Handler) {
+			h.Handle(context.Background(), goldenRecord(Info, "many attributes",
+				"alpha", "first value", "beta", "second value", "gamma", "third value",
+				"delta", "fourth value", "epsilon", 5, "zeta", 6*time.Second))
+		},
+	},
+	{
+		name: "multiline",
+		log: func(h slog.Handler) {
+			h.Handle(context.Background(), goldenRecord(Warn, "config changed",
+				"diff", "- level: info\n+ level: debug", "after", "done"))
+		},
+	},
+	{
+		name: "quoting",
+		log: func(h slog.Handler) {
+			h.Handle(context.Background(), goldenRecord(Info, "values",
+				"empty", "", "space", "a b", "quote", `say "hi"`, "bytes", []byte("raw"), "nil", nil))
+		},
+	},
+}
+
+func TestGolden(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+
+	for _, colored := range []bool{true, false} {
+		for _, width := range []int{0, 40, 80} {
+			mode := "plain"
+			if colored {
+				mode = "color"
+			}
+
+			name := fmt.Sprintf("%s-%d", mode, width)
+			t.Run(name, func(t *testing.T) {
+				color.NoColor = !colored
+
+				var buf bytes.Buffer
+				for _, gc := range goldenCases {
+					fmt.Fprintf(&buf, "=== %s\n", gc.name)
+					options := append([]Option{WithTerminalWidth(width)}, gc.options...)
+					gc.log(New(&buf, &slog.HandlerOptions{Level: Trace}, options...))
+				}
+
+				path := filepath.Join("testdata", "golden", name+".golden")
+				if *update {
+					require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+					require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+					return
+				}
+
+				expected, err := os.ReadFile(path)
+				require.NoError(t, err, "run go test -update to create golden files")
+				assert.Equal(t, string(expected), buf.String())
+			})
+		}
+	}
+}
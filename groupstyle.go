@@ -0,0 +1,42 @@
+package trifle
+
+// GroupStyle is how a [TextHandler] shows the groups started with
+// WithGroup.
+type GroupStyle int
+
+const (
+	// GroupPrefix qualifies the keys of attributes with the groups they
+	// are in, as in "request.method". It is the default.
+	GroupPrefix GroupStyle = iota
+
+	// GroupIndent indents records by two spaces for every group they are
+	// logged in, and leaves keys unqualified, so that nested operations
+	// read as an outline:
+	//
+	//	12:00:00.000 [INFO]  request │ path: /users
+	//	12:00:00.001 [INFO]    handler │ name: list
+	//	12:00:00.002 [INFO]      query │ rows: 12
+	//
+	// The time and level stay in place. Groups of attributes built with
+	// slog.Group still qualify their keys.
+	GroupIndent
+)
+
+// WithGroupStyle returns an Option that sets how groups started with
+// WithGroup are shown.
+func WithGroupStyle(style GroupStyle) Option {
+	return func(h *TextHandler) {
+		h.groupStyle = style
+	}
+}
+
+// groupDepth returns the number of named groups started with WithGroup.
+func (h *commonHandler) groupDepth() int {
+	n := 0
+	for _, g := range h.groups {
+		if g != "" {
+			n++
+		}
+	}
+	return n
+}
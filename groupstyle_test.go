@@ -0,0 +1,54 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupIndent(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithGroupStyle(GroupIndent))
+
+	request := slog.New(h).WithGroup("request")
+	request.Info("request", "path", "/users")
+	handler := request.WithGroup("handler").With("name", "list")
+	handler.Info("handler")
+	handler.WithGroup("repo").Info("query", "rows", 12, slog.Group("db", "table", "users"))
+
+	out := Plain(buf.String())
+	assert.True(t, MatchesLine(out, `\[INFO\]    request │ path: /users$`), out)
+	assert.True(t, MatchesLine(out, `\[INFO\]      handler │ name: list$`), out)
+	assert.True(t, MatchesLine(out, `\[INFO\]        query │ name: list rows: 12 db.table: users$`), out)
+}
+
+func TestGroupIndentReplaceAttr(t *testing.T) {
+	var groups [][]string
+	opts := &slog.HandlerOptions{ReplaceAttr: func(gs []string, a slog.Attr) slog.Attr {
+		if a.Key == "k" {
+			groups = append(groups, gs)
+		}
+		return a
+	}}
+
+	var buf bytes.Buffer
+	h := New(&buf, opts, WithTerminalWidth(0), WithGroupStyle(GroupIndent))
+
+	log := slog.New(h).WithGroup("a")
+	log.With("k", 1).Info("with")
+	log.WithGroup("b").Info("record", "k", 2)
+
+	assert.Equal(t, [][]string{{"a"}, {"a", "b"}}, groups)
+	assert.True(t, ContainsAttr(buf.String(), "k", 2))
+}
+
+func TestGroupPrefixDefault(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0))
+
+	slog.New(h).WithGroup("request").Info("served", "path", "/users")
+
+	assert.True(t, ContainsAttr(buf.String(), "request.path", "/users"))
+}
@@ -0,0 +1,259 @@
+package trifle
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxHistogramSamples is how many values a histogram keeps to compute its
+// percentiles from. Beyond that, it keeps a uniform sample of them.
+const maxHistogramSamples = 10000
+
+// WithHistograms returns an Option that collects the numeric values of
+// keys, such as duration_ms, from the records written, for a quick readout
+// of a load test without any other tooling:
+//
+//	duration_ms: p50=12ms p90=85ms p99=230ms max=1.2s over 1,204 records
+//
+// [TextHandler.Summary] writes a line like this for every key before its
+// own, and [TextHandler.Histograms] returns them at any time. Keys match
+// the attributes of the log call, qualified by their groups as in
+// "db.rows". Durations are shown as such, and so are numbers whose key
+// ends in a unit: _ns, _us, _ms or _s. Counting starts over with
+// [TextHandler.ResetStats].
+func WithHistograms(keys ...string) Option {
+	return func(h *TextHandler) {
+		if h.histograms == nil {
+			h.histograms = &histograms{}
+		}
+		h.histograms.keys = append(h.histograms.keys, keys...)
+	}
+}
+
+// Histogram is the distribution of the values of a key, see
+// [WithHistograms].
+type Histogram struct {
+	Key   string
+	Count uint64 // values seen
+	Max   float64
+
+	// Unit is the duration a value of 1 stands for, or 0 if the values
+	// aren't durations.
+	Unit time.Duration
+
+	samples []float64 // sorted, up to maxHistogramSamples
+}
+
+// Percentile returns the value that p percent of the values are at or
+// below, such as Percentile(99), or 0 if there are none. Past
+// maxHistogramSamples values, it is an estimate.
+func (h Histogram) Percentile(p float64) float64 {
+	if len(h.samples) == 0 {
+		return 0
+	}
+	i := int(math.Ceil(p/100*float64(len(h.samples)))) - 1
+	return h.samples[min(max(i, 0), len(h.samples)-1)]
+}
+
+// String describes h in one line.
+func (h Histogram) String() string {
+	var b strings.Builder
+	b.WriteString(h.Key + ":")
+	for _, p := range []float64{50, 90, 99} {
+		fmt.Fprintf(&b, " p%g=%s", p, h.format(h.Percentile(p)))
+	}
+	fmt.Fprintf(&b, " max=%s", h.format(h.Max))
+
+	noun := "records"
+	if h.Count == 1 {
+		noun = "record"
+	}
+	fmt.Fprintf(&b, " over %s %s", locales["en"].groupDigits(strconv.FormatUint(h.Count, 10), 4), noun)
+	return b.String()
+}
+
+// attr describes h in a group, as Summary writes it in JSON.
+func (h Histogram) attr() slog.Attr {
+	value := func(key string, v float64) slog.Attr {
+		if h.Unit != 0 {
+			return slog.Duration(key, roundSignificant(time.Duration(v*float64(h.Unit))))
+		}
+		return slog.Float64(key, v)
+	}
+	return slog.Group(h.Key,
+		value("p50", h.Percentile(50)),
+		value("p90", h.Percentile(90)),
+		value("p99", h.Percentile(99)),
+		value("max", h.Max),
+		slog.Uint64("count", h.Count),
+	)
+}
+
+func (h Histogram) format(v float64) string {
+	if h.Unit != 0 {
+		return roundSignificant(time.Duration(v * float64(h.Unit))).String()
+	}
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+// roundSignificant rounds d to three significant digits.
+func roundSignificant(d time.Duration) time.Duration {
+	for unit := time.Duration(1); unit < time.Second; unit *= 10 {
+		if d < 1000*unit {
+			return d.Round(unit)
+		}
+	}
+	return d.Round(time.Second)
+}
+
+// histograms collects the values of the keys of WithHistograms. It is
+// shared among clones.
+type histograms struct {
+	keys []string
+
+	mu     sync.Mutex
+	byKey  map[string]*histogram
+	random *rand.Rand
+}
+
+type histogram struct {
+	count   uint64
+	max     float64
+	unit    time.Duration
+	samples []float64
+}
+
+// observe adds the values of r for the keys of hs.
+func (hs *histograms) observe(r slog.Record) {
+	r.Attrs(func(a slog.Attr) bool {
+		hs.observeAttr("", a)
+		return true
+	})
+}
+
+func (hs *histograms) observeAttr(prefix string, a slog.Attr) {
+	key := a.Key
+	if prefix != "" {
+		key = prefix + "." + a.Key
+	}
+
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		for _, ga := range v.Group() {
+			hs.observeAttr(key, ga)
+		}
+		return
+	}
+	if !slices.Contains(hs.keys, key) {
+		return
+	}
+
+	n, unit, ok := histogramValue(key, v)
+	if !ok {
+		return
+	}
+
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	if hs.byKey == nil {
+		hs.byKey = make(map[string]*histogram)
+		hs.random = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	h := hs.byKey[key]
+	if h == nil {
+		h = &histogram{max: n, unit: unit}
+		hs.byKey[key] = h
+	}
+
+	h.count++
+	h.max = max(h.max, n)
+	if len(h.samples) < maxHistogramSamples {
+		h.samples = append(h.samples, n)
+	} else if i := hs.random.Uint64N(h.count); i < maxHistogramSamples {
+		h.samples[i] = n
+	}
+}
+
+// histogramValue returns the number v holds, and the duration it stands
+// for when it is one.
+func histogramValue(key string, v slog.Value) (n float64, unit time.Duration, ok bool) {
+	switch v.Kind() {
+	case slog.KindDuration:
+		return float64(v.Duration()), time.Nanosecond, true
+	case slog.KindInt64:
+		n = float64(v.Int64())
+	case slog.KindUint64:
+		n = float64(v.Uint64())
+	case slog.KindFloat64:
+		n = v.Float64()
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return 0, 0, false
+		}
+	default:
+		return 0, 0, false
+	}
+
+	for _, u := range keyUnits {
+		if strings.HasSuffix(key, u.suffix) {
+			return n, u.unit, true
+		}
+	}
+	return n, 0, true
+}
+
+var keyUnits = []struct {
+	suffix string
+	unit   time.Duration
+}{
+	{"_ns", time.Nanosecond},
+	{"_us", time.Microsecond},
+	{"_ms", time.Millisecond},
+	{"_s", time.Second},
+}
+
+// snapshot returns the histograms of the keys that had values, in the
+// order of the keys.
+func (hs *histograms) snapshot() []Histogram {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	var out []Histogram
+	for _, key := range hs.keys {
+		h := hs.byKey[key]
+		if h == nil {
+			continue
+		}
+		samples := slices.Clone(h.samples)
+		slices.Sort(samples)
+		out = append(out, Histogram{Key: key, Count: h.count, Max: h.max, Unit: h.unit, samples: samples})
+	}
+	return out
+}
+
+func (hs *histograms) reset() {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	hs.byKey = nil
+}
+
+// Histograms returns the distributions of the values of the keys of
+// [WithHistograms] seen so far, by h and every handler derived from it.
+// Keys without any values are left out.
+func (h *TextHandler) Histograms() []Histogram {
+	if h.histograms == nil {
+		return nil
+	}
+	return h.histograms.snapshot()
+}
@@ -0,0 +1,68 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistograms(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := NewE(&buf, nil, PresetTest, WithHistograms("duration_ms", "took", "db.rows", "missing"))
+	require.NoError(t, err)
+
+	log := slog.New(h)
+	for i := 1; i <= 1200; i++ {
+		log.Info("served", "duration_ms", i%100+1, "took", time.Duration(i)*time.Microsecond)
+	}
+	log.Info("queried", slog.Group("db", "rows", 12))
+	log.Info("queried", slog.Group("db", "rows", 2.5))
+	log.Info("odd", "duration_ms", "slow")
+
+	hists := h.Histograms()
+	require.Len(t, hists, 3)
+
+	assert.Equal(t, "duration_ms", hists[0].Key)
+	assert.Equal(t, uint64(1200), hists[0].Count)
+	assert.Equal(t, 50.0, hists[0].Percentile(50))
+	assert.Equal(t, 99.0, hists[0].Percentile(99))
+	assert.Equal(t, "duration_ms: p50=50ms p90=90ms p99=99ms max=100ms over 1,200 records", hists[0].String())
+	assert.Equal(t, "took: p50=600µs p90=1.08ms p99=1.19ms max=1.2ms over 1,200 records", hists[1].String())
+	assert.Equal(t, "db.rows: p50=2.5 p90=12 p99=12 max=12 over 2 records", hists[2].String())
+
+	buf.Reset()
+	h.Summary()
+	lines := strings.Split(Plain(buf.String()), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, hists[0].String(), lines[0])
+	assert.Equal(t, hists[2].String(), lines[2])
+	assert.Contains(t, lines[3], "✔ completed")
+
+	h.ResetStats()
+	assert.Empty(t, h.Histograms())
+}
+
+func TestHistogramSamples(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, PresetTest, WithHistograms("n"))
+
+	log := slog.New(h)
+	for i := range 3 * maxHistogramSamples {
+		log.Info("tick", "n", i)
+	}
+
+	hist := h.Histograms()[0]
+	assert.Equal(t, uint64(3*maxHistogramSamples), hist.Count)
+	assert.Equal(t, float64(3*maxHistogramSamples-1), hist.Max)
+	assert.InDelta(t, 1.5*maxHistogramSamples, hist.Percentile(50), 0.1*maxHistogramSamples)
+}
+
+func TestHistogramsValidation(t *testing.T) {
+	_, err := NewE(&bytes.Buffer{}, nil, WithHistograms(""))
+	assert.ErrorContains(t, err, "histogram key must not be empty")
+}
@@ -0,0 +1,31 @@
+package trifle
+
+import (
+	"log"
+	"log/slog"
+)
+
+// Install builds a handler with [Quick] using the given options and makes it
+// the default for both log/slog and the standard log package, so existing
+// calls to log.Printf are rendered by trifle as Info records.
+//
+// The returned function restores the previous slog default logger and the
+// previous output and flags of the standard logger.
+func Install(options ...Option) (restore func()) {
+	var (
+		prevLogger = slog.Default()
+		prevOutput = log.Writer()
+		prevFlags  = log.Flags()
+		prevPrefix = log.Prefix()
+	)
+
+	// slog.SetDefault redirects the standard logger into the handler.
+	slog.SetDefault(slog.New(Quick(options...)))
+
+	return func() {
+		slog.SetDefault(prevLogger)
+		log.SetOutput(prevOutput)
+		log.SetFlags(prevFlags)
+		log.SetPrefix(prevPrefix)
+	}
+}
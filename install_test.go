@@ -0,0 +1,33 @@
+package trifle
+
+import (
+	"bytes"
+	"log"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstall(t *testing.T) {
+	var buf bytes.Buffer
+
+	prevLogger := slog.Default()
+	prevOutput := log.Writer()
+	prevFlags := log.Flags()
+
+	restore := Install(func(h *TextHandler) { h.w = &buf })
+
+	log.Printf("from the standard logger")
+	slog.Info("from slog", "key", "value")
+
+	output := buf.String()
+	assert.Contains(t, output, "from the standard logger")
+	assert.Contains(t, output, "from slog")
+
+	restore()
+
+	assert.Same(t, prevLogger, slog.Default())
+	assert.Equal(t, prevOutput, log.Writer())
+	assert.Equal(t, prevFlags, log.Flags())
+}
@@ -0,0 +1,148 @@
+package trifle
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"math"
+	"slices"
+	"sync"
+)
+
+// NewJSON returns a handler writing records to w as JSON lines, as
+// [slog.JSONHandler] does, with the same options as [New], so that the
+// configuration used for the terminal in development can be used for JSON
+// in production by swapping the constructor:
+//
+//	h := trifle.NewJSON(os.Stdout, nil, trifle.WithContextKey("request_id"))
+//	slog.New(h).With(trifle.ModuleKey, "api").Info("served", "request_id", "req-1", "status", 200)
+//
+//	{"time":"2025-01-02T12:00:00Z","level":"INFO","msg":"served","module":"api","request_id":"req-1","status":200}
+//
+// Records go through the same pipeline as those of New: transformers,
+// filters, dedup, decorators, alerts and the rest. The module accumulated
+// from "module" attributes and the values of the keys given to
+// [WithContextKey] are written as strings at the top level, after the
+// message, wherever they were set. [slog.HandlerOptions.ReplaceAttr] sees
+// every attribute, with its groups, as it would for slog.JSONHandler.
+//
+// The options that only change how records look on a terminal, such as
+// [WithGroupStyle], [WithAdaptiveVerbosity], [WithLocale] and the
+// translators, have no effect, and neither [WithBanner], [WithGapAnnotations]
+// nor [WithWatchKeys] write lines of their own, so that every line is a
+// record; [TextHandler.Summary] writes a record too.
+func NewJSON(w io.Writer, opts *slog.HandlerOptions, options ...Option) *TextHandler {
+	return New(w, opts, append([]Option{jsonOutput}, options...)...)
+}
+
+// jsonOutput is the Option that NewJSON applies before the others.
+func jsonOutput(h *TextHandler) {
+	h.json = &jsonEncoder{}
+}
+
+// WithNumericLevel returns an Option that writes the level of each record
+// as a number too, by a convention such as [SeverityOTLP], for downstream
+// systems that sort or filter by severity:
+//
+//	h := trifle.NewJSON(os.Stdout, nil, trifle.WithNumericLevel(trifle.SeverityOTLP))
+//
+//	{"time":"…","level":"WARN","severity_number":13,"msg":"slow"}
+//
+// It applies to handlers made with [NewJSON], after
+// [slog.HandlerOptions.ReplaceAttr], and has no effect on others.
+func WithNumericLevel(s Severity) Option {
+	return func(h *TextHandler) {
+		if h.json != nil {
+			h.json.severity = s
+		}
+	}
+}
+
+// jsonEncoder renders records as JSON lines with one slog.JSONHandler,
+// shared by a handler and its clones, writing into the Buffer of the
+// record being encoded.
+type jsonEncoder struct {
+	severity Severity // see WithNumericLevel
+
+	once sync.Once
+	h    *slog.JSONHandler
+
+	mu  sync.Mutex
+	buf *Buffer // the Buffer being written by encode
+}
+
+// encode renders r with the options of h into a Buffer taken from the
+// pool. The caller must Free the returned Buffer.
+func (e *jsonEncoder) encode(h *commonHandler, r slog.Record) *Buffer {
+	// The JSONHandler is made on first use rather than by NewJSON, so that
+	// it sees the options applied after jsonOutput.
+	e.once.Do(func() {
+		rep := h.opts.ReplaceAttr
+		if e.severity.Number != nil {
+			rep = func(groups []string, a slog.Attr) slog.Attr {
+				if h.opts.ReplaceAttr != nil {
+					a = h.opts.ReplaceAttr(groups, a)
+				}
+				return e.severity.replaceLevel(groups, a)
+			}
+		}
+		e.h = slog.NewJSONHandler(e, &slog.HandlerOptions{
+			Level:       slog.Level(math.MinInt),
+			AddSource:   h.opts.AddSource,
+			ReplaceAttr: rep,
+		})
+	})
+
+	buf := NewBuffer()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.buf = buf
+	_ = e.h.Handle(context.Background(), r)
+	e.buf = nil
+	return buf
+}
+
+// Write is called by the JSONHandler with each line it encodes.
+func (e *jsonEncoder) Write(p []byte) (int, error) {
+	return e.buf.Write(p)
+}
+
+// formatJSON renders r as a JSON line, see NewJSON. The caller must Free
+// the returned Buffer.
+func (h *commonHandler) formatJSON(r slog.Record, module string) *Buffer {
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	if module != "" {
+		out.AddAttrs(slog.String(ModuleKey, module))
+	}
+	for i, val := range h.contextValuesOf(r) {
+		if val.first != "" {
+			out.AddAttrs(slog.String(h.contextKeys[i], val.current()))
+		}
+	}
+
+	// Fold the attributes and groups around the record's own, innermost
+	// first, as attrChain does.
+	var attrs []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		if !slices.Contains(h.contextKeys, a.Key) {
+			attrs = append(attrs, a)
+		}
+		return true
+	})
+	for i := len(h.goas) - 1; i >= 0; i-- {
+		goa := h.goas[i]
+		if goa.group != "" {
+			if len(attrs) > 0 {
+				attrs = []slog.Attr{{Key: goa.group, Value: slog.GroupValue(attrs...)}}
+			}
+			continue
+		}
+		with := slices.DeleteFunc(slices.Clone(goa.attrs), func(a slog.Attr) bool {
+			return slices.Contains(h.contextKeys, a.Key)
+		})
+		attrs = append(with, attrs...)
+	}
+	out.AddAttrs(attrs...)
+
+	return h.json.encode(h, out)
+}
@@ -0,0 +1,188 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewJSON(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewJSON(&buf, nil, WithContextKey("request_id"))
+
+	log := slog.New(h).With(ModuleKey, "api", "request_id", "req-1").With(ModuleKey, "users")
+	log.WithGroup("req").With("method", "GET").Info("served", "status", 200, Hint("cache it"))
+
+	recs := jsonLines(t, buf.String())
+	require.Len(t, recs, 1)
+	rec := recs[0]
+	assert.Equal(t, "INFO", rec["level"])
+	assert.Equal(t, "served", rec["msg"])
+	assert.Equal(t, "api.users", rec[ModuleKey])
+	assert.Equal(t, "req-1", rec["request_id"])
+	assert.Equal(t, map[string]any{"method": "GET", "status": 200.0, "hint": "cache it"}, rec["req"])
+	assert.NotContains(t, buf.String(), "│")
+}
+
+func TestNewJSONFieldOrder(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewJSON(&buf, nil, WithContextKey("request_id", "user"))
+
+	slog.New(h).With("user", "ada").Info("served", "request_id", "req-1", "status", 200)
+
+	line := buf.String()
+	msg := strings.Index(line, `"msg":"served"`)
+	id := strings.Index(line, `"request_id":"req-1"`)
+	user := strings.Index(line, `"user":"ada"`)
+	status := strings.Index(line, `"status":200`)
+	require.True(t, msg >= 0 && id >= 0 && user >= 0 && status >= 0, line)
+	assert.True(t, msg < id && id < user && user < status, line)
+	assert.Equal(t, 1, strings.Count(line, "request_id"), line)
+}
+
+func TestNewJSONContextKeyChanged(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewJSON(&buf, nil, WithContextKey("request_id"))
+
+	slog.New(h).With("request_id", "req-1").Info("retry", "request_id", "req-2")
+
+	assert.Equal(t, "req-2", jsonLines(t, buf.String())[0]["request_id"])
+}
+
+func TestNewJSONReplaceAttr(t *testing.T) {
+	var groups [][]string
+	opts := &slog.HandlerOptions{ReplaceAttr: func(gs []string, a slog.Attr) slog.Attr {
+		switch a.Key {
+		case slog.TimeKey:
+			return slog.Attr{}
+		case "password":
+			groups = append(groups, gs)
+			return slog.String(a.Key, "***")
+		case ModuleKey:
+			return slog.String("component", a.Value.String())
+		}
+		return a
+	}}
+
+	var buf bytes.Buffer
+	h := NewJSON(&buf, opts)
+	slog.New(h).With(ModuleKey, "auth").WithGroup("login").Info("attempt", "password", "hunter2")
+
+	assert.Equal(t, `{"level":"INFO","msg":"attempt","component":"auth","login":{"password":"***"}}`+"\n", buf.String())
+	assert.Equal(t, [][]string{{"login"}}, groups)
+}
+
+func TestNewJSONPipeline(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewJSON(&buf, nil,
+		WithBanner(),
+		WithGapAnnotations(time.Millisecond),
+		WithFilter(func(_ context.Context, r slog.Record) bool { return r.Message != "noise" }),
+		WithLevelDecorator(slog.LevelError, func(context.Context, slog.Record) []slog.Attr {
+			return []slog.Attr{slog.String("oncall", "ops")}
+		}),
+		WithModuleLevel("db", slog.LevelWarn),
+	)
+	log := slog.New(h)
+
+	log.Info("noise")
+	log.Info("first", "n", 1)
+	log.With(ModuleKey, "db").Info("connected")
+	log.Error("failed", "n", 2)
+
+	recs := jsonLines(t, buf.String())
+	require.Len(t, recs, 2, buf.String())
+	assert.Equal(t, "first", recs[0]["msg"])
+	assert.Equal(t, "failed", recs[1]["msg"])
+	assert.Equal(t, "ops", recs[1]["oncall"])
+}
+
+func TestNewJSONSummary(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewJSON(&buf, nil, WithWatchKeys("queue_depth"), WithHistograms("latency"))
+
+	log := slog.New(h)
+	log.Info("dispatched", "queue_depth", 12, "latency", 20*time.Millisecond)
+	log.Error("lost", "queue_depth", 3)
+	assert.Equal(t, 1, h.Summary())
+
+	recs := jsonLines(t, buf.String())
+	require.Len(t, recs, 3)
+	rec := recs[2]
+	assert.Equal(t, "ERROR", rec["level"])
+	assert.Equal(t, "failed", rec["msg"])
+	assert.Contains(t, rec, "elapsed")
+	assert.Equal(t, 1.0, rec["errors"])
+	assert.Equal(t, 0.0, rec["warnings"])
+	assert.Equal(t, map[string]any{"queue_depth": 3.0}, rec["latest"])
+	assert.Equal(t, map[string]any{"latency": map[string]any{
+		"p50": 20e6, "p90": 20e6, "p99": 20e6, "max": 20e6, "count": 1.0,
+	}}, rec["histograms"])
+}
+
+func TestNewJSONConcurrent(t *testing.T) {
+	var buf syncBuffer
+	log := slog.New(NewJSON(&buf, nil))
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				log.With("worker", i).Info("tick")
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, jsonLines(t, buf.String()), 400)
+}
+
+func TestNewJSONNumericLevel(t *testing.T) {
+	for _, tt := range []struct {
+		severity Severity
+		want     map[string]any
+	}{
+		{SeverityOTLP, map[string]any{"level": "WARN", "severity_number": 13.0}},
+		{SeverityGCP, map[string]any{"level": "WARN", "severity": 400.0}},
+		{SeveritySlog, map[string]any{"level": 4.0}},
+		{Severity{}, map[string]any{"level": "WARN", "severity_number": nil, "severity": nil}},
+	} {
+		t.Run(tt.severity.Key, func(t *testing.T) {
+			var buf bytes.Buffer
+			h := NewJSON(&buf, nil, WithNumericLevel(tt.severity))
+			slog.New(h).WithGroup("req").Warn("slow", "level", "not the level")
+
+			rec := jsonLines(t, buf.String())[0]
+			for k, v := range tt.want {
+				assert.Equal(t, v, rec[k], k)
+			}
+			assert.Equal(t, map[string]any{"level": "not the level"}, rec["req"])
+		})
+	}
+}
+
+func TestNewJSONNumericLevelAfterReplaceAttr(t *testing.T) {
+	opts := &slog.HandlerOptions{ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+		// Report Info records as Warn.
+		if l, ok := a.Value.Any().(slog.Level); ok && len(groups) == 0 && a.Key == slog.LevelKey && l == slog.LevelInfo {
+			return slog.Any(slog.LevelKey, slog.LevelWarn)
+		}
+		return a
+	}}
+
+	var buf bytes.Buffer
+	slog.New(NewJSON(&buf, opts, WithNumericLevel(SeverityOTLP))).Info("raised")
+
+	rec := jsonLines(t, buf.String())[0]
+	assert.Equal(t, "WARN", rec["level"])
+	assert.Equal(t, 13.0, rec["severity_number"])
+}
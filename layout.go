@@ -0,0 +1,53 @@
+package trifle
+
+import "strings"
+
+// The layout of a record written by a [TextHandler], for renderers of its
+// output, such as HTML exports and TUIs, to line up with the terminal:
+//
+//	12:00:00.000 [INFO]  req-1 served │ path: /users status: 200
+//	                     wrapped: attributes
+//	    ↳ a footnote, such as a Hint
+//
+// Widths are in columns.
+const (
+	// TimeWidth is the width of the time of a record, in [MiniTimeFormat].
+	// A record more than an hour after the previous one shows the longer
+	// [TimeFormat] instead, and the rest of its line moves along.
+	TimeWidth = len(MiniTimeFormat)
+
+	// LevelWidth is the width of the level after the time, such as
+	// " [INFO]  ", spaces included. Levels with longer names, such as
+	// ERROR+4, or translated ones take more.
+	LevelWidth = len(" [ERROR] ")
+
+	// WrapIndent is the column that attributes wrapped onto the next line
+	// start at, under the message.
+	WrapIndent = TimeWidth + LevelWidth
+
+	// FootnoteIndent is the indentation of the lines written under a
+	// record, such as those of [Hint], [Details] and [Diff].
+	FootnoteIndent = 4
+
+	// GroupIndentWidth is how far each group started with WithGroup
+	// indents a record, with the [GroupIndent] style.
+	GroupIndentWidth = 2
+
+	// AttrsSeparator separates the message from the attributes.
+	AttrsSeparator = " │ "
+
+	// ContextSeparator separates the values of the keys given to
+	// [WithContextKey], shown before the message.
+	ContextSeparator = " "
+)
+
+var (
+	// wrapIndent is how far wrapped attributes are indented.
+	wrapIndent = strings.Repeat(" ", WrapIndent)
+
+	// footnoteIndent starts the lines written under a record.
+	footnoteIndent = strings.Repeat(" ", FootnoteIndent)
+
+	// groupIndent is how far each group indents a record with GroupIndent.
+	groupIndent = strings.Repeat(" ", GroupIndentWidth)
+)
@@ -0,0 +1,50 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestLayoutMatchesOutput(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(60), WithContextKey("request_id", "user"))
+
+	slog.New(h).With("request_id", "req-1", "user", "ada").Info("served",
+		"path", "/users/profile/settings",
+		"status", 200,
+		"wrapped", "attributes that go past the width",
+		Hint("a footnote"),
+	)
+
+	lines := strings.Split(strings.TrimSuffix(color.Strip(buf.String()), "\n"), "\n")
+	require.GreaterOrEqual(t, len(lines), 3, buf.String())
+
+	first := lines[0]
+	assert.Equal(t, " [INFO]  ", first[TimeWidth:WrapIndent], first)
+	assert.True(t, strings.HasPrefix(first[WrapIndent:], "req-1"+ContextSeparator+"ada"), first)
+	assert.True(t, strings.HasSuffix(first, "served"+AttrsSeparator), first)
+
+	wrapped := lines[1]
+	assert.Equal(t, strings.Repeat(" ", WrapIndent), wrapped[:WrapIndent], wrapped)
+	assert.NotEqual(t, ' ', rune(wrapped[WrapIndent]), wrapped)
+
+	footnote := lines[len(lines)-1]
+	assert.Equal(t, strings.Repeat(" ", FootnoteIndent), footnote[:FootnoteIndent], footnote)
+	assert.Contains(t, footnote, "a footnote")
+}
+
+func TestLayoutGroupIndent(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithGroupStyle(GroupIndent))
+
+	slog.New(h).WithGroup("request").Info("served")
+
+	line := Plain(buf.String())
+	assert.Equal(t, strings.Repeat(" ", GroupIndentWidth)+"served", line[WrapIndent:WrapIndent+GroupIndentWidth+len("served")], line)
+}
@@ -0,0 +1,158 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+type rawMessageKey struct{}
+
+// withRawMessage marks records logged with ctx as carrying a message with
+// its own styling, which the handler sanitizes instead of writing verbatim.
+func withRawMessage(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rawMessageKey{}, true)
+}
+
+func isRawMessage(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	raw, _ := ctx.Value(rawMessageKey{}).(bool)
+	return raw
+}
+
+// LineWriter is an io.Writer that turns every line written to it into a
+// record logged at a fixed level, so output from code that only knows how
+// to write bytes (a child process, a library taking an io.Writer) ends up
+// in the same stream as structured records.
+//
+// A trailing line without a newline is held until more data arrives or
+// Flush is called. Trailing carriage returns are dropped, so CRLF output
+// renders cleanly. Escape sequences in the lines are removed, unless the
+// writer was created with [NewRawLineWriter].
+type LineWriter struct {
+	logger *slog.Logger
+	level  slog.Level
+	raw    bool
+
+	mu      sync.Mutex
+	partial []byte
+	lines   []string // complete lines of the current Write
+}
+
+// batchHandler is implemented by handlers that can write many records at
+// once, such as [TextHandler].
+type batchHandler interface {
+	HandleBatch(ctx context.Context, records []slog.Record) error
+}
+
+// NewLineWriter returns a LineWriter logging each line to logger at level.
+func NewLineWriter(logger *slog.Logger, level slog.Level) *LineWriter {
+	return &LineWriter{logger: logger, level: level}
+}
+
+// NewRawLineWriter returns a LineWriter that keeps the colors and text
+// attributes of the lines it logs, so tools such as compilers keep their
+// colored diagnostics inside trifle's framing. Other escape sequences,
+// which could move the cursor or clear the screen, are still removed.
+func NewRawLineWriter(logger *slog.Logger, level slog.Level) *LineWriter {
+	return &LineWriter{logger: logger, level: level, raw: true}
+}
+
+// Write logs every complete line in p. It always consumes all of p.
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n := len(p)
+
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.partial = append(w.partial, p...)
+			break
+		}
+
+		if len(w.partial) > 0 {
+			w.partial = append(w.partial, p[:i]...)
+			w.add(w.partial)
+			w.partial = w.partial[:0]
+		} else {
+			w.add(p[:i])
+		}
+
+		p = p[i+1:]
+	}
+
+	w.emit()
+	return n, nil
+}
+
+// Flush logs any buffered partial line.
+func (w *LineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.partial) > 0 {
+		w.add(w.partial)
+		w.partial = w.partial[:0]
+		w.emit()
+	}
+}
+
+// Close flushes any buffered partial line. The LineWriter stays usable.
+func (w *LineWriter) Close() error {
+	w.Flush()
+	return nil
+}
+
+// add queues line to be logged by the next emit.
+func (w *LineWriter) add(line []byte) {
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+
+	if w.raw {
+		w.lines = append(w.lines, string(line))
+	} else {
+		w.lines = append(w.lines, color.Strip(string(line)))
+	}
+}
+
+// emit logs the queued lines, as a single batch when the logger's handler
+// supports it, so a large write of many lines takes the handler's lock once.
+func (w *LineWriter) emit() {
+	if len(w.lines) == 0 {
+		return
+	}
+	defer func() {
+		clear(w.lines)
+		w.lines = w.lines[:0]
+	}()
+
+	ctx := context.Background()
+	if w.raw {
+		ctx = withRawMessage(ctx)
+	}
+
+	if !w.logger.Enabled(ctx, w.level) {
+		return
+	}
+
+	if bh, ok := w.logger.Handler().(batchHandler); ok && len(w.lines) > 1 {
+		now := time.Now()
+		records := make([]slog.Record, len(w.lines))
+		for i, line := range w.lines {
+			records[i] = slog.NewRecord(now, w.level, line, 0)
+		}
+		_ = bh.HandleBatch(ctx, records)
+		return
+	}
+
+	for _, line := range w.lines {
+		w.logger.Log(ctx, w.level, line)
+	}
+}
@@ -0,0 +1,52 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestLineWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := slog.New(New(&buf, nil, PresetTest))
+	w := NewLineWriter(logger, slog.LevelWarn)
+
+	_, err := w.Write([]byte("first line\nsecond "))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("line\r\nthird"))
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "[WARN]")
+	assert.Contains(t, lines[0], "first line")
+	assert.Contains(t, lines[1], "second line")
+	assert.NotContains(t, lines[1], "\r")
+
+	w.Flush()
+	assert.Contains(t, buf.String(), "third")
+}
+
+func TestRawLineWriter(t *testing.T) {
+	color.NoColor = false
+
+	var buf bytes.Buffer
+
+	logger := slog.New(New(&buf, nil, PresetTest))
+
+	NewLineWriter(logger, slog.LevelInfo).Write([]byte("\x1b[1;31mplain\x1b[0m\n"))
+	NewRawLineWriter(logger, slog.LevelInfo).Write([]byte("\x1b[1;31merror\x1b[0m: bad\x1b[2J\n"))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.NotContains(t, lines[0], "\x1b[1;31m")
+	assert.Contains(t, lines[0], "plain")
+	assert.Contains(t, lines[1], "\x1b[1;31merror\x1b[0m: bad\x1b[0m")
+	assert.NotContains(t, lines[1], "\x1b[2J")
+}
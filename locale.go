@@ -0,0 +1,252 @@
+package trifle
+
+import (
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WithLocale returns an Option that renders numbers, durations, times and
+// [Money] values the way the locale named by tag writes them, for output
+// read by people rather than parsed:
+//
+//	trifle.WithLocale("de-DE")
+//
+//	total: 1.234.567   took: 1,5s   at: 01.05.2024 12:00:00 CEST   price: 12,50 €
+//
+// Integers of five digits or more get thousands separators, so years and
+// port numbers stay as they are. Times are shown in the local time zone,
+// with its name. Tags are matched by language and region, such as "en-US",
+// "de", "fr-CA" or "pt_BR.UTF-8"; an empty tag takes the locale from the
+// LC_ALL, LC_NUMERIC or LANG environment variables. Locales that aren't
+// known are reported by [NewE].
+func WithLocale(tag string) Option {
+	return func(h *TextHandler) {
+		if tag == "" {
+			tag = envLocale()
+		}
+		h.localeTag = tag
+		h.locale, _ = findLocale(tag)
+	}
+}
+
+// locale holds how a locale writes numbers, times and amounts of money.
+type locale struct {
+	group, decimal string
+	timeLayout     string
+	currencyAfter  bool // "12,50 €" rather than "€12.50"
+}
+
+// isoTimeLayout is used by locales without a customary date order.
+const isoTimeLayout = "2006-01-02 15:04:05 MST"
+
+// locales are the known locales, by lower-case language and optional
+// region.
+var locales = map[string]*locale{
+	"en":    {group: ",", decimal: ".", timeLayout: isoTimeLayout},
+	"en-us": {group: ",", decimal: ".", timeLayout: "Jan 2, 2006 3:04:05 PM MST"},
+	"en-gb": {group: ",", decimal: ".", timeLayout: "02/01/2006 15:04:05 MST"},
+	"en-in": {group: ",", decimal: ".", timeLayout: "02/01/2006 15:04:05 MST"},
+	"de":    {group: ".", decimal: ",", timeLayout: "02.01.2006 15:04:05 MST", currencyAfter: true},
+	"de-ch": {group: "’", decimal: ".", timeLayout: "02.01.2006 15:04:05 MST"},
+	"fr":    {group: " ", decimal: ",", timeLayout: "02/01/2006 15:04:05 MST", currencyAfter: true},
+	"fr-ca": {group: " ", decimal: ",", timeLayout: isoTimeLayout, currencyAfter: true},
+	"es":    {group: ".", decimal: ",", timeLayout: "02/01/2006 15:04:05 MST", currencyAfter: true},
+	"it":    {group: ".", decimal: ",", timeLayout: "02/01/2006 15:04:05 MST", currencyAfter: true},
+	"nl":    {group: ".", decimal: ",", timeLayout: "02-01-2006 15:04:05 MST"},
+	"pt":    {group: " ", decimal: ",", timeLayout: "02/01/2006 15:04:05 MST", currencyAfter: true},
+	"pt-br": {group: ".", decimal: ",", timeLayout: "02/01/2006 15:04:05 MST"},
+	"sv":    {group: " ", decimal: ",", timeLayout: isoTimeLayout, currencyAfter: true},
+	"pl":    {group: " ", decimal: ",", timeLayout: "02.01.2006 15:04:05 MST", currencyAfter: true},
+	"ja":    {group: ",", decimal: ".", timeLayout: "2006/01/02 15:04:05 MST"},
+	"zh":    {group: ",", decimal: ".", timeLayout: "2006/01/02 15:04:05 MST"},
+}
+
+// findLocale looks tag up by language and region, then by language alone.
+// The encoding and modifier of POSIX locale names are ignored.
+func findLocale(tag string) (*locale, bool) {
+	tag, _, _ = strings.Cut(tag, ".")
+	tag, _, _ = strings.Cut(tag, "@")
+	tag = strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+
+	if loc, ok := locales[tag]; ok {
+		return loc, true
+	}
+	lang, _, _ := strings.Cut(tag, "-")
+	if lang == "c" || lang == "posix" {
+		lang = "en"
+	}
+	loc, ok := locales[lang]
+	return loc, ok
+}
+
+func envLocale() string {
+	for _, key := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+	}
+	return "en"
+}
+
+// localize renders v for loc. Values of other kinds are returned as they
+// are.
+func (loc *locale) localize(v slog.Value) slog.Value {
+	switch v.Kind() {
+	case slog.KindInt64:
+		n := v.Int64()
+		if n > -10000 && n < 10000 {
+			return v
+		}
+		digits := strconv.FormatInt(n, 10)
+		if n < 0 {
+			return slog.AnyValue(rendered("-" + loc.groupDigits(digits[1:], 5)))
+		}
+		return slog.AnyValue(rendered(loc.groupDigits(digits, 5)))
+	case slog.KindUint64:
+		if v.Uint64() < 10000 {
+			return v
+		}
+		return slog.AnyValue(rendered(loc.groupDigits(strconv.FormatUint(v.Uint64(), 10), 5)))
+	case slog.KindFloat64:
+		return slog.AnyValue(rendered(loc.float(v.Float64())))
+	case slog.KindDuration:
+		return slog.AnyValue(rendered(strings.Replace(v.Duration().String(), ".", loc.decimal, 1)))
+	case slog.KindTime:
+		return slog.AnyValue(rendered(v.Time().In(time.Local).Format(loc.timeLayout)))
+	case slog.KindAny:
+		if m, ok := v.Any().(Money); ok {
+			return slog.AnyValue(rendered(loc.money(m)))
+		}
+	}
+	return v
+}
+
+// groupDigits puts group separators in a string of digits, if it has at
+// least minLen of them.
+func (loc *locale) groupDigits(digits string, minLen int) string {
+	if len(digits) < minLen {
+		return digits
+	}
+
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(loc.group)
+		}
+		b.WriteRune(d)
+	}
+	return b.String()
+}
+
+func (loc *locale) float(f float64) string {
+	if math.IsNaN(f) || math.IsInf(f, 0) || f != 0 && (math.Abs(f) < 1e-4 || math.Abs(f) >= 1e21) {
+		return strings.Replace(strconv.FormatFloat(f, 'g', -1, 64), ".", loc.decimal, 1)
+	}
+
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	s = sign + loc.groupDigits(whole, 5)
+	if hasFrac {
+		s += loc.decimal + frac
+	}
+	return s
+}
+
+// Money is an amount of money, in the minor unit of its currency such as
+// cents, so that amounts are exact. With [WithLocale] it is rendered the
+// way the locale writes amounts, as "$1,234.50" or "1.234,50 €";
+// otherwise as "1234.50 USD".
+type Money struct {
+	Amount   int64
+	Currency string // ISO 4217 code, such as "USD"
+}
+
+// currencySymbols holds the symbols of common currencies.
+var currencySymbols = map[string]string{
+	"USD": "$", "EUR": "€", "GBP": "£", "JPY": "¥", "CNY": "¥",
+	"INR": "₹", "KRW": "₩", "BRL": "R$", "RUB": "₽", "TRY": "₺",
+}
+
+// minorDigits returns the number of digits of the minor unit of the
+// currency.
+func minorDigits(currency string) int {
+	switch currency {
+	case "JPY", "KRW", "VND", "CLP", "ISK":
+		return 0
+	case "BHD", "KWD", "OMR", "JOD", "TND":
+		return 3
+	}
+	return 2
+}
+
+// split returns the whole and fractional parts of m as digits, and
+// whether it is negative.
+func (m Money) split() (whole, frac string, negative bool) {
+	digits := strconv.FormatInt(m.Amount, 10)
+	if negative = m.Amount < 0; negative {
+		digits = digits[1:]
+	}
+
+	n := minorDigits(m.Currency)
+	if n == 0 {
+		return digits, "", negative
+	}
+	if len(digits) <= n {
+		digits = strings.Repeat("0", n-len(digits)+1) + digits
+	}
+	return digits[:len(digits)-n], digits[len(digits)-n:], negative
+}
+
+// String returns the amount with a decimal point, followed by the
+// currency code.
+func (m Money) String() string {
+	whole, frac, negative := m.split()
+
+	s := whole
+	if frac != "" {
+		s += "." + frac
+	}
+	if negative {
+		s = "-" + s
+	}
+	return s + " " + m.Currency
+}
+
+// money renders m with the currency's symbol, or its code when it has
+// none known, before or after the amount as loc places it.
+func (loc *locale) money(m Money) string {
+	whole, frac, negative := m.split()
+
+	s := loc.groupDigits(whole, 4)
+	if frac != "" {
+		s += loc.decimal + frac
+	}
+
+	code := m.Currency
+	if needsQuoting(code) {
+		code = strconv.Quote(code)
+	}
+
+	symbol, ok := currencySymbols[m.Currency]
+	switch {
+	case loc.currencyAfter && ok:
+		s += " " + symbol
+	case loc.currencyAfter || !ok:
+		s += " " + code
+	default:
+		s = symbol + s
+	}
+
+	if negative {
+		s = "-" + s
+	}
+	return s
+}
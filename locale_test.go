@@ -0,0 +1,96 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocaleNumbers(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := NewE(&buf, nil, WithTerminalWidth(0), WithLocale("de_DE.UTF-8"))
+	require.NoError(t, err)
+
+	slog.New(h).Info("done",
+		"total", 1234567,
+		"port", 8080,
+		"debt", -98765,
+		"ratio", 12345.5,
+		"took", 1500*time.Millisecond,
+		"price", Money{Amount: 123450, Currency: "EUR"})
+
+	out := Plain(buf.String())
+	assert.Contains(t, out, "total: 1.234.567")
+	assert.Contains(t, out, "port: 8080")
+	assert.Contains(t, out, "debt: -98.765")
+	assert.Contains(t, out, "ratio: 12.345,5")
+	assert.Contains(t, out, "took: 1,5s")
+	assert.Contains(t, out, "price: 1.234,50 €")
+}
+
+func TestLocaleTime(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("CEST", 2*60*60)
+	defer func() { time.Local = local }()
+
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithLocale("en-GB"))
+
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	slog.New(h).Info("deployed", "at", at)
+
+	assert.Contains(t, Plain(buf.String()), "at: 01/05/2024 12:00:00 CEST")
+}
+
+func TestMoney(t *testing.T) {
+	us, _ := findLocale("en-US")
+	fr, _ := findLocale("fr")
+
+	tests := []struct {
+		m           Money
+		str, us, fr string
+	}{
+		{Money{123450, "USD"}, "1234.50 USD", "$1,234.50", "1\u202f234,50 $"},
+		{Money{-5, "EUR"}, "-0.05 EUR", "-€0.05", "-0,05 €"},
+		{Money{1500, "JPY"}, "1500 JPY", "¥1,500", "1\u202f500 ¥"},
+		{Money{2500, "CHF"}, "25.00 CHF", "25.00 CHF", "25,00 CHF"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.str, tt.m.String())
+		assert.Equal(t, tt.us, us.money(tt.m))
+		assert.Equal(t, tt.fr, fr.money(tt.m))
+	}
+}
+
+func TestWithoutLocale(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0))
+
+	slog.New(h).Info("done", "total", 1234567, "price", Money{Amount: 999, Currency: "USD"})
+
+	out := Plain(buf.String())
+	assert.Contains(t, out, "total: 1234567")
+	assert.Contains(t, out, `price: "9.99 USD"`)
+}
+
+func TestUnknownLocale(t *testing.T) {
+	_, err := NewE(&bytes.Buffer{}, nil, WithLocale("xx-YY"))
+	assert.ErrorContains(t, err, `unknown locale "xx-YY"`)
+}
+
+func TestLocaleFromEnvironment(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_NUMERIC", "")
+	t.Setenv("LANG", "sv_SE.UTF-8")
+
+	var buf bytes.Buffer
+	h, err := NewE(&buf, nil, WithTerminalWidth(0), WithLocale(""))
+	require.NoError(t, err)
+
+	slog.New(h).Info("done", "ratio", 0.25)
+	assert.Contains(t, Plain(buf.String()), "ratio: 0,25")
+}
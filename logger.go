@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"reflect"
 	"runtime"
@@ -92,6 +94,13 @@ func WithCriticalKeys(keys ...string) Option {
 // WithContextKey returns an Option that sets keys whose values will be displayed
 // before the message without the key names, in a subdued color. Multiple keys
 // are displayed in the order specified, separated by spaces. Missing keys are skipped.
+// A key set again to a different value shows both, as "req-1→req-2", unless
+// the new value is given with [OverrideContext].
+//
+// Without this option, the keys are [RequestIDKey], [TraceIDKey] and
+// [SpanIDKey], so that the ids added by [ContextWithRequestID] and
+// [ContextWithTraceparent] are shown; WithContextKey with no keys shows
+// none.
 func WithContextKey(keys ...string) Option {
 	return func(h *TextHandler) {
 		h.contextKeys = keys
@@ -109,7 +118,28 @@ func WithTerminalWidth(width int) Option {
 // New creates a [TextHandler] that writes to w,
 // using the given options.
 // If opts is nil, the default options are used.
+//
+// New panics if the options conflict with each other. Use [NewE] to
+// receive the problem as an error instead.
+//
+// A nil w, as dependency injection can leave behind in a partial setup,
+// is replaced by io.Discard, and a warning saying so is written to
+// os.Stderr. NewE reports it as an error instead.
 func New(w io.Writer, opts *slog.HandlerOptions, options ...Option) *TextHandler {
+	if isNilWriter(w) {
+		warnNilWriter()
+		w = io.Discard
+	}
+	h, err := NewE(w, opts, options...)
+	if err != nil {
+		panic(fmt.Sprintf("trifle: invalid handler options: %v", err))
+	}
+	return h
+}
+
+// NewE is like [New] but returns an error describing any problems with the
+// configuration instead of panicking.
+func NewE(w io.Writer, opts *slog.HandlerOptions, options ...Option) (*TextHandler, error) {
 	if opts == nil {
 		opts = &slog.HandlerOptions{}
 	}
@@ -117,33 +147,193 @@ func New(w io.Writer, opts *slog.HandlerOptions, options ...Option) *TextHandler
 	termWidth := getTerminalWidth(w)
 	h := &TextHandler{
 		commonHandler: &commonHandler{
-			w:             w,
+			w:             consoleWriter(w),
 			opts:          *opts,
-			mu:            &sync.Mutex{},
+			mu:            newWriteLock(),
 			terminalWidth: termWidth,
+			stats:         newHandlerStats(),
+			contextKeys:   defaultContextKeys,
 		},
 		module: "",
 	}
+	h.selfDebug = os.Getenv(DebugHandlerEnv) != ""
 
 	// Apply options
 	for _, opt := range options {
 		opt(h)
 	}
 
+	if err := h.validate(); err != nil {
+		h.Close()
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// validate reports conflicting or nonsensical settings left behind by the
+// options. All problems are reported together, joined into one error.
+func (h *TextHandler) validate() error {
+	var errs []error
+
+	if isNilWriter(h.w) {
+		errs = append(errs, errors.New("writer must not be nil"))
+	}
+
+	if h.terminalWidth < 0 {
+		errs = append(errs, fmt.Errorf("terminal width must not be negative, got %d", h.terminalWidth))
+	}
+
+	if h.attrsColumn < 0 {
+		errs = append(errs, fmt.Errorf("attrs column must not be negative, got %d", h.attrsColumn))
+	}
+
+	seen := make(map[string]bool, len(h.contextKeys))
+	for _, key := range h.contextKeys {
+		switch {
+		case key == "":
+			errs = append(errs, errors.New("context key must not be empty"))
+		case seen[key]:
+			errs = append(errs, fmt.Errorf("context key %q specified more than once", key))
+		}
+		seen[key] = true
+	}
+
+	if h.criticalKeys[""] || h.importantKeys[""] {
+		errs = append(errs, errors.New("highlighted key must not be empty"))
+	}
+
+	if h.dedup != nil && h.dedup.window <= 0 {
+		errs = append(errs, fmt.Errorf("dedup window must be positive, got %v", h.dedup.window))
+	}
+
+	if h.fallback != nil && isNilWriter(h.fallback.w) {
+		errs = append(errs, errors.New("fallback writer must not be nil"))
+	}
+
+	errs = append(errs, h.validateFilters()...)
+	errs = append(errs, h.validateTransformers()...)
+	errs = append(errs, h.validateDecorators()...)
+	errs = append(errs, h.validateVerbosity()...)
+	errs = append(errs, h.validateModuleLevels()...)
+
+	if h.gaps != nil && h.gaps.threshold <= 0 {
+		errs = append(errs, fmt.Errorf("gap threshold must be positive, got %v", h.gaps.threshold))
+	}
+
+	if h.histograms != nil && slices.Contains(h.histograms.keys, "") {
+		errs = append(errs, errors.New("histogram key must not be empty"))
+	}
+
+	if h.watches != nil && slices.Contains(h.watches.keys, "") {
+		errs = append(errs, errors.New("watch key must not be empty"))
+	}
+
+	if h.localeTag != "" && h.locale == nil {
+		errs = append(errs, fmt.Errorf("unknown locale %q", h.localeTag))
+	}
+
+	if h.shadow != nil && h.shadow.err != nil {
+		errs = append(errs, fmt.Errorf("opening shadow file: %w", h.shadow.err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// isNilWriter reports whether w is nil, or holds a nil pointer, map,
+// slice, channel or function, which would panic once written to.
+func isNilWriter(w io.Writer) bool {
+	if w == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(w); v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// warnNilWriter tells on os.Stderr that New was given a nil writer.
+func warnNilWriter() {
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	r := slog.NewRecord(time.Now(), slog.LevelWarn, "trifle: handler writer is nil, discarding its records", pcs[0])
+	if frame, _ := runtime.CallersFrames(pcs[:]).Next(); frame.File != "" {
+		r.AddAttrs(slog.String("caller", fmt.Sprintf("%s:%d", frame.File, frame.Line)))
+	}
+
+	New(os.Stderr, nil).Handle(context.Background(), r)
+}
+
+// levelBase returns the named level that l is at or above, which decides
+// how l is colored.
+func levelBase(l slog.Level) slog.Level {
+	switch {
+	case l >= slog.LevelError:
+		return slog.LevelError
+	case l >= slog.LevelWarn:
+		return slog.LevelWarn
+	case l >= slog.LevelInfo:
+		return slog.LevelInfo
+	case l >= slog.LevelDebug:
+		return slog.LevelDebug
+	default:
+		return Trace
+	}
+}
+
+// Quick returns a [TextHandler] that writes to os.Stderr at the Debug level,
+// configured with the given options.
+//
+// The level can be changed without code changes through the environment:
+// LOG_LEVEL accepts a level name (trace, debug, info, warn, error) or an
+// slog level string such as "INFO+2", and a non-empty TRIFLE_TRACE enables
+// the Trace level regardless of LOG_LEVEL. Color output honors NO_COLOR.
+//
+// In a process started with the entry returned by [Environ], the handler
+// has the module and attributes passed down through TRIFLE_CONTEXT.
+func Quick(options ...Option) *TextHandler {
+	level := slog.LevelDebug
+	if l, ok := parseLevel(os.Getenv("LOG_LEVEL")); ok {
+		level = l
+	}
+	if os.Getenv("TRIFLE_TRACE") != "" {
+		level = Trace
+	}
+
+	h := New(os.Stderr, &slog.HandlerOptions{
+		Level: level,
+	}, options...)
+
+	if snap, ok := SnapshotFromEnv(); ok {
+		h = snap.Apply(h).(*TextHandler)
+	}
 	return h
 }
 
-// Quick returns a [TextHandler] that writes to os.Stderr at the Debug level.
-func Quick() *TextHandler {
-	return New(os.Stderr, &slog.HandlerOptions{
-		Level: slog.LevelDebug,
-	})
+// parseLevel parses a level name as found in the environment. Besides the
+// names understood by [slog.Level.UnmarshalText] it accepts "trace".
+func parseLevel(s string) (slog.Level, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	if strings.EqualFold(s, "trace") {
+		return Trace, true
+	}
+
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, false
+	}
+	return l, true
 }
 
 // Enabled reports whether the handler handles records at the given level.
-// The handler ignores records whose level is lower.
+// The handler ignores records whose level is lower than its own, or than
+// the level of its module, see [WithModuleLevel].
 func (h *TextHandler) Enabled(_ context.Context, level slog.Level) bool {
-	return h.enabled(level)
+	return h.enabledModule(level, h.module)
 }
 
 const ModuleKey = "module"
@@ -168,11 +358,17 @@ func (h *TextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 		}
 	}
 
-	return &TextHandler{commonHandler: h.withAttrs(goodAttrs), module: module}
+	c := h.deriveDropSink(h.withAttrs(goodAttrs), func(sink slog.Handler) slog.Handler {
+		return sink.WithAttrs(attrs)
+	})
+	return &TextHandler{commonHandler: c, module: module}
 }
 
 func (h *TextHandler) WithGroup(name string) slog.Handler {
-	return &TextHandler{commonHandler: h.withGroup(name), module: h.module}
+	c := h.deriveDropSink(h.withGroup(name), func(sink slog.Handler) slog.Handler {
+		return sink.WithGroup(name)
+	})
+	return &TextHandler{commonHandler: c, module: h.module}
 }
 
 // Handle formats its argument [Record] as a single line of space-separated
@@ -211,8 +407,31 @@ func (h *TextHandler) WithGroup(name string) slog.Handler {
 //
 // Each call to Handle results in a single serialized call to
 // io.Writer.Write.
-func (h *TextHandler) Handle(_ context.Context, r slog.Record) error {
-	return h.handle(r, h.module)
+func (h *TextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if len(h.moduleLevels) > 0 && !h.enabledModule(r.Level, h.module) {
+		return nil
+	}
+	raw := isRawMessage(ctx)
+
+	r, ok := h.prepare(ctx, r, raw)
+	if !ok {
+		return nil
+	}
+	return h.handle(ctx, r, h.module, raw)
+}
+
+// prepare puts r through the pipeline before formatting it, see
+// WithTransformer. It reports false when r is dropped and must not be
+// written.
+func (h *TextHandler) prepare(ctx context.Context, r slog.Record, raw bool) (slog.Record, bool) {
+	for _, run := range pipeline {
+		var ok bool
+		if r, ok = run(h, ctx, r, raw); !ok {
+			h.drop(ctx, r)
+			return r, false
+		}
+	}
+	return r, true
 }
 
 type commonHandler struct {
@@ -225,15 +444,57 @@ type commonHandler struct {
 	groupPrefix   string
 	groups        []string // all groups started from WithGroup
 	nOpenGroups   int      // the number of groups opened in preformattedAttrs
-	mu            *sync.Mutex
+	mu            writeLock
 	w             io.Writer
+	errW          io.Writer // if set, receives Warn and above instead of w
 	importantKeys map[string]bool
 	criticalKeys  map[string]bool
+	moduleLevels  map[string]slog.Leveler // levels of modules, see WithModuleLevel; read-only once shared
 	contextKeys   []string
-	contextValues map[string]string // cached context values from preformatted attrs
-	terminalWidth int               // terminal width for word wrapping
-
-	lastTime atomic.Int64
+	contextValues contextMap      // context values from preformatted attrs; read-only once shared
+	terminalWidth int             // terminal width for word wrapping
+	stats         *handlerStats   // shared among all clones of this handler
+	attrsColumn   int             // column of the attrs separator, or the limit when adaptive
+	attrsLeader   *atomic.Int64   // widest message so far when the column is adaptive
+	shadow        *shadowFile     // plain copy of the output, shared among clones
+	alerts        []*alertHook    // hooks for matching records, shared among clones
+	dedup         *deduper        // recently written records, shared among clones
+	banner        *sync.Once      // writes the banner, shared among clones
+	seq           *atomic.Uint64  // last sequence number, shared among clones
+	gaps          *gapMarker      // time of the latest record, shared among clones
+	fallback      *fallbackWriter // receives records whose context ended, shared among clones
+	moduleColors  *moduleColors   // colors of module names, shared among clones
+	filters       []*recordFilter // records must pass all of them, shared among clones
+	filterAttrs   []slog.Attr     // attrs added with WithAttrs, for filters
+	dropSink      slog.Handler    // receives dropped records, derived with the handler
+	goas          []groupOrAttrs  // groups and attrs applied so far, for Snapshot
+	locale        *locale         // renders values for people, if set
+	localeTag     string          // locale asked for, to report it when unknown
+	shortIDs      bool            // shorten identifiers, see WithShortIDs
+	hyperlinks    bool            // the terminal supports OSC 8 hyperlinks
+	addresses     bool            // style IPs and URLs, see WithAddresses
+	redactIPs     bool            // redact IPs, see WithRedactedIPs
+
+	visibleWhitespace bool // show invisible characters, see WithVisibleWhitespace
+	binarySafe        bool // escape unprintable characters everywhere, see WithBinarySafe
+	selfDebug         bool // mark where attrs come from, see WithSelfDebug
+	compact           bool // collapse the attrs of records below Warn, see WithAdaptiveVerbosity
+	compactTop        int  // the number of attrs such records keep
+	groupStyle        GroupStyle
+
+	json *jsonEncoder // writes records as JSON lines, see NewJSON
+
+	transformers []RecordTransformer // rewrite records before the filters, shared among clones
+	decorators   []levelDecorator    // add attrs to records by level, shared among clones
+	histograms   *histograms         // values of some keys, shared among clones
+	watches      *watches            // latest values of some keys, shared among clones
+	schemaWarned *sync.Map           // problems with events warned about, shared among clones
+
+	footnotes         []string   // lines from attrs added with WithAttrs, see Hint
+	levelTranslator   Translator // shows level names, see WithTranslator
+	messageTranslator Translator // shows messages, see WithMessageTranslator
+
+	lastTime atomic.Int64 // UnixNano of the previous record, 0 before the first
 }
 
 func (h *commonHandler) clone() *commonHandler {
@@ -245,18 +506,50 @@ func (h *commonHandler) clone() *commonHandler {
 		groups:            slices.Clip(h.groups),
 		nOpenGroups:       h.nOpenGroups,
 		w:                 h.w,
+		errW:              h.errW,
 		mu:                h.mu, // mutex shared among all clones of this handler
 		importantKeys:     h.importantKeys,
 		criticalKeys:      h.criticalKeys,
+		moduleLevels:      h.moduleLevels,
 		contextKeys:       slices.Clip(h.contextKeys),
 		terminalWidth:     h.terminalWidth,
-	}
-	// Deep copy the context values map
-	if h.contextValues != nil {
-		cloned.contextValues = make(map[string]string)
-		for k, v := range h.contextValues {
-			cloned.contextValues[k] = v
-		}
+		stats:             h.stats,
+		attrsColumn:       h.attrsColumn,
+		attrsLeader:       h.attrsLeader,
+		shadow:            h.shadow,
+		alerts:            h.alerts,
+		dedup:             h.dedup,
+		banner:            h.banner,
+		seq:               h.seq,
+		gaps:              h.gaps,
+		fallback:          h.fallback,
+		moduleColors:      h.moduleColors,
+		filters:           h.filters,
+		transformers:      h.transformers,
+		decorators:        h.decorators,
+		histograms:        h.histograms,
+		watches:           h.watches,
+		schemaWarned:      h.schemaWarned,
+		footnotes:         h.footnotes,
+		levelTranslator:   h.levelTranslator,
+		messageTranslator: h.messageTranslator,
+		filterAttrs:       h.filterAttrs,
+		dropSink:          h.dropSink,
+		goas:              slices.Clip(h.goas),
+		locale:            h.locale,
+		localeTag:         h.localeTag,
+		shortIDs:          h.shortIDs,
+		hyperlinks:        h.hyperlinks,
+		addresses:         h.addresses,
+		redactIPs:         h.redactIPs,
+		visibleWhitespace: h.visibleWhitespace,
+		binarySafe:        h.binarySafe,
+		selfDebug:         h.selfDebug,
+		json:              h.json,
+		compact:           h.compact,
+		compactTop:        h.compactTop,
+		groupStyle:        h.groupStyle,
+		contextValues:     h.contextValues, // never modified, see withAttrs
 	}
 	return cloned
 }
@@ -278,17 +571,35 @@ func (h *commonHandler) withAttrs(as []slog.Attr) *commonHandler {
 		return h
 	}
 	h2 := h.clone()
+	h2.goas = append(h2.goas, groupOrAttrs{attrs: as})
+
+	if len(h2.filters) > 0 {
+		h2.addFilterAttrs(as)
+	}
 
-	// Check if any context keys are being added
+	// Check if any context keys are being added. The map may be shared
+	// with other clones, which can be logging concurrently, so it is
+	// copied before the first new value is stored rather than modified.
 	if len(h2.contextKeys) > 0 {
-		if h2.contextValues == nil {
-			h2.contextValues = make(map[string]string)
-		}
+		copied := false
 		for _, a := range as {
 			for _, contextKey := range h2.contextKeys {
-				if a.Key == contextKey && h2.contextValues[contextKey] == "" {
-					h2.contextValues[contextKey] = fmt.Sprint(a.Value.Any())
+				if a.Key != contextKey {
+					continue
+				}
+				cur := h2.contextValues[contextKey]
+				v := cur.set(contextString(a.Value))
+				if v == cur {
+					continue
 				}
+				if !copied {
+					h2.contextValues = maps.Clone(h2.contextValues)
+					if h2.contextValues == nil {
+						h2.contextValues = make(contextMap)
+					}
+					copied = true
+				}
+				h2.contextValues[contextKey] = v
 			}
 		}
 	}
@@ -314,6 +625,7 @@ func (h *commonHandler) withAttrs(as []slog.Attr) *commonHandler {
 	// Pre-format the attributes as an optimization.
 	state := h2.newHandleState((*Buffer)(&h2.preformattedAttrs), false, "")
 	defer state.free()
+	state.prefix = NewBuffer()
 	state.prefix.WriteString(h.groupPrefix)
 	if pfa := h2.preformattedAttrs; len(pfa) > 0 {
 		state.sep = h.attrSep()
@@ -321,7 +633,11 @@ func (h *commonHandler) withAttrs(as []slog.Attr) *commonHandler {
 	// Remember the position in the buffer, in case all attrs are empty.
 	pos := state.buf.Len()
 	state.openGroups()
-	if !state.appendAttrs(as) {
+	appended := state.appendAttrs(as)
+	if len(state.footnotes) > 0 {
+		h2.footnotes = append(slices.Clip(h.footnotes), state.footnotes...)
+	}
+	if !appended {
 		state.buf.SetLen(pos)
 	} else {
 		// Remember the new prefix for later keys.
@@ -336,6 +652,9 @@ func (h *commonHandler) withAttrs(as []slog.Attr) *commonHandler {
 func (h *commonHandler) withGroup(name string) *commonHandler {
 	h2 := h.clone()
 	h2.groups = append(h2.groups, name)
+	if name != "" {
+		h2.goas = append(h2.goas, groupOrAttrs{group: name})
+	}
 	return h2
 }
 
@@ -354,10 +673,85 @@ func recordSource(r slog.Record) *slog.Source {
 }
 
 // handle is the internal implementation of Handler.Handle
-// used by TextHandler and JSONHandler.
-func (h *commonHandler) handle(r slog.Record, module string) error {
-	state := h.newHandleState(NewBuffer(), true, "")
+// used by TextHandler, for text and for JSON, see NewJSON.
+func (h *commonHandler) handle(ctx context.Context, r slog.Record, module string, raw bool) error {
+	buf := h.format(r, module, raw)
+	defer buf.Free()
+
+	var shadow *Buffer
+	if h.shadow != nil {
+		shadow = h.formatWidth(r, module, raw, 0)
+		defer shadow.Free()
+	}
+
+	if h.gaps != nil && h.json == nil {
+		if gap := h.gaps.mark(r.Time); gap != "" {
+			*buf = slices.Insert(*buf, 0, []byte(gap)...)
+			if shadow != nil {
+				*shadow = slices.Insert(*shadow, 0, []byte(gap)...)
+			}
+		}
+	}
+
+	w := h.w
+	if h.errW != nil && r.Level >= slog.LevelWarn {
+		w = h.errW
+	}
+
+	if h.fallback != nil && ctx != nil {
+		return h.handleContext(ctx, w, buf, shadow, r.Level, module)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n, err := w.Write(*buf)
+	h.stats.record(r.Level, module, n, err)
+	if shadow != nil {
+		h.shadow.write(*shadow)
+	}
+	return err
+}
+
+// handleContext writes a formatted record like handle, but hands it to the
+// fallback writer when ctx is done before the record is written.
+func (h *commonHandler) handleContext(ctx context.Context, w io.Writer, buf, shadow *Buffer, level slog.Level, module string) error {
+	if ctx.Err() != nil || !h.mu.lockContext(ctx) {
+		h.stats.drop()
+		return h.fallback.write(*buf)
+	}
+
+	n, err := h.fallback.writePrimary(ctx, w, h.errW != nil && level >= slog.LevelWarn, *buf)
+	h.stats.record(level, module, n, err)
+	if shadow != nil {
+		h.shadow.write(*shadow)
+	}
+	h.mu.Unlock()
+
+	// The write deadline can expire a moment before ctx notices. What
+	// made it to w stays there, so only the rest goes to the fallback.
+	if err != nil && (ctx.Err() != nil || errors.Is(err, os.ErrDeadlineExceeded)) {
+		return h.fallback.write((*buf)[n:])
+	}
+	return err
+}
+
+// format renders r, including the trailing newline, into a Buffer taken
+// from the pool. The caller must Free the returned Buffer. When raw is set,
+// the SGR sequences in the message are kept rather than written verbatim.
+func (h *commonHandler) format(r slog.Record, module string, raw bool) *Buffer {
+	return h.formatWidth(r, module, raw, h.terminalWidth)
+}
+
+// formatWidth is like format, but wraps at width rather than the terminal
+// width. A width of 0 disables wrapping.
+func (h *commonHandler) formatWidth(r slog.Record, module string, raw bool, width int) *Buffer {
+	if h.json != nil {
+		return h.formatJSON(r, module)
+	}
+	state := h.newHandleState(NewBuffer(), false, "")
+	state.width = width
 	defer state.free()
+	r, collapsed := h.compactRecord(r)
 	// Built-in attributes. They are not in a group.
 	stateGroups := state.groups
 	state.groups = nil // So ReplaceAttrs sees no groups instead of the pre groups.
@@ -371,14 +765,16 @@ func (h *commonHandler) handle(r slog.Record, module string) error {
 		val := r.Time.Round(0) // strip monotonic to match Attr behavior
 
 		if rep == nil {
-			lastTime := time.Unix(h.lastTime.Load(), 0)
+			// Swap rather than Load and Store, so concurrent records each
+			// compare against a time that was actually written before them.
+			prev := h.lastTime.Swap(val.UnixNano())
+			lastTime := time.Unix(0, prev)
 
-			if lastTime.IsZero() || val.Sub(lastTime) < 1*time.Hour || val.YearDay() != lastTime.YearDay() {
+			if prev == 0 || val.Sub(lastTime) < 1*time.Hour || val.YearDay() != lastTime.YearDay() {
 				state.linePos += state.appendMiniTime(val)
 			} else {
 				state.linePos += state.appendShortTime(val)
 			}
-			h.lastTime.Store(val.Unix())
 		} else {
 			state.appendAttr(slog.Time(key, val))
 			state.linePos += len(key) + 2 + 10 // key + ": ", 10 is a random guess for now.
@@ -388,21 +784,14 @@ func (h *commonHandler) handle(r slog.Record, module string) error {
 	// level
 	key := slog.LevelKey
 	val := r.Level
-	str := val.String()
+	str := h.levelLabel(val)
 
-	spec, ok := _levelToName[r.Level]
-	if ok {
-		str = spec
-	}
-
-	state.linePos += len(str)
-
-	if col, ok := _levelToColor[val]; ok {
-		str = col.Sprint(str)
+	if col, ok := _levelToColor[levelBase(val)]; ok {
+		state.appendSegments(col.Styled(str))
+	} else {
+		state.appendSegments(color.Plain(str))
 	}
 
-	state.appendRawString(str)
-
 	// source
 	if h.opts.AddSource {
 		state.appendAttr(slog.Any(slog.SourceKey, recordSource(r)))
@@ -410,61 +799,54 @@ func (h *commonHandler) handle(r slog.Record, module string) error {
 		state.linePos += len(slog.SourceKey) + 3 // key + ": " + " "
 	}
 
-	state.indentPos = 21
+	state.indentPos = WrapIndent
+
+	if h.groupStyle == GroupIndent {
+		if depth := h.groupDepth(); depth > 0 {
+			state.appendSegments(color.Plain(strings.Repeat(groupIndent, depth)))
+		}
+	}
 
 	// Extract and display context values if contextKeys are set
 	if len(h.contextKeys) > 0 {
 		var contextParts []string
-
-		// Build a map of available values from record attrs
-		recordValues := make(map[string]string)
-		r.Attrs(func(a slog.Attr) bool {
-			for _, contextKey := range h.contextKeys {
-				if a.Key == contextKey {
-					recordValues[contextKey] = fmt.Sprint(a.Value.Any())
-				}
-			}
-			return true
-		})
-
-		// Collect values in the order specified by contextKeys
-		for _, contextKey := range h.contextKeys {
-			// Check cached values first
-			if h.contextValues != nil {
-				if val, ok := h.contextValues[contextKey]; ok && val != "" {
-					contextParts = append(contextParts, val)
-					continue
-				}
-			}
-			// Then check record values
-			if val, ok := recordValues[contextKey]; ok {
-				contextParts = append(contextParts, val)
+		for _, val := range h.contextValuesOf(r) {
+			if val.first != "" {
+				contextParts = append(contextParts, val.String())
 			}
 		}
 
 		// Display all found context values
 		if len(contextParts) > 0 {
-			str := strings.Join(contextParts, " ")
-			state.appendRawString(contextColor.Sprint(str))
-			state.appendRawString(" ")
-			state.linePos += len(str) + 1 // +1 for the space after context values
+			str := strings.Join(contextParts, ContextSeparator)
+			state.appendSegments(contextColor.Styled(h.safe(str)), color.Plain(" "))
 		}
 	}
 
 	if module != "" {
-		state.appendRawString(moduleColor.Sprint(module))
-		state.appendRawString(" ")
-		state.linePos += len(module) + 1 // +1 for the space after module
+		col := moduleColor
+		if h.moduleColors != nil {
+			col = h.moduleColors.color(module)
+		}
+		state.appendSegments(col.Styled(h.safe(module)), color.Plain(" "))
 	}
 
 	key = slog.MessageKey
 	msg := r.Message
+	if h.messageTranslator != nil {
+		msg = h.translateMessage(r)
+	}
 	if rep == nil {
-		state.appendRawString(msg)
-		state.linePos += len(msg)
-		if r.NumAttrs() > 0 || len(state.h.preformattedAttrs) > 0 {
-			state.appendRawString(" │ ")
-			state.linePos += 3 // " │ "
+		if h.binarySafe {
+			state.appendSegments(color.Plain(escapeText(msg, true)))
+		} else if raw {
+			state.appendSegments(color.Raw(msg))
+		} else {
+			state.appendSegments(color.Plain(msg))
+		}
+		if len(state.h.preformattedAttrs) > 0 || r.NumAttrs() > 0 && inlineAttrs(r) || collapsed > 0 {
+			state.alignAttrs()
+			state.appendSegments(color.Plain(AttrsSeparator))
 		}
 	} else {
 		state.appendAttr(slog.String(key, msg))
@@ -473,17 +855,27 @@ func (h *commonHandler) handle(r slog.Record, module string) error {
 
 	state.groups = stateGroups // Restore groups passed to ReplaceAttrs.
 	state.appendNonBuiltIns(r)
+	if collapsed > 0 {
+		state.appendCollapsed(collapsed)
+	}
 	state.buf.WriteNewLine()
 
-	h.mu.Lock()
-	defer h.mu.Unlock()
-	_, err := h.w.Write(*state.buf)
-	return err
+	for _, lines := range [][]string{h.footnotes, state.footnotes} {
+		for _, line := range lines {
+			state.buf.WriteString(line)
+			state.buf.WriteNewLine()
+		}
+	}
+
+	return state.buf
 }
 
 func (s *handleState) appendNonBuiltIns(r slog.Record) {
 	// preformatted Attrs
 	if pfa := s.h.preformattedAttrs; len(pfa) > 0 {
+		if s.h.selfDebug {
+			s.appendDebugMarker("with")
+		}
 		s.buf.WriteString(s.sep)
 		s.buf.Write(pfa)
 		s.sep = s.h.attrSep()
@@ -492,7 +884,20 @@ func (s *handleState) appendNonBuiltIns(r slog.Record) {
 	// from WithGroup.
 	// If the record has no Attrs, don't output any groups.
 	if r.NumAttrs() > 0 {
-		s.prefix.WriteString(s.h.groupPrefix)
+		if s.h.selfDebug && inlineAttrs(r) {
+			s.appendDebugMarker(s.h.recordMarker())
+		}
+		if s.groups == nil && s.h.groupPrefix == "" && s.h.nOpenGroups == len(s.h.groups) && r.NumAttrs() <= fastPathAttrs {
+			s.appendAttrsFast(r)
+			return
+		}
+
+		if s.h.groupPrefix != "" {
+			if s.prefix == nil {
+				s.prefix = NewBuffer()
+			}
+			s.prefix.WriteString(s.h.groupPrefix)
+		}
 		// The group may turn out to be empty even though it has attrs (for
 		// example, ReplaceAttr may delete all the attrs).
 		// So remember where we are in the buffer, to restore the position
@@ -512,6 +917,29 @@ func (s *handleState) appendNonBuiltIns(r slog.Record) {
 	}
 }
 
+// fastPathAttrs is the largest number of attributes a record may have to
+// take the fast path of appendNonBuiltIns.
+const fastPathAttrs = 8
+
+// appendAttrsFast appends the attributes of r, which are not in any group
+// and are not passed to ReplaceAttr. Collecting them into an array first
+// keeps the loop free of the callback and the group bookkeeping.
+func (s *handleState) appendAttrsFast(r slog.Record) {
+	var (
+		attrs [fastPathAttrs]slog.Attr
+		n     int
+	)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[n] = a
+		n++
+		return true
+	})
+
+	for _, a := range attrs[:n] {
+		s.appendAttr(a)
+	}
+}
+
 // attrSep returns the separator between attributes.
 func (h *commonHandler) attrSep() string {
 	return " "
@@ -530,6 +958,8 @@ type handleState struct {
 	linePos     int       // current position on the line for word wrapping
 	needsIndent bool      // whether next output needs indentation
 	indentPos   int       // position to indent wrapped lines to (after time/level)
+	width       int       // width to wrap at, 0 to not wrap
+	footnotes   []string  // lines to write under the record, see Hint
 }
 
 var groupPool = sync.Pool{New: func() any {
@@ -537,16 +967,22 @@ var groupPool = sync.Pool{New: func() any {
 	return &s
 }}
 
+// newHandleState returns the state for rendering into buf. The state is
+// returned by value on purpose: it does not escape, so it lives on the
+// stack and costs nothing to create. Its prefix buffer, which does reach
+// the heap, is only taken from the pool once a group is opened, and is
+// returned by free.
 func (h *commonHandler) newHandleState(buf *Buffer, freeBuf bool, sep string) handleState {
 	s := handleState{
 		h:           h,
 		buf:         buf,
 		freeBuf:     freeBuf,
 		sep:         sep,
-		prefix:      NewBuffer(),
+		prefix:      nil, // allocated when the first group is opened
 		linePos:     0,
 		needsIndent: false,
 		indentPos:   0,
+		width:       h.terminalWidth,
 	}
 	if h.opts.ReplaceAttr != nil {
 		s.groups = groupPool.Get().(*[]string)
@@ -563,11 +999,22 @@ func (s *handleState) free() {
 		*gs = (*gs)[:0]
 		groupPool.Put(gs)
 	}
-	s.prefix.Free()
+	if s.prefix != nil {
+		s.prefix.Free()
+		s.prefix = nil
+	}
 }
 
 func (s *handleState) openGroups() {
 	for _, n := range s.h.groups[s.h.nOpenGroups:] {
+		if s.h.groupStyle == GroupIndent {
+			// The group shows as the indentation of the record instead,
+			// but ReplaceAttr still sees it.
+			if s.groups != nil {
+				*s.groups = append(*s.groups, n)
+			}
+			continue
+		}
 		s.openGroup(n)
 	}
 }
@@ -578,7 +1025,10 @@ const keyComponentSep = '.'
 // openGroup starts a new group of attributes
 // with the given name.
 func (s *handleState) openGroup(name string) {
-	s.prefix.WriteString(name)
+	if s.prefix == nil {
+		s.prefix = NewBuffer()
+	}
+	s.prefix.WriteString(s.h.safe(name))
 	s.prefix.WriteByte(keyComponentSep)
 	// Collect group names for ReplaceAttr.
 	if s.groups != nil {
@@ -588,7 +1038,7 @@ func (s *handleState) openGroup(name string) {
 
 // closeGroup ends the group with the given name.
 func (s *handleState) closeGroup(name string) {
-	(*s.prefix) = (*s.prefix)[:len(*s.prefix)-len(name)-1 /* for keyComponentSep */]
+	(*s.prefix) = (*s.prefix)[:len(*s.prefix)-len(s.h.safe(name))-1 /* for keyComponentSep */]
 	s.sep = s.h.attrSep()
 	if s.groups != nil {
 		*s.groups = (*s.groups)[:len(*s.groups)-1]
@@ -666,6 +1116,11 @@ func (s *handleState) appendAttr(a slog.Attr) bool {
 		}
 	}
 
+	if lines, ok := s.footnote(a.Value); ok {
+		s.footnotes = append(s.footnotes, lines...)
+		return false
+	}
+
 	a.Value = a.Value.Resolve()
 	if rep := s.h.opts.ReplaceAttr; rep != nil && a.Value.Kind() != slog.KindGroup {
 		var gs []string
@@ -687,6 +1142,23 @@ func (s *handleState) appendAttr(a slog.Attr) bool {
 			a.Value = slog.StringValue(fmt.Sprintf("%s:%d", src.File, src.Line))
 		}
 	}
+	if a.Value.Kind() == slog.KindGroup {
+		if c, ok := changeSetOf(a.Value); ok {
+			a.Value = c.render()
+		}
+	}
+	if s.h.locale != nil {
+		a.Value = s.h.locale.localize(a.Value)
+	}
+	if s.h.shortIDs {
+		a.Value = s.h.shortID(a.Value)
+	}
+	if s.h.addresses || s.h.redactIPs {
+		a.Value = s.h.address(a.Value)
+	}
+	if s.h.visibleWhitespace {
+		a.Value = showWhitespace(a.Value)
+	}
 	if a.Value.Kind() == slog.KindGroup {
 		attrs := a.Value.Group()
 		// Output only non-empty groups.
@@ -721,35 +1193,30 @@ func (s *handleState) appendAttr(a slog.Attr) bool {
 		}
 
 		// For wrapping: check if key + value would fit on current line
-		if s.h.terminalWidth > 0 {
+		if s.width > 0 {
 			// Calculate the actual formatted value string
 			valueStr := formatValueAsString(a.Value)
 
-			// Calculate the total length of key + value
+			// Calculate the total width of key + value
 			sepLen := 0
 			if s.sep != "" {
-				sepLen = calculateVisibleLength(s.sep)
+				sepLen = color.StringWidth(s.sep)
 			}
-			keyLen := len(a.Key) + 2 // key + ": "
-			valueLen := len(valueStr)
+			keyLen := s.keyWidth(a.Key) // prefix + key + ": "
+			valueLen := color.StringWidth(color.Strip(valueStr))
 
 			// Check if the entire key-value pair would overflow
 			totalLen := sepLen + keyLen + valueLen
 
-			// Wrap if adding this key-value pair would exceed terminal width
-			// Exception: don't wrap if we're at the start of a line and the pair fits
-			if s.linePos+totalLen > s.h.terminalWidth && s.linePos > s.indentPos {
-				// Wrap to new line and indent to match time/level position
-				s.buf.WriteNewLine()
-				for i := 0; i < s.indentPos; i++ {
-					s.buf.WriteByte(' ')
-				}
-				s.linePos = s.indentPos
-				s.sep = "" // No separator needed at start of new line
-			}
+			s.wrapFor(totalLen)
 
 			s.appendKey(a.Key)
-			s.appendValue(a.Value)
+			if isScalar(a.Value.Kind()) {
+				// valueStr is exactly what appendValue would write.
+				s.buf.WriteString(valueStr)
+			} else {
+				s.appendValue(a.Value)
+			}
 			s.linePos += totalLen
 		} else {
 			s.appendKey(a.Key)
@@ -760,6 +1227,31 @@ func (s *handleState) appendAttr(a slog.Attr) bool {
 	return true
 }
 
+// wrapFor starts a new line, indented to match the time and level, if n
+// more columns would exceed the terminal width. It doesn't when the line
+// holds nothing past the indentation yet, as n wouldn't fit any better on
+// the next one.
+func (s *handleState) wrapFor(n int) {
+	if s.linePos+n > s.width && s.linePos > s.indentPos {
+		s.buf.WriteNewLine()
+		for i := 0; i < s.indentPos; i++ {
+			s.buf.WriteByte(' ')
+		}
+		s.linePos = s.indentPos
+		s.sep = "" // No separator needed at start of new line
+	}
+}
+
+// isScalar reports whether values of kind k are written by appendValue
+// exactly as formatValueAsString renders them.
+func isScalar(k slog.Kind) bool {
+	switch k {
+	case slog.KindString, slog.KindInt64, slog.KindUint64, slog.KindFloat64, slog.KindBool, slog.KindDuration:
+		return true
+	}
+	return false
+}
+
 // TimeFormat is the time format to use for plain (non-JSON) output.
 // This is a version of RFC3339 that contains millisecond precision.
 const (
@@ -768,15 +1260,15 @@ const (
 )
 
 func (s *handleState) appendShortTime(t time.Time) int {
-	str := t.Format(TimeFormat)
-	s.buf.WriteString(str)
-	return len(str)
+	n := s.buf.Len()
+	*s.buf = t.AppendFormat(*s.buf, TimeFormat)
+	return s.buf.Len() - n
 }
 
 func (s *handleState) appendMiniTime(t time.Time) int {
-	str := t.Format(MiniTimeFormat)
-	s.buf.WriteString(str)
-	return len(str)
+	n := s.buf.Len()
+	*s.buf = t.AppendFormat(*s.buf, MiniTimeFormat)
+	return s.buf.Len() - n
 }
 
 func (s *handleState) appendError(err error) {
@@ -788,22 +1280,9 @@ var (
 	boldColor      = color.New(color.Bold)
 )
 
-// calculateVisibleLength estimates the visible length of a string, ignoring ANSI codes
-func calculateVisibleLength(s string) int {
-	// Simple approximation: strip ANSI codes
-	inCode := false
-	length := 0
-	for _, r := range s {
-		if r == '\x1b' {
-			inCode = true
-		} else if inCode && r == 'm' {
-			inCode = false
-		} else if !inCode {
-			length++
-		}
-	}
-	return length
-}
+// rendered is a value already rendered for display, such as by a locale,
+// written as it is. It may contain escape sequences.
+type rendered string
 
 // formatValueAsString returns the exact string representation of a value as it will be printed
 func formatValueAsString(v slog.Value) string {
@@ -831,6 +1310,9 @@ func formatValueAsString(v slog.Value) string {
 		if v.Any() == nil {
 			return "<nil>"
 		}
+		if r, ok := v.Any().(rendered); ok {
+			return string(r)
+		}
 		if tm, ok := v.Any().(encoding.TextMarshaler); ok {
 			if data, err := tm.MarshalText(); err == nil {
 				str := string(data)
@@ -863,27 +1345,38 @@ func formatValueAsString(v slog.Value) string {
 	}
 }
 
+// keyColor returns the color key is written in. Critical keys take
+// precedence over important ones.
+func (s *handleState) keyColor(key string) *color.Color {
+	if s.h.criticalKeys != nil && s.h.criticalKeys[key] {
+		return criticalKeyColor
+	} else if s.h.importantKeys != nil && s.h.importantKeys[key] {
+		return importantKeyColor
+	}
+	return faintBoldColor
+}
+
+// keyWidth returns the width key occupies on screen when written by
+// appendKey: its group prefix, the key and ": ".
+func (s *handleState) keyWidth(key string) int {
+	w := color.StringWidth(s.h.safe(key)) + 2
+	if s.prefix != nil {
+		w += color.StringWidth(s.prefix.String())
+	}
+	return w
+}
+
 func (s *handleState) appendKey(key string) {
 	// Write separator if needed
 	if s.sep != "" {
 		s.buf.WriteString(s.sep)
 	}
 
-	// Check key priority: critical > important > normal
-	if s.h.criticalKeys != nil && s.h.criticalKeys[key] {
-		key = criticalKeyColor.Colorize(key) + boldColor.Colorize(": ")
-	} else if s.h.importantKeys != nil && s.h.importantKeys[key] {
-		key = importantKeyColor.Colorize(key) + boldColor.Colorize(": ")
-	} else {
-		key = faintBoldColor.Colorize(key) + boldColor.Colorize(": ")
-	}
-
-	if s.prefix != nil && len(*s.prefix) > 0 {
+	if s.prefix != nil {
 		s.buf.Write(*s.prefix)
-		s.buf.WriteString(key)
-	} else {
-		s.buf.WriteString(key)
 	}
+	*s.buf = s.keyColor(key).Styled(s.h.safe(key)).AppendTo(*s.buf)
+	*s.buf = boldColor.Styled(": ").AppendTo(*s.buf)
 	s.sep = s.h.attrSep()
 }
 
@@ -895,8 +1388,9 @@ func needsQuoting(s string) bool {
 		b := s[i]
 		if b < utf8.RuneSelf {
 			// Quote anything except a backslash that would need quoting in a
-			// JSON string, as well as space and '='
-			if b != '\\' && (b == ' ' || b == '=' || !safeSet[b]) {
+			// JSON string, as well as space, '=' and DEL, which JSON allows
+			// but terminals don't show.
+			if b != '\\' && (b == ' ' || b == '=' || b == 0x7f || !safeSet[b]) {
 				return true
 			}
 			i++
@@ -912,15 +1406,28 @@ func needsQuoting(s string) bool {
 }
 
 func (s *handleState) appendRawString(str string) {
-	// Handle any needed indentation
-	if s.needsIndent && s.h.terminalWidth > 0 {
+	s.indentIfNeeded()
+	s.buf.WriteString(str)
+}
+
+// indentIfNeeded writes the indentation of a wrapped line, if it is due.
+func (s *handleState) indentIfNeeded() {
+	if s.needsIndent && s.width > 0 {
 		for i := 0; i < s.indentPos; i++ {
 			s.buf.WriteByte(' ')
 		}
 		s.needsIndent = false
 	}
+}
 
-	s.buf.WriteString(str)
+// appendSegments writes styled parts and advances linePos by the width
+// they occupy on screen, which excludes their escape codes.
+func (s *handleState) appendSegments(parts ...color.Styled) {
+	for _, p := range parts {
+		s.indentIfNeeded()
+		*s.buf = p.AppendTo(*s.buf)
+		s.linePos += p.Width()
+	}
 }
 
 func (s *handleState) appendString(str string) {
@@ -1012,6 +1519,10 @@ func appendTextValue(s *handleState, v slog.Value) error {
 	case slog.KindTime:
 		s.appendTime(v.Time())
 	case slog.KindAny:
+		if r, ok := v.Any().(rendered); ok {
+			s.appendRawString(string(r))
+			return nil
+		}
 		if tm, ok := v.Any().(encoding.TextMarshaler); ok {
 			data, err := tm.MarshalText()
 			if err != nil {
@@ -1189,7 +1700,7 @@ const (
 
 func needsEscaping(str string) bool {
 	for _, b := range str {
-		if !unicode.IsPrint(b) || b == '"' {
+		if !unicode.IsPrint(b) || b == '"' || b == utf8.RuneError {
 			return true
 		}
 	}
@@ -1203,10 +1714,8 @@ func writeEscapedForOutput(w *handleState, str string, escapeQuotes bool) {
 		return
 	}
 
-	bb := bufPool.Get().(*Buffer)
-	bb.Reset()
-
-	bb.Free()
+	bb := NewBuffer()
+	defer bb.Free()
 
 	for _, r := range str {
 		if escapeQuotes && r == '"' {
@@ -1260,9 +1769,10 @@ func writeEscapedForOutput(w *handleState, str string, escapeQuotes bool) {
 // with the stdlib testing pkg.
 type testHandler struct {
 	slog.Handler
-	t   testing.T
-	buf *bytes.Buffer
-	mu  *sync.Mutex
+	t    testing.T
+	buf  *bytes.Buffer
+	mu   *sync.Mutex
+	done *atomic.Bool // set once the test has finished
 }
 
 // Handle implements slog.Handler.
@@ -1270,12 +1780,13 @@ func (b *testHandler) Handle(ctx context.Context, rec slog.Record) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	err := b.Handler.Handle(ctx, rec)
-	if err != nil {
-		return err
+	// Goroutines may outlive the test that started them, and t.Log panics
+	// once the test has finished.
+	if b.done.Load() {
+		return nil
 	}
 
-	output, err := io.ReadAll(b.buf)
+	output, err := b.render(ctx, rec)
 	if err != nil {
 		return err
 	}
@@ -1284,10 +1795,6 @@ func (b *testHandler) Handle(ctx context.Context, rec slog.Record) error {
 	// callsite will be printed. See discussion in README.md.
 	b.t.Helper()
 
-	// The output comes back with a newline, which we need to
-	// trim before feeding to t.Log.
-	output = bytes.TrimSuffix(output, []byte("\n"))
-
 	if bytes.ContainsRune(output, '\n') {
 		parts := bytes.Split(output, []byte{'\n'})
 
@@ -1301,12 +1808,27 @@ func (b *testHandler) Handle(ctx context.Context, rec slog.Record) error {
 	return nil
 }
 
+// render returns rec as the handler writes it, without the trailing
+// newline, which t.Log adds. b.mu must be held.
+func (b *testHandler) render(ctx context.Context, rec slog.Record) ([]byte, error) {
+	if err := b.Handler.Handle(ctx, rec); err != nil {
+		return nil, err
+	}
+
+	output, err := io.ReadAll(b.buf)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(output, []byte("\n")), nil
+}
+
 // WithAttrs implements slog.Handler.
 func (b *testHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	return &testHandler{
 		t:       b.t,
 		buf:     b.buf,
 		mu:      b.mu,
+		done:    b.done,
 		Handler: b.Handler.WithAttrs(attrs),
 	}
 }
@@ -1317,18 +1839,34 @@ func (b *testHandler) WithGroup(name string) slog.Handler {
 		t:       b.t,
 		buf:     b.buf,
 		mu:      b.mu,
+		done:    b.done,
 		Handler: b.Handler.WithGroup(name),
 	}
 }
 
-func NewTest(t testing.T, opts *slog.HandlerOptions) slog.Handler {
+// NewTest returns a handler that writes records to the log of t, so they
+// are shown with the test's output. The module is set to the test's name,
+// including subtests, so output of parallel tests stays attributable; a
+// "module" attribute nests under it as usual.
+//
+// Every handler returned by NewTest has its own buffer, so parallel tests
+// each calling it don't share state. Records logged after t has finished
+// are dropped.
+func NewTest(t testing.T, opts *slog.HandlerOptions, options ...Option) slog.Handler {
+	return newTestHandler(t, opts, options...)
+}
+
+func newTestHandler(t testing.T, opts *slog.HandlerOptions, options ...Option) *testHandler {
 	h := &testHandler{
-		t:   t,
-		buf: new(bytes.Buffer),
-		mu:  new(sync.Mutex),
+		t:    t,
+		buf:  new(bytes.Buffer),
+		mu:   new(sync.Mutex),
+		done: new(atomic.Bool),
 	}
 
-	h.Handler = New(h.buf, opts)
+	t.Cleanup(func() { h.done.Store(true) })
+
+	h.Handler = New(h.buf, opts, options...).WithAttrs([]slog.Attr{slog.String(ModuleKey, t.Name())})
 
 	return h
 }
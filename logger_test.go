@@ -2,8 +2,13 @@ package trifle
 
 import (
 	"bytes"
+	"context"
+	"io"
 	"log/slog"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -104,7 +109,7 @@ func TestImportantKeysWithGroups(t *testing.T) {
 	// Create handler with important keys
 	handler := New(&buf, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
-	}, WithImportantKeys("request_id", "user_id"))
+	}, WithImportantKeys("request_id", "user_id"), WithContextKey())
 
 	logger := slog.New(handler).With("request_id", "req-123")
 	logger = logger.WithGroup("user")
@@ -396,7 +401,7 @@ func TestContextKey(t *testing.T) {
 			var buf bytes.Buffer
 
 			opts := []Option{}
-			if len(tt.contextKeys) > 0 {
+			if tt.contextKeys != nil {
 				opts = append(opts, WithContextKey(tt.contextKeys...))
 			}
 
@@ -486,3 +491,130 @@ func TestMultipleContextKeysWithGroups(t *testing.T) {
 	assert.NotContains(t, output, "session_id:", "Context keys should not appear in attributes")
 	assert.NotContains(t, output, "trace_id:", "Context keys should not appear in attributes")
 }
+
+func TestNewValidation(t *testing.T) {
+	var buf bytes.Buffer
+
+	assert.NotPanics(t, func() {
+		New(&buf, nil,
+			WithCriticalKeys("error"),
+			WithImportantKeys("error", "user_id", "request_id"),
+			WithContextKey("request_id"),
+		)
+	})
+
+	tests := []struct {
+		name    string
+		options []Option
+		errMsg  string
+	}{
+		{
+			name:    "negative width",
+			options: []Option{WithTerminalWidth(-1)},
+			errMsg:  "terminal width must not be negative",
+		},
+		{
+			name:    "duplicate context key",
+			options: []Option{WithContextKey("request_id", "request_id")},
+			errMsg:  `context key "request_id" specified more than once`,
+		},
+		{
+			name:    "empty important key",
+			options: []Option{WithImportantKeys("")},
+			errMsg:  "highlighted key must not be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := NewE(&buf, nil, tt.options...)
+			require.Error(t, err)
+			assert.Nil(t, h)
+			assert.Contains(t, err.Error(), tt.errMsg)
+
+			assert.Panics(t, func() {
+				New(&buf, nil, tt.options...)
+			})
+		})
+	}
+}
+
+func TestNilWriter(t *testing.T) {
+	for name, w := range map[string]io.Writer{
+		"nil":         nil,
+		"nil buffer":  (*bytes.Buffer)(nil),
+		"nil file":    (*os.File)(nil),
+		"nil builder": (*strings.Builder)(nil),
+	} {
+		t.Run(name, func(t *testing.T) {
+			h, err := NewE(w, nil)
+			assert.Nil(t, h)
+			assert.ErrorContains(t, err, "writer must not be nil")
+
+			stderr := captureStderr(t, func() {
+				logger := slog.New(New(w, nil))
+				assert.NotPanics(t, func() { logger.Info("dropped") })
+			})
+			assert.Contains(t, Plain(stderr), "[WARN]  trifle: handler writer is nil, discarding its records │ caller: ")
+			assert.Contains(t, stderr, "logger_test.go:")
+		})
+	}
+}
+
+// captureStderr returns what f writes to os.Stderr.
+func captureStderr(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+
+	f()
+	require.NoError(t, w.Close())
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestQuickEnvironment(t *testing.T) {
+	tests := []struct {
+		name     string
+		logLevel string
+		trace    string
+		expected slog.Level
+	}{
+		{name: "default", expected: slog.LevelDebug},
+		{name: "named level", logLevel: "warn", expected: slog.LevelWarn},
+		{name: "offset level", logLevel: "INFO+2", expected: slog.LevelInfo + 2},
+		{name: "trace level", logLevel: "TRACE", expected: Trace},
+		{name: "invalid level", logLevel: "loud", expected: slog.LevelDebug},
+		{name: "trace override", logLevel: "error", trace: "1", expected: Trace},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LOG_LEVEL", tt.logLevel)
+			t.Setenv("TRIFLE_TRACE", tt.trace)
+
+			h := Quick(WithImportantKeys("user_id"))
+			assert.Equal(t, tt.expected, h.opts.Level.Level())
+			assert.True(t, h.importantKeys["user_id"])
+		})
+	}
+}
+
+func TestWrappingUsesDisplayWidth(t *testing.T) {
+	color.NoColor = false
+
+	var buf bytes.Buffer
+
+	handler := New(&buf, nil, WithTerminalWidth(40), WithImportantKeys("k"))
+	r := slog.NewRecord(time.Time{}, slog.LevelInfo, "width", 0)
+	// 6 wide characters occupy 12 cells, though they are 18 bytes long.
+	// Counting bytes would push the second attribute past the width.
+	r.AddAttrs(slog.String("k", "日本語日本語"), slog.String("x", "y"))
+	require.NoError(t, handler.Handle(context.Background(), r))
+
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"), "record should fit on one line: %q", buf.String())
+}
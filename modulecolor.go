@@ -0,0 +1,55 @@
+package trifle
+
+import (
+	"strings"
+	"sync"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+// WithModuleColors returns an Option that colors module names with colors
+// from p instead of showing them faint. Modules are colored by their first
+// component, so "api" and "api.db" share a color, and a module keeps its
+// color across runs, which makes it easy to follow one component, or one
+// source when logs are merged, through interleaved output.
+//
+// The modules listed are given the colors of p in order, so that they are
+// sure to be told apart; other modules may share a color.
+func WithModuleColors(p color.Palette, modules ...string) Option {
+	return func(h *TextHandler) {
+		mc := &moduleColors{palette: p}
+		for i, module := range modules {
+			mc.colors.Store(module, p.At(i))
+		}
+		h.moduleColors = mc
+	}
+}
+
+// moduleColored returns h, or a handler sharing its output and everything
+// else with it, that colors modules, along with the colors it gives them.
+// A handler without [WithModuleColors] gets colors from p.
+func (h *TextHandler) moduleColored(p color.Palette) (*TextHandler, *moduleColors) {
+	if h.moduleColors != nil {
+		return h, h.moduleColors
+	}
+
+	c := h.clone()
+	c.moduleColors = &moduleColors{palette: p}
+	return &TextHandler{commonHandler: c, module: h.module}, c.moduleColors
+}
+
+// moduleColors caches the color of each module. It is shared among clones.
+type moduleColors struct {
+	palette color.Palette
+	colors  sync.Map // first module component to *color.Color
+}
+
+func (m *moduleColors) color(module string) *color.Color {
+	top, _, _ := strings.Cut(module, ".")
+
+	if c, ok := m.colors.Load(top); ok {
+		return c.(*color.Color)
+	}
+	c, _ := m.colors.LoadOrStore(top, m.palette.For(top))
+	return c.(*color.Color)
+}
@@ -0,0 +1,30 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestModuleColors(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := slog.New(New(&buf, nil, WithTerminalWidth(0), WithModuleColors(color.PaletteDefault, "api", "worker")))
+	logger.With("module", "api").Info("one")
+	logger.With("module", "worker").Info("two")
+	logger.With("module", "api").With("module", "db").Info("three")
+
+	api := color.PaletteDefault.At(0).Sprint("api")
+	worker := color.PaletteDefault.At(1).Sprint("worker")
+	assert.Contains(t, buf.String(), api+" one")
+	assert.Contains(t, buf.String(), worker+" two")
+	assert.Contains(t, buf.String(), color.PaletteDefault.At(0).Sprint("api.db")+" three")
+}
+
+func TestModuleColorsStable(t *testing.T) {
+	mc := &moduleColors{palette: color.PaletteDefault}
+	assert.Equal(t, color.PaletteDefault.For("billing").Sprint("x"), mc.color("billing.invoices").Sprint("x"))
+}
@@ -0,0 +1,64 @@
+package trifle
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// WithModuleLevel returns an Option that gives module its own minimum
+// level, in place of the level of the handler, to quiet a noisy subsystem
+// or to debug a single one without a logger of its own:
+//
+//	h := trifle.New(os.Stderr, nil, trifle.WithModuleLevel("database", slog.LevelWarn))
+//	db := slog.New(h).With(trifle.ModuleKey, "database")
+//	db.Info("connected")   // not written
+//	db.Warn("slow query")  // written
+//
+// The module of a logger is the one set with the "module" attribute, see
+// [ModuleKey]. Nested modules, such as "database.pool", take the level of
+// the nearest module they are in that has one, so the option can be used
+// more than once, for a module and for some of the modules in it. Modules
+// without a level of their own follow the level of the handler.
+func WithModuleLevel(module string, level slog.Leveler) Option {
+	return func(h *TextHandler) {
+		levels := maps.Clone(h.moduleLevels)
+		if levels == nil {
+			levels = make(map[string]slog.Leveler)
+		}
+		levels[module] = level
+		h.moduleLevels = levels
+	}
+}
+
+// enabledModule reports whether a record at level l from module is
+// written, taking the level of the module into account.
+func (h *commonHandler) enabledModule(l slog.Level, module string) bool {
+	for m := module; len(h.moduleLevels) > 0 && m != ""; {
+		if level, ok := h.moduleLevels[m]; ok {
+			return l >= level.Level()
+		}
+		i := strings.LastIndexByte(m, '.')
+		if i < 0 {
+			break
+		}
+		m = m[:i]
+	}
+	return h.enabled(l)
+}
+
+func (h *commonHandler) validateModuleLevels() []error {
+	var errs []error
+	for _, m := range slices.Sorted(maps.Keys(h.moduleLevels)) {
+		switch {
+		case m == "":
+			errs = append(errs, errors.New("module with a level must not be empty"))
+		case h.moduleLevels[m] == nil:
+			errs = append(errs, fmt.Errorf("level of module %q must not be nil", m))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,88 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModuleLevel(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0),
+		WithModuleLevel("database", slog.LevelWarn),
+		WithModuleLevel("api", slog.LevelDebug))
+	log := slog.New(h)
+
+	db := log.With(ModuleKey, "database")
+	db.Info("connected")
+	db.Warn("slow query")
+	db.With(ModuleKey, "pool").Info("checked out")
+
+	api := log.With(ModuleKey, "api")
+	api.Debug("routing")
+
+	log.Debug("startup")
+	log.Info("ready")
+
+	out := Plain(buf.String())
+	assert.NotContains(t, out, "connected")
+	assert.Contains(t, out, "slow query")
+	assert.NotContains(t, out, "checked out")
+	assert.Contains(t, out, "routing")
+	assert.NotContains(t, out, "startup")
+	assert.Contains(t, out, "ready")
+}
+
+func TestModuleLevelNested(t *testing.T) {
+	h := New(&bytes.Buffer{}, nil,
+		WithModuleLevel("database", slog.LevelWarn),
+		WithModuleLevel("database.pool", slog.LevelDebug))
+	ctx := context.Background()
+
+	pool := h.WithAttrs([]slog.Attr{slog.String(ModuleKey, "database"), slog.String(ModuleKey, "pool")})
+	assert.True(t, pool.Enabled(ctx, slog.LevelDebug))
+
+	conn := pool.WithAttrs([]slog.Attr{slog.String(ModuleKey, "conn")})
+	assert.True(t, conn.Enabled(ctx, slog.LevelDebug))
+
+	migrate := h.WithAttrs([]slog.Attr{slog.String(ModuleKey, "database.migrate")})
+	assert.False(t, migrate.Enabled(ctx, slog.LevelInfo))
+
+	// A module only sharing a prefix is not nested.
+	other := h.WithAttrs([]slog.Attr{slog.String(ModuleKey, "databases")})
+	assert.True(t, other.Enabled(ctx, slog.LevelInfo))
+}
+
+func TestModuleLevelHandle(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithModuleLevel("database", slog.LevelWarn))
+	db := h.WithAttrs([]slog.Attr{slog.String(ModuleKey, "database")})
+
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "connected", 0)
+	require.NoError(t, db.Handle(context.Background(), r))
+	assert.Empty(t, buf.String())
+}
+
+func TestModuleLevelVar(t *testing.T) {
+	var level slog.LevelVar
+	level.Set(slog.LevelWarn)
+
+	h := New(&bytes.Buffer{}, nil, WithModuleLevel("database", &level))
+	db := h.WithAttrs([]slog.Attr{slog.String(ModuleKey, "database")})
+	assert.False(t, db.Enabled(context.Background(), slog.LevelInfo))
+
+	level.Set(slog.LevelInfo)
+	assert.True(t, db.Enabled(context.Background(), slog.LevelInfo))
+}
+
+func TestModuleLevelInvalid(t *testing.T) {
+	_, err := NewE(&bytes.Buffer{}, nil, WithModuleLevel("", slog.LevelWarn), WithModuleLevel("database", nil))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "module with a level must not be empty")
+	assert.Contains(t, err.Error(), `level of module "database" must not be nil`)
+}
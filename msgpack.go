@@ -0,0 +1,186 @@
+package trifle
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// msgpackCodec writes and reads MessagePack, as specified at msgpack.org.
+// Extension types are not supported.
+type msgpackCodec struct{}
+
+func (msgpackCodec) appendMap(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xde), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, 0xdf), uint32(n))
+	}
+}
+
+func (msgpackCodec) appendArray(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xdc), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, 0xdd), uint32(n))
+	}
+}
+
+func (msgpackCodec) appendString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+func (msgpackCodec) appendBytes(b []byte, bs []byte) []byte {
+	switch n := len(bs); {
+	case n <= math.MaxUint8:
+		b = append(b, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xc5), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xc6), uint32(n))
+	}
+	return append(b, bs...)
+}
+
+func (c msgpackCodec) appendInt(b []byte, i int64) []byte {
+	switch {
+	case i >= 0:
+		return c.appendUint(b, uint64(i))
+	case i >= -32:
+		return append(b, byte(i))
+	case i >= math.MinInt8:
+		return append(b, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(i))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(i))
+	}
+}
+
+func (msgpackCodec) appendUint(b []byte, u uint64) []byte {
+	switch {
+	case u <= math.MaxInt8:
+		return append(b, byte(u))
+	case u <= math.MaxUint8:
+		return append(b, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(u))
+	case u <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(u))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), u)
+	}
+}
+
+func (msgpackCodec) appendFloat(b []byte, f float64) []byte {
+	return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(f))
+}
+
+func (msgpackCodec) appendBool(b []byte, v bool) []byte {
+	if v {
+		return append(b, 0xc3)
+	}
+	return append(b, 0xc2)
+}
+
+func (c msgpackCodec) decode(data []byte, depth int) (any, []byte, error) {
+	if depth > maxBinaryDepth {
+		return nil, nil, errBinaryDepth
+	}
+	if len(data) == 0 {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+
+	b, data := data[0], data[1:]
+	switch {
+	case b <= 0x7f:
+		return uint64(b), data, nil
+	case b >= 0xe0:
+		return int64(int8(b)), data, nil
+	case b&0xf0 == 0x80:
+		return decodeBinaryMap(c, data, uint64(b&0x0f), depth)
+	case b&0xf0 == 0x90:
+		return decodeBinaryArray(c, data, uint64(b&0x0f), depth)
+	case b&0xe0 == 0xa0:
+		s, rest, err := binaryTake(data, uint64(b&0x1f))
+		return string(s), rest, err
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, data, nil
+	case 0xc2:
+		return false, data, nil
+	case 0xc3:
+		return true, data, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, rest, err := binaryNumber(data, 1<<(b-0xc4))
+		if err != nil {
+			return nil, nil, err
+		}
+		bs, rest, err := binaryTake(rest, n)
+		if err != nil {
+			return nil, nil, err
+		}
+		return append([]byte(nil), bs...), rest, nil
+	case 0xca:
+		n, rest, err := binaryNumber(data, 4)
+		return float64(math.Float32frombits(uint32(n))), rest, err
+	case 0xcb:
+		n, rest, err := binaryNumber(data, 8)
+		return math.Float64frombits(n), rest, err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return binaryNumber(data, 1<<(b-0xcc))
+	case 0xd0:
+		n, rest, err := binaryNumber(data, 1)
+		return int64(int8(n)), rest, err
+	case 0xd1:
+		n, rest, err := binaryNumber(data, 2)
+		return int64(int16(n)), rest, err
+	case 0xd2:
+		n, rest, err := binaryNumber(data, 4)
+		return int64(int32(n)), rest, err
+	case 0xd3:
+		n, rest, err := binaryNumber(data, 8)
+		return int64(n), rest, err
+	case 0xd9, 0xda, 0xdb:
+		n, rest, err := binaryNumber(data, 1<<(b-0xd9))
+		if err != nil {
+			return nil, nil, err
+		}
+		s, rest, err := binaryTake(rest, n)
+		return string(s), rest, err
+	case 0xdc, 0xdd:
+		n, rest, err := binaryNumber(data, 2<<(b-0xdc))
+		if err != nil {
+			return nil, nil, err
+		}
+		return decodeBinaryArray(c, rest, n, depth)
+	case 0xde, 0xdf:
+		n, rest, err := binaryNumber(data, 2<<(b-0xde))
+		if err != nil {
+			return nil, nil, err
+		}
+		return decodeBinaryMap(c, rest, n, depth)
+	}
+	return nil, nil, fmt.Errorf("unsupported MessagePack type 0x%02x", b)
+}
@@ -0,0 +1,59 @@
+package trifle
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+type multiHandler []slog.Handler
+
+// Multi returns a handler that passes every record to each of handlers
+// that is enabled for its level, such as a [TextHandler] for the console
+// and a [FileSink]:
+//
+//	slog.SetDefault(slog.New(trifle.Multi(trifle.Quick(), sink)))
+//
+// Errors from the handlers are joined.
+func Multi(handlers ...slog.Handler) slog.Handler {
+	return multiHandler(handlers)
+}
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range m {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		// Each handler gets its own copy, as handlers may retain records.
+		if err := h.Handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	hs := make(multiHandler, len(m))
+	for i, h := range m {
+		hs[i] = h.WithAttrs(attrs)
+	}
+	return hs
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	hs := make(multiHandler, len(m))
+	for i, h := range m {
+		hs[i] = h.WithGroup(name)
+	}
+	return hs
+}
@@ -0,0 +1,592 @@
+package trifle
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default limits used by [NewNetWriter] when the corresponding field of
+// [NetWriterOptions] is zero.
+const (
+	DefaultNetTimeout = 10 * time.Second
+	DefaultMinBackoff = 100 * time.Millisecond
+	DefaultMaxBackoff = 30 * time.Second
+	DefaultMaxSpill   = 16 << 20
+)
+
+// NetWriterOptions configures a [NetWriter].
+type NetWriterOptions struct {
+	// TLS, if not nil, secures TCP connections with this configuration.
+	TLS *tls.Config
+
+	// DialTimeout bounds each connection attempt, and WriteTimeout each
+	// write. A write that times out counts as a lost connection.
+	DialTimeout  time.Duration
+	WriteTimeout time.Duration
+
+	// MinBackoff is the wait after the first failed connection attempt,
+	// doubled after every further one up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// SpillPath is a file keeping what is written while disconnected.
+	// Empty keeps it in memory instead.
+	SpillPath string
+
+	// MaxSpill is how many bytes are kept while disconnected. Writes that
+	// don't fit are dropped.
+	MaxSpill int64
+}
+
+// NetWriter writes to a collector over the network, such as Vector or
+// Fluent Bit listening on a TCP or UDP socket, so records can be shipped
+// without a sidecar tailing a file. It also writes to local log daemons and
+// test harnesses through Unix domain sockets and named pipes. Pair it with
+// a JSON handler for the collector, or a [Recorder]:
+//
+//	nw, err := trifle.NewNetWriter("tcp", "collector:9000", nil)
+//	if err != nil {
+//		return err
+//	}
+//	defer nw.Close()
+//	slog.SetDefault(slog.New(trifle.Multi(trifle.Quick(), slog.NewJSONHandler(nw, nil))))
+//
+// A NetWriter connects in the background and never blocks a write on the
+// network beyond WriteTimeout. While it is disconnected, writes are kept,
+// in memory or in the file at SpillPath, and sent in order once it has
+// reconnected; connection attempts back off from MinBackoff to MaxBackoff.
+// A spill file left by a previous run is sent first. A write cut short by
+// a lost connection is sent again whole, and one made just before the
+// collector went away may be lost with the connection.
+//
+// Over UDP and unixgram sockets, each write is sent as one datagram.
+// Datagrams have no connection to lose, so those that can't be sent are
+// dropped.
+type NetWriter struct {
+	dialer       func(ctx context.Context) (sinkConn, error)
+	writeTimeout time.Duration
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	datagrams    bool // each write is a datagram
+	watch        bool // the collector may close the connection
+
+	mu      sync.Mutex
+	conn    sinkConn
+	spill   spillStore
+	dropped uint64
+	closed  bool
+
+	lost   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewNetWriter returns a [NetWriter] sending to addr over network, which is
+// one of "tcp", "tcp4", "tcp6", "udp", "udp4", "udp6", "unix", "unixgram"
+// and "unixpacket", as for [net.Dial], or "pipe". With "pipe", addr is the
+// path of a named pipe: one such as \\.\pipe\app on Windows, a FIFO made
+// with mkfifo elsewhere. A FIFO counts as disconnected while nothing reads
+// from it.
+//
+// NewNetWriter returns an error only if the options are invalid or the
+// spill file can't be opened; connecting happens in the background. If
+// opts is nil, the defaults are used.
+func NewNetWriter(network, addr string, opts *NetWriterOptions) (*NetWriter, error) {
+	if opts == nil {
+		opts = &NetWriterOptions{}
+	}
+
+	var datagrams, stream bool
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		stream = true
+	case "unix", "unixpacket":
+		stream = true
+	case "udp", "udp4", "udp6", "unixgram":
+		datagrams = true
+	case "pipe":
+	default:
+		return nil, fmt.Errorf("trifle: unsupported network %q", network)
+	}
+	if opts.TLS != nil && !strings.HasPrefix(network, "tcp") {
+		return nil, fmt.Errorf("trifle: TLS is not supported over %s", network)
+	}
+
+	o := *opts
+	if o.DialTimeout <= 0 {
+		o.DialTimeout = DefaultNetTimeout
+	}
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = DefaultNetTimeout
+	}
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = DefaultMinBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = DefaultMaxBackoff
+	}
+	o.MaxBackoff = max(o.MaxBackoff, o.MinBackoff)
+	if o.MaxSpill <= 0 {
+		o.MaxSpill = DefaultMaxSpill
+	}
+
+	var spill spillStore = &memorySpill{max: o.MaxSpill}
+	if o.SpillPath != "" {
+		fs, err := openFileSpill(o.SpillPath, o.MaxSpill)
+		if err != nil {
+			return nil, err
+		}
+		spill = fs
+	}
+
+	w := &NetWriter{
+		writeTimeout: o.WriteTimeout,
+		minBackoff:   o.MinBackoff,
+		maxBackoff:   o.MaxBackoff,
+		datagrams:    datagrams,
+		watch:        stream,
+		spill:        spill,
+		lost:         make(chan struct{}, 1),
+	}
+
+	d := &net.Dialer{Timeout: o.DialTimeout}
+	switch {
+	case network == "pipe":
+		w.dialer = func(context.Context) (sinkConn, error) {
+			return openPipe(addr)
+		}
+	case o.TLS != nil:
+		td := &tls.Dialer{NetDialer: d, Config: o.TLS}
+		w.dialer = func(ctx context.Context) (sinkConn, error) {
+			return td.DialContext(ctx, network, addr)
+		}
+	default:
+		w.dialer = func(ctx context.Context) (sinkConn, error) {
+			return d.DialContext(ctx, network, addr)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.wg.Add(1)
+	go w.run(ctx)
+
+	return w, nil
+}
+
+// Write sends p, or keeps it to be sent once connected. It only fails once
+// the writer is closed.
+func (w *NetWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return 0, net.ErrClosed
+	}
+	conn := w.conn
+	if conn == nil {
+		w.keep(p)
+		w.mu.Unlock()
+		return len(p), nil
+	}
+	w.mu.Unlock()
+
+	// The lock isn't held while sending, so that a slow collector only
+	// holds up the writes made meanwhile, not Connected, Dropped and the
+	// rest. Connections take concurrent writes whole.
+	err := w.send(conn, p)
+	if err == nil {
+		return len(p), nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch {
+	case w.closed:
+		return 0, net.ErrClosed
+	case w.datagrams:
+		w.dropped++
+	default:
+		if w.conn == conn {
+			w.disconnect()
+		}
+		w.keep(p)
+	}
+	return len(p), nil
+}
+
+// keep adds p to the spill, counting it as dropped if it doesn't fit. The
+// caller must hold w.mu.
+func (w *NetWriter) keep(p []byte) {
+	if !w.spill.add(p) {
+		w.dropped++
+	}
+}
+
+// Connected reports whether the writer is connected to the collector.
+func (w *NetWriter) Connected() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.conn != nil
+}
+
+// Dropped returns how many writes were lost: those that didn't fit in the
+// spill, and datagrams that couldn't be sent.
+func (w *NetWriter) Dropped() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.dropped
+}
+
+// Close stops connecting and closes the connection. Writes not sent by
+// then are lost, unless they are in a spill file, which the next NetWriter
+// using it sends.
+func (w *NetWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	w.cancel()
+	w.wg.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	if w.conn != nil {
+		err = w.conn.Close()
+		w.conn = nil
+	}
+	if serr := w.spill.close(); err == nil {
+		err = serr
+	}
+	return err
+}
+
+// run keeps the writer connected until ctx is canceled.
+func (w *NetWriter) run(ctx context.Context) {
+	defer w.wg.Done()
+
+	backoff := w.minBackoff
+	for {
+		if conn, err := w.dialer(ctx); err == nil && w.attach(conn) == nil {
+			backoff = w.minBackoff
+			select {
+			case <-w.lost:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return
+		}
+		backoff = min(backoff*2, w.maxBackoff)
+	}
+}
+
+// attach sends what was kept while disconnected over conn, and makes it
+// the connection of w. The writes kept are sent without w.mu held, so
+// writes made meanwhile are kept too, after them, until none are left.
+func (w *NetWriter) attach(conn sinkConn) error {
+	for {
+		w.mu.Lock()
+		if w.closed {
+			w.mu.Unlock()
+			conn.Close()
+			return net.ErrClosed
+		}
+		if w.spill.empty() {
+			w.conn = conn
+			if w.watch {
+				go w.watchConn(conn)
+			}
+			w.mu.Unlock()
+			return nil
+		}
+		sendKept := w.spill.kept()
+		w.mu.Unlock()
+
+		var dropped uint64
+		sent, err := sendKept(func(p []byte) error {
+			err := w.send(conn, p)
+			if err != nil && w.datagrams {
+				dropped++
+				return nil
+			}
+			return err
+		})
+
+		w.mu.Lock()
+		w.dropped += dropped
+		if derr := w.spill.discard(sent); err == nil {
+			err = derr
+		}
+		w.mu.Unlock()
+
+		if err != nil {
+			conn.Close()
+			return err
+		}
+	}
+}
+
+// watchConn notices when the collector closes conn, which a write may not
+// until the one after it is lost. Collectors don't send anything back.
+func (w *NetWriter) watchConn(conn sinkConn) {
+	_, _ = io.Copy(io.Discard, conn)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn == conn {
+		w.disconnect()
+	}
+}
+
+func (w *NetWriter) send(conn sinkConn, p []byte) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(w.writeTimeout))
+	_, err := conn.Write(p)
+	return err
+}
+
+// disconnect drops the connection and has run reconnect.
+func (w *NetWriter) disconnect() {
+	w.conn.Close()
+	w.conn = nil
+
+	select {
+	case w.lost <- struct{}{}:
+	default:
+	}
+}
+
+// sinkConn is a connection to a collector: a net.Conn, or the *os.File of
+// a named pipe.
+type sinkConn interface {
+	io.ReadWriteCloser
+	SetWriteDeadline(t time.Time) error
+}
+
+// spillStore keeps writes while a NetWriter is disconnected. Its methods
+// are called with the lock of the NetWriter held, except for the function
+// returned by kept.
+type spillStore interface {
+	// add keeps p, reporting whether it fit.
+	add(p []byte) bool
+
+	// empty reports whether no writes are kept.
+	empty() bool
+
+	// kept returns a function passing the writes kept so far to send, in
+	// order, which may be called after the lock is released: writes added
+	// meanwhile are left for the next call to kept. It stops at the first
+	// error from send, and returns it with how much of the store was sent,
+	// to be given to discard.
+	kept() func(send func(p []byte) error) (int64, error)
+
+	// discard forgets the first n bytes kept, which were sent.
+	discard(n int64) error
+
+	close() error
+}
+
+type memorySpill struct {
+	max    int64
+	size   int64
+	writes [][]byte
+}
+
+func (s *memorySpill) add(p []byte) bool {
+	if s.size+int64(len(p)) > s.max {
+		return false
+	}
+	s.writes = append(s.writes, append([]byte(nil), p...))
+	s.size += int64(len(p))
+	return true
+}
+
+func (s *memorySpill) empty() bool {
+	return len(s.writes) == 0
+}
+
+func (s *memorySpill) kept() func(send func(p []byte) error) (int64, error) {
+	// Writes added meanwhile are appended past the end of this slice,
+	// without changing what it holds.
+	writes := s.writes
+	return func(send func(p []byte) error) (int64, error) {
+		var n int64
+		for _, p := range writes {
+			if err := send(p); err != nil {
+				return n, err
+			}
+			n += int64(len(p))
+		}
+		return n, nil
+	}
+}
+
+func (s *memorySpill) discard(n int64) error {
+	s.size -= n
+	for n > 0 {
+		n -= int64(len(s.writes[0]))
+		s.writes[0] = nil
+		s.writes = s.writes[1:]
+	}
+	if len(s.writes) == 0 {
+		s.writes = nil
+	}
+	return nil
+}
+
+func (s *memorySpill) close() error {
+	return nil
+}
+
+// fileSpill keeps writes in a file, each as a uvarint length followed by
+// the bytes written, like the frames of the replay format.
+type fileSpill struct {
+	f    *os.File
+	max  int64
+	size int64
+}
+
+func openFileSpill(path string, limit int64) (*fileSpill, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	// A write cut short by a crash is dropped, so that those added after
+	// it aren't lost behind it.
+	size := completeFrames(f, info.Size())
+	if size < info.Size() {
+		if err := f.Truncate(size); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return &fileSpill{f: f, max: limit, size: size}, nil
+}
+
+// completeFrames returns the size of the complete frames at the start of
+// the first size bytes of f.
+func completeFrames(f *os.File, size int64) int64 {
+	br := bufio.NewReader(io.NewSectionReader(f, 0, size))
+	var n int64
+	for {
+		_, frame, err := readFrame(br, size-n)
+		if err != nil {
+			return n
+		}
+		n += frame
+	}
+}
+
+// readFrame reads a write kept in a spill file from br, which has left
+// bytes, returning it and the size of its frame. A frame cut short, or
+// claiming more bytes than are left, is reported as io.EOF, as is the end.
+func readFrame(br *bufio.Reader, left int64) ([]byte, int64, error) {
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, 0, io.EOF
+	}
+	header := int64(len(binary.AppendUvarint(nil, n)))
+	if n > uint64(left-header) {
+		return nil, 0, io.EOF
+	}
+	p := make([]byte, n)
+	if _, err := io.ReadFull(br, p); err != nil {
+		return nil, 0, io.EOF
+	}
+	return p, header + int64(n), nil
+}
+
+func (s *fileSpill) add(p []byte) bool {
+	frame := binary.AppendUvarint(nil, uint64(len(p)))
+	frame = append(frame, p...)
+	if s.size+int64(len(frame)) > s.max {
+		return false
+	}
+
+	n, err := s.f.Write(frame)
+	s.size += int64(n)
+	return err == nil
+}
+
+func (s *fileSpill) empty() bool {
+	return s.size == 0
+}
+
+func (s *fileSpill) kept() func(send func(p []byte) error) (int64, error) {
+	// Writes added meanwhile are appended past the end of this section,
+	// and ReadAt doesn't move the offset they are written at.
+	r := io.NewSectionReader(s.f, 0, s.size)
+	return func(send func(p []byte) error) (int64, error) {
+		br := bufio.NewReader(r)
+		var sent int64
+		for {
+			p, frame, err := readFrame(br, r.Size()-sent)
+			if err != nil {
+				// The end of the kept writes, or a frame that is torn,
+				// which is forgotten with the rest.
+				return r.Size(), nil
+			}
+
+			if err := send(p); err != nil {
+				return sent, err
+			}
+			sent += frame
+		}
+	}
+}
+
+func (s *fileSpill) discard(n int64) error {
+	if n == 0 {
+		return nil
+	}
+	rest := make([]byte, s.size-n)
+	if _, err := s.f.ReadAt(rest, n); err != nil {
+		return err
+	}
+	return s.reset(rest)
+}
+
+// reset replaces the contents of the file with data.
+func (s *fileSpill) reset(data []byte) error {
+	if err := s.f.Truncate(0); err != nil {
+		return err
+	}
+	s.size = 0
+	n, err := s.f.Write(data)
+	s.size = int64(n)
+	return err
+}
+
+func (s *fileSpill) close() error {
+	return s.f.Close()
+}
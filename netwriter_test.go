@@ -0,0 +1,355 @@
+package trifle
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collector accepts connections on a local TCP port and keeps what they
+// send.
+type collector struct {
+	ln net.Listener
+
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	conns []net.Conn
+}
+
+func listenCollector(t *testing.T, addr string) *collector {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", addr)
+	require.NoError(t, err)
+
+	c := &collector{ln: ln}
+	t.Cleanup(c.close)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			c.mu.Lock()
+			c.conns = append(c.conns, conn)
+			c.mu.Unlock()
+
+			go func() {
+				buf := make([]byte, 1024)
+				for {
+					n, err := conn.Read(buf)
+					c.mu.Lock()
+					c.buf.Write(buf[:n])
+					c.mu.Unlock()
+					if err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return c
+}
+
+func (c *collector) received() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.buf.String()
+}
+
+func (c *collector) close() {
+	c.ln.Close()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, conn := range c.conns {
+		conn.Close()
+	}
+}
+
+var fastBackoff = &NetWriterOptions{MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+func TestNetWriterSends(t *testing.T) {
+	c := listenCollector(t, "127.0.0.1:0")
+
+	nw, err := NewNetWriter("tcp", c.ln.Addr().String(), fastBackoff)
+	require.NoError(t, err)
+	defer nw.Close()
+
+	for _, line := range []string{"one\n", "two\n", "three\n"} {
+		_, err := nw.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	assert.Eventually(t, func() bool {
+		return c.received() == "one\ntwo\nthree\n"
+	}, time.Second, time.Millisecond)
+	assert.True(t, nw.Connected())
+	assert.Zero(t, nw.Dropped())
+}
+
+func TestNetWriterReconnects(t *testing.T) {
+	c := listenCollector(t, "127.0.0.1:0")
+	addr := c.ln.Addr().String()
+
+	nw, err := NewNetWriter("tcp", addr, fastBackoff)
+	require.NoError(t, err)
+	defer nw.Close()
+
+	require.Eventually(t, nw.Connected, time.Second, time.Millisecond)
+	c.close()
+	require.Eventually(t, func() bool { return !nw.Connected() }, time.Second, time.Millisecond)
+
+	for _, line := range []string{"kept\n", "while\n", "away\n"} {
+		_, err := nw.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	c = listenCollector(t, addr)
+	assert.Eventually(t, func() bool {
+		return c.received() == "kept\nwhile\naway\n"
+	}, 5*time.Second, time.Millisecond)
+
+	_, err = nw.Write([]byte("after\n"))
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return c.received() == "kept\nwhile\naway\nafter\n"
+	}, time.Second, time.Millisecond)
+}
+
+// heldConn is a connection whose writes wait until it is released.
+type heldConn struct {
+	release chan struct{}
+	closed  chan struct{}
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func newHeldConn() *heldConn {
+	return &heldConn{release: make(chan struct{}), closed: make(chan struct{})}
+}
+
+func (c *heldConn) Write(p []byte) (int, error) {
+	<-c.release
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf.Write(p)
+}
+
+func (c *heldConn) Read([]byte) (int, error) {
+	<-c.closed
+	return 0, net.ErrClosed
+}
+
+func (c *heldConn) Close() error {
+	select {
+	case <-c.closed:
+	default:
+		close(c.closed)
+	}
+	return nil
+}
+
+func (c *heldConn) SetWriteDeadline(time.Time) error { return nil }
+
+func (c *heldConn) received() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.buf.String()
+}
+
+func TestNetWriterSendsSpillUnlocked(t *testing.T) {
+	nw, err := NewNetWriter("tcp", "127.0.0.1:1", fastBackoff)
+	require.NoError(t, err)
+	defer nw.Close()
+
+	_, err = nw.Write([]byte("kept\n"))
+	require.NoError(t, err)
+
+	conn := newHeldConn()
+	attached := make(chan error)
+	go func() { attached <- nw.attach(conn) }()
+
+	// While the collector holds up the spill, the writer stays usable, and
+	// what is written meanwhile follows the spill.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.False(t, nw.Connected())
+		_, err := nw.Write([]byte("meanwhile\n"))
+		assert.NoError(t, err)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("writer blocked by the spill being sent")
+	}
+
+	close(conn.release)
+	require.NoError(t, <-attached)
+	assert.True(t, nw.Connected())
+	assert.Equal(t, "kept\nmeanwhile\n", conn.received())
+}
+
+func TestNetWriterSpillFile(t *testing.T) {
+	// a port nothing listens on
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	opts := *fastBackoff
+	opts.SpillPath = filepath.Join(t.TempDir(), "spill")
+
+	nw, err := NewNetWriter("tcp", addr, &opts)
+	require.NoError(t, err)
+	_, err = nw.Write([]byte("from the last run\n"))
+	require.NoError(t, err)
+	require.NoError(t, nw.Close())
+
+	info, err := os.Stat(opts.SpillPath)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Size())
+
+	c := listenCollector(t, addr)
+	nw, err = NewNetWriter("tcp", addr, &opts)
+	require.NoError(t, err)
+	defer nw.Close()
+
+	assert.Eventually(t, func() bool {
+		return c.received() == "from the last run\n"
+	}, time.Second, time.Millisecond)
+
+	require.Eventually(t, nw.Connected, time.Second, time.Millisecond)
+	info, err = os.Stat(opts.SpillPath)
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+}
+
+func TestNetWriterSpillFileTorn(t *testing.T) {
+	// a port nothing listens on
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	opts := *fastBackoff
+	opts.SpillPath = filepath.Join(t.TempDir(), "spill")
+
+	for _, torn := range [][]byte{
+		binary.AppendUvarint(nil, 1<<62),               // a length far past the end
+		append(binary.AppendUvarint(nil, 9), "cut"...), // a write cut short
+		{0xff, 0xff}, // a length cut short
+	} {
+		frame := append(binary.AppendUvarint(nil, 5), "kept\n"...)
+		require.NoError(t, os.WriteFile(opts.SpillPath, append(frame, torn...), 0o644))
+
+		nw, err := NewNetWriter("tcp", addr, &opts)
+		require.NoError(t, err)
+		_, err = nw.Write([]byte("after\n"))
+		require.NoError(t, err)
+		require.NoError(t, nw.Close())
+
+		c := listenCollector(t, addr)
+		nw, err = NewNetWriter("tcp", addr, &opts)
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			return c.received() == "kept\nafter\n"
+		}, time.Second, time.Millisecond, "%q", c.received())
+		require.NoError(t, nw.Close())
+		c.close()
+	}
+}
+
+func TestNetWriterSpillLimit(t *testing.T) {
+	opts := *fastBackoff
+	opts.MaxSpill = 10
+
+	nw, err := NewNetWriter("tcp", "127.0.0.1:1", &opts)
+	require.NoError(t, err)
+	defer nw.Close()
+
+	for range 3 {
+		_, err := nw.Write([]byte("12345"))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, uint64(1), nw.Dropped())
+}
+
+func TestNetWriterUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	nw, err := NewNetWriter("udp", pc.LocalAddr().String(), fastBackoff)
+	require.NoError(t, err)
+	defer nw.Close()
+
+	require.Eventually(t, nw.Connected, time.Second, time.Millisecond)
+	_, err = nw.Write([]byte("first"))
+	require.NoError(t, err)
+	_, err = nw.Write([]byte("second"))
+	require.NoError(t, err)
+
+	buf := make([]byte, 64)
+	for _, want := range []string{"first", "second"} {
+		require.NoError(t, pc.SetReadDeadline(time.Now().Add(time.Second)))
+		n, _, err := pc.ReadFrom(buf)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(buf[:n]))
+	}
+}
+
+func TestNetWriterOptions(t *testing.T) {
+	_, err := NewNetWriter("ip4:icmp", "127.0.0.1", nil)
+	assert.EqualError(t, err, `trifle: unsupported network "ip4:icmp"`)
+
+	_, err = NewNetWriter("udp", "127.0.0.1:514", &NetWriterOptions{TLS: &tls.Config{}})
+	assert.EqualError(t, err, "trifle: TLS is not supported over udp")
+	_, err = NewNetWriter("pipe", "/tmp/log.fifo", &NetWriterOptions{TLS: &tls.Config{}})
+	assert.EqualError(t, err, "trifle: TLS is not supported over pipe")
+
+	nw, err := NewNetWriter("tcp", "127.0.0.1:1", nil)
+	require.NoError(t, err)
+	require.NoError(t, nw.Close())
+	_, err = nw.Write([]byte("late"))
+	assert.ErrorIs(t, err, net.ErrClosed)
+}
+
+func TestNetWriterUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	ln, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer ln.Close()
+
+	nw, err := NewNetWriter("unix", path, fastBackoff)
+	require.NoError(t, err)
+	defer nw.Close()
+
+	_, err = nw.Write([]byte("local\n"))
+	require.NoError(t, err)
+
+	conn, err := ln.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "local\n", string(buf[:n]))
+}
@@ -0,0 +1,10 @@
+//go:build !darwin && !linux && !freebsd && !netbsd && !openbsd && !dragonfly && !solaris && !windows
+
+package trifle
+
+import "errors"
+
+// openPipe fails on platforms without named pipes.
+func openPipe(path string) (sinkConn, error) {
+	return nil, errors.New("trifle: named pipes are not supported on this platform")
+}
@@ -0,0 +1,21 @@
+//go:build darwin || linux || freebsd || netbsd || openbsd || dragonfly || solaris
+
+package trifle
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// openPipe opens the FIFO at path for writing. Without O_NONBLOCK the open
+// would wait for a reader; with it, it fails while there is none, which
+// the NetWriter treats like a refused connection. The file is then
+// pollable, so write deadlines apply.
+func openPipe(path string) (sinkConn, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,45 @@
+//go:build darwin || linux || freebsd || netbsd || openbsd || dragonfly || solaris
+
+package trifle
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestNetWriterFIFO(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.fifo")
+	require.NoError(t, unix.Mkfifo(path, 0o600))
+
+	nw, err := NewNetWriter("pipe", path, fastBackoff)
+	require.NoError(t, err)
+	defer nw.Close()
+
+	// Nothing reads from the FIFO yet, so this is kept.
+	_, err = nw.Write([]byte("before the reader\n"))
+	require.NoError(t, err)
+	assert.False(t, nw.Connected())
+
+	f, err := os.OpenFile(path, os.O_RDONLY|unix.O_NONBLOCK, 0)
+	require.NoError(t, err)
+	defer f.Close()
+
+	require.Eventually(t, nw.Connected, time.Second, time.Millisecond)
+	_, err = nw.Write([]byte("after\n"))
+	require.NoError(t, err)
+
+	require.NoError(t, f.SetReadDeadline(time.Now().Add(time.Second)))
+	br := bufio.NewReader(f)
+	for _, want := range []string{"before the reader\n", "after\n"} {
+		line, err := br.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, want, line)
+	}
+}
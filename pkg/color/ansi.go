@@ -0,0 +1,142 @@
+package color
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Strip returns s with every escape sequence and control character other
+// than tab and newline removed, leaving only the text a terminal would display.
+func Strip(s string) string {
+	out, _ := sanitize(s, false)
+	return out
+}
+
+// SanitizeSGR returns s with only its SGR sequences (colors and text
+// attributes) kept. Sequences that move the cursor, clear the screen, set
+// the window title and the like are removed, as are stray control
+// characters. If any styling is kept, a reset is appended so it does not
+// leak into what follows. When NoColor is set the result is the same as
+// Strip.
+func SanitizeSGR(s string) string {
+	if NoColor {
+		return Strip(s)
+	}
+
+	out, styled := sanitize(s, true)
+	if styled && !strings.HasSuffix(out, escape+"[0m") {
+		out += escape + "[0m"
+	}
+	return out
+}
+
+// Raw returns s, which may contain its own escape sequences, as Styled.
+// Only SGR sequences are kept when rendering, and they don't count towards
+// its width. Use it to embed output of other programs that style their
+// text themselves.
+func Raw(s string) Styled {
+	return Styled{text: s, raw: true}
+}
+
+// sanitize removes escape sequences and control characters from s, except
+// for SGR sequences when keepSGR is set. It reports whether any SGR
+// sequence was kept.
+func sanitize(s string, keepSGR bool) (string, bool) {
+	if !hasControl(s) {
+		return s, false
+	}
+
+	var (
+		b      strings.Builder
+		styled bool
+	)
+	b.Grow(len(s))
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case c == 0x1b:
+			end, sgr := escapeEnd(s, i)
+			if sgr && keepSGR {
+				b.WriteString(s[i:end])
+				styled = true
+			}
+			i = end
+		case c == '\t' || c == '\n':
+			b.WriteByte(c)
+			i++
+		case c < 0x20 || c == 0x7f:
+			i++
+		case c < utf8.RuneSelf:
+			b.WriteByte(c)
+			i++
+		default:
+			r, size := utf8.DecodeRuneInString(s[i:])
+			// C1 controls, including the single byte CSI
+			if r < 0x80 || r > 0x9f {
+				b.WriteString(s[i : i+size])
+			}
+			i += size
+		}
+	}
+
+	return b.String(), styled
+}
+
+func hasControl(s string) bool {
+	for _, r := range s {
+		if r != '\t' && r != '\n' && (r < 0x20 || (r >= 0x7f && r <= 0x9f)) {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeEnd returns the index just past the escape sequence starting at
+// s[i], which is ESC, and whether it is an SGR sequence.
+func escapeEnd(s string, i int) (end int, sgr bool) {
+	if i+1 >= len(s) {
+		return len(s), false
+	}
+
+	switch s[i+1] {
+	case '[':
+		// CSI: parameter bytes, intermediate bytes, one final byte
+		j := i + 2
+		params := true
+		for j < len(s) && s[j] >= 0x30 && s[j] <= 0x3f {
+			if (s[j] < '0' || s[j] > '9') && s[j] != ';' && s[j] != ':' {
+				params = false
+			}
+			j++
+		}
+		start := j
+		for j < len(s) && s[j] >= 0x20 && s[j] <= 0x2f {
+			j++
+		}
+		if j >= len(s) {
+			return len(s), false
+		}
+		return j + 1, s[j] == 'm' && params && j == start
+	case ']', 'P', '_', '^', 'X':
+		// string sequences, terminated by BEL or ST
+		for j := i + 2; j < len(s); j++ {
+			if s[j] == 0x07 {
+				return j + 1, false
+			}
+			if s[j] == 0x1b && j+1 < len(s) && s[j+1] == '\\' {
+				return j + 2, false
+			}
+		}
+		return len(s), false
+	default:
+		return i + 2, false
+	}
+}
+
+// rawWidth returns the on-screen width of a string that may contain escape
+// sequences.
+func rawWidth(s string) int {
+	return StringWidth(Strip(s))
+}
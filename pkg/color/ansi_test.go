@@ -0,0 +1,67 @@
+package color
+
+import "testing"
+
+func TestStrip(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"\x1b[1;31merror\x1b[0m: bad", "error: bad"},
+		{"\x1b]0;title\x07text", "text"},
+		{"\x1b]8;;http://x\x1b\\link\x1b]8;;\x1b\\", "link"},
+		{"a\x1b[2Jb\x1b[Hc", "abc"},
+		{"tab\there\r\x00\nnext", "tab\there\nnext"},
+		{"trailing\x1b[", "trailing"},
+		{"c1\u009b31mx", "c131mx"},
+	}
+
+	for _, tt := range tests {
+		if got := Strip(tt.in); got != tt.want {
+			t.Errorf("Strip(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeSGR(t *testing.T) {
+	defer func(noColor bool) { NoColor = noColor }(NoColor)
+	NoColor = false
+
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"\x1b[1;31merror\x1b[0m: bad", "\x1b[1;31merror\x1b[0m: bad\x1b[0m"},
+		{"\x1b[32mok\x1b[0m", "\x1b[32mok\x1b[0m"},
+		{"\x1b[38:5:196mred", "\x1b[38:5:196mred\x1b[0m"},
+		{"\x1b[2J\x1b[?25lhidden cursor", "hidden cursor"},
+		{"\x1b]0;title\x07text", "text"},
+	}
+
+	for _, tt := range tests {
+		if got := SanitizeSGR(tt.in); got != tt.want {
+			t.Errorf("SanitizeSGR(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	NoColor = true
+	if got := SanitizeSGR("\x1b[31mred\x1b[0m"); got != "red" {
+		t.Errorf("expected escapes to be stripped without color, got %q", got)
+	}
+}
+
+func TestRaw(t *testing.T) {
+	defer func(noColor bool) { NoColor = noColor }(NoColor)
+	NoColor = false
+
+	s := Raw("\x1b[31m日本\x1b[0m\x1b[K")
+	if s.Text() != "日本" {
+		t.Errorf("unexpected text %q", s.Text())
+	}
+	if s.Width() != 4 {
+		t.Errorf("expected width 4, got %d", s.Width())
+	}
+	if s.String() != "\x1b[31m日本\x1b[0m" {
+		t.Errorf("unexpected rendering %q", s.String())
+	}
+}
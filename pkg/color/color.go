@@ -421,26 +421,50 @@ func (c *Color) wrap(s string) string {
 		return s
 	}
 
-	return c.format() + s + c.unformat()
+	return string(c.appendWrapped(make([]byte, 0, len(s)+16), s))
+}
+
+// appendWrapped appends s wrapped in the escape codes of c to dst.
+func (c *Color) appendWrapped(dst []byte, s string) []byte {
+	dst = c.appendFormat(dst)
+	dst = append(dst, s...)
+	return c.appendUnformat(dst)
 }
 
 func (c *Color) format() string {
-	return fmt.Sprintf("%s[%sm", escape, c.sequence())
+	return string(c.appendFormat(nil))
+}
+
+func (c *Color) appendFormat(dst []byte) []byte {
+	dst = append(dst, escape+"["...)
+	for i, v := range c.params {
+		if i > 0 {
+			dst = append(dst, ';')
+		}
+		dst = strconv.AppendInt(dst, int64(v), 10)
+	}
+	return append(dst, 'm')
 }
 
 func (c *Color) unformat() string {
-	//return fmt.Sprintf("%s[%dm", escape, Reset)
-	//for each element in sequence let's use the specific reset escape, or the generic one if not found
-	format := make([]string, len(c.params))
+	return string(c.appendUnformat(nil))
+}
+
+// appendUnformat appends the sequence that ends c: for each attribute the
+// specific reset, or the generic one if there is none.
+func (c *Color) appendUnformat(dst []byte) []byte {
+	dst = append(dst, escape+"["...)
 	for i, v := range c.params {
-		format[i] = strconv.Itoa(int(Reset))
+		if i > 0 {
+			dst = append(dst, ';')
+		}
 		ra, ok := mapResetAttributes[v]
-		if ok {
-			format[i] = strconv.Itoa(int(ra))
+		if !ok {
+			ra = Reset
 		}
+		dst = strconv.AppendInt(dst, int64(ra), 10)
 	}
-
-	return fmt.Sprintf("%s[%sm", escape, strings.Join(format, ";"))
+	return append(dst, 'm')
 }
 
 // DisableColor disables the color output. Useful to not change any existing
@@ -1,46 +1,59 @@
 package color
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/lucasb-eyer/go-colorful"
 	"github.com/muesli/termenv"
-	"golang.org/x/sys/unix"
 )
 
+// DefaultQueryTimeout bounds how long Background and LiveFaint wait for the
+// terminal to answer. Terminals that don't support the query normally answer
+// the trailing cursor position request right away, but some never answer at
+// all or stop halfway.
+const DefaultQueryTimeout = time.Second
+
+// Background returns the background color of the terminal attached to
+// os.Stdout in #rrggbb form, or an empty string if it can't be determined
+// within DefaultQueryTimeout.
 func Background() string {
-	to := termenv.NewOutput(os.Stdout)
-	s, err := termStatusReport(to, 11)
-	if err != nil {
-		return ""
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultQueryTimeout)
+	defer cancel()
 
-	bgc, err := xTermColor(s)
-	if err != nil {
+	return BackgroundContext(ctx)
+}
+
+// BackgroundContext is like Background but gives up when ctx is done.
+func BackgroundContext(ctx context.Context) string {
+	rgb, ok := queryBackground(ctx)
+	if !ok {
 		return ""
 	}
 
-	rgb := termenv.ConvertToRGB(bgc)
-
 	return rgb.Hex()
 }
 
+// LiveFaint returns a color in #rrggbb form that stands out only slightly
+// from the terminal background, or an empty string if the background can't
+// be determined within DefaultQueryTimeout.
 func LiveFaint() string {
-	to := termenv.NewOutput(os.Stdout)
-	sr, err := termStatusReport(to, 11)
-	if err != nil {
-		return ""
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultQueryTimeout)
+	defer cancel()
 
-	bgc, err := xTermColor(sr)
-	if err != nil {
+	return LiveFaintContext(ctx)
+}
+
+// LiveFaintContext is like LiveFaint but gives up when ctx is done.
+func LiveFaintContext(ctx context.Context) string {
+	rgb, ok := queryBackground(ctx)
+	if !ok {
 		return ""
 	}
 
-	rgb := termenv.ConvertToRGB(bgc)
-
 	h, s, l := rgb.Hsl()
 
 	if l < 0.5 {
@@ -52,67 +65,19 @@ func LiveFaint() string {
 	return colorful.Hsl(h, s, l).Hex()
 }
 
-// Pulled over from termenv because termenv disables reading
-// doing termStatusReport on tmux, even though tmux supports it.
-
-func isForeground(fd int) bool {
-	pgrp, err := unix.IoctlGetInt(fd, unix.TIOCGPGRP)
-	if err != nil {
-		return false
-	}
-
-	return pgrp == unix.Getpgrp()
-}
-
-func termStatusReport(o *termenv.Output, sequence int) (string, error) {
-	tty := o.TTY()
-	if tty == nil {
-		return "", termenv.ErrStatusReport
-	}
-	fd := int(tty.Fd())
-	// if in background, we can't control the terminal
-	if !isForeground(fd) {
-		return "", termenv.ErrStatusReport
-	}
-
-	t, err := unix.IoctlGetTermios(fd, tcgetattr)
-	if err != nil {
-		return "", fmt.Errorf("%s: %s", termenv.ErrStatusReport, err)
-	}
-	defer unix.IoctlSetTermios(fd, tcsetattr, t) //nolint:errcheck
-
-	noecho := *t
-	noecho.Lflag = noecho.Lflag &^ unix.ECHO
-	noecho.Lflag = noecho.Lflag &^ unix.ICANON
-	if err := unix.IoctlSetTermios(fd, tcsetattr, &noecho); err != nil {
-		return "", fmt.Errorf("%s: %s", termenv.ErrStatusReport, err)
-	}
-
-	// first, send OSC query, which is ignored by terminal which do not support it
-	fmt.Fprintf(tty, termenv.OSC+"%d;?"+termenv.ST, sequence)
-
-	// then, query cursor position, should be supported by all terminals
-	fmt.Fprintf(tty, termenv.CSI+"6n")
-
-	// read the next response
-	res, isOSC, err := readNextResponse(o)
+func queryBackground(ctx context.Context) (colorful.Color, bool) {
+	to := termenv.NewOutput(os.Stdout)
+	s, err := termStatusReport(ctx, to, 11)
 	if err != nil {
-		return "", fmt.Errorf("%s: %s", termenv.ErrStatusReport, err)
-	}
-
-	// if this is not OSC response, then the terminal does not support it
-	if !isOSC {
-		return "", termenv.ErrStatusReport
+		return colorful.Color{}, false
 	}
 
-	// read the cursor query response next and discard the result
-	_, _, err = readNextResponse(o)
+	bgc, err := xTermColor(s)
 	if err != nil {
-		return "", err
+		return colorful.Color{}, false
 	}
 
-	// fmt.Println("Rcvd", res[1:])
-	return res, nil
+	return termenv.ConvertToRGB(bgc), true
 }
 
 func xTermColor(s string) (termenv.RGBColor, error) {
@@ -154,7 +119,11 @@ func xTermColor(s string) (termenv.RGBColor, error) {
 	return termenv.RGBColor(hex), nil
 }
 
-func readNextByte(o *termenv.Output) (byte, error) {
+func readNextByte(ctx context.Context, o *termenv.Output) (byte, error) {
+	if err := waitForInput(ctx, o.TTY()); err != nil {
+		return 0, err
+	}
+
 	var b [1]byte
 	n, err := o.TTY().Read(b[:])
 	if err != nil {
@@ -171,15 +140,15 @@ func readNextByte(o *termenv.Output) (byte, error) {
 // readNextResponse reads either an OSC response or a cursor position response:
 //   - OSC response: "\x1b]11;rgb:1111/1111/1111\x1b\\"
 //   - cursor position response: "\x1b[42;1R"
-func readNextResponse(o *termenv.Output) (response string, isOSC bool, err error) {
-	start, err := readNextByte(o)
+func readNextResponse(ctx context.Context, o *termenv.Output) (response string, isOSC bool, err error) {
+	start, err := readNextByte(ctx, o)
 	if err != nil {
 		return "", false, err
 	}
 
 	// first byte must be ESC
 	for start != termenv.ESC {
-		start, err = readNextByte(o)
+		start, err = readNextByte(ctx, o)
 		if err != nil {
 			return "", false, err
 		}
@@ -188,7 +157,7 @@ func readNextResponse(o *termenv.Output) (response string, isOSC bool, err error
 	response += string(start)
 
 	// next byte is either '[' (cursor position response) or ']' (OSC response)
-	tpe, err := readNextByte(o)
+	tpe, err := readNextByte(ctx, o)
 	if err != nil {
 		return "", false, err
 	}
@@ -206,7 +175,7 @@ func readNextResponse(o *termenv.Output) (response string, isOSC bool, err error
 	}
 
 	for {
-		b, err := readNextByte(o)
+		b, err := readNextByte(ctx, o)
 		if err != nil {
 			return "", false, err
 		}
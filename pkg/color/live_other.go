@@ -0,0 +1,21 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+package color
+
+import (
+	"context"
+
+	"github.com/muesli/termenv"
+)
+
+// termStatusReport is not supported on platforms without termios, such as
+// Windows, Plan 9 and js/wasm, so live color queries always fail there.
+func termStatusReport(ctx context.Context, o *termenv.Output, sequence int) (string, error) {
+	return "", termenv.ErrStatusReport
+}
+
+// waitForInput returns immediately; reads are never attempted on these
+// platforms.
+func waitForInput(ctx context.Context, tty termenv.File) error {
+	return ctx.Err()
+}
@@ -0,0 +1,117 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package color
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/muesli/termenv"
+	"golang.org/x/sys/unix"
+)
+
+// Pulled over from termenv because termenv disables reading
+// doing termStatusReport on tmux, even though tmux supports it
+// through passthrough.
+
+func isForeground(fd int) bool {
+	pgrp, err := unix.IoctlGetInt(fd, unix.TIOCGPGRP)
+	if err != nil {
+		return false
+	}
+
+	return pgrp == unix.Getpgrp()
+}
+
+func termStatusReport(ctx context.Context, o *termenv.Output, sequence int) (string, error) {
+	tty := o.TTY()
+	if tty == nil {
+		return "", termenv.ErrStatusReport
+	}
+	fd := int(tty.Fd())
+	// if in background, we can't control the terminal
+	if !isForeground(fd) {
+		return "", termenv.ErrStatusReport
+	}
+
+	t, err := unix.IoctlGetTermios(fd, tcgetattr)
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", termenv.ErrStatusReport, err)
+	}
+	defer unix.IoctlSetTermios(fd, tcsetattr, t) //nolint:errcheck
+
+	noecho := *t
+	noecho.Lflag = noecho.Lflag &^ unix.ECHO
+	noecho.Lflag = noecho.Lflag &^ unix.ICANON
+	if err := unix.IoctlSetTermios(fd, tcsetattr, &noecho); err != nil {
+		return "", fmt.Errorf("%s: %s", termenv.ErrStatusReport, err)
+	}
+
+	// inside tmux or screen both queries are passed through to the outer
+	// terminal, so that it answers them in order
+	mux := detectMultiplexer()
+
+	// first, send OSC query, which is ignored by terminal which do not support it
+	fmt.Fprint(tty, mux.wrap(fmt.Sprintf(termenv.OSC+"%d;?"+termenv.ST, sequence)))
+
+	// then, query cursor position, should be supported by all terminals
+	fmt.Fprint(tty, mux.wrap(termenv.CSI+"6n"))
+
+	// read the next response
+	res, isOSC, err := readNextResponse(ctx, o)
+	if err != nil {
+		return "", fmt.Errorf("%s: %s", termenv.ErrStatusReport, err)
+	}
+
+	// if this is not OSC response, then the terminal does not support it
+	if !isOSC {
+		return "", termenv.ErrStatusReport
+	}
+
+	// read the cursor query response next and discard the result
+	_, _, err = readNextResponse(ctx, o)
+	if err != nil {
+		return "", err
+	}
+
+	// fmt.Println("Rcvd", res[1:])
+	return res, nil
+}
+
+// pollInterval is how often waitForInput rechecks ctx while waiting, so
+// that cancellation is noticed even when ctx has no deadline.
+const pollInterval = 50 * time.Millisecond
+
+// waitForInput blocks until tty has input to read, or ctx is done. This
+// keeps a terminal that never finishes its answer from blocking the read
+// forever.
+func waitForInput(ctx context.Context, tty termenv.File) error {
+	fds := []unix.PollFd{{Fd: int32(tty.Fd()), Events: unix.POLLIN}}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		timeout := pollInterval
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = min(timeout, time.Until(deadline))
+		}
+		if timeout <= 0 {
+			return context.DeadlineExceeded
+		}
+
+		n, err := unix.Poll(fds, int(max(timeout.Milliseconds(), 1)))
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return nil
+		}
+	}
+}
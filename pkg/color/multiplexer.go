@@ -0,0 +1,54 @@
+package color
+
+import (
+	"os"
+	"strings"
+)
+
+// multiplexer identifies a terminal multiplexer sitting between the
+// program and the real terminal.
+type multiplexer int
+
+const (
+	noMultiplexer multiplexer = iota
+	tmuxMultiplexer
+	screenMultiplexer
+)
+
+// detectMultiplexer reports which terminal multiplexer, if any, the
+// process is running under.
+func detectMultiplexer() multiplexer {
+	// TERM is checked as well, since it survives ssh sessions started
+	// from inside the multiplexer while TMUX and STY don't.
+	if os.Getenv("TMUX") != "" || strings.HasPrefix(os.Getenv("TERM"), "tmux") {
+		return tmuxMultiplexer
+	}
+	if os.Getenv("STY") != "" || strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		return screenMultiplexer
+	}
+	return noMultiplexer
+}
+
+// wrap encloses an escape sequence in the multiplexer's DCS passthrough, so
+// that it reaches the outer terminal instead of being swallowed. The answer
+// from the outer terminal comes back unwrapped.
+//
+// tmux 3.3 and later only forward passthrough sequences when the
+// allow-passthrough option is on.
+func (m multiplexer) wrap(seq string) string {
+	switch m {
+	case tmuxMultiplexer:
+		// tmux requires every ESC inside the passthrough to be doubled.
+		return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	case screenMultiplexer:
+		// screen ends the passthrough at the first ST, so a sequence
+		// terminated by ST is switched to BEL first.
+		seq = strings.TrimSuffix(seq, "\x1b\\")
+		if strings.HasPrefix(seq, "\x1b]") && !strings.HasSuffix(seq, "\a") {
+			seq += "\a"
+		}
+		return "\x1bP" + seq + "\x1b\\"
+	default:
+		return seq
+	}
+}
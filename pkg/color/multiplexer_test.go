@@ -0,0 +1,50 @@
+package color
+
+import "testing"
+
+func TestMultiplexerWrap(t *testing.T) {
+	tests := []struct {
+		name string
+		mux  multiplexer
+		seq  string
+		want string
+	}{
+		{"none", noMultiplexer, "\x1b]11;?\x1b\\", "\x1b]11;?\x1b\\"},
+		{"tmux", tmuxMultiplexer, "\x1b]11;?\x1b\\", "\x1bPtmux;\x1b\x1b]11;?\x1b\x1b\\\x1b\\"},
+		{"tmux csi", tmuxMultiplexer, "\x1b[6n", "\x1bPtmux;\x1b\x1b[6n\x1b\\"},
+		{"screen", screenMultiplexer, "\x1b]11;?\x1b\\", "\x1bP\x1b]11;?\a\x1b\\"},
+		{"screen csi", screenMultiplexer, "\x1b[6n", "\x1bP\x1b[6n\x1b\\"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.mux.wrap(tt.seq); got != tt.want {
+				t.Errorf("wrap(%q) = %q, want %q", tt.seq, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectMultiplexer(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1234,0")
+	t.Setenv("STY", "")
+	t.Setenv("TERM", "screen-256color")
+	if got := detectMultiplexer(); got != tmuxMultiplexer {
+		t.Errorf("expected tmux, got %v", got)
+	}
+
+	t.Setenv("TMUX", "")
+	if got := detectMultiplexer(); got != screenMultiplexer {
+		t.Errorf("expected screen, got %v", got)
+	}
+
+	t.Setenv("TERM", "tmux-256color")
+	if got := detectMultiplexer(); got != tmuxMultiplexer {
+		t.Errorf("expected tmux from TERM, got %v", got)
+	}
+
+	t.Setenv("TERM", "xterm-256color")
+	if got := detectMultiplexer(); got != noMultiplexer {
+		t.Errorf("expected none, got %v", got)
+	}
+}
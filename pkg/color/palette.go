@@ -0,0 +1,115 @@
+package color
+
+import "hash/fnv"
+
+// Palette is an ordered set of foreground colors that are easy to tell
+// apart, for coloring things such as module names or log sources.
+type Palette struct {
+	// Name describes the palette, e.g. "colorblind-safe".
+	Name string
+
+	colors [][]Attribute
+}
+
+// NewPalette returns a palette made of the given colors, in order.
+func NewPalette(name string, colors ...*Color) Palette {
+	p := Palette{Name: name}
+	for _, c := range colors {
+		p.colors = append(p.colors, append([]Attribute(nil), c.params...))
+	}
+	return p
+}
+
+func rgb(r, g, b int) []Attribute {
+	return []Attribute{foreground, 2, Attribute(r), Attribute(g), Attribute(b)}
+}
+
+var (
+	// PaletteDefault uses the basic ANSI colors, which every color terminal
+	// supports and which follow the user's terminal theme.
+	PaletteDefault = Palette{
+		Name: "default",
+		colors: [][]Attribute{
+			{FgHiBlue}, {FgHiGreen}, {FgHiMagenta}, {FgHiCyan},
+			{FgBlue}, {FgGreen}, {FgMagenta}, {FgCyan},
+		},
+	}
+
+	// PaletteColorblindSafe is the Okabe-Ito palette, whose colors remain
+	// distinguishable under the common forms of color vision deficiency.
+	// It requires a terminal with 24-bit color support.
+	PaletteColorblindSafe = Palette{
+		Name: "colorblind-safe",
+		colors: [][]Attribute{
+			rgb(0xe6, 0x9f, 0x00), // orange
+			rgb(0x56, 0xb4, 0xe9), // sky blue
+			rgb(0x00, 0x9e, 0x73), // bluish green
+			rgb(0xf0, 0xe4, 0x42), // yellow
+			rgb(0x00, 0x72, 0xb2), // blue
+			rgb(0xd5, 0x5e, 0x00), // vermillion
+			rgb(0xcc, 0x79, 0xa7), // reddish purple
+		},
+	}
+
+	// PaletteHighContrast uses bold, high intensity colors that stay
+	// readable on dark backgrounds and low quality displays.
+	PaletteHighContrast = Palette{
+		Name: "high-contrast",
+		colors: [][]Attribute{
+			{FgHiWhite, Bold}, {FgHiYellow, Bold}, {FgHiCyan, Bold},
+			{FgHiGreen, Bold}, {FgHiMagenta, Bold},
+		},
+	}
+)
+
+// Len returns the number of distinct colors in p.
+func (p Palette) Len() int {
+	return len(p.colors)
+}
+
+// At returns the i'th color of p. Indexes beyond the end of the palette
+// wrap around, and each time they do the color is underlined, so that up to
+// twice Len colors remain distinguishable. Negative indexes wrap around the
+// other way: At(-1) is At(2*Len-1).
+func (p Palette) At(i int) *Color {
+	n := len(p.colors)
+	if n == 0 {
+		return New()
+	}
+
+	// The remainder of i is made non-negative, and the number of wraps
+	// rounded down to match, without negating i, which overflows for
+	// math.MinInt.
+	index, wraps := ((i%n)+n)%n, i/n
+	if i%n < 0 {
+		wraps--
+	}
+
+	c := New(p.colors[index]...)
+	if wraps&1 == 1 {
+		c.Add(Underline)
+	}
+	return c
+}
+
+// Pick returns n colors from p that are as distinguishable as the palette
+// allows.
+func (p Palette) Pick(n int) []*Color {
+	colors := make([]*Color, n)
+	for i := range colors {
+		colors[i] = p.At(i)
+	}
+	return colors
+}
+
+// For returns the color of p assigned to name. The same name always gets
+// the same color, which keeps a module's color stable across runs.
+func (p Palette) For(name string) *Color {
+	if len(p.colors) == 0 {
+		return New()
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return p.At(int(h.Sum32() % uint32(len(p.colors))))
+}
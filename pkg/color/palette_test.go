@@ -0,0 +1,71 @@
+package color
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPalettePick(t *testing.T) {
+	for _, p := range []Palette{PaletteDefault, PaletteColorblindSafe, PaletteHighContrast} {
+		colors := p.Pick(p.Len() * 2)
+		for i, a := range colors {
+			for j, b := range colors {
+				if i != j && a.Equals(b) {
+					t.Errorf("%s: colors %d and %d are equal", p.Name, i, j)
+				}
+			}
+		}
+	}
+}
+
+func TestPaletteAtDoesNotShareState(t *testing.T) {
+	c := PaletteDefault.At(0)
+	c.Add(Bold)
+
+	if PaletteDefault.At(0).Equals(c) {
+		t.Error("modifying a returned color changed the palette")
+	}
+}
+
+func TestPaletteAtNegative(t *testing.T) {
+	p := NewPalette("custom", New(FgRed), New(FgGreen), New(FgBlue))
+
+	tests := []struct {
+		i, same int
+	}{
+		{-1, 5},
+		{-3, 3},
+		{-4, 2},
+		{-6, 0},
+		{math.MinInt, math.MinInt % 6},
+		{math.MaxInt, math.MaxInt % 6},
+	}
+	for _, tt := range tests {
+		if got, want := p.At(tt.i), p.At((tt.same+6)%6); !got.Equals(want) {
+			t.Errorf("At(%d) = %q, want At(%d) = %q", tt.i, got.sequence(), (tt.same+6)%6, want.sequence())
+		}
+	}
+}
+
+func TestPaletteFor(t *testing.T) {
+	p := NewPalette("custom", New(FgRed), New(FgGreen), New(FgBlue))
+	if p.Len() != 3 {
+		t.Fatalf("expected 3 colors, got %d", p.Len())
+	}
+
+	if !p.For("database").Equals(p.For("database")) {
+		t.Error("expected the same name to get the same color")
+	}
+
+	seen := map[string]bool{}
+	for _, name := range []string{"api", "auth", "database", "cache", "queue", "worker"} {
+		seen[p.For(name).sequence()] = true
+	}
+	if len(seen) < 2 {
+		t.Error("expected names to be spread over the palette")
+	}
+
+	if got := (Palette{}).For("x"); len(got.params) != 0 {
+		t.Errorf("expected empty color from empty palette, got %v", got.params)
+	}
+}
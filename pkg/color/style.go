@@ -0,0 +1,124 @@
+package color
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/rivo/uniseg"
+)
+
+// Bold adds the bold attribute to c. Like Add, it modifies c and returns it
+// so calls can be chained: New(FgRed).Bold().Underline().
+func (c *Color) Bold() *Color { return c.Add(Bold) }
+
+// Faint adds the faint attribute to c and returns it.
+func (c *Color) Faint() *Color { return c.Add(Faint) }
+
+// Italic adds the italic attribute to c and returns it.
+func (c *Color) Italic() *Color { return c.Add(Italic) }
+
+// Underline adds the underline attribute to c and returns it.
+func (c *Color) Underline() *Color { return c.Add(Underline) }
+
+// Strike adds the crossed-out attribute to c and returns it.
+func (c *Color) Strike() *Color { return c.Add(CrossedOut) }
+
+// Styled is a piece of text together with the color it is rendered in.
+// Unlike a string returned by Sprint, it knows the width the text occupies
+// on screen, which the escape codes around it don't contribute to.
+type Styled struct {
+	text  string
+	color *Color
+	raw   bool // text carries its own escape sequences, see Raw
+}
+
+// Styled returns s styled with c.
+func (c *Color) Styled(s string) Styled {
+	return Styled{text: s, color: c}
+}
+
+// Styledf formats according to a format specifier and returns the result
+// styled with c.
+func (c *Color) Styledf(format string, a ...interface{}) Styled {
+	return Styled{text: fmt.Sprintf(format, a...), color: c}
+}
+
+// Plain returns s without any styling.
+func Plain(s string) Styled {
+	return Styled{text: s}
+}
+
+// Text returns the text of s without escape codes.
+func (s Styled) Text() string {
+	if s.raw {
+		return Strip(s.text)
+	}
+	return s.text
+}
+
+// Width returns the number of terminal cells s occupies, accounting for
+// wide characters such as CJK and emoji.
+func (s Styled) Width() int {
+	if s.raw {
+		return rawWidth(s.text)
+	}
+	return StringWidth(s.text)
+}
+
+// StringWidth returns the number of terminal cells s occupies. It is
+// uniseg.StringWidth with a fast path for printable ASCII, which is what
+// most log output consists of.
+func StringWidth(s string) int {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x20 || c >= 0x7f {
+			return uniseg.StringWidth(s)
+		}
+	}
+	return len(s)
+}
+
+// String returns the text of s wrapped in its escape codes, unless color
+// output is disabled.
+func (s Styled) String() string {
+	if s.raw {
+		return SanitizeSGR(s.text)
+	}
+	if s.color == nil {
+		return s.text
+	}
+	return s.color.wrap(s.text)
+}
+
+// AppendTo appends the text of s wrapped in its escape codes to dst, like
+// String but without allocating a string.
+func (s Styled) AppendTo(dst []byte) []byte {
+	if s.raw {
+		return append(dst, SanitizeSGR(s.text)...)
+	}
+	if s.color == nil || s.color.isNoColorSet() {
+		return append(dst, s.text...)
+	}
+	return s.color.appendWrapped(dst, s.text)
+}
+
+// Fprint writes the styled parts to w, one after another, and returns the
+// total number of bytes written.
+func Fprint(w io.Writer, parts ...Styled) (n int, err error) {
+	for _, p := range parts {
+		m, err := io.WriteString(w, p.String())
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Width returns the combined on-screen width of the styled parts.
+func Width(parts ...Styled) int {
+	w := 0
+	for _, p := range parts {
+		w += p.Width()
+	}
+	return w
+}
@@ -0,0 +1,53 @@
+package color
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestChainedStyles(t *testing.T) {
+	c := New(FgRed).Bold().Italic().Underline().Strike().Faint()
+	want := New(FgRed, Bold, Italic, Underline, CrossedOut, Faint)
+
+	if !c.Equals(want) {
+		t.Errorf("expected %q, got %q", want.sequence(), c.sequence())
+	}
+}
+
+func TestStyled(t *testing.T) {
+	defer func(noColor bool) { NoColor = noColor }(NoColor)
+	NoColor = false
+
+	s := New(FgGreen).Styledf("%d items", 3)
+	if s.Text() != "3 items" {
+		t.Errorf("unexpected text %q", s.Text())
+	}
+	if s.String() != "\x1b[32m3 items\x1b[0m" {
+		t.Errorf("unexpected rendering %q", s.String())
+	}
+	if s.Width() != 7 {
+		t.Errorf("expected width 7, got %d", s.Width())
+	}
+
+	wide := Plain("日本")
+	if wide.Width() != 4 {
+		t.Errorf("expected wide characters to count double, got %d", wide.Width())
+	}
+	if Width(s, Plain(" "), wide) != 12 {
+		t.Errorf("unexpected combined width %d", Width(s, Plain(" "), wide))
+	}
+
+	var buf bytes.Buffer
+	n, err := Fprint(&buf, Plain("["), New(FgRed).Styled("x"), Plain("]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "[\x1b[31mx\x1b[0m]" || n != buf.Len() {
+		t.Errorf("unexpected output %q (%d bytes)", buf.String(), n)
+	}
+
+	NoColor = true
+	if s.String() != "3 items" {
+		t.Errorf("expected plain text with color disabled, got %q", s.String())
+	}
+}
@@ -0,0 +1,212 @@
+package expr
+
+import (
+	"cmp"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The keys standing for the parts of a record rather than an attribute.
+const (
+	LevelKey   = "level"
+	MessageKey = "msg"
+	ModuleKey  = "module"
+)
+
+// Env is what an expression is evaluated against: a record and what the
+// logger that handles it adds.
+type Env struct {
+	Record slog.Record
+
+	// Module is the module the record is logged by.
+	Module string
+
+	// Attrs are the attributes added to the logger, with their keys
+	// qualified by the groups they are in.
+	Attrs []slog.Attr
+
+	// Group is the group the record's attributes are in, its components
+	// joined by dots, or "" for none.
+	Group string
+}
+
+// Match reports whether the record of env matches e.
+func (e *Expr) Match(env *Env) bool {
+	return e.root.eval(env)
+}
+
+type node interface {
+	eval(env *Env) bool
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(env *Env) bool { return n.left.eval(env) || n.right.eval(env) }
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(env *Env) bool { return n.left.eval(env) && n.right.eval(env) }
+
+type notNode struct{ n node }
+
+func (n notNode) eval(env *Env) bool { return !n.n.eval(env) }
+
+type existsNode struct{ key string }
+
+func (n existsNode) eval(env *Env) bool {
+	switch n.key {
+	case LevelKey:
+		return true
+	case MessageKey:
+		return env.Record.Message != ""
+	case ModuleKey:
+		return env.Module != ""
+	}
+	_, ok := env.lookup(n.key)
+	return ok
+}
+
+type compareNode struct {
+	key, op, value string
+
+	re    *regexp.Regexp
+	level slog.Level
+	num   float64
+	isNum bool
+	dur   time.Duration
+	isDur bool
+}
+
+func (n compareNode) eval(env *Env) bool {
+	var v slog.Value
+
+	switch n.key {
+	case LevelKey:
+		if n.re != nil {
+			return n.match(env.Record.Level.String())
+		}
+		return n.result(cmp.Compare(env.Record.Level, n.level))
+	case MessageKey:
+		v = slog.StringValue(env.Record.Message)
+	case ModuleKey:
+		v = slog.StringValue(env.Module)
+	default:
+		var ok bool
+		if v, ok = env.lookup(n.key); !ok {
+			return n.op == "!=" || n.op == "!~"
+		}
+	}
+
+	if n.re != nil {
+		return n.match(v.String())
+	}
+	return n.result(n.compare(v))
+}
+
+func (n compareNode) match(s string) bool {
+	return n.re.MatchString(s) == (n.op == "~")
+}
+
+// result turns the outcome of a comparison, as returned by cmp.Compare,
+// into the truth of the operator.
+func (n compareNode) result(c int) bool {
+	switch n.op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// compare compares v with the value of n: as durations or numbers when
+// both are, and as text otherwise.
+func (n compareNode) compare(v slog.Value) int {
+	switch v.Kind() {
+	case slog.KindDuration:
+		if n.isDur {
+			return cmp.Compare(v.Duration(), n.dur)
+		}
+	case slog.KindInt64:
+		if n.isNum {
+			return cmp.Compare(float64(v.Int64()), n.num)
+		}
+	case slog.KindUint64:
+		if n.isNum {
+			return cmp.Compare(float64(v.Uint64()), n.num)
+		}
+	case slog.KindFloat64:
+		if n.isNum {
+			return cmp.Compare(v.Float64(), n.num)
+		}
+	case slog.KindString:
+		// Attributes read from text logs carry numbers and durations as
+		// strings.
+		s := v.String()
+		if n.isNum {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				return cmp.Compare(f, n.num)
+			}
+		}
+		if n.isDur {
+			if d, err := time.ParseDuration(s); err == nil {
+				return cmp.Compare(d, n.dur)
+			}
+		}
+	}
+	return strings.Compare(v.String(), n.value)
+}
+
+// lookup finds the attribute named key among the attributes of the logger
+// and of the record, matching either its own key or its key qualified by
+// its groups.
+func (env *Env) lookup(key string) (slog.Value, bool) {
+	for _, a := range env.Attrs {
+		if v, ok := findAttr(key, "", a); ok {
+			return v, true
+		}
+	}
+
+	var (
+		found slog.Value
+		ok    bool
+	)
+	env.Record.Attrs(func(a slog.Attr) bool {
+		found, ok = findAttr(key, env.Group, a)
+		return !ok
+	})
+	return found, ok
+}
+
+func findAttr(key, group string, a slog.Attr) (slog.Value, bool) {
+	qualified := a.Key
+	if group != "" {
+		qualified = group + "." + a.Key
+	}
+
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		for _, ga := range v.Group() {
+			if v, ok := findAttr(key, qualified, ga); ok {
+				return v, true
+			}
+		}
+		return slog.Value{}, false
+	}
+
+	if a.Key == key || qualified == key {
+		return v, true
+	}
+	return slog.Value{}, false
+}
@@ -0,0 +1,336 @@
+// Package expr implements the small expression language trifle uses to
+// select records, for filtering and highlighting alike:
+//
+//	level >= warn && module = db
+//	msg ~ "timeout|refused" || status >= 500
+//	!(path = /healthz) and took > 250ms
+//
+// A comparison names a key, an operator and a value:
+//
+//	=, ==, !=      equal, not equal
+//	<, <=, >, >=   ordered comparison
+//	~, !~          match, or don't match, a regular expression
+//
+// A key on its own is true when the record has it. Comparisons combine
+// with && (or "and"), || (or "or"), ! (or "not") and parentheses.
+//
+// The keys level, msg and module stand for the record's level, message
+// and module. Levels compare by severity and are written as names, such
+// as "debug" or "warn", optionally with an offset, as in "info+2". Other
+// keys name attributes, either by their own key or qualified by their
+// groups, as in "req.method". An attribute that is missing never equals,
+// orders or matches anything, so != and !~ are true for it.
+//
+// Values are bare words, or quoted with double quotes when they contain
+// spaces, parentheses or operators. They compare as numbers or durations
+// when both sides are, and as text otherwise.
+package expr
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Expr is a parsed expression.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse parses s as an expression.
+func Parse(s string) (*Expr, error) {
+	p := &parser{lex: lexer{src: s}}
+	p.next()
+
+	root, err := p.or()
+	if err == nil && p.tok.kind != tokEOF {
+		err = p.errorf("unexpected %s", p.tok)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expr: %w", err)
+	}
+	return &Expr{src: s, root: root}, nil
+}
+
+// MustParse is like [Parse] but panics if s can't be parsed.
+func MustParse(s string) *Expr {
+	e, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// String returns the source of e.
+func (e *Expr) String() string {
+	return e.src
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+type lexer struct {
+	src string
+	pos int
+}
+
+// operators are the comparison operators, longest first so that "<="
+// isn't read as "<".
+var operators = []string{"==", "!=", "<=", ">=", "!~", "=", "<", ">", "~"}
+
+// wordBreak reports whether c ends a bare word.
+func wordBreak(c rune) bool {
+	return unicode.IsSpace(c) || strings.ContainsRune(`()!=<>~&|"`, c)
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && unicode.IsSpace(rune(l.src[l.pos])) {
+		l.pos++
+	}
+	start := l.pos
+	rest := l.src[l.pos:]
+
+	switch {
+	case rest == "":
+		return token{kind: tokEOF, pos: start}, nil
+	case strings.HasPrefix(rest, "&&"):
+		l.pos += 2
+		return token{kind: tokAnd, text: "&&", pos: start}, nil
+	case strings.HasPrefix(rest, "||"):
+		l.pos += 2
+		return token{kind: tokOr, text: "||", pos: start}, nil
+	case rest[0] == '(':
+		l.pos++
+		return token{kind: tokLParen, text: "(", pos: start}, nil
+	case rest[0] == ')':
+		l.pos++
+		return token{kind: tokRParen, text: ")", pos: start}, nil
+	case rest[0] == '"':
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return token{}, fmt.Errorf("unterminated string at offset %d", start)
+		}
+		l.pos += len(quoted)
+		s, _ := strconv.Unquote(quoted)
+		return token{kind: tokString, text: s, pos: start}, nil
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			l.pos += len(op)
+			return token{kind: tokOp, text: op, pos: start}, nil
+		}
+	}
+	if rest[0] == '!' {
+		l.pos++
+		return token{kind: tokNot, text: "!", pos: start}, nil
+	}
+
+	end := strings.IndexFunc(rest, wordBreak)
+	if end < 0 {
+		end = len(rest)
+	}
+	if end == 0 {
+		return token{}, fmt.Errorf("unexpected %q at offset %d", rest[0], start)
+	}
+	l.pos += end
+
+	word := rest[:end]
+	switch strings.ToLower(word) {
+	case "and":
+		return token{kind: tokAnd, text: word, pos: start}, nil
+	case "or":
+		return token{kind: tokOr, text: word, pos: start}, nil
+	case "not":
+		return token{kind: tokNot, text: word, pos: start}, nil
+	}
+	return token{kind: tokWord, text: word, pos: start}, nil
+}
+
+type parser struct {
+	lex lexer
+	tok token
+	err error
+}
+
+func (p *parser) next() {
+	if p.err != nil {
+		return
+	}
+	p.tok, p.err = p.lex.next()
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf(format+" at offset %d", append(args, p.tok.pos)...)
+}
+
+// or = and { "||" and }
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	for err == nil && p.tok.kind == tokOr {
+		p.next()
+		var right node
+		if right, err = p.and(); err == nil {
+			left = orNode{left, right}
+		}
+	}
+	return left, err
+}
+
+// and = unary { "&&" unary }
+func (p *parser) and() (node, error) {
+	left, err := p.unary()
+	for err == nil && p.tok.kind == tokAnd {
+		p.next()
+		var right node
+		if right, err = p.unary(); err == nil {
+			left = andNode{left, right}
+		}
+	}
+	return left, err
+}
+
+// unary = "!" unary | "(" or ")" | comparison
+func (p *parser) unary() (node, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	switch p.tok.kind {
+	case tokNot:
+		p.next()
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	case tokLParen:
+		p.next()
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, p.errorf("expected \")\", found %s", p.tok)
+		}
+		p.next()
+		return n, p.err
+	case tokWord, tokString:
+		return p.comparison()
+	default:
+		return nil, p.errorf("expected a key, found %s", p.tok)
+	}
+}
+
+// comparison = key [ op value ]
+func (p *parser) comparison() (node, error) {
+	key := p.tok.text
+	p.next()
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	if p.tok.kind != tokOp {
+		return existsNode{key}, nil
+	}
+	op := p.tok.text
+	p.next()
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	if p.tok.kind != tokWord && p.tok.kind != tokString {
+		return nil, p.errorf("expected a value after %s, found %s", op, p.tok)
+	}
+	value := p.tok.text
+	p.next()
+
+	return newCompare(key, op, value)
+}
+
+// newCompare builds the comparison of key with value, checking that value
+// suits the key and operator.
+func newCompare(key, op, value string) (node, error) {
+	c := compareNode{key: key, op: op, value: value}
+
+	switch op {
+	case "~", "!~":
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", value, err)
+		}
+		c.re = re
+		return c, nil
+	case "==":
+		c.op = "="
+	}
+
+	if key == LevelKey {
+		l, ok := parseLevel(value)
+		if !ok {
+			return nil, fmt.Errorf("invalid level %q", value)
+		}
+		c.level = l
+		return c, nil
+	}
+
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		c.num, c.isNum = f, true
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		c.dur, c.isDur = d, true
+	}
+	return c, nil
+}
+
+// parseLevel reads a level name, as understood by
+// [slog.Level.UnmarshalText], or "trace", "warning" or "fatal".
+func parseLevel(s string) (slog.Level, bool) {
+	switch strings.ToLower(s) {
+	case "trace":
+		return slog.LevelDebug - 4, true
+	case "warning":
+		return slog.LevelWarn, true
+	case "fatal":
+		return slog.LevelError + 4, true
+	}
+
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, false
+	}
+	return l, true
+}
@@ -0,0 +1,89 @@
+package expr
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEnv() *Env {
+	r := slog.NewRecord(time.Time{}, slog.LevelWarn, "upstream timeout", 0)
+	r.AddAttrs(
+		slog.Int("status", 504),
+		slog.Duration("took", 1500*time.Millisecond),
+		slog.String("path", "/api/users"),
+		slog.Group("req", slog.String("method", "GET")),
+		slog.String("size", "1024"),
+	)
+	return &Env{
+		Record: r,
+		Module: "proxy",
+		Attrs:  []slog.Attr{slog.String("request_id", "abc")},
+	}
+}
+
+func TestMatch(t *testing.T) {
+	env := testEnv()
+
+	for src, want := range map[string]bool{
+		`level >= warn`:                       true,
+		`level > warn`:                        false,
+		`level >= info && level < error`:      true,
+		`level = WARN`:                        true,
+		`level < info+2`:                      false,
+		`module = proxy`:                      true,
+		`module == db`:                        false,
+		`msg ~ "timeout|refused"`:             true,
+		`msg !~ timeout`:                      false,
+		`status >= 500`:                       true,
+		`status = 504 and path = /api/users`:  true,
+		`status < 50`:                         false,
+		`took > 1s`:                           true,
+		`took < 250ms`:                        false,
+		`size > 999`:                          true,
+		`req.method = GET`:                    true,
+		`method = GET`:                        true,
+		`request_id = abc`:                    true,
+		`request_id`:                          true,
+		`user`:                                false,
+		`user = bob`:                          false,
+		`user != bob`:                         true,
+		`!(path = /healthz)`:                  true,
+		`not status = 504 or module = proxy`:  true,
+		`level >= error || (took > 1s && !x)`: true,
+		`"path" = "/api/users"`:               true,
+	} {
+		e, err := Parse(src)
+		require.NoError(t, err, src)
+		assert.Equal(t, want, e.Match(env), src)
+	}
+}
+
+func TestGroupedRecord(t *testing.T) {
+	r := slog.NewRecord(time.Time{}, slog.LevelInfo, "", 0)
+	r.AddAttrs(slog.String("id", "7"))
+
+	env := &Env{Record: r, Group: "job"}
+	assert.True(t, MustParse("job.id = 7").Match(env))
+	assert.True(t, MustParse("id = 7").Match(env))
+	assert.False(t, MustParse("msg").Match(env))
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{
+		``,
+		`level >=`,
+		`level = loud`,
+		`(status = 5`,
+		`status = 5)`,
+		`msg ~ "("`,
+		`a && || b`,
+		`msg = "open`,
+	} {
+		_, err := Parse(src)
+		assert.Error(t, err, src)
+	}
+}
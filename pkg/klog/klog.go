@@ -0,0 +1,188 @@
+// Package klog maps the verbosity flags of klog and glog, as used by
+// Kubernetes tooling, onto trifle levels and modules. Operators keep using
+// -v and -vmodule while output goes through trifle.
+//
+//	var flags klog.Flags
+//	flags.Register(flag.CommandLine)
+//	flag.Parse()
+//
+//	logger := slog.New(flags.NewHandler(os.Stderr))
+//	logger.With("module", "scheduler").Log(ctx, klog.Level(4), "scoring node")
+package klog
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strconv"
+	"strings"
+
+	"miren.dev/trifle"
+)
+
+// Level returns the slog level corresponding to klog verbosity v. V(0)
+// messages are Info, V(1) through V(4) are Debug and anything more verbose
+// is Trace, following the klog conventions where V(4) is debug output and
+// V(5) and up is tracing.
+func Level(v int) slog.Level {
+	switch {
+	case v <= 0:
+		return slog.LevelInfo
+	case v <= 4:
+		return slog.LevelDebug
+	default:
+		return trifle.Trace
+	}
+}
+
+// ModuleVerbosity is one pattern=N entry of a -vmodule flag. Pattern is
+// matched against trifle module names with path.Match, so "db*" matches
+// "db" and "dbpool".
+type ModuleVerbosity struct {
+	Pattern string
+	V       int
+}
+
+// ModuleFlag is a flag.Value holding a comma separated list of pattern=N
+// entries, as accepted by -vmodule.
+type ModuleFlag []ModuleVerbosity
+
+// String implements flag.Value.
+func (m *ModuleFlag) String() string {
+	if m == nil {
+		return ""
+	}
+
+	parts := make([]string, len(*m))
+	for i, mv := range *m {
+		parts[i] = mv.Pattern + "=" + strconv.Itoa(mv.V)
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set implements flag.Value.
+func (m *ModuleFlag) Set(value string) error {
+	var entries ModuleFlag
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		pattern, v, ok := strings.Cut(part, "=")
+		if !ok || pattern == "" {
+			return fmt.Errorf("invalid vmodule entry %q, expected pattern=N", part)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid vmodule pattern %q: %w", pattern, err)
+		}
+
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid vmodule verbosity %q for %q", v, pattern)
+		}
+
+		entries = append(entries, ModuleVerbosity{Pattern: pattern, V: n})
+	}
+
+	*m = entries
+	return nil
+}
+
+// verbosity returns the verbosity for module: that of the first matching
+// pattern, or ok == false if none match.
+func (m ModuleFlag) verbosity(module string) (v int, ok bool) {
+	for _, mv := range m {
+		if matched, _ := path.Match(mv.Pattern, module); matched {
+			return mv.V, true
+		}
+	}
+	return 0, false
+}
+
+// Flags holds klog style verbosity settings.
+type Flags struct {
+	// V is the global verbosity, set by -v.
+	V int
+
+	// VModule overrides the verbosity per module, set by -vmodule.
+	VModule ModuleFlag
+}
+
+// Register defines the -v and -vmodule flags on fs.
+func (f *Flags) Register(fs *flag.FlagSet) {
+	fs.IntVar(&f.V, "v", f.V, "number for the log level verbosity")
+	fs.Var(&f.VModule, "vmodule", "comma-separated list of pattern=N settings for module-filtered logging")
+}
+
+// NewHandler returns a trifle handler writing to w that applies the
+// verbosity settings, filtering records by the module set with the
+// "module" attribute.
+func (f *Flags) NewHandler(w io.Writer, options ...trifle.Option) slog.Handler {
+	// The trifle handler accepts everything; filtering happens in the
+	// wrapper, which knows the module.
+	return f.Wrap(trifle.New(w, &slog.HandlerOptions{Level: trifle.Trace}, options...))
+}
+
+// Wrap applies the verbosity settings in front of h. h must accept records
+// at every level the settings allow, which NewHandler takes care of.
+func (f *Flags) Wrap(h slog.Handler) slog.Handler {
+	wh := &handler{next: h, flags: f}
+	wh.minLevel = wh.levelFor("")
+	return wh
+}
+
+type handler struct {
+	next     slog.Handler
+	flags    *Flags
+	module   string
+	minLevel slog.Level
+}
+
+func (h *handler) levelFor(module string) slog.Level {
+	if v, ok := h.flags.VModule.verbosity(module); ok {
+		return Level(v)
+	}
+	return Level(h.flags.V)
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.minLevel && h.next.Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	module := h.module
+	for _, a := range attrs {
+		if a.Key == trifle.ModuleKey && a.Value.Kind() == slog.KindString {
+			if module == "" {
+				module = a.Value.String()
+			} else {
+				module += "." + a.Value.String()
+			}
+		}
+	}
+
+	return &handler{
+		next:     h.next.WithAttrs(attrs),
+		flags:    h.flags,
+		module:   module,
+		minLevel: h.levelFor(module),
+	}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{
+		next:     h.next.WithGroup(name),
+		flags:    h.flags,
+		module:   h.module,
+		minLevel: h.minLevel,
+	}
+}
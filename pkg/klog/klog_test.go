@@ -0,0 +1,61 @@
+package klog
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle"
+)
+
+func TestLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelInfo, Level(0))
+	assert.Equal(t, slog.LevelDebug, Level(2))
+	assert.Equal(t, slog.LevelDebug, Level(4))
+	assert.Equal(t, trifle.Trace, Level(5))
+}
+
+func TestFlags(t *testing.T) {
+	var f Flags
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	f.Register(fs)
+	require.NoError(t, fs.Parse([]string{"-v=2", "-vmodule=db*=6,api=0"}))
+
+	assert.Equal(t, 2, f.V)
+	assert.Equal(t, "db*=6,api=0", f.VModule.String())
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(&bytes.Buffer{})
+	f.Register(fs)
+	assert.Error(t, fs.Parse([]string{"-vmodule=db"}))
+	assert.Error(t, fs.Parse([]string{"-vmodule=db=x"}))
+	assert.Error(t, fs.Parse([]string{"-vmodule=[=1"}))
+}
+
+func TestHandlerFiltersByModule(t *testing.T) {
+	var buf bytes.Buffer
+
+	f := Flags{V: 0}
+	require.NoError(t, f.VModule.Set("db*=5,api=0"))
+
+	logger := slog.New(f.NewHandler(&buf))
+	ctx := context.Background()
+
+	logger.Log(ctx, Level(2), "global debug hidden")
+	logger.Info("global info shown")
+	logger.With("module", "dbpool").Log(ctx, Level(5), "db trace shown")
+	logger.With("module", "api").Log(ctx, Level(1), "api debug hidden")
+	logger.With("module", "db").WithGroup("g").Log(ctx, Level(3), "grouped db debug shown")
+
+	output := buf.String()
+	assert.NotContains(t, output, "global debug hidden")
+	assert.Contains(t, output, "global info shown")
+	assert.Contains(t, output, "db trace shown")
+	assert.NotContains(t, output, "api debug hidden")
+	assert.Contains(t, output, "grouped db debug shown")
+}
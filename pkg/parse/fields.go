@@ -0,0 +1,226 @@
+package parse
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"miren.dev/trifle"
+)
+
+// field is a key and value read from a structured line, in the order it
+// appeared. Values are strings, json.Number, bools, nil, []field for
+// nested objects or []any for arrays.
+type field struct {
+	key   string
+	value any
+}
+
+// The keys recognized for the parts of a record, in order of preference.
+var (
+	timeKeys    = []string{"time", "ts", "timestamp", "@timestamp"}
+	levelKeys   = []string{"level", "lvl", "severity", "levelname"}
+	messageKeys = []string{"msg", "message"}
+	moduleKeys  = []string{trifle.ModuleKey, "logger", "component"}
+)
+
+func isKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasKnownKey reports whether fields carries any of the keys that make a
+// line a record rather than, say, prose that happens to contain '='.
+func hasKnownKey(fields []field) bool {
+	for _, f := range fields {
+		if isKey(timeKeys, f.key) || isKey(levelKeys, f.key) || isKey(messageKeys, f.key) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldsEntry builds an entry from the fields of a structured line. The
+// first field for each of the time, level, message and module is taken
+// out, provided it can be understood; all others become attributes.
+func fieldsEntry(fields []field, format Format) Entry {
+	var (
+		when                time.Time
+		level               = slog.LevelInfo
+		msg, module         string
+		haveTime, haveLevel bool
+		haveMsg, haveModule bool
+		attrs               = make([]slog.Attr, 0, len(fields))
+	)
+
+	for _, f := range fields {
+		switch {
+		case !haveTime && isKey(timeKeys, f.key):
+			if t, ok := parseTime(f.value); ok {
+				when, haveTime = t, true
+				continue
+			}
+		case !haveLevel && isKey(levelKeys, f.key):
+			if l, ok := parseLevelValue(f.value); ok {
+				level, haveLevel = l, true
+				continue
+			}
+		case !haveMsg && isKey(messageKeys, f.key):
+			if s, ok := f.value.(string); ok {
+				msg, haveMsg = s, true
+				continue
+			}
+		case !haveModule && isKey(moduleKeys, f.key):
+			if s, ok := f.value.(string); ok {
+				module, haveModule = s, true
+				continue
+			}
+		}
+		attrs = append(attrs, slog.Attr{Key: f.key, Value: fieldValue(f.value)})
+	}
+
+	r := slog.NewRecord(when, level, msg, 0)
+	r.AddAttrs(attrs...)
+	return Entry{Record: r, Module: module, Format: format}
+}
+
+func fieldValue(v any) slog.Value {
+	switch v := v.(type) {
+	case string:
+		return slog.StringValue(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return slog.Int64Value(i)
+		}
+		if f, err := v.Float64(); err == nil {
+			return slog.Float64Value(f)
+		}
+		return slog.StringValue(v.String())
+	case bool:
+		return slog.BoolValue(v)
+	case []field:
+		attrs := make([]slog.Attr, len(v))
+		for i, f := range v {
+			attrs[i] = slog.Attr{Key: f.key, Value: fieldValue(f.value)}
+		}
+		return slog.GroupValue(attrs...)
+	default:
+		return slog.AnyValue(v)
+	}
+}
+
+// ParseLevel returns the level named s. Besides the names understood by
+// [slog.Level.UnmarshalText] it accepts the names used by other logging
+// libraries, such as "trace", "warning", "fatal" and "critical", in any
+// case. Fatal, panic and critical levels map to four above Error.
+func ParseLevel(s string) (slog.Level, bool) {
+	s = strings.TrimSpace(s)
+
+	switch strings.ToLower(s) {
+	case "":
+		return 0, false
+	case "trace", "trc", "finest", "finer":
+		return trifle.Trace, true
+	case "dbg", "fine", "verbose":
+		return slog.LevelDebug, true
+	case "information", "inf", "notice":
+		return slog.LevelInfo, true
+	case "warning", "wrn":
+		return slog.LevelWarn, true
+	case "err", "eror", "severe":
+		return slog.LevelError, true
+	case "fatal", "ftl", "panic", "critical", "crit", "alert", "emerg", "emergency", "dpanic":
+		return slog.LevelError + 4, true
+	}
+
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, false
+	}
+	return l, true
+}
+
+// parseLevelValue reads a level given as a name or, as bunyan and pino
+// write them, as a number from 10 (trace) to 60 (fatal).
+func parseLevelValue(v any) (slog.Level, bool) {
+	switch v := v.(type) {
+	case string:
+		return ParseLevel(v)
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			return 0, false
+		}
+		switch {
+		case n >= 60:
+			return slog.LevelError + 4, true
+		case n >= 50:
+			return slog.LevelError, true
+		case n >= 40:
+			return slog.LevelWarn, true
+		case n >= 30:
+			return slog.LevelInfo, true
+		case n >= 20:
+			return slog.LevelDebug, true
+		case n >= 10:
+			return trifle.Trace, true
+		}
+	}
+	return 0, false
+}
+
+// timeLayouts are the layouts tried for times given as text.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05,999",
+}
+
+// parseTime reads a time given as text or as a number of seconds,
+// milliseconds, microseconds or nanoseconds since the epoch, telling them
+// apart by magnitude.
+func parseTime(v any) (time.Time, bool) {
+	switch v := v.(type) {
+	case string:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return epochTime(n)
+		}
+	case json.Number:
+		if n, err := v.Float64(); err == nil {
+			return epochTime(n)
+		}
+	}
+	return time.Time{}, false
+}
+
+func epochTime(n float64) (time.Time, bool) {
+	if n <= 0 || math.IsInf(n, 0) || math.IsNaN(n) {
+		return time.Time{}, false
+	}
+
+	switch {
+	case n >= 1e17:
+		return time.Unix(0, int64(n)), true
+	case n >= 1e14:
+		return time.UnixMicro(int64(n)), true
+	case n >= 1e11:
+		return time.UnixMilli(int64(n)), true
+	default:
+		sec, frac := math.Modf(n)
+		return time.Unix(int64(sec), int64(frac*1e9)), true
+	}
+}
@@ -0,0 +1,137 @@
+package parse
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// levelNames matches the level names found in unstructured logs.
+const levelNames = `TRACE|DEBUG|INFO|NOTICE|WARN(?:ING)?|ERROR|EROR|ERR|FATAL|PANIC|CRIT(?:ICAL)?|SEVERE|TRC|DBG|INF|WRN|FTL`
+
+// timestampPattern matches a date and time as commonly written at the
+// start of a line.
+const timestampPattern = `\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`
+
+// A textFormat recognizes the lines of one logging library's default
+// format. Submatches are picked out by name: time, level, module, thread
+// and msg.
+type textFormat struct {
+	re *regexp.Regexp
+	// layouts parse the time submatch, tried in order.
+	layouts []string
+}
+
+var textFormats = []textFormat{
+	// Ruby's Logger, as used by Rails:
+	// E, [2024-05-01T10:00:00.123456 #1234] ERROR -- app: message
+	{
+		re:      regexp.MustCompile(`^[DIWEFA], \[(?P<time>\S+) #\d+\]\s+(?P<level>[A-Z]+) -- (?P<module>[^:]*): ?(?P<msg>.*)$`),
+		layouts: []string{"2006-01-02T15:04:05.999999999"},
+	},
+	// Python's logging.basicConfig: ERROR:app.db:message
+	{
+		re: regexp.MustCompile(`^(?P<level>DEBUG|INFO|WARNING|ERROR|CRITICAL):(?P<module>[\w.]*):(?P<msg>.*)$`),
+	},
+	// The usual Python format: 2024-05-01 10:00:00,123 - app.db - ERROR - message
+	{
+		re:      regexp.MustCompile(`^(?P<time>` + timestampPattern + `) - (?P<module>\S+) - (?P<level>[A-Z]+) - (?P<msg>.*)$`),
+		layouts: timeLayouts,
+	},
+	// Log4j and Spring Boot:
+	// 2024-05-01 10:00:00.123 ERROR [main] com.example.App - message
+	// 2024-05-01 10:00:00.123  INFO 1234 --- [main] c.e.App : message
+	{
+		re:      regexp.MustCompile(`^(?P<time>` + timestampPattern + `)\s+(?P<level>` + levelNames + `)\s+(?:\d+ --- )?\[(?P<thread>[^\]]*)\]\s+(?P<module>\S+)\s+[-:] (?P<msg>.*)$`),
+		layouts: timeLayouts,
+	},
+	// Logback's default pattern: 10:00:00.123 [main] ERROR com.example.App - message
+	{
+		re:      regexp.MustCompile(`^(?P<time>\d{2}:\d{2}:\d{2}\.\d{3}) \[(?P<thread>[^\]]*)\]\s+(?P<level>` + levelNames + `)\s+(?P<module>\S+) - (?P<msg>.*)$`),
+		layouts: []string{"15:04:05.000"},
+	},
+	// Anything else starting with an optional time and a level, bare or in
+	// brackets: "ERROR: message", "[warn] message", "2024-05-01 10:00:00 INFO message"
+	{
+		re:      regexp.MustCompile(`^(?:(?P<time>` + timestampPattern + `)\s+)?(?:\[(?P<level>(?i:` + levelNames + `))\]|(?P<level>` + levelNames + `)\b|(?P<level>(?i:` + levelNames + `)):)[:\s]*(?P<msg>.*)$`),
+		layouts: timeLayouts,
+	},
+}
+
+// textEntry builds an entry for an unstructured line. When the line is in
+// the format of a common logging library, or starts with a level, the
+// level, time and logger name are taken from it and the message is what
+// follows. Other lines become Info records with the whole line as the
+// message.
+func textEntry(line string) Entry {
+	for _, tf := range textFormats {
+		if e, ok := tf.entry(line, time.Now()); ok {
+			return e
+		}
+	}
+
+	return Entry{
+		Record: slog.NewRecord(time.Time{}, slog.LevelInfo, line, 0),
+		Format: Text,
+	}
+}
+
+func (tf textFormat) entry(line string, now time.Time) (Entry, bool) {
+	m := tf.re.FindStringSubmatch(line)
+	if m == nil {
+		return Entry{}, false
+	}
+
+	var (
+		when                time.Time
+		level               = slog.LevelInfo
+		msg, module, thread string
+	)
+	for i, name := range tf.re.SubexpNames() {
+		if m[i] == "" {
+			continue
+		}
+		switch name {
+		case "time":
+			when = parseTextTime(m[i], tf.layouts, now)
+		case "level":
+			l, ok := ParseLevel(m[i])
+			if !ok {
+				return Entry{}, false
+			}
+			level = l
+		case "module":
+			module = m[i]
+		case "thread":
+			thread = m[i]
+		case "msg":
+			msg = m[i]
+		}
+	}
+
+	r := slog.NewRecord(when, level, strings.TrimSpace(msg), 0)
+	if thread != "" {
+		r.AddAttrs(slog.String("thread", thread))
+	}
+	return Entry{Record: r, Module: module, Format: Text}, true
+}
+
+// parseTextTime parses a time found in a line. A time of day without a
+// date is taken to be today.
+func parseTextTime(s string, layouts []string, now time.Time) time.Time {
+	s = strings.Replace(s, ",", ".", 1)
+
+	for _, layout := range layouts {
+		t, err := time.ParseInLocation(layout, s, now.Location())
+		if err != nil {
+			continue
+		}
+		if t.Year() == 0 {
+			y, mo, d := now.Date()
+			t = time.Date(y, mo, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), now.Location())
+		}
+		return t
+	}
+	return time.Time{}
+}
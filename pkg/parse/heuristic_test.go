@@ -0,0 +1,72 @@
+package parse
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTextLevels(t *testing.T) {
+	now := time.Now()
+	today := func(h, m, s, ms int) time.Time {
+		y, mo, d := now.Date()
+		return time.Date(y, mo, d, h, m, s, ms*1e6, time.Local)
+	}
+
+	tests := []struct {
+		line   string
+		level  slog.Level
+		msg    string
+		module string
+		time   time.Time
+	}{
+		{"ERROR: disk full", slog.LevelError, "disk full", "", time.Time{}},
+		{"[warn] retrying", slog.LevelWarn, "retrying", "", time.Time{}},
+		{"WARNING something odd", slog.LevelWarn, "something odd", "", time.Time{}},
+		{"error: cannot open file", slog.LevelError, "cannot open file", "", time.Time{}},
+		{"2024-05-01 10:00:00.250 DEBUG cache warmed", slog.LevelDebug, "cache warmed", "",
+			time.Date(2024, 5, 1, 10, 0, 0, 25e7, time.Local)},
+
+		// Ruby's Logger
+		{"E, [2024-05-01T10:00:00.123456 #42] ERROR -- app: boom", slog.LevelError, "boom", "app",
+			time.Date(2024, 5, 1, 10, 0, 0, 123456000, time.Local)},
+		// Python
+		{"CRITICAL:app.db:connection lost", slog.LevelError + 4, "connection lost", "app.db", time.Time{}},
+		{"2024-05-01 10:00:00,123 - app.db - WARNING - slow", slog.LevelWarn, "slow", "app.db",
+			time.Date(2024, 5, 1, 10, 0, 0, 123e6, time.Local)},
+		// Log4j and Spring Boot
+		{"2024-05-01 10:00:00.123 ERROR [main] com.example.App - failed", slog.LevelError, "failed", "com.example.App",
+			time.Date(2024, 5, 1, 10, 0, 0, 123e6, time.Local)},
+		{"2024-05-01 10:00:00.123  INFO 1234 --- [main] c.e.App : Started", slog.LevelInfo, "Started", "c.e.App",
+			time.Date(2024, 5, 1, 10, 0, 0, 123e6, time.Local)},
+		// Logback
+		{"10:00:00.123 [main] WARN  com.example.App - careful", slog.LevelWarn, "careful", "com.example.App", today(10, 0, 0, 123)},
+
+		// Not levels
+		{"Information is power", slog.LevelInfo, "Information is power", "", time.Time{}},
+		{"ERRORS were made", slog.LevelInfo, "ERRORS were made", "", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		e := textEntry(tt.line)
+
+		assert.Equal(t, Text, e.Format, tt.line)
+		assert.Equal(t, tt.level, e.Record.Level, tt.line)
+		assert.Equal(t, tt.msg, e.Record.Message, tt.line)
+		assert.Equal(t, tt.module, e.Module, tt.line)
+		assert.True(t, tt.time.Equal(e.Record.Time), "%s: got time %v", tt.line, e.Record.Time)
+	}
+}
+
+func TestTextThread(t *testing.T) {
+	e := textEntry("10:00:00.123 [worker-1] INFO  com.example.Job - done")
+	assert.Equal(t, map[string]string{"thread": "worker-1"}, attrs(e.Record))
+}
+
+func TestKlogWithoutThread(t *testing.T) {
+	e, err := Line("W0102 10:00:00.000001 main.go:10] careful", Klog)
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelWarn, e.Record.Level)
+}
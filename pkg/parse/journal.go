@@ -0,0 +1,151 @@
+package parse
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// journalPriorities maps the syslog priorities used by journald, from 0
+// (emerg) to 7 (debug), to levels.
+var journalPriorities = [...]slog.Level{
+	slog.LevelError + 4, slog.LevelError + 4, slog.LevelError + 4,
+	slog.LevelError, slog.LevelWarn, slog.LevelInfo, slog.LevelInfo,
+	slog.LevelDebug,
+}
+
+// isJournal reports whether fields are a journal entry as written by
+// journalctl -o json.
+func isJournal(fields []field) bool {
+	var realtime, message bool
+	for _, f := range fields {
+		switch f.key {
+		case "__REALTIME_TIMESTAMP":
+			realtime = true
+		case "MESSAGE":
+			message = true
+		}
+	}
+	return realtime && message
+}
+
+// journalEntry builds an entry from the fields of a journal entry. The
+// module is the syslog identifier, or failing that the systemd unit or
+// command name. The fields journald adds itself, whose names start with an
+// underscore, are left out but for the pid, and the code location becomes
+// a "source" attribute. Fields added by the program are kept as they are.
+//
+// A message that is itself a structured line, as written by a service
+// logging JSON or logfmt to its standard output, is parsed as such, with
+// the journal supplying what it lacks.
+func journalEntry(fields []field) Entry {
+	var (
+		when               time.Time
+		level              = slog.LevelInfo
+		msg                string
+		identifier, unit   string
+		comm               string
+		codeFile, codeLine string
+		attrs              []slog.Attr
+	)
+
+	for _, f := range fields {
+		s := journalString(f.value)
+
+		switch f.key {
+		case "MESSAGE":
+			msg = s
+		case "PRIORITY":
+			if p, err := strconv.Atoi(s); err == nil && p >= 0 && p < len(journalPriorities) {
+				level = journalPriorities[p]
+			}
+		case "__REALTIME_TIMESTAMP":
+			if us, err := strconv.ParseInt(s, 10, 64); err == nil {
+				when = time.UnixMicro(us)
+			}
+		case "SYSLOG_IDENTIFIER":
+			identifier = s
+		case "_SYSTEMD_UNIT":
+			unit = strings.TrimSuffix(s, ".service")
+		case "_COMM":
+			comm = s
+		case "_PID":
+			if pid, err := strconv.Atoi(s); err == nil {
+				attrs = append(attrs, slog.Int("pid", pid))
+			}
+		case "CODE_FILE":
+			codeFile = s
+		case "CODE_LINE":
+			codeLine = s
+		default:
+			if strings.HasPrefix(f.key, "_") || strings.HasPrefix(f.key, "SYSLOG_") || strings.HasPrefix(f.key, "CODE_") {
+				continue
+			}
+			attrs = append(attrs, slog.Attr{Key: f.key, Value: fieldValue(f.value)})
+		}
+	}
+
+	if codeFile != "" {
+		source := codeFile
+		if codeLine != "" {
+			source += ":" + codeLine
+		}
+		attrs = append(attrs, slog.String(slog.SourceKey, source))
+	}
+
+	module := identifier
+	if module == "" {
+		module = unit
+	}
+	if module == "" {
+		module = comm
+	}
+
+	e, _ := parseLine(msg, Auto)
+	if e.Format == Text {
+		// The priority is all journald knows of a plain line's level,
+		// but services commonly log everything at the default one and
+		// put the level in the message.
+		if level > e.Record.Level {
+			e.Record.Level = level
+		}
+	}
+	if e.Record.Time.IsZero() {
+		e.Record.Time = when
+	}
+	if e.Module != "" && module != "" {
+		e.Module = module + "." + e.Module
+	} else if module != "" {
+		e.Module = module
+	}
+	e.Record.AddAttrs(attrs...)
+	e.Format = Journal
+	return e
+}
+
+// journalString returns a field of a journal entry as a string. journalctl
+// writes fields that aren't valid UTF-8 as arrays of bytes.
+func journalString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []any:
+		b := make([]byte, 0, len(v))
+		for _, c := range v {
+			n, ok := c.(json.Number)
+			if !ok {
+				return ""
+			}
+			i, err := n.Int64()
+			if err != nil || i < 0 || i > 255 {
+				return ""
+			}
+			b = append(b, byte(i))
+		}
+		return string(b)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,51 @@
+package parse
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal(t *testing.T) {
+	e, err := Line(`{"__CURSOR":"s=abc","__REALTIME_TIMESTAMP":"1714557600123456","__MONOTONIC_TIMESTAMP":"12345","_BOOT_ID":"f00","PRIORITY":"4","_PID":"812","_COMM":"sshd","SYSLOG_IDENTIFIER":"sshd","SYSLOG_FACILITY":"4","_SYSTEMD_UNIT":"ssh.service","CODE_FILE":"auth.c","CODE_LINE":"42","MESSAGE":"Failed password for root","USER_ID":"root"}`, Auto)
+	require.NoError(t, err)
+
+	assert.Equal(t, Journal, e.Format)
+	assert.Equal(t, "sshd", e.Module)
+	assert.Equal(t, slog.LevelWarn, e.Record.Level)
+	assert.Equal(t, "Failed password for root", e.Record.Message)
+	assert.True(t, e.Record.Time.Equal(time.UnixMicro(1714557600123456)))
+	assert.Equal(t, []string{"pid", "USER_ID", "source"}, attrKeys(e.Record))
+	assert.Equal(t, "auth.c:42", attrs(e.Record)["source"])
+}
+
+func TestJournalStructuredMessage(t *testing.T) {
+	e, err := Line(`{"__REALTIME_TIMESTAMP":"1714557600000000","PRIORITY":"6","_SYSTEMD_UNIT":"api.service","MESSAGE":"{\"level\":\"debug\",\"msg\":\"cache miss\",\"module\":\"cache\",\"key\":\"u1\"}"}`, Journal)
+	require.NoError(t, err)
+
+	assert.Equal(t, "api.cache", e.Module)
+	assert.Equal(t, slog.LevelDebug, e.Record.Level)
+	assert.Equal(t, "cache miss", e.Record.Message)
+	assert.Equal(t, "u1", attrs(e.Record)["key"])
+	assert.True(t, e.Record.Time.Equal(time.UnixMicro(1714557600000000)))
+
+	// A plain message keeps the more severe of the priority and the level
+	// it starts with.
+	e, err = Line(`{"__REALTIME_TIMESTAMP":"1714557600000000","PRIORITY":"6","_COMM":"worker","MESSAGE":"ERROR: job 7 failed"}`, Journal)
+	require.NoError(t, err)
+	assert.Equal(t, "worker", e.Module)
+	assert.Equal(t, slog.LevelError, e.Record.Level)
+	assert.Equal(t, "job 7 failed", e.Record.Message)
+
+	// Messages that aren't UTF-8 are written as arrays of bytes.
+	e, err = Line(`{"__REALTIME_TIMESTAMP":"1714557600000000","PRIORITY":"3","MESSAGE":[104,105,255]}`, Journal)
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelError, e.Record.Level)
+	assert.Equal(t, "hi\xff", e.Record.Message)
+
+	_, err = Line(`{"msg":"hi"}`, Journal)
+	assert.ErrorIs(t, err, ErrFormat)
+}
@@ -0,0 +1,93 @@
+package parse
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// parseJSON reads a line holding one JSON object, keeping its keys in
+// order.
+func parseJSON(line string) ([]field, error) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return nil, ErrFormat
+	}
+
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+
+	if _, err := dec.Token(); err != nil {
+		return nil, ErrFormat
+	}
+	fields, err := decodeObject(dec)
+	if err != nil {
+		return nil, ErrFormat
+	}
+	if dec.More() {
+		return nil, ErrFormat
+	}
+	return fields, nil
+}
+
+// decodeObject reads the members of an object whose opening brace has
+// been consumed, up to and including the closing brace.
+func decodeObject(dec *json.Decoder) ([]field, error) {
+	var fields []field
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+
+		value, err := decodeValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field{key: key, value: value})
+	}
+
+	_, err := dec.Token()
+	return fields, err
+}
+
+func decodeValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		return decodeObject(dec)
+	case json.Delim('['):
+		var values []any
+		for dec.More() {
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, plainValue(v))
+		}
+		_, err := dec.Token()
+		return values, err
+	default:
+		return tok, nil
+	}
+}
+
+// plainValue converts nested objects inside arrays to maps, which print
+// more readably than a slice of fields.
+func plainValue(v any) any {
+	fields, ok := v.([]field)
+	if !ok {
+		return v
+	}
+
+	m := make(map[string]any, len(fields))
+	for _, f := range fields {
+		m[f.key] = plainValue(f.value)
+	}
+	return m
+}
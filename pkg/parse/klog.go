@@ -0,0 +1,83 @@
+package parse
+
+import (
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// klogHeader matches the header klog and glog put before every message:
+//
+//	Lmmdd hh:mm:ss.uuuuuu threadid file:line] msg
+//
+// Some glog ports leave out the thread id.
+var klogHeader = regexp.MustCompile(`^([IWEF])(\d{2})(\d{2}) (\d{2}):(\d{2}):(\d{2})\.(\d{6})(?:\s+\d+)? ([^ \]]+:\d+)\] ?(.*)$`)
+
+var klogLevels = map[byte]slog.Level{
+	'I': slog.LevelInfo,
+	'W': slog.LevelWarn,
+	'E': slog.LevelError,
+	'F': slog.LevelError + 4,
+}
+
+// parseKlog reads a line in the klog format. The header carries no year,
+// so the one that puts the time closest before now is used. The source
+// location becomes a "source" attribute. Structured klog messages, a
+// quoted message followed by key="value" pairs, have the pairs turned
+// into attributes.
+func parseKlog(line string, now time.Time) (Entry, error) {
+	m := klogHeader.FindStringSubmatch(line)
+	if m == nil {
+		return Entry{}, ErrFormat
+	}
+
+	num := func(s string) int {
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+
+	when := time.Date(now.Year(), time.Month(num(m[2])), num(m[3]),
+		num(m[4]), num(m[5]), num(m[6]), num(m[7])*1000, now.Location())
+	if when.After(now.Add(24 * time.Hour)) {
+		when = when.AddDate(-1, 0, 0)
+	}
+
+	msg, attrs := klogMessage(m[9])
+
+	r := slog.NewRecord(when, klogLevels[m[1][0]], msg, 0)
+	r.AddAttrs(attrs...)
+	r.AddAttrs(slog.String(slog.SourceKey, m[8]))
+	return Entry{Record: r, Format: Klog}, nil
+}
+
+// klogMessage splits a structured klog message into the message and its
+// key/value pairs. Other messages are returned whole.
+func klogMessage(s string) (string, []slog.Attr) {
+	if !strings.HasPrefix(s, `"`) {
+		return s, nil
+	}
+
+	quoted, err := strconv.QuotedPrefix(s)
+	if err != nil {
+		return s, nil
+	}
+	msg, _ := strconv.Unquote(quoted)
+
+	rest := strings.TrimSpace(s[len(quoted):])
+	if rest == "" {
+		return msg, nil
+	}
+
+	fields, err := parseLogfmt(rest)
+	if err != nil {
+		return s, nil
+	}
+
+	attrs := make([]slog.Attr, len(fields))
+	for i, f := range fields {
+		attrs[i] = slog.Attr{Key: f.key, Value: fieldValue(f.value)}
+	}
+	return msg, attrs
+}
@@ -0,0 +1,53 @@
+package parse
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseLogfmt reads a line of space separated key=value pairs. Values
+// containing spaces are double quoted, with Go escapes. Dots in keys, as
+// slog.TextHandler writes for groups, are kept as part of the key.
+func parseLogfmt(line string) ([]field, error) {
+	var fields []field
+
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			break
+		}
+
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 || strings.ContainsAny(line[:eq], " \t\"") {
+			return nil, ErrFormat
+		}
+		key := line[:eq]
+		line = line[eq+1:]
+
+		var value string
+		if strings.HasPrefix(line, `"`) {
+			quoted, err := strconv.QuotedPrefix(line)
+			if err != nil {
+				return nil, ErrFormat
+			}
+			value, _ = strconv.Unquote(quoted)
+			line = line[len(quoted):]
+			if line != "" && line[0] != ' ' && line[0] != '\t' {
+				return nil, ErrFormat
+			}
+		} else {
+			end := strings.IndexAny(line, " \t")
+			if end < 0 {
+				end = len(line)
+			}
+			value, line = line[:end], line[end:]
+		}
+
+		fields = append(fields, field{key: key, value: value})
+	}
+
+	if len(fields) == 0 {
+		return nil, ErrFormat
+	}
+	return fields, nil
+}
@@ -0,0 +1,143 @@
+package parse
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"miren.dev/trifle"
+)
+
+// Source is one of the streams combined by [Merge].
+type Source struct {
+	// Name identifies the source in the output. It becomes the module of
+	// every entry read from it, with the entry's own module nested below.
+	Name string
+
+	// Reader supplies the lines, in Format.
+	Reader io.Reader
+	Format Format
+
+	// Offset is added to the times read from the source, to correct a
+	// clock known to be off. A source whose clock runs 2s behind the
+	// others takes an Offset of 2s.
+	Offset time.Duration
+}
+
+// MergeOptions configures [Merge].
+type MergeOptions struct {
+	// Skew is how far apart the clocks of the sources may be. Entries less
+	// than Skew apart are taken to be simultaneous, and a run of entries
+	// from one source is then kept together rather than interleaved with
+	// another source on the strength of timestamps that can't be trusted
+	// at that resolution.
+	Skew time.Duration
+}
+
+// Merge reads all sources and passes their entries to h ordered by time,
+// as if they had been logged to one stream. It stops at the end of the
+// last source, or at the first error from reading or from h.
+//
+// Lines without a time, such as the lines of a stack trace, stay right
+// after the entry they follow in their source. A source's time never goes
+// backwards: an entry stamped earlier than the one before it in the same
+// source is placed as if it had the same time.
+func Merge(sources []Source, h slog.Handler, opts MergeOptions) error {
+	ctx := context.Background()
+
+	streams := make([]*mergeStream, len(sources))
+	for i, src := range sources {
+		streams[i] = &mergeStream{
+			src:      src,
+			sc:       NewScanner(src.Reader, src.Format),
+			handlers: entryHandlers{base: h},
+		}
+		if src.Name != "" {
+			streams[i].handlers.base = h.WithAttrs([]slog.Attr{slog.String(trifle.ModuleKey, src.Name)})
+		}
+		streams[i].advance()
+	}
+
+	var last *mergeStream
+	for {
+		next := pickStream(streams, last, opts.Skew)
+		if next == nil {
+			break
+		}
+
+		if err := next.handle(ctx); err != nil {
+			return err
+		}
+		last = next
+		next.advance()
+	}
+
+	for _, s := range streams {
+		if err := s.sc.Err(); err != nil {
+			if s.src.Name != "" {
+				return fmt.Errorf("%s: %w", s.src.Name, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeStream is a source being merged, with the entry it will contribute
+// next.
+type mergeStream struct {
+	src      Source
+	sc       *Scanner
+	handlers entryHandlers // based on a handler with the source's module
+
+	entry Entry
+	at    time.Time // when entry is placed; never earlier than the one before
+	done  bool
+}
+
+func (s *mergeStream) advance() {
+	if !s.sc.Scan() {
+		s.done = true
+		return
+	}
+
+	s.entry = s.sc.Entry()
+
+	t := s.entry.Record.Time
+	if t.IsZero() {
+		return
+	}
+
+	t = t.Add(s.src.Offset)
+	s.entry.Record.Time = t
+	if t.After(s.at) {
+		s.at = t
+	}
+}
+
+func (s *mergeStream) handle(ctx context.Context) error {
+	h := s.handlers.get(s.entry)
+	if !h.Enabled(ctx, s.entry.Record.Level) {
+		return nil
+	}
+	return h.Handle(ctx, s.entry.Record)
+}
+
+// pickStream returns the stream whose entry comes next, or nil when all
+// are done. The stream picked last keeps its turn while its entry is within
+// skew of the earliest one.
+func pickStream(streams []*mergeStream, last *mergeStream, skew time.Duration) *mergeStream {
+	var first *mergeStream
+	for _, s := range streams {
+		if !s.done && (first == nil || s.at.Before(first.at)) {
+			first = s
+		}
+	}
+
+	if last != nil && !last.done && first != nil && !last.at.After(first.at.Add(skew)) {
+		return last
+	}
+	return first
+}
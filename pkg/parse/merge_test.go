@@ -0,0 +1,102 @@
+package parse
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle"
+)
+
+func mergeOutput(t *testing.T, sources []Source, opts MergeOptions) []string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	h := trifle.New(&buf, nil, trifle.WithTerminalWidth(0))
+	require.NoError(t, Merge(sources, h, opts))
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(trifle.Plain(buf.String())), "\n") {
+		// Keep the module and message, which is what the order is about.
+		_, after, _ := strings.Cut(line, "] ")
+		lines = append(lines, strings.TrimSpace(after))
+	}
+	return lines
+}
+
+func TestMergeByTime(t *testing.T) {
+	api := `{"time":"2024-05-01T10:00:00Z","msg":"a1"}
+{"time":"2024-05-01T10:00:02Z","msg":"a2"}
+`
+	worker := `time=2024-05-01T10:00:01Z msg=b1
+panic: boom
+time=2024-05-01T10:00:03Z msg=b2 module=db
+`
+
+	lines := mergeOutput(t, []Source{
+		{Name: "api", Reader: strings.NewReader(api)},
+		{Name: "worker", Reader: strings.NewReader(worker)},
+	}, MergeOptions{})
+
+	assert.Equal(t, []string{"api a1", "worker b1", "worker boom", "api a2", "worker.db b2"}, lines)
+}
+
+func TestMergeSkewKeepsRunsTogether(t *testing.T) {
+	a := `{"time":"2024-05-01T10:00:00.000Z","msg":"a1"}
+{"time":"2024-05-01T10:00:00.300Z","msg":"a2"}
+`
+	b := `{"time":"2024-05-01T10:00:00.100Z","msg":"b1"}
+{"time":"2024-05-01T10:00:00.200Z","msg":"b2"}
+`
+	sources := func() []Source {
+		return []Source{
+			{Name: "a", Reader: strings.NewReader(a)},
+			{Name: "b", Reader: strings.NewReader(b)},
+		}
+	}
+
+	assert.Equal(t, []string{"a a1", "b b1", "b b2", "a a2"}, mergeOutput(t, sources(), MergeOptions{}))
+	assert.Equal(t, []string{"a a1", "a a2", "b b1", "b b2"}, mergeOutput(t, sources(), MergeOptions{Skew: time.Second}))
+}
+
+func TestMergeOffsetAndBackwardsTime(t *testing.T) {
+	a := `{"time":"2024-05-01T10:00:02Z","msg":"a1"}
+`
+	b := `{"time":"2024-05-01T10:00:00Z","msg":"b1"}
+{"time":"2024-05-01T09:00:00Z","msg":"b2"}
+{"time":"2024-05-01T10:00:01Z","msg":"b3"}
+`
+
+	lines := mergeOutput(t, []Source{
+		{Name: "a", Reader: strings.NewReader(a)},
+		{Name: "b", Reader: strings.NewReader(b)},
+	}, MergeOptions{})
+	assert.Equal(t, []string{"b b1", "b b2", "b b3", "a a1"}, lines)
+
+	lines = mergeOutput(t, []Source{
+		{Name: "a", Reader: strings.NewReader(a), Offset: -5 * time.Second},
+		{Name: "b", Reader: strings.NewReader(b)},
+	}, MergeOptions{})
+	assert.Equal(t, []string{"a a1", "b b1", "b b2", "b b3"}, lines)
+}
+
+func TestMergeWithGapAnnotations(t *testing.T) {
+	a := `{"time":"2024-05-01T10:00:00Z","msg":"before"}
+`
+	b := `{"time":"2024-05-01T10:05:00Z","msg":"after"}
+`
+
+	var buf bytes.Buffer
+	h := trifle.New(&buf, nil, trifle.WithTerminalWidth(0), trifle.WithGapAnnotations(time.Minute))
+	require.NoError(t, Merge([]Source{
+		{Name: "a", Reader: strings.NewReader(a)},
+		{Name: "b", Reader: strings.NewReader(b)},
+	}, h, MergeOptions{}))
+
+	lines := strings.Split(strings.TrimSpace(trifle.Plain(buf.String())), "\n")
+	require.Len(t, lines, 3, buf.String())
+	assert.Equal(t, "… 5m0s with no output …", lines[1])
+}
@@ -0,0 +1,253 @@
+// Package parse turns lines of text logs into slog records, so that logs
+// written by other programs can be rendered by trifle. It reads JSON lines
+// as written by slog.JSONHandler, zap, zerolog or logrus, logfmt as written
+// by slog.TextHandler, the header format of klog and glog, and the JSON
+// written by journalctl -o json.
+//
+// The time, level, message and module of a record are taken from the
+// conventional keys for them, so other keys become attributes in the order
+// they appear in the line.
+//
+//	err := parse.Feed(os.Stdin, trifle.New(os.Stdout, nil), parse.Auto)
+package parse
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"miren.dev/trifle"
+	"miren.dev/trifle/pkg/color"
+)
+
+// Format is a format of log lines.
+type Format int
+
+const (
+	// Auto detects the format of every line on its own, falling back to
+	// Text for lines in none of the other formats.
+	Auto Format = iota
+	// JSON is one JSON object per line.
+	JSON
+	// Logfmt is space separated key=value pairs.
+	Logfmt
+	// Klog is the klog and glog format: a header carrying the level, time
+	// and source location, followed by the message.
+	Klog
+	// Text is unstructured text. The level, time and logger are taken
+	// from lines in the default formats of common logging libraries, such
+	// as Python's logging, Log4j, Logback and Ruby's Logger, and the level
+	// from lines starting with one, such as "ERROR: ..." or "[warn] ...".
+	// Other lines become Info records holding the whole line.
+	Text
+	// Journal is the output of journalctl -o json: one JSON object per
+	// journal entry, its fields named in upper case. JSON lines holding
+	// journal entries are read as such.
+	Journal
+)
+
+var formatNames = []string{"auto", "json", "logfmt", "klog", "text", "journal"}
+
+func (f Format) String() string {
+	if f < 0 || int(f) >= len(formatNames) {
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+	return formatNames[f]
+}
+
+// ParseFormat returns the Format named s, as returned by Format.String.
+func ParseFormat(s string) (Format, error) {
+	for i, name := range formatNames {
+		if strings.EqualFold(s, name) {
+			return Format(i), nil
+		}
+	}
+	return 0, fmt.Errorf("parse: unknown format %q", s)
+}
+
+// Entry is a parsed line.
+type Entry struct {
+	// Record holds the time, level, message and attributes of the line.
+	// The time is zero when the line carries none.
+	Record slog.Record
+
+	// Module is the module the line was logged by, taken from a "module",
+	// "logger" or "component" key, or "" when there is none.
+	Module string
+
+	// Group, if not empty, is the group the record's attributes belong
+	// in, such as the container of a Kubernetes pod whose name is the
+	// module.
+	Group string
+
+	// Format is the format the line was read as.
+	Format Format
+}
+
+// ErrFormat is returned by [Line] when a line is not in the requested
+// format.
+var ErrFormat = errors.New("parse: line is not in the expected format")
+
+// Line parses one line of log output in format. With Auto, Line never
+// fails: lines in no known format are returned as Text entries.
+//
+// Escape sequences, such as the colors added by stern, are removed first.
+// So are the prefixes added by kubectl logs --prefix and --timestamps, by
+// stern and by docker compose logs: the pod or compose container becomes
+// the module, the container of a pod the group, and the time is used when
+// the line has none of its own.
+func Line(line string, format Format) (Entry, error) {
+	line = color.Strip(strings.TrimRight(line, "\r\n"))
+
+	line, prefix := cutPrefix(line)
+	e, err := parseLine(line, format)
+	if err != nil {
+		return Entry{}, err
+	}
+	prefix.apply(&e)
+	return e, nil
+}
+
+func parseLine(line string, format Format) (Entry, error) {
+	switch format {
+	case Auto:
+		for _, f := range []Format{JSON, Klog, Logfmt} {
+			if e, err := parseLine(line, f); err == nil {
+				return e, nil
+			}
+		}
+		return textEntry(line), nil
+	case JSON:
+		fields, err := parseJSON(line)
+		if err != nil {
+			return Entry{}, err
+		}
+		if isJournal(fields) {
+			return journalEntry(fields), nil
+		}
+		return fieldsEntry(fields, JSON), nil
+	case Journal:
+		fields, err := parseJSON(line)
+		if err != nil || !isJournal(fields) {
+			return Entry{}, ErrFormat
+		}
+		return journalEntry(fields), nil
+	case Logfmt:
+		fields, err := parseLogfmt(line)
+		if err != nil || !hasKnownKey(fields) {
+			return Entry{}, ErrFormat
+		}
+		return fieldsEntry(fields, Logfmt), nil
+	case Klog:
+		return parseKlog(line, time.Now())
+	case Text:
+		return textEntry(line), nil
+	default:
+		return Entry{}, fmt.Errorf("parse: unknown format %v", format)
+	}
+}
+
+// MaxLineSize is the length of the longest line a [Scanner] accepts.
+const MaxLineSize = 1 << 20
+
+// Scanner reads entries from a stream of log lines. Lines that are not in
+// the requested format are returned as Text entries rather than stopping
+// the scan, since logs commonly mix in output such as panics and stack
+// traces. Empty lines are skipped.
+type Scanner struct {
+	sc     *bufio.Scanner
+	format Format
+	entry  Entry
+}
+
+// NewScanner returns a Scanner reading lines in format from r.
+func NewScanner(r io.Reader, format Format) *Scanner {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64<<10), MaxLineSize)
+	return &Scanner{sc: sc, format: format}
+}
+
+// Scan advances to the next entry, which is then available through Entry.
+// It returns false at the end of the input or on a read error.
+func (s *Scanner) Scan() bool {
+	for s.sc.Scan() {
+		line := s.sc.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		e, err := Line(line, s.format)
+		if err != nil {
+			e, _ = Line(line, Text)
+		}
+		s.entry = e
+		return true
+	}
+	return false
+}
+
+// Entry returns the entry read by the last call to Scan.
+func (s *Scanner) Entry() Entry {
+	return s.entry
+}
+
+// Err returns the first read error, or nil at the end of the input.
+func (s *Scanner) Err() error {
+	return s.sc.Err()
+}
+
+// Feed reads lines in format from r and passes each enabled entry to h,
+// with the entry's module set through a "module" attribute and its
+// attributes in its group. It stops at the end of the input, or at the
+// first error from reading or from h.
+func Feed(r io.Reader, h slog.Handler, format Format) error {
+	handlers := entryHandlers{base: h}
+	ctx := context.Background()
+
+	sc := NewScanner(r, format)
+	for sc.Scan() {
+		e := sc.Entry()
+
+		eh := handlers.get(e)
+		if !eh.Enabled(ctx, e.Record.Level) {
+			continue
+		}
+		if err := eh.Handle(ctx, e.Record); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// entryHandlers derives, and remembers, the handler for the module and
+// group of each entry.
+type entryHandlers struct {
+	base     slog.Handler
+	handlers map[[2]string]slog.Handler
+}
+
+func (eh *entryHandlers) get(e Entry) slog.Handler {
+	key := [2]string{e.Module, e.Group}
+	if h, ok := eh.handlers[key]; ok {
+		return h
+	}
+
+	h := eh.base
+	if e.Module != "" {
+		h = h.WithAttrs([]slog.Attr{slog.String(trifle.ModuleKey, e.Module)})
+	}
+	if e.Group != "" {
+		h = h.WithGroup(e.Group)
+	}
+
+	if eh.handlers == nil {
+		eh.handlers = make(map[[2]string]slog.Handler)
+	}
+	eh.handlers[key] = h
+	return h
+}
@@ -0,0 +1,184 @@
+package parse
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle"
+)
+
+func attrs(r slog.Record) map[string]string {
+	m := make(map[string]string)
+	r.Attrs(func(a slog.Attr) bool {
+		m[a.Key] = a.Value.String()
+		return true
+	})
+	return m
+}
+
+func attrKeys(r slog.Record) []string {
+	var keys []string
+	r.Attrs(func(a slog.Attr) bool {
+		keys = append(keys, a.Key)
+		return true
+	})
+	return keys
+}
+
+func TestJSON(t *testing.T) {
+	e, err := Line(`{"time":"2024-05-01T10:00:00.5Z","level":"WARN","msg":"slow query","module":"db","took":1.5,"rows":3,"q":{"table":"users","ok":true}}`, JSON)
+	require.NoError(t, err)
+
+	assert.Equal(t, JSON, e.Format)
+	assert.Equal(t, "db", e.Module)
+	assert.Equal(t, slog.LevelWarn, e.Record.Level)
+	assert.Equal(t, "slow query", e.Record.Message)
+	assert.True(t, e.Record.Time.Equal(time.Date(2024, 5, 1, 10, 0, 0, 5e8, time.UTC)))
+	assert.Equal(t, []string{"took", "rows", "q"}, attrKeys(e.Record))
+
+	e.Record.Attrs(func(a slog.Attr) bool {
+		switch a.Key {
+		case "took":
+			assert.Equal(t, slog.KindFloat64, a.Value.Kind())
+		case "rows":
+			assert.Equal(t, slog.KindInt64, a.Value.Kind())
+		case "q":
+			assert.Equal(t, slog.KindGroup, a.Value.Kind())
+		}
+		return true
+	})
+}
+
+func TestJSONConventions(t *testing.T) {
+	// zap
+	e, err := Line(`{"level":"error","ts":1714557600.25,"logger":"api","msg":"boom"}`, JSON)
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelError, e.Record.Level)
+	assert.Equal(t, "api", e.Module)
+	assert.Equal(t, int64(1714557600250), e.Record.Time.UnixMilli())
+
+	// bunyan and pino
+	e, err = Line(`{"level":10,"time":1714557600250,"msg":"tick"}`, JSON)
+	require.NoError(t, err)
+	assert.Equal(t, trifle.Trace, e.Record.Level)
+	assert.Equal(t, int64(1714557600250), e.Record.Time.UnixMilli())
+
+	_, err = Line(`not json`, JSON)
+	assert.ErrorIs(t, err, ErrFormat)
+}
+
+func TestLogfmt(t *testing.T) {
+	e, err := Line(`time=2024-05-01T10:00:00Z level=DEBUG+2 msg="cache miss" key="a b" req.id=7`, Logfmt)
+	require.NoError(t, err)
+
+	assert.Equal(t, slog.LevelDebug+2, e.Record.Level)
+	assert.Equal(t, "cache miss", e.Record.Message)
+	assert.Equal(t, map[string]string{"key": "a b", "req.id": "7"}, attrs(e.Record))
+
+	_, err = Line(`a=b but not logfmt`, Logfmt)
+	assert.ErrorIs(t, err, ErrFormat)
+
+	_, err = Line(`color=red`, Logfmt)
+	assert.ErrorIs(t, err, ErrFormat, "a line without a known key is not a record")
+}
+
+func TestKlog(t *testing.T) {
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	e, err := parseKlog(`E1231 23:59:58.123456    4242 controller.go:87] "Sync failed" pod="kube-system/dns" attempt=3`, now)
+	require.NoError(t, err)
+
+	assert.Equal(t, slog.LevelError, e.Record.Level)
+	assert.Equal(t, "Sync failed", e.Record.Message)
+	assert.Equal(t, time.Date(2023, 12, 31, 23, 59, 58, 123456000, time.UTC), e.Record.Time)
+	assert.Equal(t, map[string]string{"pod": "kube-system/dns", "attempt": "3", "source": "controller.go:87"}, attrs(e.Record))
+
+	e, err = parseKlog(`I0102 00:00:00.000001       1 main.go:10] plain message`, now)
+	require.NoError(t, err)
+	assert.Equal(t, "plain message", e.Record.Message)
+	assert.Equal(t, 2024, e.Record.Time.Year())
+}
+
+func TestAuto(t *testing.T) {
+	tests := []struct {
+		line   string
+		format Format
+	}{
+		{`{"msg":"hi"}`, JSON},
+		{`W0102 00:00:00.000001       1 main.go:10] careful`, Klog},
+		{`level=info msg=hi`, Logfmt},
+		{`panic: runtime error`, Text},
+		{`{"unterminated`, Text},
+	}
+
+	for _, tt := range tests {
+		e, err := Line(tt.line, Auto)
+		require.NoError(t, err)
+		assert.Equal(t, tt.format, e.Format, tt.line)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for name, want := range map[string]slog.Level{
+		"trace":    trifle.Trace,
+		"Warning":  slog.LevelWarn,
+		"ERR":      slog.LevelError,
+		"fatal":    slog.LevelError + 4,
+		"INFO+1":   slog.LevelInfo + 1,
+		"critical": slog.LevelError + 4,
+	} {
+		l, ok := ParseLevel(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, want, l, name)
+	}
+
+	_, ok := ParseLevel("loud")
+	assert.False(t, ok)
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := ParseFormat("LOGFMT")
+	require.NoError(t, err)
+	assert.Equal(t, Logfmt, f)
+	assert.Equal(t, "logfmt", f.String())
+
+	_, err = ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestScannerFallsBackToText(t *testing.T) {
+	input := strings.Join([]string{
+		`{"level":"info","msg":"starting"}`,
+		``,
+		`goroutine 1 [running]:`,
+		`{"level":"error","msg":"stopped"}`,
+	}, "\n")
+
+	sc := NewScanner(strings.NewReader(input), JSON)
+
+	var got []string
+	for sc.Scan() {
+		got = append(got, sc.Entry().Format.String()+":"+sc.Entry().Record.Message)
+	}
+	require.NoError(t, sc.Err())
+	assert.Equal(t, []string{"json:starting", "text:goroutine 1 [running]:", "json:stopped"}, got)
+}
+
+func TestFeed(t *testing.T) {
+	var buf bytes.Buffer
+
+	h := trifle.New(&buf, nil, trifle.WithTerminalWidth(0))
+	input := `{"level":"debug","msg":"hidden"}
+{"level":"info","module":"db","msg":"connected","host":"pg"}
+`
+	require.NoError(t, Feed(strings.NewReader(input), h, Auto))
+
+	out := trifle.Plain(buf.String())
+	assert.NotContains(t, out, "hidden")
+	assert.Contains(t, out, "db connected │ host: pg")
+}
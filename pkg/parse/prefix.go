@@ -0,0 +1,86 @@
+package parse
+
+import (
+	"regexp"
+	"time"
+)
+
+// podSuffix is the alphabet of the random suffixes of pod names.
+const podSuffix = `[bcdfghjklmnpqrstvwxz2456789]`
+
+var (
+	// kubectlPrefix matches the prefix added by kubectl logs --prefix:
+	// [pod/web-7d9f8b6c5d-x2x9k/nginx] message
+	kubectlPrefix = regexp.MustCompile(`^\[pod/([^/\]]+)/([^\]]+)\] `)
+
+	// sternPrefix matches the pod and container names stern puts before
+	// every line. Since they are not delimited, the pod name has to end in
+	// the random suffix Kubernetes gives the pods of a Deployment,
+	// ReplicaSet, Job or DaemonSet, whose alphabet has no vowels, so words
+	// don't pass for one.
+	sternPrefix = regexp.MustCompile(`^([a-z0-9][-a-z0-9]*-(?:` + podSuffix + `{6,10}-)?` + podSuffix + `{5}) ([a-z0-9](?:[-a-z0-9]*[a-z0-9])?) `)
+
+	// composePrefix matches the container name docker compose logs puts
+	// before every line, padded to the longest name: "web-1  | message".
+	// Compose v1 named containers project_web_1. The replica number is
+	// required, so that text which merely contains a '|' isn't taken for a
+	// prefix.
+	composePrefix = regexp.MustCompile(`^([a-zA-Z0-9][a-zA-Z0-9_.-]*[-_]\d+) +\| `)
+
+	// prefixTimestamp matches the time added by kubectl logs --timestamps
+	// and docker compose logs --timestamps.
+	prefixTimestamp = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:\d{2})) `)
+)
+
+// linePrefix is what kubectl, stern and docker compose put in front of a
+// container's log line.
+type linePrefix struct {
+	// module is the pod or compose container, group the container within
+	// the pod.
+	module, group string
+	time          time.Time
+}
+
+// cutPrefix removes the pod, container and time prefixes added by kubectl
+// logs, stern and docker compose logs from line.
+func cutPrefix(line string) (string, linePrefix) {
+	var p linePrefix
+
+	if m := kubectlPrefix.FindStringSubmatch(line); m != nil {
+		p.module, p.group = m[1], m[2]
+		line = line[len(m[0]):]
+	} else if m := sternPrefix.FindStringSubmatch(line); m != nil {
+		p.module, p.group = m[1], m[2]
+		line = line[len(m[0]):]
+	} else if m := composePrefix.FindStringSubmatch(line); m != nil {
+		p.module = m[1]
+		line = line[len(m[0]):]
+	}
+
+	if m := prefixTimestamp.FindStringSubmatch(line); m != nil {
+		if t, err := time.Parse(time.RFC3339Nano, m[1]); err == nil {
+			p.time = t
+			line = line[len(m[0]):]
+		}
+	}
+
+	return line, p
+}
+
+// apply puts the module and group of p in e, with the entry's own module
+// nested below that of p. The time of p is used when the line carries none
+// of its own.
+func (p linePrefix) apply(e *Entry) {
+	if p.module != "" {
+		if e.Module != "" {
+			e.Module = p.module + "." + e.Module
+		} else {
+			e.Module = p.module
+		}
+		e.Group = p.group
+	}
+
+	if e.Record.Time.IsZero() && !p.time.IsZero() {
+		e.Record.Time = p.time
+	}
+}
@@ -0,0 +1,80 @@
+package parse
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle"
+)
+
+func TestKubectlPrefix(t *testing.T) {
+	e, err := Line(`[pod/web-7d9f8b6c5d-x2x9k/nginx] 2024-05-01T10:00:00.123456789Z {"level":"warn","msg":"slow upstream","module":"proxy"}`, Auto)
+	require.NoError(t, err)
+
+	assert.Equal(t, "web-7d9f8b6c5d-x2x9k.proxy", e.Module)
+	assert.Equal(t, "nginx", e.Group)
+	assert.Equal(t, JSON, e.Format)
+	assert.Equal(t, slog.LevelWarn, e.Record.Level)
+	assert.True(t, e.Record.Time.Equal(time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC)))
+}
+
+func TestKubectlTimestampOnly(t *testing.T) {
+	e, err := Line(`2024-05-01T10:00:00Z listening on :8080`, Auto)
+	require.NoError(t, err)
+
+	assert.Equal(t, "listening on :8080", e.Record.Message)
+	assert.Equal(t, "", e.Module)
+	assert.True(t, e.Record.Time.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)))
+}
+
+func TestSternPrefix(t *testing.T) {
+	// stern colors the pod and container names
+	e, err := Line("\x1b[32mapi-6b7c9d8f4b-q2w4z\x1b[0m \x1b[36mserver\x1b[0m level=error msg=\"db down\"", Auto)
+	require.NoError(t, err)
+
+	assert.Equal(t, "api-6b7c9d8f4b-q2w4z", e.Module)
+	assert.Equal(t, "server", e.Group)
+	assert.Equal(t, slog.LevelError, e.Record.Level)
+	assert.Equal(t, "db down", e.Record.Message)
+
+	// Words don't pass for pod names.
+	e, err = Line("hello-world again today", Auto)
+	require.NoError(t, err)
+	assert.Equal(t, "", e.Module)
+	assert.Equal(t, "hello-world again today", e.Record.Message)
+}
+
+func TestFeedGroupsByContainer(t *testing.T) {
+	var buf bytes.Buffer
+
+	input := "[pod/web-7d9f8b6c5d-x2x9k/nginx] level=info msg=ready port=80\n"
+	require.NoError(t, Feed(strings.NewReader(input), trifle.New(&buf, nil, trifle.WithTerminalWidth(0)), Auto))
+
+	assert.Contains(t, trifle.Plain(buf.String()), "web-7d9f8b6c5d-x2x9k ready │ nginx.port: 80")
+}
+
+func TestComposePrefix(t *testing.T) {
+	e, err := Line(`web-1  | 2024-05-01T10:00:00.000000000Z level=warn msg="slow request" path=/`, Auto)
+	require.NoError(t, err)
+
+	assert.Equal(t, "web-1", e.Module)
+	assert.Equal(t, "", e.Group)
+	assert.Equal(t, slog.LevelWarn, e.Record.Level)
+	assert.True(t, e.Record.Time.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)))
+
+	// Compose v1
+	e, err = Line(`myapp_db_1   | ready to accept connections`, Auto)
+	require.NoError(t, err)
+	assert.Equal(t, "myapp_db_1", e.Module)
+	assert.Equal(t, "ready to accept connections", e.Record.Message)
+
+	e, err = Line(`a | b`, Auto)
+	require.NoError(t, err)
+	assert.Equal(t, "", e.Module)
+	assert.Equal(t, "a | b", e.Record.Message)
+}
@@ -0,0 +1,63 @@
+package trifle
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+// Plain returns output produced by a [TextHandler] with colors and other
+// escape sequences removed and wrapped attributes joined back onto the
+// line of their record, so tests can compare it without depending on the
+// styling or the terminal width. Lines of multiline values are kept.
+func Plain(output string) string {
+	lines := strings.Split(color.Strip(output), "\n")
+
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if rest, ok := strings.CutPrefix(line, wrapIndent); ok && len(out) > 0 && rest != "" && rest[0] != ' ' {
+			prev := out[len(out)-1]
+			if !strings.HasSuffix(prev, " ") {
+				prev += " "
+			}
+			out[len(out)-1] = prev + rest
+			continue
+		}
+		out = append(out, line)
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// ContainsAttr reports whether output, as produced by a [TextHandler],
+// contains the attribute key with value, rendered the way the handler
+// renders it. Attributes in groups are matched by their full dotted key,
+// such as "request.method".
+func ContainsAttr(output, key string, value any) bool {
+	v := formatValueAsString(slog.AnyValue(value).Resolve())
+	re := regexp.MustCompile(`(^|[ ])` + regexp.QuoteMeta(key+": "+v) + `( |$)`)
+
+	for _, line := range strings.Split(Plain(output), "\n") {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesLine reports whether any record in output, as produced by a
+// [TextHandler], matches the regular expression pattern. The records are
+// matched as returned by [Plain], one line each. It panics if pattern does
+// not compile.
+func MatchesLine(output, pattern string) bool {
+	re := regexp.MustCompile(pattern)
+
+	for _, line := range strings.Split(Plain(output), "\n") {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
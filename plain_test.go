@@ -0,0 +1,40 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestPlain(t *testing.T) {
+	color.NoColor = false
+
+	var buf bytes.Buffer
+
+	logger := slog.New(New(&buf, nil, WithTerminalWidth(40), WithImportantKeys("status")))
+	logger.WithGroup("request").Info("payment failed",
+		"error", "card declined",
+		"status", 402,
+		"took", 15*time.Millisecond,
+		"method", "POST",
+	)
+	logger.Warn("config", "diff", "- a\n+ b")
+
+	plain := Plain(buf.String())
+	assert.NotContains(t, plain, "\x1b")
+	assert.Regexp(t, `\[INFO\]  payment failed │ request.error: "card declined" request.status: 402 request.took: 15ms request.method: POST\n`, plain)
+	assert.Contains(t, plain, "\n  │ - a\n  │ + b\n")
+
+	assert.True(t, ContainsAttr(buf.String(), "request.status", 402))
+	assert.True(t, ContainsAttr(buf.String(), "request.error", "card declined"))
+	assert.True(t, ContainsAttr(buf.String(), "request.took", 15*time.Millisecond))
+	assert.False(t, ContainsAttr(buf.String(), "status", 402))
+	assert.False(t, ContainsAttr(buf.String(), "request.status", 40))
+
+	assert.True(t, MatchesLine(buf.String(), `payment failed .* request.method: POST$`))
+	assert.False(t, MatchesLine(buf.String(), `^payment`))
+}
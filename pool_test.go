@@ -0,0 +1,64 @@
+package trifle
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscapeBufferReturnedAfterUse(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := slog.New(New(&buf, nil, PresetTest))
+	logger.Info("escaped", "value", "bell\a\nnext\x01line")
+
+	assert.Contains(t, buf.String(), "  │ bell\\a\n  │ next\\x01line\n")
+
+	// Escaping uses a pooled buffer. Had it been returned to the pool
+	// before being written to, the pool would now hold a buffer that is
+	// still in use, which shows as leftover contents.
+	for range 4 {
+		b := bufPool.Get().(*Buffer)
+		assert.Zero(t, b.Len(), "pooled buffer holds %q", b.String())
+		defer bufPool.Put(b)
+	}
+}
+
+func TestConcurrentHandlersShareNothingMutable(t *testing.T) {
+	var (
+		out syncBuffer
+		wg  sync.WaitGroup
+	)
+
+	handler := New(&out, nil, WithTerminalWidth(60))
+
+	const workers, records = 8, 200
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			logger := slog.New(handler).WithGroup(fmt.Sprintf("g%d", w)).With("worker", w)
+			for i := range records {
+				logger.Info("work", slog.Group("item", "id", i, "note", "a\tb\nc\x02"))
+			}
+		}()
+	}
+	wg.Wait()
+
+	plain := Plain(out.String())
+	for w := range workers {
+		prefix := fmt.Sprintf("g%d.", w)
+		for i := range records {
+			line := fmt.Sprintf("%sworker: %d %sitem.id: %d %sitem.note: ", prefix, w, prefix, i, prefix)
+			require.Contains(t, plain, line)
+		}
+	}
+	assert.Equal(t, workers*records, strings.Count(plain, "  │ c\\x02"))
+}
@@ -0,0 +1,37 @@
+package trifle
+
+// Options returns an Option that applies each of the given options in order.
+// It allows a set of options to be bundled and passed around as one.
+func Options(options ...Option) Option {
+	return func(h *TextHandler) {
+		for _, opt := range options {
+			if opt != nil {
+				opt(h)
+			}
+		}
+	}
+}
+
+var (
+	// PresetServer configures a handler for long-running services: errors
+	// and panics are critical, HTTP status and user ids are important, and
+	// request and trace ids are shown as context before the message.
+	PresetServer = Options(
+		WithCriticalKeys("error", "panic"),
+		WithImportantKeys("status", "user_id"),
+		WithContextKey("request_id", "trace_id"),
+	)
+
+	// PresetCLI configures a handler for command line tools, where output is
+	// read by a person as it happens and only errors need to stand out.
+	PresetCLI = Options(
+		WithCriticalKeys("error"),
+	)
+
+	// PresetTest configures a handler for use in tests. Wrapping is disabled
+	// so that output does not depend on the terminal running the tests.
+	PresetTest = Options(
+		WithCriticalKeys("error", "panic"),
+		WithTerminalWidth(0),
+	)
+)
@@ -0,0 +1,47 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestOptionsComposition(t *testing.T) {
+	color.NoColor = false
+
+	var buf bytes.Buffer
+
+	bundle := Options(
+		WithImportantKeys("user_id"),
+		nil,
+		WithCriticalKeys("error"),
+	)
+
+	handler := New(&buf, nil, bundle)
+	slog.New(handler).Info("composed", "user_id", "u-1", "error", "boom")
+
+	output := buf.String()
+	assert.Contains(t, output, color.New(color.FgHiYellow).Sprint("user_id"))
+	assert.Contains(t, output, color.New(color.FgHiRed).Sprint("error"))
+}
+
+func TestPresetServer(t *testing.T) {
+	color.NoColor = false
+
+	var buf bytes.Buffer
+
+	handler := New(&buf, nil, PresetServer)
+	slog.New(handler).Info("request handled", "request_id", "req-1", "status", 200)
+
+	output := buf.String()
+	assert.Regexp(t, `req-1.*request handled`, output)
+	assert.Contains(t, output, color.New(color.FgHiYellow).Sprint("status"))
+}
+
+func TestPresetTestDisablesWrapping(t *testing.T) {
+	handler := New(&bytes.Buffer{}, nil, WithTerminalWidth(40), PresetTest)
+	assert.Equal(t, 0, handler.terminalWidth)
+}
@@ -0,0 +1,221 @@
+package trifle
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+// Supervisor runs child processes and merges their output into a single
+// log stream, in the style of foreman or overmind. Each line a child writes
+// becomes a record attributed to a module named after the process, with
+// its level chosen by the stream it was written to.
+//
+// Records are written in the order lines arrive; the handler serializes
+// them, so lines from different children never interleave mid-line. With
+// a [TextHandler], each process is given its own color from Palette.
+type Supervisor struct {
+	// StdoutLevel is the level of lines written to a child's stdout.
+	StdoutLevel slog.Level
+
+	// StderrLevel is the level of lines written to a child's stderr.
+	StderrLevel slog.Level
+
+	// Raw keeps the colors and text attributes children write, see
+	// [NewRawLineWriter]. Otherwise their escape sequences are removed.
+	Raw bool
+
+	// Palette colors the names of the processes, in the order they are
+	// first given to Writers or Start, when the logger writes to a
+	// [TextHandler]. Modules given colors with [WithModuleColors] keep
+	// them.
+	Palette color.Palette
+
+	logger *slog.Logger
+
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	errs []error
+
+	// colored is logger with module colors, made by the first call to
+	// processLogger, and colors the colors it gives modules.
+	colored *slog.Logger
+	colors  *moduleColors
+	named   int // processes given a color so far
+}
+
+// NewSupervisor returns a Supervisor logging to logger. Stdout lines are
+// logged at Info and stderr lines at Warn, and processes are colored from
+// color.PaletteDefault.
+func NewSupervisor(logger *slog.Logger) *Supervisor {
+	return &Supervisor{
+		StdoutLevel: slog.LevelInfo,
+		StderrLevel: slog.LevelWarn,
+		Palette:     color.PaletteDefault,
+		logger:      logger,
+	}
+}
+
+// processLogger returns the logger for the records of the process called
+// name, giving the process the next color of the palette if it has none.
+func (s *Supervisor) processLogger(name string) *slog.Logger {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.colored == nil {
+		s.colored = s.logger
+		if th, ok := s.logger.Handler().(*TextHandler); ok && s.Palette.Len() > 0 {
+			th, s.colors = th.moduleColored(s.Palette)
+			s.colored = slog.New(th)
+		}
+	}
+
+	if s.colors != nil {
+		top, _, _ := strings.Cut(name, ".")
+		if _, loaded := s.colors.colors.LoadOrStore(top, s.Palette.At(s.named)); !loaded {
+			s.named++
+		}
+	}
+	return s.colored.With(ModuleKey, name)
+}
+
+// Writers returns the stdout and stderr writers for a process called
+// name. They are useful for wiring output that Start does not manage, such
+// as a process started elsewhere. Flush both once the process exits.
+func (s *Supervisor) Writers(name string) (stdout, stderr *LineWriter) {
+	logger := s.processLogger(name)
+	if s.Raw {
+		return NewRawLineWriter(logger, s.StdoutLevel), NewRawLineWriter(logger, s.StderrLevel)
+	}
+	return NewLineWriter(logger, s.StdoutLevel), NewLineWriter(logger, s.StderrLevel)
+}
+
+// Start starts cmd as a process called name, with its stdout and stderr
+// merged into the log. cmd.Stdout and cmd.Stderr must not be set. The exit
+// of the process is logged, and a failure is also returned from Wait.
+func (s *Supervisor) Start(name string, cmd *exec.Cmd) error {
+	if cmd.Stdout != nil || cmd.Stderr != nil {
+		return fmt.Errorf("trifle: %s: stdout and stderr must not be set", name)
+	}
+
+	stdout, stderr := s.Writers(name)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("trifle: starting %s: %w", name, err)
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		err := cmd.Wait()
+		stdout.Flush()
+		stderr.Flush()
+
+		logger := s.processLogger(name)
+		if err != nil {
+			logger.Error("process exited", "error", err)
+
+			s.mu.Lock()
+			s.errs = append(s.errs, fmt.Errorf("%s: %w", name, err))
+			s.mu.Unlock()
+			return
+		}
+
+		logger.Info("process exited")
+	}()
+
+	return nil
+}
+
+// Wait waits for every started process to exit and returns the joined
+// errors of those that failed.
+func (s *Supervisor) Wait() error {
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return errors.Join(s.errs...)
+}
+
+// Run runs cmd to completion the way build tools show the commands they
+// run: it logs the command line, with its arguments quoted for a shell,
+// logs each line of its output as a record of a module named after the
+// command, and logs how it exited and how long it took:
+//
+//	12:00:00.000 [INFO]  go $ go test -run 'Test(A|B)' ./...
+//	12:00:01.100 [INFO]  go ok  example.com/pkg  1.1s
+//	12:00:01.200 [INFO]  go exited │ status: 0 duration: 1.2s
+//
+// A failure is logged at Error, such as "exit status 1", and returned. The
+// status is logged whenever the command ran, whether it succeeded or not.
+// Stdout lines are logged at Info and stderr lines at Warn. cmd.Stdout
+// and cmd.Stderr must not be set.
+func Run(logger *slog.Logger, cmd *exec.Cmd) error {
+	if cmd.Stdout != nil || cmd.Stderr != nil {
+		return fmt.Errorf("trifle: %s: stdout and stderr must not be set", cmd.Path)
+	}
+
+	args := cmd.Args
+	if len(args) == 0 {
+		args = []string{cmd.Path}
+	}
+	logger = logger.With(ModuleKey, filepath.Base(args[0]))
+
+	stdout := NewLineWriter(logger, slog.LevelInfo)
+	stderr := NewLineWriter(logger, slog.LevelWarn)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	logger.Info("$ " + shellQuote(args))
+
+	start := time.Now()
+	err := cmd.Run()
+	stdout.Flush()
+	stderr.Flush()
+	duration := summaryDuration(time.Since(start))
+
+	var attrs []any
+	if cmd.ProcessState != nil {
+		attrs = append(attrs, "status", cmd.ProcessState.ExitCode())
+	}
+	attrs = append(attrs, "duration", duration)
+
+	if err != nil {
+		logger.Error(err.Error(), attrs...)
+		return err
+	}
+	logger.Info("exited", attrs...)
+	return nil
+}
+
+// shellQuote joins args into a command line that a POSIX shell would split
+// back into them, quoting only the arguments that need it.
+func shellQuote(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = arg
+		if arg == "" || strings.IndexFunc(arg, needsShellQuote) >= 0 {
+			quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+func needsShellQuote(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return false
+	}
+	return !strings.ContainsRune("@%+=:,./_-", r)
+}
@@ -0,0 +1,96 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestSupervisor(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	var buf bytes.Buffer
+
+	s := NewSupervisor(slog.New(New(&buf, nil, PresetTest)))
+	require.NoError(t, s.Start("web", exec.Command(sh, "-c", "echo listening; echo oops >&2")))
+	require.NoError(t, s.Start("worker", exec.Command(sh, "-c", "printf partial; exit 3")))
+
+	err = s.Wait()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "worker")
+
+	output := buf.String()
+	assert.Regexp(t, `\[INFO\].*web.*listening`, output)
+	assert.Regexp(t, `\[WARN\].*web.*oops`, output)
+	assert.Regexp(t, `worker.*partial`, output)
+	assert.Regexp(t, `\[ERROR\].*worker.*process exited`, output)
+
+	assert.Error(t, s.Start("bad", &exec.Cmd{Path: sh, Stdout: &buf}))
+}
+
+func TestSupervisorColors(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	var buf bytes.Buffer
+
+	s := NewSupervisor(slog.New(New(&buf, nil)))
+	for _, name := range []string{"web", "worker", "web.http"} {
+		stdout, _ := s.Writers(name)
+		_, err := stdout.Write([]byte("hello\n"))
+		require.NoError(t, err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], color.PaletteDefault.At(0).Sprint("web"))
+	assert.Contains(t, lines[1], color.PaletteDefault.At(1).Sprint("worker"))
+	assert.Contains(t, lines[2], color.PaletteDefault.At(0).Sprint("web.http"))
+
+	buf.Reset()
+	s = NewSupervisor(slog.New(New(&buf, nil)))
+	s.Palette = color.Palette{}
+	stdout, _ := s.Writers("web")
+	_, err := stdout.Write([]byte("hello\n"))
+	require.NoError(t, err)
+	assert.NotContains(t, buf.String(), color.PaletteDefault.At(0).Sprint("web"))
+}
+
+func TestRun(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(New(&buf, nil, PresetTest))
+
+	require.NoError(t, Run(logger, exec.Command(sh, "-c", "echo built; echo 'warning: slow' >&2", "it's")))
+	err = Run(logger, exec.Command(sh, "-c", "exit 3"))
+	require.Error(t, err)
+
+	lines := strings.Split(strings.TrimSpace(Plain(buf.String())), "\n")
+	require.Len(t, lines, 6, buf.String())
+	assert.Regexp(t, `\[INFO\]  sh \$ \S*sh -c 'echo built; echo '\\''warning: slow'\\'' >&2' 'it'\\''s'$`, lines[0])
+	// The streams are read concurrently, so their lines may come in any
+	// order.
+	assert.True(t, MatchesLine(buf.String(), `\[INFO\]  sh built$`), buf.String())
+	assert.True(t, MatchesLine(buf.String(), `\[WARN\]  sh warning: slow$`), buf.String())
+	assert.Regexp(t, `\[INFO\]  sh exited │ status: 0 duration: \S+$`, lines[3])
+	assert.Regexp(t, `\[ERROR\] sh exit status 3 │ status: 3 duration: \S+$`, lines[5])
+
+	assert.Error(t, Run(logger, &exec.Cmd{Path: sh, Stdout: &buf}))
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, `go test -run 'Test(A|B)' ./... '' 'a b'`, shellQuote([]string{"go", "test", "-run", "Test(A|B)", "./...", "", "a b"}))
+}
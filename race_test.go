@@ -0,0 +1,85 @@
+package trifle
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// These tests are meant to be run with -race. They derive handlers from a
+// shared parent while other derived handlers are logging, which is how a
+// server uses a logger across request goroutines.
+
+func TestClonesWithContextValuesLogInParallel(t *testing.T) {
+	var (
+		out syncBuffer
+		wg  sync.WaitGroup
+	)
+
+	root := slog.New(New(&out, nil,
+		WithTerminalWidth(0),
+		WithContextKey("request_id", "session_id"),
+	)).With("session_id", "sess")
+
+	const workers, records = 8, 100
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range records {
+				logger := root.With("request_id", fmt.Sprintf("req-%d-%d", w, i))
+				logger.With("module", "worker").Info("step", "n", i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	require.Len(t, lines, workers*records)
+
+	plain := Plain(out.String())
+	for w := range workers {
+		for i := range records {
+			assert.Contains(t, plain, fmt.Sprintf(" req-%d-%d sess worker step ", w, i))
+		}
+	}
+}
+
+func TestDerivingWhileLoggingKeepsParentUnchanged(t *testing.T) {
+	var (
+		out syncBuffer
+		wg  sync.WaitGroup
+	)
+
+	parent := slog.New(New(&out, nil,
+		WithTerminalWidth(0),
+		WithContextKey("request_id"),
+	))
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range 500 {
+			parent.With("request_id", fmt.Sprint(i)).WithGroup("g").With("k", i)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 500 {
+			parent.Info("parent")
+		}
+	}()
+	wg.Wait()
+
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		assert.Contains(t, line, "parent")
+		assert.NotContains(t, line, "g.k")
+	}
+	assert.Equal(t, 500, strings.Count(out.String(), "\n"))
+}
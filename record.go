@@ -0,0 +1,47 @@
+package trifle
+
+import "log/slog"
+
+// The functions below make changed copies of records, for handlers,
+// middleware and [RecordTransformer] functions. A record passed to a
+// handler may be shared with other handlers, and its copies share the
+// storage of its attributes, so adding attributes to a copy without
+// cloning it first can change what the others see. The copies keep the
+// time, level, message and PC of the record, so the source of the log call
+// is still found.
+
+// CloneWithAttrs returns a copy of r with attrs added after its own.
+func CloneWithAttrs(r slog.Record, attrs ...slog.Attr) slog.Record {
+	r = r.Clone()
+	r.AddAttrs(attrs...)
+	return r
+}
+
+// CloneWithoutAttrs returns a copy of r without its attributes whose key
+// is one of keys. Attributes within groups are kept.
+func CloneWithoutAttrs(r slog.Record, keys ...string) slog.Record {
+	return CloneReplaceAttrs(r, func(a slog.Attr) slog.Attr {
+		for _, key := range keys {
+			if a.Key == key {
+				return slog.Attr{}
+			}
+		}
+		return a
+	})
+}
+
+// CloneReplaceAttrs returns a copy of r with each of its attributes
+// replaced by what fn returns for it, in order, like the ReplaceAttr
+// function of [slog.HandlerOptions] does for handlers. An attribute for
+// which fn returns one with an empty key is left out, unless it is a group,
+// whose attributes slog inlines.
+func CloneReplaceAttrs(r slog.Record, fn func(slog.Attr) slog.Attr) slog.Record {
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		if a = fn(a); a.Key != "" || a.Value.Kind() == slog.KindGroup {
+			out.AddAttrs(a)
+		}
+		return true
+	})
+	return out
+}
@@ -0,0 +1,77 @@
+package trifle
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// attrStrings returns the attributes of r as key=value strings.
+func attrStrings(r slog.Record) []string {
+	var out []string
+	r.Attrs(func(a slog.Attr) bool {
+		out = append(out, a.String())
+		return true
+	})
+	return out
+}
+
+func TestCloneWithAttrs(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	r := slog.NewRecord(at, slog.LevelWarn, "slow", 42)
+	// Enough attributes that the record keeps some outside its inline
+	// array, where careless copies would share them.
+	r.AddAttrs(slog.Int("a", 1), slog.Int("b", 2), slog.Int("c", 3), slog.Int("d", 4), slog.Int("e", 5), slog.Int("f", 6))
+
+	x := CloneWithAttrs(r, slog.String("x", "1"))
+	y := CloneWithAttrs(r, slog.String("y", "2"))
+
+	assert.Equal(t, "a=1 b=2 c=3 d=4 e=5 f=6", strings.Join(attrStrings(r), " "))
+	assert.Equal(t, "a=1 b=2 c=3 d=4 e=5 f=6 x=1", strings.Join(attrStrings(x), " "))
+	assert.Equal(t, "a=1 b=2 c=3 d=4 e=5 f=6 y=2", strings.Join(attrStrings(y), " "))
+
+	for _, c := range []slog.Record{x, y} {
+		assert.Equal(t, at, c.Time)
+		assert.Equal(t, slog.LevelWarn, c.Level)
+		assert.Equal(t, "slow", c.Message)
+		assert.Equal(t, uintptr(42), c.PC)
+	}
+}
+
+func TestCloneWithoutAttrs(t *testing.T) {
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "login", 7)
+	r.Add("user", "ada", "password", "hunter2", slog.Group("req", "password", "kept"), "token", "abc")
+
+	c := CloneWithoutAttrs(r, "password", "token")
+	assert.Equal(t, []string{"user=ada", "req=[password=kept]"}, attrStrings(c))
+	assert.Equal(t, uintptr(7), c.PC)
+	assert.Equal(t, 4, r.NumAttrs())
+}
+
+func TestCloneReplaceAttrs(t *testing.T) {
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "query", 0)
+	r.Add("table", "users", "rows", 3, "sql", "select 1")
+
+	c := CloneReplaceAttrs(r, func(a slog.Attr) slog.Attr {
+		switch a.Key {
+		case "sql":
+			return slog.Attr{}
+		case "rows":
+			return slog.Int("rows", int(a.Value.Int64())*10)
+		}
+		return a
+	})
+	assert.Equal(t, []string{"table=users", "rows=30"}, attrStrings(c))
+	assert.Equal(t, []string{"table=users", "rows=3", "sql=select 1"}, attrStrings(r))
+}
+
+func TestCloneReplaceAttrsKeepsInlineGroups(t *testing.T) {
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "query", 0)
+	r.AddAttrs(slog.String("", "dropped"), slog.Group("", "table", "users"))
+
+	c := CloneReplaceAttrs(r, func(a slog.Attr) slog.Attr { return a })
+	assert.Equal(t, []string{"=[table=users]"}, attrStrings(c))
+}
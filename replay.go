@@ -0,0 +1,440 @@
+package trifle
+
+import (
+	"bufio"
+	"context"
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The replay format stores records so a session can be rendered again
+// later, with a different width or color setting, exactly as it was
+// logged. A stream is a sequence of frames, each a uvarint byte length
+// followed by that many bytes of JSON, or of one of the binary encodings
+// of [Encoding]. The first frame is a replayHeader, every following frame a
+// replayRecord, and all frames of a stream have the same encoding.
+//
+// Attribute values keep their kind, so durations, times and groups render
+// the same on replay. Values of other types are stored as the text the
+// handler would print for them.
+const (
+	replayFormat  = "trifle-replay"
+	replayVersion = 1
+
+	// maxReplayFrame bounds the size of a frame accepted by Replay, so a
+	// corrupt length can't exhaust memory.
+	maxReplayFrame = 64 << 20
+)
+
+type replayHeader struct {
+	Format  string    `json:"format"`
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+}
+
+type replayRecord struct {
+	Seq    uint64       `json:"n,omitempty"`
+	Time   time.Time    `json:"t,omitempty"`
+	Level  slog.Level   `json:"l"`
+	Msg    string       `json:"m"`
+	Module string       `json:"mod,omitempty"`
+	Raw    bool         `json:"raw,omitempty"`
+	Attrs  []replayAttr `json:"a,omitempty"`
+}
+
+// replayAttr is an attribute with its kind, one of the replayKind values.
+type replayAttr struct {
+	Key   string          `json:"k"`
+	Kind  string          `json:"t"`
+	Value json.RawMessage `json:"v"`
+}
+
+const (
+	replayString   = "s"
+	replayInt      = "i"
+	replayUint     = "u"
+	replayFloat    = "f"
+	replayBool     = "b"
+	replayDuration = "d"
+	replayTime     = "ts"
+	replayBytes    = "x"
+	replayGroup    = "g"
+)
+
+// Recorder is a [slog.Handler] that writes records in the replay format,
+// to be rendered later with [Replay] or the trifle replay command. It
+// accepts every level unless configured otherwise.
+//
+// Like [TextHandler], it treats "module" attributes as the record's module.
+// Context values such as request ids are stored with the record.
+//
+// Every record is numbered in the order it reaches the recorder. A handler
+// configured with [WithSequenceNumbers] shows these numbers on replay.
+type Recorder struct {
+	rw    *recorderWriter
+	opts  slog.HandlerOptions
+	chain attrChain
+}
+
+type recorderWriter struct {
+	mu  sync.Mutex
+	seq atomic.Uint64
+	w   io.Writer
+	enc Encoding
+	buf []byte
+	err error // from writing the header, returned by every Handle
+}
+
+// NewRecorder returns a Recorder writing JSON to w. The header is written
+// immediately. If opts is nil, records at every level are recorded.
+func NewRecorder(w io.Writer, opts *slog.HandlerOptions) *Recorder {
+	return NewEncodedRecorder(w, EncodingJSON, opts)
+}
+
+// NewEncodedRecorder is like [NewRecorder], but writes frames in the
+// encoding enc.
+func NewEncodedRecorder(w io.Writer, enc Encoding, opts *slog.HandlerOptions) *Recorder {
+	if opts == nil {
+		opts = &slog.HandlerOptions{Level: slog.Level(math.MinInt)}
+	}
+
+	rw := &recorderWriter{w: w, enc: enc}
+	if enc < EncodingJSON || enc > EncodingCBOR {
+		rw.err = fmt.Errorf("trifle: unknown encoding %v", enc)
+	} else {
+		rw.err = rw.writeHeader(replayHeader{
+			Format:  replayFormat,
+			Version: replayVersion,
+			Created: time.Now(),
+		})
+	}
+
+	return &Recorder{rw: rw, opts: *opts}
+}
+
+func (rw *recorderWriter) writeHeader(h replayHeader) error {
+	if c := rw.enc.codec(); c != nil {
+		return rw.writeFrame(appendBinaryHeader(c, nil, h))
+	}
+
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return rw.writeFrame(data)
+}
+
+func (rw *recorderWriter) writeRecord(rec replayRecord, attrs []slog.Attr) error {
+	if c := rw.enc.codec(); c != nil {
+		return rw.writeFrame(appendBinaryRecord(c, nil, rec, attrs))
+	}
+
+	rec.Attrs = encodeReplayAttrs(attrs)
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return rw.writeFrame(data)
+}
+
+func (rw *recorderWriter) writeFrame(data []byte) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	rw.buf = binary.AppendUvarint(rw.buf[:0], uint64(len(data)))
+	rw.buf = append(rw.buf, data...)
+	_, err := rw.w.Write(rw.buf)
+	return err
+}
+
+// Enabled reports whether the recorder records messages at level.
+func (r *Recorder) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if r.opts.Level != nil {
+		minLevel = r.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+// WithAttrs returns a Recorder that adds attrs to every record.
+func (r *Recorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	nr := *r
+	nr.chain = r.chain.withAttrs(attrs)
+	return &nr
+}
+
+// WithGroup returns a Recorder that nests later attributes in group name.
+func (r *Recorder) WithGroup(name string) slog.Handler {
+	nr := *r
+	nr.chain = r.chain.withGroup(name)
+	return &nr
+}
+
+// Handle writes rec as one frame.
+func (r *Recorder) Handle(ctx context.Context, rec slog.Record) error {
+	if r.rw.err != nil {
+		return r.rw.err
+	}
+
+	return r.rw.writeRecord(replayRecord{
+		Seq:    r.rw.seq.Add(1),
+		Time:   rec.Time,
+		Level:  rec.Level,
+		Msg:    rec.Message,
+		Module: r.chain.module,
+		Raw:    isRawMessage(ctx),
+	}, r.chain.attrs(ctx, rec))
+}
+
+func encodeReplayAttrs(as []slog.Attr) []replayAttr {
+	as = replayableAttrs(as)
+	out := make([]replayAttr, 0, len(as))
+	for _, a := range as {
+		out = append(out, encodeReplayAttr(a))
+	}
+	return out
+}
+
+func encodeReplayAttr(a slog.Attr) replayAttr {
+	var (
+		kind string
+		v    any
+	)
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		kind, v = replayString, a.Value.String()
+	case slog.KindInt64:
+		kind, v = replayInt, a.Value.Int64()
+	case slog.KindUint64:
+		kind, v = replayUint, a.Value.Uint64()
+	case slog.KindFloat64:
+		// as text, since JSON can't represent NaN and the infinities
+		kind, v = replayFloat, strconv.FormatFloat(a.Value.Float64(), 'g', -1, 64)
+	case slog.KindBool:
+		kind, v = replayBool, a.Value.Bool()
+	case slog.KindDuration:
+		kind, v = replayDuration, int64(a.Value.Duration())
+	case slog.KindTime:
+		kind, v = replayTime, a.Value.Time()
+	case slog.KindGroup:
+		kind, v = replayGroup, encodeReplayAttrs(a.Value.Group())
+	default:
+		if bs, ok := byteSlice(a.Value.Any()); ok {
+			kind, v = replayBytes, bs
+		} else {
+			kind, v = replayString, anyText(a.Value.Any())
+		}
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		kind, data = replayString, []byte(strconv.Quote(fmt.Sprintf("!ERROR:%v", err)))
+	}
+
+	return replayAttr{Key: a.Key, Kind: kind, Value: data}
+}
+
+// anyText returns the text the handler prints for a value of kind Any.
+func anyText(v any) (str string) {
+	defer func() {
+		if r := recover(); r != nil {
+			str = fmt.Sprintf("!PANIC: %v", r)
+		}
+	}()
+
+	if tm, ok := v.(encoding.TextMarshaler); ok {
+		data, err := tm.MarshalText()
+		if err != nil {
+			return fmt.Sprintf("!ERROR:%v", err)
+		}
+		return string(data)
+	}
+	return fmt.Sprintf("%+v", v)
+}
+
+func decodeReplayAttrs(ras []replayAttr) ([]slog.Attr, error) {
+	attrs := make([]slog.Attr, 0, len(ras))
+	for _, ra := range ras {
+		v, err := decodeReplayValue(ra)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %q: %w", ra.Key, err)
+		}
+		attrs = append(attrs, slog.Attr{Key: ra.Key, Value: v})
+	}
+	return attrs, nil
+}
+
+func decodeReplayValue(ra replayAttr) (slog.Value, error) {
+	var err error
+
+	switch ra.Kind {
+	case replayString:
+		var s string
+		err = json.Unmarshal(ra.Value, &s)
+		return slog.StringValue(s), err
+	case replayInt:
+		var i int64
+		err = json.Unmarshal(ra.Value, &i)
+		return slog.Int64Value(i), err
+	case replayUint:
+		var u uint64
+		err = json.Unmarshal(ra.Value, &u)
+		return slog.Uint64Value(u), err
+	case replayFloat:
+		var s string
+		if err = json.Unmarshal(ra.Value, &s); err != nil {
+			return slog.Value{}, err
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		return slog.Float64Value(f), err
+	case replayBool:
+		var b bool
+		err = json.Unmarshal(ra.Value, &b)
+		return slog.BoolValue(b), err
+	case replayDuration:
+		var d int64
+		err = json.Unmarshal(ra.Value, &d)
+		return slog.DurationValue(time.Duration(d)), err
+	case replayTime:
+		var t time.Time
+		err = json.Unmarshal(ra.Value, &t)
+		return slog.TimeValue(t), err
+	case replayBytes:
+		var bs []byte
+		err = json.Unmarshal(ra.Value, &bs)
+		return slog.AnyValue(bs), err
+	case replayGroup:
+		var group []replayAttr
+		if err = json.Unmarshal(ra.Value, &group); err != nil {
+			return slog.Value{}, err
+		}
+		attrs, err := decodeReplayAttrs(group)
+		return slog.GroupValue(attrs...), err
+	default:
+		return slog.Value{}, fmt.Errorf("unknown kind %q", ra.Kind)
+	}
+}
+
+// ErrNotReplay is returned by [Replay] when the input does not start with
+// a replay header.
+var ErrNotReplay = errors.New("trifle: not a replay stream")
+
+// Replay reads records written by a [Recorder] from r and passes each to h,
+// with the record's module set through a "module" attribute. It stops at
+// the end of the stream, or at the first error from reading or from h.
+func Replay(r io.Reader, h slog.Handler) error {
+	br := bufio.NewReader(r)
+
+	frame, err := readReplayFrame(br)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return ErrNotReplay
+		}
+		return fmt.Errorf("%w: %v", ErrNotReplay, err)
+	}
+
+	// The first frame tells the encoding of the stream.
+	codec := frameEncoding(frame).codec()
+
+	var header replayHeader
+	if codec != nil {
+		header, err = decodeBinaryHeader(codec, frame)
+	} else {
+		err = json.Unmarshal(frame, &header)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotReplay, err)
+	}
+	if header.Format != replayFormat {
+		return ErrNotReplay
+	}
+	if header.Version > replayVersion {
+		return fmt.Errorf("trifle: unsupported replay version %d", header.Version)
+	}
+
+	modules := map[string]slog.Handler{"": h}
+	ctx := context.Background()
+
+	for {
+		frame, err := readReplayFrame(br)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("trifle: reading replay: %w", err)
+		}
+
+		rec, attrs, err := decodeReplayRecord(codec, frame)
+		if err != nil {
+			return fmt.Errorf("trifle: reading replay: %w", err)
+		}
+
+		mh, ok := modules[rec.Module]
+		if !ok {
+			mh = h.WithAttrs([]slog.Attr{slog.String(ModuleKey, rec.Module)})
+			modules[rec.Module] = mh
+		}
+
+		if !mh.Enabled(ctx, rec.Level) {
+			continue
+		}
+
+		sr := slog.NewRecord(rec.Time, rec.Level, rec.Msg, 0)
+		sr.AddAttrs(attrs...)
+
+		hctx := ctx
+		if rec.Seq != 0 {
+			hctx = withSequence(hctx, rec.Seq)
+		}
+		if rec.Raw {
+			hctx = withRawMessage(hctx)
+		}
+		if err := mh.Handle(hctx, sr); err != nil {
+			return err
+		}
+	}
+}
+
+// decodeReplayRecord decodes a record frame, binary if codec is not nil.
+func decodeReplayRecord(codec binaryCodec, frame []byte) (replayRecord, []slog.Attr, error) {
+	if codec != nil {
+		return decodeBinaryRecord(codec, frame)
+	}
+
+	var rec replayRecord
+	if err := json.Unmarshal(frame, &rec); err != nil {
+		return rec, nil, err
+	}
+	attrs, err := decodeReplayAttrs(rec.Attrs)
+	return rec, attrs, err
+}
+
+func readReplayFrame(br *bufio.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxReplayFrame {
+		return nil, fmt.Errorf("frame of %d bytes exceeds the limit", n)
+	}
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(br, data); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
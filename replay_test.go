@@ -0,0 +1,90 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestReplayRoundTrip(t *testing.T) {
+	color.NoColor = false
+
+	// Attributes added with WithAttrs are preformatted and never wrap, while
+	// on replay they arrive with the record, so compare without wrapping.
+	var (
+		direct, recorded bytes.Buffer
+		options          = []Option{WithTerminalWidth(0), PresetServer}
+	)
+
+	handlers := []slog.Handler{
+		New(&direct, &slog.HandlerOptions{Level: Trace}, options...),
+		NewRecorder(&recorded, nil),
+	}
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ctx := ContextWithRequestID(context.Background(), "req-42")
+
+	for _, h := range handlers {
+		h = h.WithAttrs([]slog.Attr{slog.String("module", "api"), slog.String("region", "eu")})
+
+		r := slog.NewRecord(start, slog.LevelInfo, "request handled", 0)
+		r.AddAttrs(
+			slog.Int("status", 200),
+			slog.Duration("took", 12*time.Millisecond),
+			slog.Float64("ratio", math.Inf(1)),
+			slog.Any("error", errors.New("boom")),
+			slog.Any("body", []byte("hi")),
+			slog.Group("client", slog.String("ip", "10.0.0.7"), slog.Bool("tls", true)),
+		)
+		require.NoError(t, h.Handle(ctx, r))
+
+		g := h.WithGroup("db").WithAttrs([]slog.Attr{slog.String("table", "users")})
+		r = slog.NewRecord(start.Add(time.Second), Trace, "query", 0)
+		r.AddAttrs(slog.Time("at", start), slog.Uint64("rows", 3), slog.String("sql", "SELECT *\nFROM users"))
+		require.NoError(t, g.Handle(ctx, r))
+	}
+
+	var replayed bytes.Buffer
+	err := Replay(&recorded, New(&replayed, &slog.HandlerOptions{Level: Trace}, options...))
+	require.NoError(t, err)
+
+	assert.Equal(t, direct.String(), replayed.String())
+}
+
+func TestReplayDifferentWidth(t *testing.T) {
+	var recorded bytes.Buffer
+
+	logger := slog.New(NewRecorder(&recorded, nil))
+	logger.Debug("wide record", "alpha", "the first value", "beta", "the second value")
+
+	var out bytes.Buffer
+	require.NoError(t, Replay(bytes.NewReader(recorded.Bytes()), New(&out, nil, WithTerminalWidth(40))))
+	assert.Empty(t, out.String(), "replay honors the handler's level")
+
+	require.NoError(t, Replay(bytes.NewReader(recorded.Bytes()), New(&out, &slog.HandlerOptions{Level: Debug}, WithTerminalWidth(40))))
+	assert.Greater(t, strings.Count(out.String(), "\n"), 1)
+}
+
+func TestReplayRejectsOtherInput(t *testing.T) {
+	err := Replay(strings.NewReader(""), New(&bytes.Buffer{}, nil))
+	assert.ErrorIs(t, err, ErrNotReplay)
+
+	err = Replay(strings.NewReader("\x05hello"), New(&bytes.Buffer{}, nil))
+	assert.ErrorIs(t, err, ErrNotReplay)
+
+	var recorded bytes.Buffer
+	slog.New(NewRecorder(&recorded, nil)).Info("cut short")
+	truncated := recorded.Bytes()[:recorded.Len()-3]
+
+	err = Replay(bytes.NewReader(truncated), New(&bytes.Buffer{}, nil))
+	assert.Error(t, err)
+}
@@ -0,0 +1,126 @@
+package trifle
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"log/slog"
+	"time"
+)
+
+// RequestIDKey is the attribute key used for request ids taken from a
+// context. It is a context key of handlers not given others with
+// [WithContextKey], and one of those of [PresetServer].
+const RequestIDKey = "request_id"
+
+// defaultContextKeys are the context keys of a handler not given any with
+// [WithContextKey]: those of the ids trifle takes from a context itself.
+var defaultContextKeys = []string{RequestIDKey, TraceIDKey, SpanIDKey}
+
+// crockford is the Crockford base32 alphabet, which sorts the same way as
+// the values it encodes and avoids easily confused letters.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewRequestID returns a short, URL-safe id that sorts by creation time.
+// The first 10 characters encode the current time in milliseconds and the
+// remaining 8 are random, similar to a truncated ULID.
+func NewRequestID() string {
+	return newRequestID(time.Now())
+}
+
+func newRequestID(t time.Time) string {
+	var id [18]byte
+
+	ms := uint64(t.UnixMilli())
+	for i := 9; i >= 0; i-- {
+		id[i] = crockford[ms&0x1f]
+		ms >>= 5
+	}
+
+	var rnd [8]byte
+	_, _ = rand.Read(rnd[3:])
+	bits := binary.BigEndian.Uint64(rnd[:])
+	for i := 17; i >= 10; i-- {
+		id[i] = crockford[bits&0x1f]
+		bits >>= 5
+	}
+
+	return string(id[:])
+}
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the request id. When a
+// record is logged with that context, the handler adds the id under
+// [RequestIDKey] unless the record or logger already has one.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request id stored in ctx by
+// [ContextWithRequestID].
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// contextAttrs returns the attributes carried by ctx that should be added
+// to records logged with it.
+func contextAttrs(ctx context.Context) []slog.Attr {
+	var attrs []slog.Attr
+	if id, ok := RequestIDFromContext(ctx); ok {
+		attrs = append(attrs, slog.String(RequestIDKey, id))
+	}
+	if tc, ok := TraceContextFromContext(ctx); ok {
+		attrs = append(attrs,
+			slog.String(TraceIDKey, tc.TraceID),
+			slog.String(SpanIDKey, tc.SpanID),
+		)
+	}
+	return attrs
+}
+
+// addContextAttrs returns r with the attributes carried by ctx added,
+// skipping any key that the record, the handler's context values or its
+// attributes from WithAttrs already provide.
+func (h *commonHandler) addContextAttrs(ctx context.Context, r slog.Record) slog.Record {
+	attrs := contextAttrs(ctx)
+	if len(attrs) == 0 {
+		return r
+	}
+
+	present := make(map[string]bool, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		present[a.Key] = true
+		return true
+	})
+
+	var missing []slog.Attr
+	for _, a := range attrs {
+		if present[a.Key] || h.contextValues[a.Key].first != "" || h.hasAttr(a.Key) {
+			continue
+		}
+		missing = append(missing, a)
+	}
+	if len(missing) == 0 {
+		return r
+	}
+
+	return CloneWithAttrs(r, missing...)
+}
+
+// hasAttr reports whether the handler was given an attribute with key
+// through WithAttrs, in any group.
+func (h *commonHandler) hasAttr(key string) bool {
+	for _, goa := range h.goas {
+		for _, a := range goa.attrs {
+			if a.Key == key {
+				return true
+			}
+		}
+	}
+	return false
+}
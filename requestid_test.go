@@ -0,0 +1,66 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRequestID(t *testing.T) {
+	id := NewRequestID()
+	assert.Len(t, id, 18)
+	for _, r := range id {
+		assert.True(t, strings.ContainsRune(crockford, r), "unexpected character %q", r)
+	}
+
+	earlier := newRequestID(time.UnixMilli(1_000_000))
+	later := newRequestID(time.UnixMilli(1_000_001))
+	assert.Less(t, earlier, later)
+	assert.NotEqual(t, NewRequestID(), NewRequestID())
+}
+
+func TestRequestIDFromContext(t *testing.T) {
+	var buf bytes.Buffer
+
+	// The request id is a context key by default.
+	logger := slog.New(New(&buf, nil))
+	ctx := ContextWithRequestID(context.Background(), "req-from-ctx")
+
+	logger.InfoContext(ctx, "handled")
+	assert.Regexp(t, `req-from-ctx.*handled`, buf.String())
+
+	buf.Reset()
+	logger.With(RequestIDKey, "req-attr").InfoContext(ctx, "explicit wins")
+	assert.Contains(t, buf.String(), "req-attr")
+	assert.NotContains(t, buf.String(), "req-from-ctx")
+
+	buf.Reset()
+	logger.InfoContext(context.Background(), "no id")
+	assert.NotContains(t, buf.String(), "req-")
+}
+
+func TestRequestIDFromContextWithAttrs(t *testing.T) {
+	var buf bytes.Buffer
+
+	// Without context keys, the id given with WithAttrs is an ordinary
+	// attribute, and still wins over the context.
+	logger := slog.New(New(&buf, nil, WithContextKey()))
+	ctx := ContextWithRequestID(context.Background(), "req-from-ctx")
+
+	logger.With(RequestIDKey, "req-attr").InfoContext(ctx, "explicit wins")
+	assert.True(t, ContainsAttr(buf.String(), RequestIDKey, "req-attr"), buf.String())
+	assert.NotContains(t, buf.String(), "req-from-ctx")
+
+	buf.Reset()
+	logger.WithGroup("req").With(RequestIDKey, "req-attr").InfoContext(ctx, "in a group")
+	assert.NotContains(t, buf.String(), "req-from-ctx")
+
+	buf.Reset()
+	logger.With("user", "ada").InfoContext(ctx, "other attrs")
+	assert.True(t, ContainsAttr(buf.String(), RequestIDKey, "req-from-ctx"), buf.String())
+}
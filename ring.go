@@ -0,0 +1,137 @@
+package trifle
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// Ring is a [slog.Handler] keeping the latest records in memory, dropping
+// the oldest once it holds its size. It records every level. Use it as a
+// [WithDropSink] to keep what was filtered out, or with [Multi] to have
+// recent history at hand when something goes wrong:
+//
+//	ring := trifle.NewRing(500)
+//	slog.SetDefault(slog.New(trifle.Multi(trifle.Quick(), ring)))
+//	...
+//	ring.Dump(trifle.New(os.Stderr, nil))
+type Ring struct {
+	chain attrChain
+	state *ringState
+}
+
+// ringState is shared by a Ring and the handlers derived from it.
+type ringState struct {
+	mu      sync.Mutex
+	entries []ringEntry
+	next    int // where the next entry goes once entries is full
+	size    int
+}
+
+type ringEntry struct {
+	module string
+	r      slog.Record
+}
+
+// NewRing returns a Ring holding up to size records. A size below one is
+// taken as one.
+func NewRing(size int) *Ring {
+	return &Ring{state: &ringState{size: max(size, 1)}}
+}
+
+// Enabled reports true: a Ring records every level.
+func (rg *Ring) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle stores a copy of r with the attributes added to rg.
+func (rg *Ring) Handle(ctx context.Context, r slog.Record) error {
+	stored := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	stored.AddAttrs(rg.chain.attrs(ctx, r)...)
+
+	st := rg.state
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	e := ringEntry{module: rg.chain.module, r: stored}
+	if len(st.entries) < st.size {
+		st.entries = append(st.entries, e)
+	} else {
+		st.entries[st.next] = e
+		st.next = (st.next + 1) % st.size
+	}
+	return nil
+}
+
+// WithAttrs returns a Ring adding attrs to every record, storing into the
+// same buffer.
+func (rg *Ring) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Ring{chain: rg.chain.withAttrs(attrs), state: rg.state}
+}
+
+// WithGroup returns a Ring nesting later attributes in group name,
+// storing into the same buffer.
+func (rg *Ring) WithGroup(name string) slog.Handler {
+	return &Ring{chain: rg.chain.withGroup(name), state: rg.state}
+}
+
+// Len returns the number of records held.
+func (rg *Ring) Len() int {
+	rg.state.mu.Lock()
+	defer rg.state.mu.Unlock()
+	return len(rg.state.entries)
+}
+
+// Records returns the records held, oldest first. The module of a record,
+// if any, is its "module" attribute.
+func (rg *Ring) Records() []slog.Record {
+	entries := rg.snapshot()
+
+	records := make([]slog.Record, len(entries))
+	for i, e := range entries {
+		records[i] = e.r.Clone()
+		if e.module != "" {
+			records[i].AddAttrs(slog.String(ModuleKey, e.module))
+		}
+	}
+	return records
+}
+
+// Dump passes the records held to h, oldest first, each with its module,
+// and stops at the first error. The records stay in rg.
+func (rg *Ring) Dump(h slog.Handler) error {
+	ctx := context.Background()
+
+	for _, e := range rg.snapshot() {
+		eh := h
+		if e.module != "" {
+			eh = h.WithAttrs([]slog.Attr{slog.String(ModuleKey, e.module)})
+		}
+		if !eh.Enabled(ctx, e.r.Level) {
+			continue
+		}
+		if err := eh.Handle(ctx, e.r.Clone()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset drops all records held.
+func (rg *Ring) Reset() {
+	rg.state.mu.Lock()
+	defer rg.state.mu.Unlock()
+
+	rg.state.entries = nil
+	rg.state.next = 0
+}
+
+func (rg *Ring) snapshot() []ringEntry {
+	st := rg.state
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	entries := make([]ringEntry, 0, len(st.entries))
+	entries = append(entries, st.entries[st.next:]...)
+	return append(entries, st.entries[:st.next]...)
+}
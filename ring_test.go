@@ -0,0 +1,47 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRingKeepsLatest(t *testing.T) {
+	ring := NewRing(3)
+	log := slog.New(ring)
+
+	for i := range 5 {
+		log.Debug("tick", "i", i)
+	}
+
+	require.Equal(t, 3, ring.Len())
+	var seen []int64
+	for _, r := range ring.Records() {
+		r.Attrs(func(a slog.Attr) bool {
+			seen = append(seen, a.Value.Int64())
+			return true
+		})
+	}
+	assert.Equal(t, []int64{2, 3, 4}, seen)
+
+	ring.Reset()
+	assert.Equal(t, 0, ring.Len())
+}
+
+func TestRingDump(t *testing.T) {
+	ring := NewRing(10)
+	log := slog.New(ring)
+	log.With(ModuleKey, "db").WithGroup("q").Info("slow", "ms", 250)
+	log.Warn("disk")
+
+	var buf bytes.Buffer
+	require.NoError(t, ring.Dump(New(&buf, nil, WithTerminalWidth(0))))
+
+	out := Plain(buf.String())
+	assert.True(t, MatchesLine(out, `db slow .*q\.ms: 250`), out)
+	assert.Contains(t, out, "disk")
+	assert.Equal(t, 2, ring.Len())
+}
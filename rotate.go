@@ -0,0 +1,237 @@
+package trifle
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// RotateOptions configures a [RotatingFile].
+type RotateOptions struct {
+	// MaxBytes is the size at which the file is rotated. Zero disables
+	// rotation.
+	MaxBytes int64
+
+	// MaxFiles is the number of rotated files kept next to the current
+	// one, named path.1 for the most recent through path.MaxFiles. Older
+	// files are removed. Zero keeps only the current file.
+	MaxFiles int
+
+	// Compress gzips rotated files, adding a .gz suffix to their names.
+	Compress bool
+
+	// OnError is called with the errors of rotations started by Write,
+	// which go on writing to the current file rather than drop the write.
+	// Nil reports them on os.Stderr.
+	OnError func(error)
+}
+
+// RotatingFile is an io.WriteCloser appending to a file that is rotated
+// once it grows past a size limit. A single Write is never split across
+// files, so a writer that writes whole records keeps them intact.
+type RotatingFile struct {
+	path string
+	opts RotateOptions
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+
+	// closed is set by Close. f alone can be nil after a failed reopen,
+	// which the next Write retries.
+	closed bool
+}
+
+// OpenRotatingFile opens path for appending, creating it if needed, and
+// returns a RotatingFile writing to it.
+func OpenRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	rf := &RotatingFile{path: path, opts: opts}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *RotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	rf.f = f
+	rf.size = fi.Size()
+	return nil
+}
+
+// reopen opens the file again if a failed rotation left it closed.
+func (rf *RotatingFile) reopen() error {
+	if rf.closed {
+		return os.ErrClosed
+	}
+	if rf.f == nil {
+		return rf.open()
+	}
+	return nil
+}
+
+// Write appends p to the file, rotating it first if p would take it past
+// MaxBytes. A write larger than MaxBytes still goes to a single file. A
+// failed rotation is reported to OnError and p is written anyway, to
+// whichever file is open at path.
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if err := rf.reopen(); err != nil {
+		return 0, err
+	}
+
+	if rf.opts.MaxBytes > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.opts.MaxBytes {
+		if err := rf.rotate(); err != nil {
+			err = fmt.Errorf("trifle: rotating %s: %w", rf.path, err)
+			if rf.f == nil {
+				return 0, err
+			}
+			rf.report(err)
+		}
+	}
+
+	n, err := rf.f.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// Rotate moves the current file aside and starts a new one, regardless of
+// its size.
+func (rf *RotatingFile) Rotate() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if err := rf.reopen(); err != nil {
+		return err
+	}
+	return rf.rotate()
+}
+
+// rotate moves the current file aside and opens a new one. Whatever fails
+// on the way, it leaves a file open at path if it can: a new one, or the
+// old one again when it couldn't be moved. A failed compression leaves the
+// backup uncompressed.
+func (rf *RotatingFile) rotate() error {
+	err := rf.f.Close()
+	rf.f = nil
+
+	first := ""
+	if err == nil {
+		first, err = rf.moveAside()
+	}
+	if oerr := rf.open(); oerr != nil {
+		return errors.Join(err, oerr)
+	}
+
+	if err != nil || first == "" || !rf.opts.Compress {
+		return err
+	}
+	return compressFile(first, first+".gz")
+}
+
+// moveAside removes the file or, when backups are kept, shifts them and
+// renames the file to the first one, whose name it returns.
+func (rf *RotatingFile) moveAside() (string, error) {
+	if rf.opts.MaxFiles <= 0 {
+		if err := os.Remove(rf.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		return "", nil
+	}
+
+	suffix := ""
+	if rf.opts.Compress {
+		suffix = ".gz"
+	}
+
+	// Shift path.N-1 to path.N and so on, dropping the oldest.
+	oldest := rf.backupName(rf.opts.MaxFiles, suffix)
+	if err := os.Remove(oldest); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	for i := rf.opts.MaxFiles - 1; i >= 1; i-- {
+		err := os.Rename(rf.backupName(i, suffix), rf.backupName(i+1, suffix))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+
+	first := rf.backupName(1, "")
+	if err := os.Rename(rf.path, first); err != nil {
+		return "", err
+	}
+	return first, nil
+}
+
+// report passes err to OnError, or tells on os.Stderr without one.
+func (rf *RotatingFile) report(err error) {
+	if rf.opts.OnError != nil {
+		rf.opts.OnError(err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, err)
+}
+
+func (rf *RotatingFile) backupName(i int, suffix string) string {
+	return fmt.Sprintf("%s.%d%s", rf.path, i, suffix)
+}
+
+// compressFile gzips src into dst and removes src.
+func compressFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(out)
+	_, err = io.Copy(zw, in)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+
+	in.Close()
+	return os.Remove(src)
+}
+
+// Close closes the current file.
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	rf.closed = true
+	if rf.f == nil {
+		return nil
+	}
+	err := rf.f.Close()
+	rf.f = nil
+	return err
+}
@@ -0,0 +1,118 @@
+package trifle
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	rf, err := OpenRotatingFile(path, RotateOptions{MaxBytes: 10, MaxFiles: 2})
+	require.NoError(t, err)
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := rf.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.NoError(t, rf.Close())
+
+	read := func(name string) string {
+		data, err := os.ReadFile(name)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	assert.Equal(t, "fourth\n", read(path))
+	assert.Equal(t, "third\n", read(path+".1"))
+	assert.Equal(t, "second\n", read(path+".2"))
+	assert.NoFileExists(t, path+".3")
+
+	_, err = rf.Write([]byte("closed"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestRotatingFileCompress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	rf, err := OpenRotatingFile(path, RotateOptions{MaxFiles: 1, Compress: true})
+	require.NoError(t, err)
+	defer rf.Close()
+
+	_, err = rf.Write([]byte(strings.Repeat("record\n", 100)))
+	require.NoError(t, err)
+	require.NoError(t, rf.Rotate())
+
+	assert.NoFileExists(t, path+".1")
+
+	f, err := os.Open(path + ".1.gz")
+	require.NoError(t, err)
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("record\n", 100), string(data))
+}
+
+func TestRotatingFileWithoutBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	rf, err := OpenRotatingFile(path, RotateOptions{MaxBytes: 4})
+	require.NoError(t, err)
+	defer rf.Close()
+
+	rf.Write([]byte("old\n"))
+	rf.Write([]byte("new\n"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new\n", string(data))
+	assert.NoFileExists(t, path+".1")
+}
+
+func TestRotatingFileRotateError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	// A non-empty directory in the way of the backup fails the rotation.
+	require.NoError(t, os.MkdirAll(filepath.Join(path+".1", "busy"), 0o755))
+
+	var errs []error
+	rf, err := OpenRotatingFile(path, RotateOptions{
+		MaxBytes: 10,
+		MaxFiles: 1,
+		OnError:  func(err error) { errs = append(errs, err) },
+	})
+	require.NoError(t, err)
+	defer rf.Close()
+
+	for _, line := range []string{"first\n", "second\n"} {
+		_, err := rf.Write([]byte(line))
+		require.NoError(t, err)
+	}
+	require.Len(t, errs, 1)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(data))
+
+	require.NoError(t, os.RemoveAll(path+".1"))
+	_, err = rf.Write([]byte("third\n"))
+	require.NoError(t, err)
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "third\n", string(data))
+	data, err = os.ReadFile(path + ".1")
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(data))
+	assert.Len(t, errs, 1)
+}
@@ -0,0 +1,278 @@
+package trifle
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+// EventKey is the attribute key that names the event a record is, for
+// records whose message doesn't, see [DefineEvent].
+const EventKey = "event"
+
+var schemaWarningColor = color.New(color.FgHiYellow)
+
+// Event is the schema of an event defined with [DefineEvent].
+type Event struct {
+	name     string
+	required []string
+	optional []string
+	template string
+}
+
+var eventRegistry struct {
+	mu     sync.RWMutex
+	events map[string]*Event
+}
+
+// DefineEvent registers the schema of the event called name, with the keys
+// its records must have, and returns it so that optional keys and a
+// template can be added:
+//
+//	var paymentFailed = trifle.DefineEvent("payment.failed", "amount", "currency", "reason").
+//		Optional("retry").
+//		Template("charge of {amount} {currency} failed: {reason}")
+//
+// A record is the event if its message is name, or if it has an [EventKey]
+// attribute set to name. Handlers made with [WithEventSchemas] check the
+// records of registered events, and render them with their templates.
+// Defining an event again replaces its schema.
+func DefineEvent(name string, required ...string) *Event {
+	e := &Event{name: name, required: required}
+
+	eventRegistry.mu.Lock()
+	defer eventRegistry.mu.Unlock()
+
+	if eventRegistry.events == nil {
+		eventRegistry.events = make(map[string]*Event)
+	}
+	eventRegistry.events[name] = e
+	return e
+}
+
+// Optional adds keys that records of e may have, and returns e.
+func (e *Event) Optional(keys ...string) *Event {
+	eventRegistry.mu.Lock()
+	defer eventRegistry.mu.Unlock()
+
+	e.optional = append(e.optional, keys...)
+	return e
+}
+
+// Template sets the message records of e are rendered with, in which
+// "{key}" stands for the value of key, and returns e.
+func (e *Event) Template(template string) *Event {
+	eventRegistry.mu.Lock()
+	defer eventRegistry.mu.Unlock()
+
+	e.template = template
+	return e
+}
+
+// Name returns the name of e.
+func (e *Event) Name() string {
+	return e.name
+}
+
+// lookupEvent returns the schema of the event r is, or nil if it is none.
+func lookupEvent(r slog.Record) *Event {
+	eventRegistry.mu.RLock()
+	defer eventRegistry.mu.RUnlock()
+
+	if len(eventRegistry.events) == 0 {
+		return nil
+	}
+	if e, ok := eventRegistry.events[r.Message]; ok {
+		return e
+	}
+
+	var e *Event
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == EventKey {
+			e = eventRegistry.events[a.Value.String()]
+			return false
+		}
+		return true
+	})
+	return e
+}
+
+// WithEventSchemas returns an Option that checks records against the
+// events registered with [DefineEvent], for use while developing: a
+// record that lacks required keys, or has keys its event doesn't declare,
+// is preceded by a warning such as
+//
+//	⚠ payment.failed: missing currency; unexpected retries
+//
+// once for every distinct problem; with [NewJSON], the warning is a
+// record with a "problem" attribute. Keys added to the logger with
+// WithAttrs count, qualified by their groups. Records of events with a
+// template show it, filled in, as their message.
+func WithEventSchemas() Option {
+	return func(h *TextHandler) {
+		h.schemaWarned = &sync.Map{}
+	}
+}
+
+func (h *TextHandler) schemaStage(ctx context.Context, r slog.Record, _ bool) (slog.Record, bool) {
+	if h.schemaWarned == nil {
+		return r, true
+	}
+	e := lookupEvent(r)
+	if e == nil {
+		return r, true
+	}
+
+	eventRegistry.mu.RLock()
+	required, optional, template := e.required, e.optional, e.template
+	eventRegistry.mu.RUnlock()
+
+	values, prefix := h.eventValues(r)
+
+	// The attributes added from the context are expected of any record.
+	for _, a := range contextAttrs(ctx) {
+		key := a.Key
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		optional = append(optional[:len(optional):len(optional)], key)
+	}
+
+	if problem := schemaProblem(values, required, optional); problem != "" {
+		warning := e.name + ": " + problem
+		if _, warned := h.schemaWarned.LoadOrStore(warning, true); !warned {
+			h.writeSchemaWarning(warning)
+		}
+	}
+
+	if template != "" {
+		r = r.Clone()
+		r.Message = fillTemplate(template, values)
+	}
+	return r, true
+}
+
+// eventValues returns the values of the attributes of r and of those added
+// with WithAttrs, by their keys qualified by their groups, and the groups
+// the attributes of r are in.
+func (h *commonHandler) eventValues(r slog.Record) (values map[string]string, prefix string) {
+	values = make(map[string]string)
+
+	var add func(prefix string, a slog.Attr)
+	add = func(prefix string, a slog.Attr) {
+		if a.Key == EventKey {
+			return
+		}
+		key := a.Key
+		if prefix != "" {
+			key = prefix + "." + a.Key
+		}
+		v := a.Value.Resolve()
+		if v.Kind() == slog.KindGroup {
+			for _, ga := range v.Group() {
+				add(key, ga)
+			}
+			return
+		}
+		values[key] = v.String()
+	}
+
+	var groups []string
+	for _, goa := range h.goas {
+		if goa.group != "" {
+			groups = append(groups, goa.group)
+			continue
+		}
+		for _, a := range goa.attrs {
+			add(strings.Join(groups, "."), a)
+		}
+	}
+	prefix = strings.Join(groups, ".")
+	r.Attrs(func(a slog.Attr) bool {
+		add(prefix, a)
+		return true
+	})
+	return values, prefix
+}
+
+// schemaProblem describes how the keys of values differ from the required
+// and optional ones, or returns "" if they don't.
+func schemaProblem(values map[string]string, required, optional []string) string {
+	var missing, unexpected []string
+	for _, key := range required {
+		if _, ok := values[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	for key := range values {
+		if !slices.Contains(required, key) && !slices.Contains(optional, key) {
+			unexpected = append(unexpected, key)
+		}
+	}
+	slices.Sort(unexpected)
+
+	var parts []string
+	if len(missing) > 0 {
+		parts = append(parts, "missing "+strings.Join(missing, ", "))
+	}
+	if len(unexpected) > 0 {
+		parts = append(parts, "unexpected "+strings.Join(unexpected, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// fillTemplate replaces the "{key}" placeholders of template with the
+// values of their keys, leaving those of unknown keys as they are.
+func fillTemplate(template string, values map[string]string) string {
+	var b strings.Builder
+	for {
+		open := strings.IndexByte(template, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(template[open:], '}')
+		if end < 0 {
+			break
+		}
+		end += open
+
+		b.WriteString(template[:open])
+		if v, ok := values[template[open+1:end]]; ok {
+			b.WriteString(v)
+		} else {
+			b.WriteString(template[open : end+1])
+		}
+		template = template[end+1:]
+	}
+	b.WriteString(template)
+	return b.String()
+}
+
+// writeSchemaWarning writes warning on a line of its own, or as a record
+// of its own in JSON mode, so that every line stays a record.
+func (h *TextHandler) writeSchemaWarning(warning string) {
+	var line []byte
+	if h.json != nil {
+		r := slog.NewRecord(time.Now(), slog.LevelWarn, "trifle: event schema", 0)
+		r.AddAttrs(slog.String("problem", warning))
+		buf := h.json.encode(h.commonHandler, r)
+		defer buf.Free()
+		line = *buf
+	} else {
+		line = []byte(schemaWarningColor.Sprintf("⚠ %s", h.safe(warning)) + "\n")
+	}
+
+	w := h.w
+	if h.errW != nil {
+		w = h.errW
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, _ = w.Write(line)
+}
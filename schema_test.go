@@ -0,0 +1,83 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventSchemas(t *testing.T) {
+	DefineEvent("test.payment.failed", "order_id", "amount", "currency").
+		Optional("retry").
+		Template("charge of {amount} {currency} failed: {reason}")
+
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithEventSchemas())
+	log := slog.New(h)
+
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	order := log.With("order_id", "o-1")
+	order.InfoContext(ctx, "test.payment.failed", "amount", 12, "currency", "EUR", "retry", true)
+	order.Info("test.payment.failed", "amount", 12, "reason", "declined")
+	order.Info("test.payment.failed", "amount", 13, "reason", "declined")
+	log.Info("charged", EventKey, "test.payment.failed", "amount", 12, "currency", "EUR")
+
+	lines := strings.Split(strings.TrimSpace(Plain(buf.String())), "\n")
+	require.Len(t, lines, 6, buf.String())
+	assert.Contains(t, lines[0], "charge of 12 EUR failed: {reason} │")
+	assert.Equal(t, "⚠ test.payment.failed: missing currency; unexpected reason", lines[1])
+	assert.Contains(t, lines[2], "charge of 12 {currency} failed: declined │")
+	assert.Contains(t, lines[3], "charge of 13")
+	assert.Equal(t, "⚠ test.payment.failed: missing order_id", lines[4])
+	assert.Contains(t, lines[5], "charge of 12 EUR failed")
+}
+
+func TestEventSchemasGroups(t *testing.T) {
+	DefineEvent("test.query", "db.table", "db.rows")
+
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithEventSchemas())
+
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	db := slog.New(h).WithGroup("db").With("table", "users")
+	db.InfoContext(ctx, "test.query", "rows", 3)
+	db.Info("test.query", "row", 3)
+
+	lines := strings.Split(strings.TrimSpace(Plain(buf.String())), "\n")
+	require.Len(t, lines, 3, buf.String())
+	assert.Equal(t, "⚠ test.query: missing db.rows; unexpected db.row", lines[1])
+}
+
+func TestEventSchemasJSON(t *testing.T) {
+	DefineEvent("test.export", "rows")
+
+	var buf bytes.Buffer
+	slog.New(NewJSON(&buf, nil, WithEventSchemas())).Info("test.export", "row", 3)
+
+	recs := jsonLines(t, buf.String())
+	require.Len(t, recs, 2, buf.String())
+	assert.Equal(t, "WARN", recs[0]["level"])
+	assert.Equal(t, "test.export: missing rows; unexpected row", recs[0]["problem"])
+	assert.Equal(t, "test.export", recs[1]["msg"])
+}
+
+func TestEventSchemasOff(t *testing.T) {
+	DefineEvent("test.signup", "user")
+
+	var buf bytes.Buffer
+	slog.New(New(&buf, nil, WithTerminalWidth(0))).Info("test.signup", "email", "a@example.com")
+
+	assert.NotContains(t, buf.String(), "⚠")
+}
+
+func TestFillTemplate(t *testing.T) {
+	values := map[string]string{"a": "1", "b": "2"}
+	assert.Equal(t, "1 and 2", fillTemplate("{a} and {b}", values))
+	assert.Equal(t, "{c} {a", fillTemplate("{c} {a", values))
+	assert.Equal(t, "} 1", fillTemplate("} {a}", values))
+}
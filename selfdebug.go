@@ -0,0 +1,64 @@
+package trifle
+
+import (
+	"fmt"
+	"strings"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+// DebugHandlerEnv is the environment variable that turns on
+// [WithSelfDebug] for every handler made by [New] when set to a non-empty
+// value, such as TRIFLE_DEBUG_HANDLER=1.
+const DebugHandlerEnv = "TRIFLE_DEBUG_HANDLER"
+
+var selfDebugColor = color.New(color.FgMagenta)
+
+// WithSelfDebug returns an Option that marks where the attributes of each
+// record come from, to troubleshoot attributes that show up twice or under
+// the wrong group after a mix of WithAttrs and WithGroup calls. The
+// attributes added with WithAttrs follow a ⟦with⟧ marker, and those of
+// the record a marker with the depth and names of the groups they are in,
+// and how many of those were opened by attributes added with WithAttrs:
+//
+//	logger.With("user", "ada").WithGroup("req").With("id", 7).Info("served", "status", 200)
+//
+//	12:00:00.000 [INFO]  served │ ⟦with⟧ user: ada req.id: 7 ⟦record depth=1 groups=req opened-by-with=1⟧ req.status: 200
+//
+// Setting [DebugHandlerEnv] does the same without changing the code. The
+// markers are meant for people reading the output, and may change from
+// one version to the next.
+func WithSelfDebug() Option {
+	return func(h *TextHandler) {
+		h.selfDebug = true
+	}
+}
+
+// appendDebugMarker writes a marker of [WithSelfDebug] saying where the
+// attributes after it come from, wrapping the line before it as before an
+// attribute.
+func (s *handleState) appendDebugMarker(text string) {
+	marker := selfDebugColor.Styled("⟦" + text + "⟧")
+	if s.width > 0 {
+		s.wrapFor(color.StringWidth(s.sep) + marker.Width())
+	}
+	s.appendSegments(color.Plain(s.sep), marker)
+	s.sep = s.h.attrSep()
+}
+
+// recordMarker returns the marker of WithSelfDebug written before the
+// attributes of a record.
+func (h *commonHandler) recordMarker() string {
+	if len(h.groups) == 0 {
+		return "record"
+	}
+	names := make([]string, len(h.groups))
+	for i, g := range h.groups {
+		names[i] = h.safe(g)
+	}
+	m := fmt.Sprintf("record depth=%d groups=%s", len(h.groups), strings.Join(names, "."))
+	if h.nOpenGroups > 0 {
+		m += fmt.Sprintf(" opened-by-with=%d", h.nOpenGroups)
+	}
+	return m
+}
@@ -0,0 +1,55 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"miren.dev/trifle/pkg/color"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfDebug(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(New(&buf, nil, WithTerminalWidth(0), WithSelfDebug()))
+
+	logger.Info("plain")
+	logger.Info("record only", "id", 7)
+	logger.With("user", "ada").WithGroup("req").With("id", 7).WithGroup("resp").Info("served", "status", 200)
+	logger.WithGroup("req").Info("hinted", Hint("no attrs shown inline"))
+
+	lines := strings.Split(Plain(buf.String()), "\n")
+	require.Len(t, lines, 6)
+	assert.True(t, strings.HasSuffix(lines[0], "[INFO]  plain"), lines[0])
+	assert.True(t, strings.HasSuffix(lines[1], "record only │ ⟦record⟧ id: 7"), lines[1])
+	assert.True(t, strings.HasSuffix(lines[2],
+		"served │ ⟦with⟧ user: ada req.id: 7 ⟦record depth=2 groups=req.resp opened-by-with=1⟧ req.resp.status: 200"), lines[2])
+	assert.True(t, strings.HasSuffix(lines[3], "[INFO]  hinted"), lines[3])
+}
+
+func TestSelfDebugFromEnv(t *testing.T) {
+	t.Setenv(DebugHandlerEnv, "1")
+
+	var buf bytes.Buffer
+	slog.New(New(&buf, nil)).Info("hello", "id", 7)
+
+	assert.Contains(t, Plain(buf.String()), "⟦record⟧ id: 7")
+}
+
+func TestSelfDebugMarkerSafeAndWrapped(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(New(&buf, nil, WithTerminalWidth(60), WithSelfDebug(), WithBinarySafe()))
+
+	logger.WithGroup("a\x1bb").Info("served", "status", 200, "bytes", 512)
+
+	assert.Contains(t, Plain(buf.String()), `groups=a\x1bb`)
+
+	lines := strings.Split(strings.TrimSuffix(color.Strip(buf.String()), "\n"), "\n")
+	require.Len(t, lines, 2, buf.String())
+	for _, line := range lines {
+		assert.LessOrEqual(t, color.StringWidth(line), 60, line)
+	}
+}
@@ -0,0 +1,50 @@
+package trifle
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// SequenceKey is the key of the attribute added by [WithSequenceNumbers].
+const SequenceKey = "seq"
+
+// WithSequenceNumbers returns an Option that numbers every record the
+// handler writes, starting at 1, in a "seq" attribute. The counter is
+// shared by all handlers derived from this one, and a number is taken
+// when the record reaches the handler, so gaps or numbers out of order in
+// the output reveal records that were dropped or reordered on the way, for
+// instance by an asynchronous handler or by merging several writers.
+//
+// Records replayed with [Replay] keep the numbers they were recorded with.
+// Records suppressed as duplicates are not numbered.
+func WithSequenceNumbers() Option {
+	return func(h *TextHandler) {
+		h.seq = new(atomic.Uint64)
+	}
+}
+
+type sequenceKey struct{}
+
+// withSequence attaches the number a record was given when it was first
+// handled, so that a handler numbering records keeps it instead of taking
+// a new one.
+func withSequence(ctx context.Context, n uint64) context.Context {
+	return context.WithValue(ctx, sequenceKey{}, n)
+}
+
+// sequence returns r with its sequence number added.
+func (h *commonHandler) sequence(ctx context.Context, r slog.Record) slog.Record {
+	var (
+		n  uint64
+		ok bool
+	)
+	if ctx != nil {
+		n, ok = ctx.Value(sequenceKey{}).(uint64)
+	}
+	if !ok {
+		n = h.seq.Add(1)
+	}
+
+	return CloneWithAttrs(r, slog.Uint64(SequenceKey, n))
+}
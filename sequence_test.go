@@ -0,0 +1,71 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequenceNumbersSharedByClones(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := slog.New(New(&buf, nil, WithTerminalWidth(0), WithSequenceNumbers()))
+	logger.Info("first")
+	logger.With("module", "db").Info("second")
+	logger.WithGroup("g").Info("third", "k", "v")
+
+	out := Plain(buf.String())
+	assert.Contains(t, out, "first │ seq: 1\n")
+	assert.Contains(t, out, "second │ seq: 2\n")
+	assert.Contains(t, out, "third │ g.k: v g.seq: 3\n")
+}
+
+func TestSequenceNumbersSkipSuppressedDuplicates(t *testing.T) {
+	var buf bytes.Buffer
+
+	h := New(&buf, nil, WithTerminalWidth(0), WithSequenceNumbers(), WithDedupWindow(DefaultDedupWindow))
+	logger := slog.New(h)
+	logger.Info("same")
+	logger.Info("same")
+	logger.Info("other")
+
+	out := Plain(buf.String())
+	assert.Contains(t, out, "same │ seq: 1\n")
+	assert.Contains(t, out, "other │ seq: 2\n")
+
+	// The summary of the suppressed record isn't numbered either.
+	require.NoError(t, h.Close())
+	assert.Contains(t, Plain(buf.String()), "same │ suppressed: 1 within: 1s\n")
+}
+
+func TestReplayKeepsRecordedSequence(t *testing.T) {
+	var recording bytes.Buffer
+
+	rec := slog.New(NewRecorder(&recording, nil))
+	rec.Info("one")
+	rec.Info("two")
+
+	// Number the first record away, so the replayed ones would get
+	// different numbers from the handler's own counter.
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithSequenceNumbers())
+	slog.New(h).Info("live")
+	require.NoError(t, Replay(&recording, h))
+
+	out := Plain(buf.String())
+	assert.Contains(t, out, "live │ seq: 1\n")
+	assert.Contains(t, out, "one │ seq: 1\n")
+	assert.Contains(t, out, "two │ seq: 2\n")
+}
+
+func TestReplayWithoutSequenceNumbers(t *testing.T) {
+	var recording, buf bytes.Buffer
+
+	slog.New(NewRecorder(&recording, nil)).Info("one")
+	require.NoError(t, Replay(&recording, New(&buf, nil, WithTerminalWidth(0))))
+
+	assert.NotContains(t, Plain(buf.String()), SequenceKey)
+}
@@ -0,0 +1,31 @@
+package trifle
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeverityScales(t *testing.T) {
+	tests := []struct {
+		level           slog.Level
+		slog, otlp, gcp int
+	}{
+		{Trace - 4, -12, 1, 100},
+		{Trace, -8, 1, 100},
+		{slog.LevelDebug, -4, 5, 100},
+		{slog.LevelInfo, 0, 9, 200},
+		{slog.LevelInfo + 2, 2, 11, 300},
+		{slog.LevelWarn, 4, 13, 400},
+		{slog.LevelError, 8, 17, 500},
+		{slog.LevelError + 4, 12, 21, 600},
+		{slog.LevelError + 8, 16, 24, 700},
+		{slog.LevelError + 40, 48, 24, 800},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.slog, SeveritySlog.Number(tt.level), tt.level.String())
+		assert.Equal(t, tt.otlp, SeverityOTLP.Number(tt.level), tt.level.String())
+		assert.Equal(t, tt.gcp, SeverityGCP.Number(tt.level), tt.level.String())
+	}
+}
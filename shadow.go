@@ -0,0 +1,72 @@
+package trifle
+
+import (
+	"os"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+// shadowFile receives a plain copy of everything a handler writes.
+type shadowFile struct {
+	f   *os.File
+	err error // from opening the file, reported by validate
+}
+
+// WithShadowFile returns an Option that also writes every record to the
+// file at path, appending to it if it exists. The copy has colors and other
+// escape sequences stripped and is not wrapped, so each record is a single
+// line at full width unless a value spans several lines.
+//
+// Failing to open the file is reported by [NewE]. Errors writing to it are
+// ignored, so they never affect console output. Call [TextHandler.Close]
+// to close the file.
+func WithShadowFile(path string) Option {
+	return func(h *TextHandler) {
+		if h.shadow != nil {
+			h.shadow.close()
+		}
+
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		h.shadow = &shadowFile{f: f, err: err}
+	}
+}
+
+// write appends the plain form of a rendered record. The caller holds the
+// handler's mutex.
+func (s *shadowFile) write(b []byte) {
+	if s.f == nil {
+		return
+	}
+	_, _ = s.f.WriteString(color.Strip(string(b)))
+}
+
+func (s *shadowFile) close() error {
+	if s.f == nil {
+		return nil
+	}
+	f := s.f
+	s.f = nil
+	return f.Close()
+}
+
+// Close releases the resources held by the handler: it writes the
+// summaries of the records [WithDedupKey] is still suppressing, waits for
+// the alert hooks to handle the records queued for them, and closes the
+// file opened by [WithShadowFile]. Records handled afterwards are still
+// written to the console, without deduplication or alerts. It is shared
+// by every handler derived from h.
+func (h *TextHandler) Close() error {
+	if h.dedup != nil {
+		h.dedup.close()
+	}
+	for _, hook := range h.alerts {
+		hook.close()
+	}
+	if h.shadow == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.shadow.close()
+}
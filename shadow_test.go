@@ -0,0 +1,50 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestShadowFile(t *testing.T) {
+	color.NoColor = false
+
+	var buf bytes.Buffer
+
+	path := filepath.Join(t.TempDir(), "session.log")
+	handler := New(&buf, nil, WithTerminalWidth(40), WithShadowFile(path))
+
+	logger := slog.New(handler).With("module", "api")
+	logger.Info("a record that wraps on the console",
+		"alpha", "the first value",
+		"beta", "the second value",
+	)
+	require.NoError(t, handler.Close())
+
+	// Records after Close still reach the console only.
+	logger.Info("after close")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	shadow := string(data)
+	assert.NotContains(t, shadow, "\x1b")
+	assert.Equal(t, 1, strings.Count(shadow, "\n"), "shadow copy should not wrap")
+	assert.Contains(t, shadow, "api a record that wraps on the console │ alpha: ")
+	assert.NotContains(t, shadow, "after close")
+
+	assert.Contains(t, buf.String(), "\x1b")
+	assert.Greater(t, strings.Count(buf.String(), "\n"), 2)
+}
+
+func TestShadowFileOpenError(t *testing.T) {
+	_, err := NewE(&bytes.Buffer{}, nil, WithShadowFile(filepath.Join(t.TempDir(), "missing", "x.log")))
+	assert.ErrorContains(t, err, "opening shadow file")
+}
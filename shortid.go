@@ -0,0 +1,126 @@
+package trifle
+
+import (
+	"log/slog"
+	"strings"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+// shortIDLen is how many characters of an identifier are shown.
+const shortIDLen = 8
+
+var shortIDColor = color.New(color.Faint)
+
+// WithShortIDs returns an Option that shortens string values recognized as
+// identifiers, UUIDs, ULIDs and hex encoded hashes of 32 hex digits or
+// more, to their first 8 characters, dimmed:
+//
+//	request: 3f2b8c1e   commit: 9a1f04c2
+//
+// The shortened value is an OSC 8 hyperlink to the full one, as
+// urn:uuid:..., urn:ulid:... or urn:hash:..., which the terminal shows on
+// hover and copies with the link. Values are only shortened on terminals
+// that support such links and while colors are enabled, so that the full
+// value is never lost: output written to files and pipes, or shown by
+// other terminals, keeps it.
+func WithShortIDs() Option {
+	return func(h *TextHandler) {
+		h.shortIDs = true
+		h.hyperlinks = supportsHyperlinks()
+	}
+}
+
+// shortID renders v shortened when it is an identifier. Other values are
+// returned as they are.
+func (h *commonHandler) shortID(v slog.Value) slog.Value {
+	if v.Kind() != slog.KindString || color.NoColor || !h.hyperlinks {
+		return v
+	}
+
+	id := v.String()
+	kind := idKind(id)
+	if kind == "" {
+		return v
+	}
+
+	short := shortIDColor.Sprint(id[:shortIDLen])
+	return slog.AnyValue(rendered(hyperlink("urn:"+kind+":"+strings.ToLower(id), short)))
+}
+
+// hyperlink returns text as an OSC 8 hyperlink to uri.
+func hyperlink(uri, text string) string {
+	return "\x1b]8;;" + uri + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// idKind returns what kind of identifier s is: "uuid", "ulid" or "hash",
+// or "" if it isn't one.
+func idKind(s string) string {
+	switch {
+	case isUUID(s):
+		return "uuid"
+	case isULID(s):
+		return "ulid"
+	case len(s) >= 32 && len(s)%8 == 0 && isHex(s):
+		return "hash"
+	}
+	return ""
+}
+
+// isUUID reports whether s is a UUID in its canonical form,
+// xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if !isHexDigit(s[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isULID reports whether s is a ULID: 26 characters of Crockford's base32,
+// the first no greater than 7.
+func isULID(s string) bool {
+	if len(s) != 26 || s[0] > '7' {
+		return false
+	}
+
+	// All-digit and all-letter strings of that length are more likely
+	// to be something else.
+	var digits, letters bool
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits = true
+		case c >= 'A' && c <= 'Z' && c != 'I' && c != 'L' && c != 'O' && c != 'U':
+			letters = true
+		default:
+			return false
+		}
+	}
+	return digits && letters
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isHexDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
@@ -0,0 +1,74 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestIDKind(t *testing.T) {
+	tests := []struct {
+		s, kind string
+	}{
+		{"3f2b8c1e-9d4a-4b6e-8f1a-2c3d4e5f6a7b", "uuid"},
+		{"3F2B8C1E-9D4A-4B6E-8F1A-2C3D4E5F6A7B", "uuid"},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAV", "ulid"},
+		{"9a1f04c2b7e3d5f60718293a4b5c6d7e8f901234", "hash"},
+		{"d41d8cd98f00b204e9800998ecf8427e", "hash"},
+		{"3f2b8c1e9d4a", ""},
+		{"ABCDEFGHJKMNPQRSTVWXYZABCD", ""},
+		{"91ARZ3NDEKTSV4RRFFQ69G5FAV", ""},
+		{"not an id at all, really not", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.kind, idKind(tt.s), tt.s)
+	}
+}
+
+func TestShortIDs(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+	t.Setenv("TERM_PROGRAM", "WezTerm")
+
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithShortIDs())
+
+	slog.New(h).Info("served", "request", "3F2B8C1E-9D4A-4B6E-8F1A-2C3D4E5F6A7B", "path", "/api")
+
+	out := buf.String()
+	assert.Contains(t, out, "\x1b]8;;urn:uuid:3f2b8c1e-9d4a-4b6e-8f1a-2c3d4e5f6a7b\x1b\\")
+	assert.True(t, ContainsAttr(out, "request", "3F2B8C1E"))
+	assert.True(t, ContainsAttr(out, "path", "/api"))
+}
+
+func TestShortIDsKeptWithoutHyperlinks(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+	t.Setenv("TERM_PROGRAM", "")
+	t.Setenv("WT_SESSION", "")
+	t.Setenv("KITTY_WINDOW_ID", "")
+	t.Setenv("VTE_VERSION", "")
+
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithShortIDs())
+
+	slog.New(h).Info("built", "commit", "9a1f04c2b7e3d5f60718293a4b5c6d7e8f901234")
+
+	assert.NotContains(t, buf.String(), "\x1b]8;;")
+	assert.True(t, ContainsAttr(buf.String(), "commit", "9a1f04c2b7e3d5f60718293a4b5c6d7e8f901234"))
+}
+
+func TestShortIDsKeptWithoutColor(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithShortIDs())
+
+	slog.New(h).Info("created", "id", "01ARZ3NDEKTSV4RRFFQ69G5FAV")
+
+	assert.True(t, ContainsAttr(buf.String(), "id", "01ARZ3NDEKTSV4RRFFQ69G5FAV"))
+}
@@ -0,0 +1,252 @@
+package trifle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Snapshot is what a handler adds to the records it handles: its module,
+// and the attributes and groups added with WithAttrs and WithGroup, in the
+// order they were applied. Values of context keys are among the
+// attributes. A Snapshot can be applied to another handler, and encoded as
+// JSON, so that the state of a logger can be carried to another process
+// and restored there.
+type Snapshot struct {
+	Module string
+	goas   []groupOrAttrs
+}
+
+// Snapshot returns what h adds to records.
+func (h *TextHandler) Snapshot() Snapshot {
+	return Snapshot{Module: h.module, goas: slices.Clip(h.goas)}
+}
+
+// IsZero reports whether s adds nothing to records.
+func (s Snapshot) IsZero() bool {
+	return s.Module == "" && len(s.goas) == 0
+}
+
+// Attrs returns the attributes of s, those added after a group nested in
+// it, followed by the module as a "module" attribute.
+func (s Snapshot) Attrs() []slog.Attr {
+	var attrs []slog.Attr
+	for i := len(s.goas) - 1; i >= 0; i-- {
+		goa := s.goas[i]
+		if goa.group != "" {
+			if len(attrs) > 0 {
+				attrs = []slog.Attr{slog.Group(goa.group, anySlice(attrs)...)}
+			}
+			continue
+		}
+		attrs = append(slices.Clone(goa.attrs), attrs...)
+	}
+
+	if s.Module != "" {
+		attrs = append(attrs, slog.String(ModuleKey, s.Module))
+	}
+	return attrs
+}
+
+func anySlice(attrs []slog.Attr) []any {
+	args := make([]any, len(attrs))
+	for i, a := range attrs {
+		args[i] = a
+	}
+	return args
+}
+
+// Apply returns h with the module, attributes and groups of s added, as if
+// the calls that built the handler s was taken from had been made on h.
+func (s Snapshot) Apply(h slog.Handler) slog.Handler {
+	if s.Module != "" {
+		h = h.WithAttrs([]slog.Attr{slog.String(ModuleKey, s.Module)})
+	}
+	for _, goa := range s.goas {
+		if goa.group != "" {
+			h = h.WithGroup(goa.group)
+		} else {
+			h = h.WithAttrs(goa.attrs)
+		}
+	}
+	return h
+}
+
+// snapshotJSON is the JSON form of a Snapshot. Attributes are written as
+// [key, value] pairs to keep their order. Values that JSON has no type
+// for are tagged, as {"duration": "1.5s"}, {"time": "..."} or
+// {"group": [pairs]}; other values are written as text.
+type snapshotJSON struct {
+	Module string         `json:"module,omitempty"`
+	Steps  []snapshotStep `json:"steps,omitempty"`
+}
+
+type snapshotStep struct {
+	Group string            `json:"group,omitempty"`
+	Attrs []json.RawMessage `json:"attrs,omitempty"`
+}
+
+// MarshalJSON encodes s so that UnmarshalJSON restores it. Values of kinds
+// JSON can't represent, such as structs, are encoded as their text.
+func (s Snapshot) MarshalJSON() ([]byte, error) {
+	sj := snapshotJSON{Module: s.Module}
+	for _, goa := range s.goas {
+		step := snapshotStep{Group: goa.group}
+		for _, a := range goa.attrs {
+			pair, err := marshalAttr(a)
+			if err != nil {
+				return nil, err
+			}
+			step.Attrs = append(step.Attrs, pair)
+		}
+		sj.Steps = append(sj.Steps, step)
+	}
+	return json.Marshal(sj)
+}
+
+// UnmarshalJSON decodes a Snapshot encoded by MarshalJSON.
+func (s *Snapshot) UnmarshalJSON(data []byte) error {
+	var sj snapshotJSON
+	if err := json.Unmarshal(data, &sj); err != nil {
+		return err
+	}
+
+	snap := Snapshot{Module: sj.Module}
+	for _, step := range sj.Steps {
+		if step.Group != "" {
+			snap.goas = append(snap.goas, groupOrAttrs{group: step.Group})
+			continue
+		}
+
+		attrs := make([]slog.Attr, 0, len(step.Attrs))
+		for _, pair := range step.Attrs {
+			a, err := unmarshalAttr(pair)
+			if err != nil {
+				return err
+			}
+			attrs = append(attrs, a)
+		}
+		if len(attrs) > 0 {
+			snap.goas = append(snap.goas, groupOrAttrs{attrs: attrs})
+		}
+	}
+
+	*s = snap
+	return nil
+}
+
+func marshalAttr(a slog.Attr) (json.RawMessage, error) {
+	v, err := marshalValue(a.Value.Resolve())
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal([]any{a.Key, v})
+}
+
+func marshalValue(v slog.Value) (any, error) {
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String(), nil
+	case slog.KindInt64:
+		return v.Int64(), nil
+	case slog.KindUint64:
+		return v.Uint64(), nil
+	case slog.KindFloat64:
+		return v.Float64(), nil
+	case slog.KindBool:
+		return v.Bool(), nil
+	case slog.KindDuration:
+		return map[string]string{"duration": v.Duration().String()}, nil
+	case slog.KindTime:
+		return map[string]string{"time": v.Time().Format(time.RFC3339Nano)}, nil
+	case slog.KindGroup:
+		pairs := make([]json.RawMessage, 0, len(v.Group()))
+		for _, ga := range v.Group() {
+			pair, err := marshalAttr(ga)
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, pair)
+		}
+		return map[string]any{"group": pairs}, nil
+	default:
+		return v.String(), nil
+	}
+}
+
+var errSnapshotAttr = errors.New("trifle: snapshot attribute must be a [key, value] pair")
+
+func unmarshalAttr(data json.RawMessage) (slog.Attr, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var pair []any
+	if err := dec.Decode(&pair); err != nil {
+		return slog.Attr{}, err
+	}
+	if len(pair) != 2 {
+		return slog.Attr{}, errSnapshotAttr
+	}
+	key, ok := pair[0].(string)
+	if !ok {
+		return slog.Attr{}, errSnapshotAttr
+	}
+
+	v, err := unmarshalValue(pair[1])
+	if err != nil {
+		return slog.Attr{}, fmt.Errorf("trifle: snapshot attribute %q: %w", key, err)
+	}
+	return slog.Attr{Key: key, Value: v}, nil
+}
+
+func unmarshalValue(v any) (slog.Value, error) {
+	switch v := v.(type) {
+	case string:
+		return slog.StringValue(v), nil
+	case bool:
+		return slog.BoolValue(v), nil
+	case json.Number:
+		if i, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			return slog.Int64Value(i), nil
+		}
+		if u, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return slog.Uint64Value(u), nil
+		}
+		f, err := v.Float64()
+		return slog.Float64Value(f), err
+	case map[string]any:
+		switch {
+		case v["duration"] != nil:
+			s, _ := v["duration"].(string)
+			d, err := time.ParseDuration(s)
+			return slog.DurationValue(d), err
+		case v["time"] != nil:
+			s, _ := v["time"].(string)
+			t, err := time.Parse(time.RFC3339Nano, s)
+			return slog.TimeValue(t), err
+		case v["group"] != nil:
+			pairs, _ := v["group"].([]any)
+			attrs := make([]slog.Attr, 0, len(pairs))
+			for _, p := range pairs {
+				data, err := json.Marshal(p)
+				if err != nil {
+					return slog.Value{}, err
+				}
+				a, err := unmarshalAttr(data)
+				if err != nil {
+					return slog.Value{}, err
+				}
+				attrs = append(attrs, a)
+			}
+			return slog.GroupValue(attrs...), nil
+		}
+	case nil:
+		return slog.AnyValue(nil), nil
+	}
+	return slog.Value{}, fmt.Errorf("unsupported value %v", v)
+}
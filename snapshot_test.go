@@ -0,0 +1,67 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	h := New(&bytes.Buffer{}, nil, WithContextKey("request_id"))
+	derived := h.WithAttrs([]slog.Attr{
+		slog.String(ModuleKey, "api"),
+		slog.String("request_id", "r1"),
+		slog.Int("attempt", 2),
+	}).WithGroup("job").WithAttrs([]slog.Attr{
+		slog.Duration("budget", 1500*time.Millisecond),
+		slog.Bool("dry", true),
+		slog.Group("owner", slog.String("name", "ops"), slog.Float64("share", 0.5)),
+	}).(*TextHandler)
+
+	snap := derived.Snapshot()
+	assert.Equal(t, "api", snap.Module)
+
+	data, err := json.Marshal(snap)
+	require.NoError(t, err)
+
+	var restored Snapshot
+	require.NoError(t, json.Unmarshal(data, &restored))
+	assert.Equal(t, snap, restored)
+
+	// Both records have the same time, so the renders compare equal.
+	r := slog.NewRecord(time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC), slog.LevelInfo, "started", 0)
+	r.AddAttrs(slog.Int("n", 1))
+
+	var want, got bytes.Buffer
+	base := New(&want, nil, WithContextKey("request_id"), WithTerminalWidth(0))
+	require.NoError(t, snap.Apply(base).Handle(context.Background(), r))
+	other := New(&got, nil, WithContextKey("request_id"), WithTerminalWidth(0))
+	require.NoError(t, restored.Apply(other).Handle(context.Background(), r))
+
+	assert.Equal(t, want.String(), got.String())
+	out := Plain(got.String())
+	assert.True(t, MatchesLine(out, `r1 api started`), out)
+	assert.True(t, ContainsAttr(got.String(), "job.budget", "1.5s"), out)
+	assert.True(t, ContainsAttr(got.String(), "job.n", "1"), out)
+}
+
+func TestSnapshotAttrs(t *testing.T) {
+	h := New(&bytes.Buffer{}, nil).
+		WithAttrs([]slog.Attr{slog.String("a", "1")}).
+		WithGroup("g").
+		WithAttrs([]slog.Attr{slog.String("b", "2"), slog.String(ModuleKey, "m")}).(*TextHandler)
+
+	attrs := h.Snapshot().Attrs()
+	require.Len(t, attrs, 3)
+	assert.Equal(t, "a=1", attrs[0].String())
+	assert.Equal(t, "g=[b=2]", attrs[1].String())
+	assert.Equal(t, "module=m", attrs[2].String())
+
+	assert.True(t, New(&bytes.Buffer{}, nil).Snapshot().IsZero())
+}
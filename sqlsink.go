@@ -0,0 +1,367 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQLTable is the table SQLSink writes records to. Its columns are id,
+// time, level, module, message and attrs, the last holding the attributes
+// as a JSON object, so a SQLite file can be sliced with json_extract.
+const SQLTable = "trifle_records"
+
+// sqlTimeFormat has a fixed width, so times sort correctly as text.
+const sqlTimeFormat = "2006-01-02T15:04:05.000000000Z"
+
+var sqlSchema = []string{
+	`CREATE TABLE IF NOT EXISTS ` + SQLTable + ` (
+		id      INTEGER PRIMARY KEY,
+		time    TEXT    NOT NULL,
+		level   INTEGER NOT NULL,
+		module  TEXT    NOT NULL,
+		message TEXT    NOT NULL,
+		attrs   TEXT    NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS ` + SQLTable + `_time ON ` + SQLTable + ` (time)`,
+	`CREATE INDEX IF NOT EXISTS ` + SQLTable + `_module ON ` + SQLTable + ` (module)`,
+}
+
+// SQLSink is a [slog.Handler] that appends records to [SQLTable], meant
+// for a local SQLite file that can be queried after a long run with
+// [QuerySQL] or any SQLite client. trifle does not link a database driver;
+// open db with the driver of your choice, such as modernc.org/sqlite or
+// github.com/mattn/go-sqlite3.
+//
+// Each record is one INSERT, so wrap the sink in a buffered or asynchronous
+// handler if records are logged at a high rate.
+type SQLSink struct {
+	insert *sql.Stmt
+	opts   slog.HandlerOptions
+	chain  attrChain
+}
+
+// NewSQLSink creates [SQLTable] in db if it doesn't exist yet and returns
+// a sink writing to it. If opts is nil, records at every level are stored.
+func NewSQLSink(ctx context.Context, db *sql.DB, opts *slog.HandlerOptions) (*SQLSink, error) {
+	if opts == nil {
+		opts = &slog.HandlerOptions{Level: slog.Level(math.MinInt)}
+	}
+
+	for _, stmt := range sqlSchema {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return nil, fmt.Errorf("trifle: creating %s: %w", SQLTable, err)
+		}
+	}
+
+	insert, err := db.PrepareContext(ctx,
+		`INSERT INTO `+SQLTable+` (time, level, module, message, attrs) VALUES (?, ?, ?, ?, ?)`)
+	if err != nil {
+		return nil, fmt.Errorf("trifle: preparing insert: %w", err)
+	}
+
+	return &SQLSink{insert: insert, opts: *opts}, nil
+}
+
+// Close releases the prepared statement shared by every handler derived
+// from s. It does not close the database.
+func (s *SQLSink) Close() error {
+	return s.insert.Close()
+}
+
+// Enabled reports whether the sink stores records at level.
+func (s *SQLSink) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if s.opts.Level != nil {
+		minLevel = s.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+// WithAttrs returns a sink that adds attrs to every record.
+func (s *SQLSink) WithAttrs(attrs []slog.Attr) slog.Handler {
+	ns := *s
+	ns.chain = s.chain.withAttrs(attrs)
+	return &ns
+}
+
+// WithGroup returns a sink that nests later attributes in group name.
+func (s *SQLSink) WithGroup(name string) slog.Handler {
+	ns := *s
+	ns.chain = s.chain.withGroup(name)
+	return &ns
+}
+
+// Handle inserts r as one row.
+func (s *SQLSink) Handle(ctx context.Context, r slog.Record) error {
+	attrs := appendJSONAttrs(nil, s.chain.attrs(ctx, r))
+
+	// The record is stored even when the context that logged it is done.
+	_, err := s.insert.ExecContext(context.WithoutCancel(ctx),
+		r.Time.UTC().Format(sqlTimeFormat),
+		int64(r.Level),
+		s.chain.module,
+		r.Message,
+		string(attrs),
+	)
+	return err
+}
+
+// appendJSONAttrs appends as as a JSON object, keeping their order.
+// Groups become nested objects. Durations are stored as their text and
+// times in RFC 3339 format; other values the handler can't represent as
+// JSON are stored as the text it would print for them.
+func appendJSONAttrs(dst []byte, as []slog.Attr) []byte {
+	dst = append(dst, '{')
+	first := true
+	for _, a := range as {
+		a.Value = a.Value.Resolve()
+		if a.Equal(slog.Attr{}) {
+			continue
+		}
+		if !first {
+			dst = append(dst, ',')
+		}
+		first = false
+
+		dst = strconv.AppendQuote(dst, a.Key)
+		dst = append(dst, ':')
+		dst = appendJSONValue(dst, a.Value)
+	}
+	return append(dst, '}')
+}
+
+func appendJSONValue(dst []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendJSONString(dst, v.String())
+	case slog.KindInt64:
+		return strconv.AppendInt(dst, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(dst, v.Uint64(), 10)
+	case slog.KindFloat64:
+		f := v.Float64()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return appendJSONString(dst, strconv.FormatFloat(f, 'g', -1, 64))
+		}
+		return strconv.AppendFloat(dst, f, 'g', -1, 64)
+	case slog.KindBool:
+		return strconv.AppendBool(dst, v.Bool())
+	case slog.KindDuration:
+		return appendJSONString(dst, v.Duration().String())
+	case slog.KindTime:
+		return appendJSONString(dst, v.Time().Format(time.RFC3339Nano))
+	case slog.KindGroup:
+		return appendJSONAttrs(dst, v.Group())
+	default:
+		if bs, ok := byteSlice(v.Any()); ok {
+			return appendJSONString(dst, string(bs))
+		}
+		return appendJSONString(dst, anyText(v.Any()))
+	}
+}
+
+func appendJSONString(dst []byte, s string) []byte {
+	data, _ := json.Marshal(s)
+	return append(dst, data...)
+}
+
+// SQLQuery selects records stored by a [SQLSink]. Zero fields don't
+// restrict the result.
+type SQLQuery struct {
+	// Level is the minimum level of the records.
+	Level slog.Leveler
+
+	// Module selects a module and its submodules: "db" matches records
+	// of "db" and "db.pool" but not "dbx".
+	Module string
+
+	// Since and Until bound the time of the records, inclusively.
+	Since, Until time.Time
+
+	// Contains is text the message must contain.
+	Contains string
+
+	// Limit is the maximum number of records, the most recent ones when
+	// the result is limited.
+	Limit int
+}
+
+// statement returns the SELECT statement for q and its arguments.
+func (q SQLQuery) statement() (string, []any) {
+	var (
+		where []string
+		args  []any
+	)
+
+	if q.Level != nil {
+		where = append(where, "level >= ?")
+		args = append(args, int64(q.Level.Level()))
+	}
+	if q.Module != "" {
+		where = append(where, "(module = ? OR substr(module, 1, ?) = ?)")
+		args = append(args, q.Module, len(q.Module)+1, q.Module+".")
+	}
+	if !q.Since.IsZero() {
+		where = append(where, "time >= ?")
+		args = append(args, q.Since.UTC().Format(sqlTimeFormat))
+	}
+	if !q.Until.IsZero() {
+		where = append(where, "time <= ?")
+		args = append(args, q.Until.UTC().Format(sqlTimeFormat))
+	}
+	if q.Contains != "" {
+		where = append(where, "instr(message, ?) > 0")
+		args = append(args, q.Contains)
+	}
+
+	const columns = "time, level, module, message, attrs"
+
+	var sb strings.Builder
+	sb.WriteString("SELECT id, " + columns + " FROM " + SQLTable)
+	if len(where) > 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(where, " AND "))
+	}
+
+	if q.Limit > 0 {
+		// Take the most recent records, which the outer SELECT puts back
+		// in order.
+		sb.WriteString(" ORDER BY time DESC, id DESC LIMIT ?")
+		args = append(args, q.Limit)
+	}
+
+	// The order of a subquery doesn't carry over to the SELECT around it,
+	// so it is given there.
+	return "SELECT " + columns + " FROM (" + sb.String() + ") ORDER BY time, id", args
+}
+
+// QuerySQL passes the records stored in db that match q to h, oldest
+// first, with each record's module set through a "module" attribute.
+func QuerySQL(ctx context.Context, db *sql.DB, q SQLQuery, h slog.Handler) error {
+	stmt, args := q.statement()
+
+	rows, err := db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	modules := map[string]slog.Handler{"": h}
+
+	for rows.Next() {
+		var (
+			ts, module, msg, attrsJSON string
+			level                      int64
+		)
+		if err := rows.Scan(&ts, &level, &module, &msg, &attrsJSON); err != nil {
+			return err
+		}
+
+		t, err := time.Parse(sqlTimeFormat, ts)
+		if err != nil {
+			return fmt.Errorf("trifle: record time %q: %w", ts, err)
+		}
+
+		attrs, err := decodeJSONAttrs(json.NewDecoder(strings.NewReader(attrsJSON)))
+		if err != nil {
+			return fmt.Errorf("trifle: record attrs: %w", err)
+		}
+
+		mh, ok := modules[module]
+		if !ok {
+			mh = h.WithAttrs([]slog.Attr{slog.String(ModuleKey, module)})
+			modules[module] = mh
+		}
+
+		if !mh.Enabled(ctx, slog.Level(level)) {
+			continue
+		}
+
+		r := slog.NewRecord(t.Local(), slog.Level(level), msg, 0)
+		r.AddAttrs(attrs...)
+		if err := mh.Handle(ctx, r); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// decodeJSONAttrs reads a JSON object written by appendJSONAttrs from dec,
+// keeping the order of its keys.
+func decodeJSONAttrs(dec *json.Decoder) ([]slog.Attr, error) {
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, fmt.Errorf("expected an object, got %v", tok)
+	}
+
+	var attrs []slog.Attr
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := tok.(string)
+
+		v, err := decodeJSONValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, slog.Attr{Key: key, Value: v})
+	}
+
+	if _, err := dec.Token(); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return attrs, nil
+}
+
+func decodeJSONValue(dec *json.Decoder) (slog.Value, error) {
+	var raw json.RawMessage
+	if err := dec.Decode(&raw); err != nil {
+		return slog.Value{}, err
+	}
+
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '{' {
+		attrs, err := decodeJSONAttrs(json.NewDecoder(bytes.NewReader(raw)))
+		return slog.GroupValue(attrs...), err
+	}
+
+	var v any
+	rd := json.NewDecoder(bytes.NewReader(raw))
+	rd.UseNumber()
+	if err := rd.Decode(&v); err != nil {
+		return slog.Value{}, err
+	}
+
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return slog.Int64Value(i), nil
+		}
+		if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return slog.Uint64Value(u), nil
+		}
+		f, err := v.Float64()
+		return slog.Float64Value(f), err
+	case nil:
+		return slog.AnyValue(nil), nil
+	default:
+		return slog.AnyValue(v), nil
+	}
+}
@@ -0,0 +1,249 @@
+package trifle
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLQueryStatement(t *testing.T) {
+	stmt, args := SQLQuery{}.statement()
+	assert.Equal(t, "SELECT time, level, module, message, attrs FROM (SELECT id, time, level, module, message, attrs FROM trifle_records) ORDER BY time, id", stmt)
+	assert.Empty(t, args)
+
+	since := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	stmt, args = SQLQuery{
+		Level:    slog.LevelWarn,
+		Module:   "db",
+		Since:    since,
+		Contains: "timeout",
+		Limit:    10,
+	}.statement()
+
+	assert.Contains(t, stmt, "WHERE level >= ? AND (module = ? OR substr(module, 1, ?) = ?) AND time >= ? AND instr(message, ?) > 0")
+	assert.Contains(t, stmt, "ORDER BY time DESC, id DESC LIMIT ?")
+	assert.Equal(t, []any{int64(4), "db", 3, "db.", "2026-03-01T12:00:00.000000000Z", "timeout", 10}, args)
+}
+
+func TestJSONAttrsRoundTrip(t *testing.T) {
+	attrs := []slog.Attr{
+		slog.String("zeta", "first"),
+		slog.Int("count", -3),
+		slog.Uint64("big", math.MaxUint64),
+		slog.Float64("ratio", 0.5),
+		slog.Float64("nan", math.NaN()),
+		slog.Bool("ok", true),
+		slog.Duration("took", 12*time.Millisecond),
+		slog.Any("error", errors.New("boom")),
+		slog.Group("client", slog.String("ip", "10.0.0.7"), slog.Group("tls", slog.String("version", "1.3"))),
+		slog.Any("nothing", nil),
+	}
+
+	data := appendJSONAttrs(nil, attrs)
+	assert.True(t, json.Valid(data), string(data))
+
+	decoded, err := decodeJSONAttrs(json.NewDecoder(strings.NewReader(string(data))))
+	require.NoError(t, err)
+
+	var keys []string
+	for _, a := range decoded {
+		keys = append(keys, a.Key)
+	}
+	assert.Equal(t, []string{"zeta", "count", "big", "ratio", "nan", "ok", "took", "error", "client", "nothing"}, keys)
+
+	assert.Equal(t, int64(-3), decoded[1].Value.Int64())
+	assert.Equal(t, uint64(math.MaxUint64), decoded[2].Value.Uint64())
+	assert.Equal(t, 0.5, decoded[3].Value.Float64())
+	assert.Equal(t, "NaN", decoded[4].Value.String())
+	assert.Equal(t, "12ms", decoded[6].Value.String())
+	assert.Equal(t, "boom", decoded[7].Value.String())
+	assert.Equal(t, slog.KindBool, decoded[5].Value.Kind())
+
+	client := decoded[8].Value.Group()
+	require.Len(t, client, 2)
+	assert.Equal(t, "tls", client[1].Key)
+	assert.Equal(t, "1.3", client[1].Value.Group()[0].Value.String())
+}
+
+// fakeSQL is a database/sql driver keeping the rows inserted by SQLSink in
+// memory. It doesn't evaluate SQL: a query returns every row, and the
+// arguments it was given are kept, since the statements themselves are
+// covered by TestSQLQueryStatement.
+type fakeSQL struct {
+	mu        sync.Mutex
+	rows      [][]driver.Value // time, level, module, message, attrs
+	queryArgs []driver.Value
+}
+
+var (
+	fakeSQLDBs   sync.Map // DSN to *fakeSQL
+	registerFake sync.Once
+)
+
+// openFakeSQL returns a database backed by a new fakeSQL.
+func openFakeSQL(t *testing.T) (*sql.DB, *fakeSQL) {
+	registerFake.Do(func() { sql.Register("trifle-fake", fakeSQLDriver{}) })
+
+	f := &fakeSQL{}
+	fakeSQLDBs.Store(t.Name(), f)
+	db, err := sql.Open("trifle-fake", t.Name())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+		fakeSQLDBs.Delete(t.Name())
+	})
+	return db, f
+}
+
+type fakeSQLDriver struct{}
+
+func (fakeSQLDriver) Open(dsn string) (driver.Conn, error) {
+	f, ok := fakeSQLDBs.Load(dsn)
+	if !ok {
+		return nil, fmt.Errorf("no fake database %q", dsn)
+	}
+	return fakeSQLConn{f.(*fakeSQL)}, nil
+}
+
+type fakeSQLConn struct{ f *fakeSQL }
+
+func (c fakeSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeSQLStmt{c.f, query}, nil
+}
+
+func (fakeSQLConn) Close() error { return nil }
+
+func (fakeSQLConn) Begin() (driver.Tx, error) { return nil, errors.New("no transactions") }
+
+type fakeSQLStmt struct {
+	f     *fakeSQL
+	query string
+}
+
+func (fakeSQLStmt) Close() error { return nil }
+
+func (fakeSQLStmt) NumInput() int { return -1 }
+
+func (s fakeSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if strings.HasPrefix(s.query, "INSERT") {
+		s.f.mu.Lock()
+		s.f.rows = append(s.f.rows, args)
+		s.f.mu.Unlock()
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	s.f.queryArgs = args
+	return &fakeSQLRows{rows: slices.Clone(s.f.rows)}, nil
+}
+
+type fakeSQLRows struct{ rows [][]driver.Value }
+
+func (*fakeSQLRows) Columns() []string {
+	return []string{"time", "level", "module", "message", "attrs"}
+}
+
+func (*fakeSQLRows) Close() error { return nil }
+
+func (r *fakeSQLRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestSQLSinkHandle(t *testing.T) {
+	db, f := openFakeSQL(t)
+	ctx := context.Background()
+
+	sink, err := NewSQLSink(ctx, db, nil)
+	require.NoError(t, err)
+	defer sink.Close()
+
+	log := slog.New(sink).With(ModuleKey, "api", "host", "a").WithGroup("req").With(ModuleKey, "users")
+	at := time.Date(2026, 3, 1, 12, 0, 0, 5, time.FixedZone("CET", 3600))
+	r := slog.NewRecord(at, slog.LevelWarn, "slow", 0)
+	r.AddAttrs(slog.Int("ms", 1200), slog.Duration("budget", time.Second))
+	require.NoError(t, log.Handler().Handle(ctx, r))
+	slog.New(sink).Debug("stored too")
+
+	require.Len(t, f.rows, 2)
+	assert.Equal(t, []driver.Value{
+		"2026-03-01T11:00:00.000000005Z",
+		int64(slog.LevelWarn),
+		"api.users",
+		"slow",
+		`{"host":"a","req":{"ms":1200,"budget":"1s"}}`,
+	}, f.rows[0])
+	assert.Equal(t, "", f.rows[1][2])
+	assert.Equal(t, "{}", f.rows[1][4])
+}
+
+func TestSQLSinkLevel(t *testing.T) {
+	db, _ := openFakeSQL(t)
+
+	sink, err := NewSQLSink(context.Background(), db, &slog.HandlerOptions{Level: slog.LevelWarn})
+	require.NoError(t, err)
+	defer sink.Close()
+
+	assert.False(t, sink.Enabled(context.Background(), slog.LevelInfo))
+	assert.True(t, sink.Enabled(context.Background(), slog.LevelWarn))
+}
+
+func TestQuerySQL(t *testing.T) {
+	db, f := openFakeSQL(t)
+	ctx := context.Background()
+
+	sink, err := NewSQLSink(ctx, db, nil)
+	require.NoError(t, err)
+	defer sink.Close()
+
+	log := slog.New(sink)
+	log.With(ModuleKey, "db").Warn("slow query", "ms", 1200, slog.Group("conn", "id", 7))
+	log.Debug("tick")
+	log.Info("served", "path", "/users")
+
+	var out strings.Builder
+	q := SQLQuery{Module: "db", Contains: "slow"}
+	require.NoError(t, QuerySQL(ctx, db, q, New(&out, nil)))
+
+	_, wantArgs := q.statement()
+	assert.Len(t, f.queryArgs, len(wantArgs))
+
+	// The fake database returns every row; the Debug one is left out by
+	// the level of the handler.
+	lines := strings.Split(strings.TrimSuffix(Plain(out.String()), "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, MatchesLine(lines[0], `\[WARN\]\s+db slow query`), lines[0])
+	assert.True(t, ContainsAttr(lines[0], "ms", "1200"), lines[0])
+	assert.True(t, ContainsAttr(lines[0], "conn.id", "7"), lines[0])
+	assert.True(t, MatchesLine(lines[1], `\[INFO\]\s+served`), lines[1])
+	assert.True(t, ContainsAttr(lines[1], "path", "/users"), lines[1])
+}
+
+func TestQuerySQLBadRow(t *testing.T) {
+	db, f := openFakeSQL(t)
+	f.rows = append(f.rows, []driver.Value{"yesterday", int64(0), "", "m", "{}"})
+
+	err := QuerySQL(context.Background(), db, SQLQuery{}, NewRing(1))
+	assert.ErrorContains(t, err, `record time "yesterday"`)
+}
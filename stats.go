@@ -0,0 +1,120 @@
+package trifle
+
+import (
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+)
+
+// Stats is a snapshot of the activity of a handler and all handlers derived
+// from it with WithAttrs or WithGroup.
+type Stats struct {
+	// Since is when counting started: the creation of the handler or the
+	// last call to ResetStats.
+	Since time.Time
+
+	// Levels counts the records handled at each level.
+	Levels map[slog.Level]uint64
+
+	// Modules counts the records handled for each module. Records logged
+	// without a module are counted under "".
+	Modules map[string]uint64
+
+	// Bytes is the number of bytes written to the writer.
+	Bytes uint64
+
+	// Dropped counts the records that reached the handler but were not
+	// written, for instance because a filter rejected them.
+	Dropped uint64
+
+	// WriteErrors counts the writes that returned an error.
+	WriteErrors uint64
+}
+
+// Total returns the number of records handled at any level.
+func (s Stats) Total() uint64 {
+	var n uint64
+	for _, c := range s.Levels {
+		n += c
+	}
+	return n
+}
+
+// AtLeast returns the number of records handled at level or above.
+func (s Stats) AtLeast(level slog.Level) uint64 {
+	var n uint64
+	for l, c := range s.Levels {
+		if l >= level {
+			n += c
+		}
+	}
+	return n
+}
+
+// handlerStats accumulates Stats. It is shared by all clones of a handler.
+type handlerStats struct {
+	mu    sync.Mutex
+	stats Stats
+}
+
+func newHandlerStats() *handlerStats {
+	s := &handlerStats{}
+	s.reset()
+	return s
+}
+
+func (s *handlerStats) reset() {
+	s.stats = Stats{
+		Since:   time.Now(),
+		Levels:  make(map[slog.Level]uint64),
+		Modules: make(map[string]uint64),
+	}
+}
+
+func (s *handlerStats) record(level slog.Level, module string, n int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.Levels[level]++
+	s.stats.Modules[module]++
+	s.stats.Bytes += uint64(n)
+	if err != nil {
+		s.stats.WriteErrors++
+	}
+}
+
+func (s *handlerStats) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.Dropped++
+}
+
+func (s *handlerStats) snapshot() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.stats
+	snap.Levels = maps.Clone(s.stats.Levels)
+	snap.Modules = maps.Clone(s.stats.Modules)
+	return snap
+}
+
+// Stats returns a snapshot of the records and bytes written by h and every
+// handler derived from it since it was created or last reset.
+func (h *TextHandler) Stats() Stats {
+	return h.stats.snapshot()
+}
+
+// ResetStats sets all counters reported by Stats back to zero, and empties
+// the histograms of [WithHistograms].
+func (h *TextHandler) ResetStats() {
+	h.stats.mu.Lock()
+	defer h.stats.mu.Unlock()
+
+	h.stats.reset()
+	if h.histograms != nil {
+		h.histograms.reset()
+	}
+}
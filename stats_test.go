@@ -0,0 +1,54 @@
+package trifle
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestStats(t *testing.T) {
+	var buf bytes.Buffer
+
+	handler := New(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	logger := slog.New(handler)
+
+	logger.Debug("one")
+	logger.With("module", "db").Info("two")
+	logger.With("module", "db").WithGroup("g").Warn("three")
+	logger.Error("four")
+
+	stats := handler.Stats()
+	assert.Equal(t, uint64(4), stats.Total())
+	assert.Equal(t, uint64(2), stats.AtLeast(slog.LevelWarn))
+	assert.Equal(t, uint64(1), stats.Levels[slog.LevelError])
+	assert.Equal(t, uint64(2), stats.Modules["db"])
+	assert.Equal(t, uint64(2), stats.Modules[""])
+	assert.Equal(t, uint64(buf.Len()), stats.Bytes)
+	assert.Zero(t, stats.WriteErrors)
+
+	// Snapshots are independent of later activity.
+	logger.Info("five")
+	assert.Equal(t, uint64(4), stats.Total())
+
+	handler.ResetStats()
+	assert.Zero(t, handler.Stats().Total())
+	assert.Zero(t, handler.Stats().Bytes)
+}
+
+func TestStatsWriteErrors(t *testing.T) {
+	handler := New(failingWriter{}, nil)
+	slog.New(handler).Info("lost")
+
+	stats := handler.Stats()
+	assert.Equal(t, uint64(1), stats.WriteErrors)
+	assert.Equal(t, uint64(1), stats.Total())
+}
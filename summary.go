@@ -0,0 +1,137 @@
+package trifle
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+var (
+	summaryOKColor     = color.New(color.FgHiGreen)
+	summaryFailedColor = color.New(color.FgHiRed)
+)
+
+// Summary writes a final line reporting how the run went, based on the
+// records handled since the handler was created or its stats were reset:
+//
+//	✔ completed in 3.2s (warnings: 2)
+//	✖ failed after 1.1s (errors: 3)
+//
+// It is preceded by a line with the latest values of the keys of
+// [WithWatchKeys], and by a line for each key of [WithHistograms]. The run
+// failed if any record at Error or above was handled. Summary
+// returns the matching process exit code, 0 or 1, so a main function can
+// end with os.Exit(handler.Summary()).
+//
+// A handler made with [NewJSON] writes the summary as a record instead,
+// with everything in attributes:
+//
+//	{"time":"…","level":"ERROR","msg":"failed","elapsed":"1.1s","errors":3,"warnings":0}
+func (h *TextHandler) Summary() int {
+	stats := h.Stats()
+	elapsed := summaryDuration(time.Since(stats.Since))
+
+	var (
+		errs     = stats.AtLeast(slog.LevelError)
+		warnings = stats.AtLeast(slog.LevelWarn) - errs
+		counts   []string
+	)
+	if h.json != nil {
+		return h.summaryJSON(elapsed, errs, warnings)
+	}
+	if errs > 0 {
+		counts = append(counts, fmt.Sprintf("errors: %d", errs))
+	}
+	if warnings > 0 {
+		counts = append(counts, fmt.Sprintf("warnings: %d", warnings))
+	}
+
+	var (
+		line string
+		code int
+	)
+	if errs > 0 {
+		line = summaryFailedColor.Sprintf("✖ failed after %s", elapsed)
+		code = 1
+	} else {
+		line = summaryOKColor.Sprintf("✔ completed in %s", elapsed)
+	}
+	if len(counts) > 0 {
+		line += " (" + strings.Join(counts, ", ") + ")"
+	}
+
+	w := h.w
+	if code != 0 && h.errW != nil {
+		w = h.errW
+	}
+
+	var b strings.Builder
+	if h.watches != nil {
+		h.watches.takeDown()
+		if line := h.watches.line(); line != "" {
+			b.WriteString(line + "\n")
+		}
+	}
+	for _, hist := range h.Histograms() {
+		b.WriteString(hist.String() + "\n")
+	}
+	b.WriteString(line + "\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, _ = w.Write([]byte(b.String()))
+
+	return code
+}
+
+// summaryJSON writes the summary as a JSON record, see Summary.
+func (h *TextHandler) summaryJSON(elapsed time.Duration, errs, warnings uint64) int {
+	var (
+		r    slog.Record
+		code int
+	)
+	if errs > 0 {
+		r = slog.NewRecord(time.Now(), slog.LevelError, "failed", 0)
+		code = 1
+	} else {
+		r = slog.NewRecord(time.Now(), slog.LevelInfo, "completed", 0)
+	}
+	r.AddAttrs(
+		slog.Duration("elapsed", elapsed),
+		slog.Uint64("errors", errs),
+		slog.Uint64("warnings", warnings),
+	)
+	if h.watches != nil {
+		if latest := h.watches.snapshot(); len(latest) > 0 {
+			r.AddAttrs(slog.Attr{Key: "latest", Value: slog.GroupValue(latest...)})
+		}
+	}
+	if hists := h.Histograms(); len(hists) > 0 {
+		attrs := make([]slog.Attr, len(hists))
+		for i, hist := range hists {
+			attrs[i] = hist.attr()
+		}
+		r.AddAttrs(slog.Attr{Key: "histograms", Value: slog.GroupValue(attrs...)})
+	}
+
+	// Written like any record, to the error writer when it is an error.
+	_ = h.handle(context.Background(), r, "", false)
+
+	return code
+}
+
+// summaryDuration rounds d to a precision that reads well in a summary.
+func summaryDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Minute:
+		return d.Round(time.Second)
+	case d >= time.Second:
+		return d.Round(100 * time.Millisecond)
+	default:
+		return d.Round(time.Millisecond)
+	}
+}
@@ -0,0 +1,48 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestSummary(t *testing.T) {
+	color.NoColor = false
+
+	var buf bytes.Buffer
+
+	handler := New(&buf, nil, PresetTest)
+	logger := slog.New(handler)
+	logger.Info("step one")
+	logger.Warn("slow step")
+
+	assert.Equal(t, 0, handler.Summary())
+	assert.Regexp(t, `\x1b\[92m✔ completed in \S+\x1b\[0m \(warnings: 1\)\n$`, buf.String())
+
+	logger.Error("step two failed")
+	logger.Error("step three failed")
+
+	assert.Equal(t, 1, handler.Summary())
+	assert.Regexp(t, `\x1b\[91m✖ failed after \S+\x1b\[0m \(errors: 2, warnings: 1\)\n$`, buf.String())
+}
+
+func TestSummaryCLIWritesFailureToStderr(t *testing.T) {
+	var out, errOut bytes.Buffer
+
+	handler := CLI(&out, &errOut)
+	slog.New(handler).Error("boom")
+
+	assert.Equal(t, 1, handler.Summary())
+	assert.Contains(t, errOut.String(), "failed after")
+	assert.NotContains(t, out.String(), "failed after")
+}
+
+func TestSummaryDuration(t *testing.T) {
+	assert.Equal(t, 3200*time.Millisecond, summaryDuration(3217*time.Millisecond))
+	assert.Equal(t, 12*time.Millisecond, summaryDuration(12345*time.Microsecond))
+	assert.Equal(t, 2*time.Minute+5*time.Second, summaryDuration(2*time.Minute+4600*time.Millisecond))
+}
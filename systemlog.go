@@ -0,0 +1,223 @@
+package trifle
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrNoSystemLog is returned by [NewSystemLog] on platforms without a
+// supported system log.
+var ErrNoSystemLog = errors.New("trifle: no system log on this platform")
+
+// SystemLog is a [slog.Handler] writing to the log of the platform, so
+// that libraries shared between servers and mobile apps log natively on
+// each: to logcat on Android, and to the unified logging system (os_log)
+// on Apple platforms, where it needs cgo.
+//
+// The platform records the time and the level, so the text of a record is
+// its message followed by its attributes:
+//
+//	payment failed │ amount: 12.5 reason: "card declined"
+//
+// The module of a record picks its logcat tag or os_log category; records
+// without one use the tag given to NewSystemLog, or the "default"
+// category. Levels map to the nearest priority or type of the platform,
+// see [NewSystemLog].
+type SystemLog struct {
+	chain attrChain
+	opts  slog.HandlerOptions
+	out   systemLogger
+}
+
+// systemLogger writes the text of a record to a system log.
+type systemLogger interface {
+	log(level slog.Level, module, text string) error
+}
+
+// NewSystemLog returns a SystemLog for the app or subsystem named tag,
+// such as "com.example.app", or [ErrNoSystemLog] if the platform has none.
+// If opts is nil, records at Info and above are written.
+//
+// On Android, levels below Debug are verbose, Debug, Info, Warn and Error
+// map to the priorities of the same names, and levels above Error are
+// fatal. On Apple platforms, tag is the os_log subsystem; levels up to
+// Debug are of the debug type, Info of the info type, Warn of the default
+// type, Error of the error type and levels above it faults.
+func NewSystemLog(tag string, opts *slog.HandlerOptions) (*SystemLog, error) {
+	out, err := openSystemLog(tag)
+	if err != nil {
+		return nil, err
+	}
+	return newSystemLog(out, opts), nil
+}
+
+func newSystemLog(out systemLogger, opts *slog.HandlerOptions) *SystemLog {
+	if opts == nil {
+		opts = &slog.HandlerOptions{}
+	}
+	return &SystemLog{opts: *opts, out: out}
+}
+
+// Enabled reports whether s writes records at level.
+func (s *SystemLog) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if s.opts.Level != nil {
+		minLevel = s.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+// Handle writes r to the system log.
+func (s *SystemLog) Handle(ctx context.Context, r slog.Record) error {
+	return s.out.log(r.Level, s.chain.module, systemLogText(r.Message, s.chain.attrs(ctx, r)))
+}
+
+// WithAttrs returns a SystemLog adding attrs to every record.
+func (s *SystemLog) WithAttrs(attrs []slog.Attr) slog.Handler {
+	ns := *s
+	ns.chain = s.chain.withAttrs(attrs)
+	return &ns
+}
+
+// WithGroup returns a SystemLog nesting later attributes in group name.
+func (s *SystemLog) WithGroup(name string) slog.Handler {
+	ns := *s
+	ns.chain = s.chain.withGroup(name)
+	return &ns
+}
+
+// systemLogText returns msg followed by attrs, flattened into keys
+// qualified by their groups, with values that contain spaces quoted.
+func systemLogText(msg string, attrs []slog.Attr) string {
+	var (
+		b     strings.Builder
+		first = true
+		add   func(prefix string, a slog.Attr)
+	)
+	b.WriteString(msg)
+
+	add = func(prefix string, a slog.Attr) {
+		a.Value = a.Value.Resolve()
+		if a.Equal(slog.Attr{}) {
+			return
+		}
+		v := a.Value
+		key := prefix + a.Key
+		if v.Kind() == slog.KindGroup {
+			for _, ga := range v.Group() {
+				add(key+".", ga)
+			}
+			return
+		}
+
+		if first {
+			b.WriteString(" │ ")
+			first = false
+		} else {
+			b.WriteByte(' ')
+		}
+
+		s := v.String()
+		if v.Kind() == slog.KindAny {
+			s = anyText(v.Any())
+		}
+		if s == "" || strings.ContainsFunc(s, unicode.IsSpace) {
+			s = strconv.Quote(s)
+		}
+		b.WriteString(key + ": " + s)
+	}
+	for _, a := range attrs {
+		add("", a)
+	}
+	return b.String()
+}
+
+// Priorities of Android's logcat.
+const (
+	logcatVerbose = 2 + iota
+	logcatDebug
+	logcatInfo
+	logcatWarn
+	logcatError
+	logcatFatal
+)
+
+// logcatPriority returns the logcat priority of level.
+func logcatPriority(level slog.Level) byte {
+	switch {
+	case level < slog.LevelDebug:
+		return logcatVerbose
+	case level < slog.LevelInfo:
+		return logcatDebug
+	case level < slog.LevelWarn:
+		return logcatInfo
+	case level < slog.LevelError:
+		return logcatWarn
+	case level == slog.LevelError:
+		return logcatError
+	default:
+		return logcatFatal
+	}
+}
+
+// logcatMaxPayload is the most logd accepts after the header of an entry.
+const logcatMaxPayload = 4068
+
+// logcatPacket returns the datagram logd takes for an entry of the main
+// log: a header of the log id, the thread id and the time, then the
+// priority and the tag and message, each ended by a NUL byte. Messages too
+// long for an entry are cut short.
+func logcatPacket(priority byte, tag, msg string, tid int, t time.Time) []byte {
+	const mainLog = 0
+
+	b := make([]byte, 0, 12+len(tag)+len(msg)+2)
+	b = append(b, mainLog)
+	b = binary.LittleEndian.AppendUint16(b, uint16(tid))
+	b = binary.LittleEndian.AppendUint32(b, uint32(t.Unix()))
+	b = binary.LittleEndian.AppendUint32(b, uint32(t.Nanosecond()))
+
+	room := logcatMaxPayload - 1 - (len(tag) + 1) - 1
+	if len(msg) > room {
+		room = max(room, 0)
+		for room > 0 && !utf8.RuneStart(msg[room]) {
+			room--
+		}
+		msg = msg[:room]
+	}
+
+	b = append(b, priority)
+	b = append(append(b, tag...), 0)
+	return append(append(b, msg...), 0)
+}
+
+// Types of os_log messages.
+const (
+	osLogDefault = 0x00
+	osLogInfo    = 0x01
+	osLogDebug   = 0x02
+	osLogError   = 0x10
+	osLogFault   = 0x11
+)
+
+// osLogType returns the os_log type of level.
+func osLogType(level slog.Level) uint8 {
+	switch {
+	case level < slog.LevelInfo:
+		return osLogDebug
+	case level < slog.LevelWarn:
+		return osLogInfo
+	case level < slog.LevelError:
+		return osLogDefault
+	case level == slog.LevelError:
+		return osLogError
+	default:
+		return osLogFault
+	}
+}
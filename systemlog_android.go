@@ -0,0 +1,56 @@
+//go:build android
+
+package trifle
+
+import (
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// logdSocket is where logd takes entries, one datagram each.
+const logdSocket = "/dev/socket/logdw"
+
+// logcat writes entries to logd, the way liblog does, so that no cgo is
+// needed.
+type logcat struct {
+	tag string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func openSystemLog(tag string) (systemLogger, error) {
+	conn, err := net.Dial("unixgram", logdSocket)
+	if err != nil {
+		return nil, err
+	}
+	return &logcat{tag: tag, conn: conn}, nil
+}
+
+func (l *logcat) log(level slog.Level, module, text string) error {
+	tag := l.tag
+	if module != "" {
+		tag = module
+	}
+	packet := logcatPacket(logcatPriority(level), tag, text, unix.Gettid(), time.Now())
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.conn.Write(packet); err != nil {
+		// logd may have restarted; try once more on a new socket.
+		conn, derr := net.Dial("unixgram", logdSocket)
+		if derr != nil {
+			return err
+		}
+		l.conn.Close()
+		l.conn = conn
+		_, err = l.conn.Write(packet)
+		return err
+	}
+	return nil
+}
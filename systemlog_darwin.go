@@ -0,0 +1,69 @@
+//go:build darwin && cgo
+
+package trifle
+
+/*
+#include <os/log.h>
+#include <stdlib.h>
+
+static os_log_t trifle_os_log_create(const char *subsystem, const char *category) {
+	return os_log_create(subsystem, category);
+}
+
+static void trifle_os_log(os_log_t log, uint8_t type, const char *msg) {
+	os_log_with_type(log, (os_log_type_t)type, "%{public}s", msg);
+}
+*/
+import "C"
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// osLog writes to the unified logging system, with a log object for each
+// category. Log objects are never released, as os_log expects.
+type osLog struct {
+	subsystem string
+
+	mu   sync.Mutex
+	logs map[string]C.os_log_t
+}
+
+func openSystemLog(subsystem string) (systemLogger, error) {
+	return &osLog{subsystem: subsystem, logs: make(map[string]C.os_log_t)}, nil
+}
+
+func (l *osLog) log(level slog.Level, module, text string) error {
+	category := module
+	if category == "" {
+		category = "default"
+	}
+
+	// C strings end at the first NUL.
+	cmsg := C.CString(strings.ReplaceAll(text, "\x00", `\0`))
+	defer C.free(unsafe.Pointer(cmsg))
+
+	C.trifle_os_log(l.logFor(category), C.uint8_t(osLogType(level)), cmsg)
+	return nil
+}
+
+func (l *osLog) logFor(category string) C.os_log_t {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if log, ok := l.logs[category]; ok {
+		return log
+	}
+
+	csub := C.CString(l.subsystem)
+	defer C.free(unsafe.Pointer(csub))
+	ccat := C.CString(category)
+	defer C.free(unsafe.Pointer(ccat))
+
+	log := C.trifle_os_log_create(csub, ccat)
+	l.logs[category] = log
+	return log
+}
@@ -0,0 +1,7 @@
+//go:build !android && !(darwin && cgo)
+
+package trifle
+
+func openSystemLog(string) (systemLogger, error) {
+	return nil, ErrNoSystemLog
+}
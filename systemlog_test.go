@@ -0,0 +1,76 @@
+package trifle
+
+import (
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type systemLogEntry struct {
+	level        slog.Level
+	module, text string
+}
+
+type fakeSystemLog struct {
+	entries []systemLogEntry
+}
+
+func (f *fakeSystemLog) log(level slog.Level, module, text string) error {
+	f.entries = append(f.entries, systemLogEntry{level, module, text})
+	return nil
+}
+
+func TestSystemLog(t *testing.T) {
+	var out fakeSystemLog
+	logger := slog.New(newSystemLog(&out, nil))
+
+	logger.Debug("not written")
+	logger.With("module", "payments").WithGroup("card").Warn("payment failed",
+		"amount", 12.5, "reason", "card declined", "err", errors.New("boom"), "note", "")
+	logger.Error("plain")
+
+	assert.Equal(t, []systemLogEntry{
+		{slog.LevelWarn, "payments", `payment failed │ card.amount: 12.5 card.reason: "card declined" card.err: boom card.note: ""`},
+		{slog.LevelError, "", "plain"},
+	}, out.entries)
+}
+
+func TestSystemLogLevels(t *testing.T) {
+	tests := []struct {
+		level    slog.Level
+		priority byte
+		osLog    uint8
+	}{
+		{Trace, logcatVerbose, osLogDebug},
+		{slog.LevelDebug, logcatDebug, osLogDebug},
+		{slog.LevelInfo, logcatInfo, osLogInfo},
+		{slog.LevelWarn, logcatWarn, osLogDefault},
+		{slog.LevelError, logcatError, osLogError},
+		{slog.LevelError + 4, logcatFatal, osLogFault},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.priority, logcatPriority(tt.level), tt.level.String())
+		assert.Equal(t, tt.osLog, osLogType(tt.level), tt.level.String())
+	}
+}
+
+func TestLogcatPacket(t *testing.T) {
+	at := time.Unix(1700000000, 123)
+	packet := logcatPacket(logcatWarn, "app", "hello", 0x1234, at)
+
+	assert.Equal(t, byte(0), packet[0], "main log")
+	assert.Equal(t, uint16(0x1234), binary.LittleEndian.Uint16(packet[1:]))
+	assert.Equal(t, uint32(1700000000), binary.LittleEndian.Uint32(packet[3:]))
+	assert.Equal(t, uint32(123), binary.LittleEndian.Uint32(packet[7:]))
+	assert.Equal(t, "\x05app\x00hello\x00", string(packet[11:]))
+
+	long := logcatPacket(logcatInfo, "app", "x"+strings.Repeat("é", logcatMaxPayload), 1, at)
+	require.Len(t, long, 11+logcatMaxPayload-1, "cut at a rune boundary")
+	assert.True(t, strings.HasSuffix(string(long), "é\x00"))
+}
@@ -9,3 +9,9 @@ func getTerminalWidth(w io.Writer) int {
 	// Terminal width detection is not implemented for this platform
 	return 0
 }
+
+// consoleWriter returns w unchanged on platforms without console
+// attribute APIs.
+func consoleWriter(w io.Writer) io.Writer {
+	return w
+}
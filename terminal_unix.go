@@ -4,15 +4,15 @@ package trifle
 
 import (
 	"io"
-	"os"
 
 	"golang.org/x/sys/unix"
 )
 
-// getTerminalWidth returns the width of the terminal, or 0 if it cannot be determined
+// getTerminalWidth returns the width of the terminal, or 0 if it cannot be determined.
+// Besides files, writers wrapping a file and exposing its Fd are measured.
 func getTerminalWidth(w io.Writer) int {
 	// Check if writer is a file
-	if f, ok := w.(*os.File); ok {
+	if f, ok := w.(interface{ Fd() uintptr }); ok {
 		// Get terminal size using ioctl
 		ws, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
 		if err == nil && ws.Col > 0 {
@@ -21,3 +21,9 @@ func getTerminalWidth(w io.Writer) int {
 	}
 	return 0 // Return 0 if not a terminal or can't get size
 }
+
+// consoleWriter returns w unchanged: Unix terminals interpret ANSI escape
+// sequences themselves.
+func consoleWriter(w io.Writer) io.Writer {
+	return w
+}
@@ -7,6 +7,9 @@ import (
 	"os"
 	"syscall"
 	"unsafe"
+
+	"github.com/mattn/go-colorable"
+	"golang.org/x/sys/windows"
 )
 
 type (
@@ -35,10 +38,18 @@ var (
 	procGetConsoleScreenBufferInfo = kernel32.NewProc("GetConsoleScreenBufferInfo")
 )
 
-// getTerminalWidth returns the width of the terminal on Windows, or 0 if it cannot be determined
+// The console calls of consoleWriter, replaced in tests.
+var (
+	getConsoleMode = windows.GetConsoleMode
+	setConsoleMode = windows.SetConsoleMode
+	newColorable   = colorable.NewColorable
+)
+
+// getTerminalWidth returns the width of the terminal on Windows, or 0 if it cannot be determined.
+// Besides files, writers wrapping a file and exposing its Fd are measured.
 func getTerminalWidth(w io.Writer) int {
 	// Check if writer is a file
-	f, ok := w.(*os.File)
+	f, ok := w.(interface{ Fd() uintptr })
 	if !ok {
 		return 0
 	}
@@ -61,3 +72,32 @@ func getTerminalWidth(w io.Writer) int {
 
 	return 0
 }
+
+// consoleWriter prepares w for colored output. If w is a console that
+// doesn't interpret ANSI escape sequences and virtual terminal processing
+// can't be enabled, as on consoles predating Windows 10, w is wrapped in a
+// writer that translates the sequences into console attribute calls.
+func consoleWriter(w io.Writer) io.Writer {
+	f, ok := w.(*os.File)
+	if !ok {
+		return w
+	}
+
+	var mode uint32
+	handle := windows.Handle(f.Fd())
+	if err := getConsoleMode(handle, &mode); err != nil {
+		// Not a console: a pipe or file keeps the escape sequences.
+		return w
+	}
+
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return w
+	}
+
+	mode |= windows.ENABLE_PROCESSED_OUTPUT | windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING
+	if err := setConsoleMode(handle, mode); err == nil {
+		return w
+	}
+
+	return newColorable(f)
+}
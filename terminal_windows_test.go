@@ -0,0 +1,74 @@
+//go:build windows
+
+package trifle
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/windows"
+)
+
+// colorableStub stands for the writer of go-colorable.
+type colorableStub struct{ io.Writer }
+
+func TestConsoleWriter(t *testing.T) {
+	get, set, wrap := getConsoleMode, setConsoleMode, newColorable
+	defer func() { getConsoleMode, setConsoleMode, newColorable = get, set, wrap }()
+	newColorable = func(f *os.File) io.Writer { return colorableStub{f} }
+
+	errNotConsole := errors.New("the handle is invalid")
+	vt := uint32(windows.ENABLE_PROCESSED_OUTPUT | windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+
+	tests := []struct {
+		name      string
+		mode      uint32
+		getErr    error
+		setErr    error
+		colorable bool
+		set       bool
+	}{
+		{name: "not a console", getErr: errNotConsole},
+		{name: "virtual terminal on", mode: vt},
+		{name: "virtual terminal enabled", mode: windows.ENABLE_WRAP_AT_EOL_OUTPUT, set: true},
+		{name: "legacy console", mode: windows.ENABLE_WRAP_AT_EOL_OUTPUT, setErr: errNotConsole, set: true, colorable: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				set    bool
+				setTo  uint32
+				stdout = os.Stdout
+			)
+			getConsoleMode = func(_ windows.Handle, mode *uint32) error {
+				*mode = tt.mode
+				return tt.getErr
+			}
+			setConsoleMode = func(_ windows.Handle, mode uint32) error {
+				set, setTo = true, mode
+				return tt.setErr
+			}
+
+			w := consoleWriter(stdout)
+
+			assert.Equal(t, tt.set, set)
+			if set {
+				assert.Equal(t, tt.mode|vt, setTo)
+			}
+			if tt.colorable {
+				assert.Equal(t, colorableStub{stdout}, w)
+			} else {
+				assert.Same(t, stdout, w)
+			}
+		})
+	}
+}
+
+func TestConsoleWriterNotFile(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Same(t, &buf, consoleWriter(&buf))
+}
@@ -0,0 +1,82 @@
+package trifle
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	testinginterface "github.com/mitchellh/go-testing-interface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle/pkg/color"
+)
+
+// recordingT captures what is logged through a test handler.
+type recordingT struct {
+	testinginterface.RuntimeT
+
+	name     string
+	mu       sync.Mutex
+	logs     []string
+	errors   []string
+	cleanups []func()
+}
+
+func (t *recordingT) Name() string { return t.name }
+
+func (t *recordingT) Log(args ...interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.logs = append(t.logs, color.Strip(fmt.Sprint(args...)))
+}
+
+func (t *recordingT) Error(args ...interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errors = append(t.errors, color.Strip(fmt.Sprint(args...)))
+}
+
+func (t *recordingT) Cleanup(f func()) { t.cleanups = append(t.cleanups, f) }
+
+func (t *recordingT) finish() {
+	for _, f := range t.cleanups {
+		f()
+	}
+}
+
+func TestNewTestSetsModule(t *testing.T) {
+	rt := &recordingT{name: "TestServer/reconnect"}
+
+	logger := slog.New(NewTest(rt, nil, PresetTest))
+	logger.Info("dialing", "addr", "10.0.0.1")
+	logger.With("module", "pool").Info("returned")
+
+	require.Len(t, rt.logs, 2)
+	assert.Contains(t, rt.logs[0], "TestServer/reconnect dialing")
+	assert.Contains(t, rt.logs[1], "TestServer/reconnect.pool returned")
+
+	rt.finish()
+	logger.Info("after the test")
+	assert.Len(t, rt.logs, 2)
+}
+
+func TestNewTestParallel(t *testing.T) {
+	for i := range 4 {
+		t.Run(fmt.Sprintf("worker-%d", i), func(t *testing.T) {
+			t.Parallel()
+
+			rt := &recordingT{name: t.Name()}
+			logger := slog.New(NewTest(rt, nil, PresetTest))
+			for j := range 50 {
+				logger.Info("step", "n", j)
+			}
+
+			require.Len(t, rt.logs, 50)
+			for _, line := range rt.logs {
+				assert.True(t, strings.Contains(line, t.Name()+" step"), line)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package trifle
+
+import (
+	"context"
+	"strings"
+)
+
+// Attribute keys used for the W3C trace context carried by a context.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// TraceContext is the correlation data of a W3C traceparent header.
+type TraceContext struct {
+	TraceID string
+	SpanID  string
+}
+
+// ParseTraceparent parses the value of a W3C traceparent header, as in
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01". It reports
+// false if the header is malformed or carries an all-zero id.
+func ParseTraceparent(header string) (TraceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 {
+		return TraceContext{}, false
+	}
+
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+
+	// Version ff is invalid, and version 00 has exactly four fields. Later
+	// versions may append fields, which we ignore.
+	if !isLowerHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return TraceContext{}, false
+	}
+	if !isLowerHex(traceID, 32) || isZeros(traceID) {
+		return TraceContext{}, false
+	}
+	if !isLowerHex(spanID, 16) || isZeros(spanID) {
+		return TraceContext{}, false
+	}
+	if !isLowerHex(flags, 2) {
+		return TraceContext{}, false
+	}
+
+	return TraceContext{TraceID: traceID, SpanID: spanID}, true
+}
+
+func isLowerHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func isZeros(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+type traceContextKey struct{}
+
+// ContextWithTraceparent returns a copy of ctx carrying the trace context
+// parsed from a traceparent header. If the header is invalid, ctx is
+// returned unchanged. Records logged with the returned context gain
+// [TraceIDKey] and [SpanIDKey] attributes, which are shown before the
+// message unless other keys are given with [WithContextKey].
+func ContextWithTraceparent(ctx context.Context, header string) context.Context {
+	tc, ok := ParseTraceparent(header)
+	if !ok {
+		return ctx
+	}
+	return ContextWithTraceContext(ctx, tc)
+}
+
+// ContextWithTraceContext returns a copy of ctx carrying tc.
+func ContextWithTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, tc)
+}
+
+// TraceContextFromContext returns the trace context stored in ctx by
+// [ContextWithTraceparent] or [ContextWithTraceContext].
+func TraceContextFromContext(ctx context.Context) (TraceContext, bool) {
+	if ctx == nil {
+		return TraceContext{}, false
+	}
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok
+}
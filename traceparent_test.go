@@ -0,0 +1,52 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		ok     bool
+	}{
+		{name: "valid", header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", ok: true},
+		{name: "future version with extra field", header: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-xyz", ok: true},
+		{name: "version 00 with extra field", header: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-xyz"},
+		{name: "invalid version", header: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		{name: "zero trace id", header: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+		{name: "zero span id", header: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01"},
+		{name: "uppercase", header: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01"},
+		{name: "short", header: "00-4bf92f35-00f067aa0ba902b7-01"},
+		{name: "empty", header: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc, ok := ParseTraceparent(tt.header)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tc.TraceID)
+				assert.Equal(t, "00f067aa0ba902b7", tc.SpanID)
+			}
+		})
+	}
+}
+
+func TestTraceparentContextPrefix(t *testing.T) {
+	var buf bytes.Buffer
+
+	// The trace and span ids are context keys by default.
+	logger := slog.New(New(&buf, nil))
+	ctx := ContextWithTraceparent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	logger.InfoContext(ctx, "traced")
+	assert.Regexp(t, `4bf92f3577b34da6a3ce929d0e0e4736 00f067aa0ba902b7.*traced`, buf.String())
+
+	assert.Equal(t, context.Background(), ContextWithTraceparent(context.Background(), "garbage"))
+}
@@ -0,0 +1,111 @@
+package trifle
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// RecordTransformer rewrites a record before it is formatted. It returns
+// the record to write in its place, or false to drop it. It may change
+// anything about the record, such as its level or attributes, but must
+// [slog.Record.Clone] it before adding attributes to it, since the record
+// may be shared with other handlers. [CloneWithAttrs], [CloneWithoutAttrs]
+// and [CloneReplaceAttrs] make such changed copies.
+type RecordTransformer func(ctx context.Context, r slog.Record) (slog.Record, bool)
+
+// WithTransformer returns an Option that adds fn to the pipeline that
+// prepares records for formatting. Records go through the pipeline in this
+// order:
+//
+//  1. the attributes carried by the context, such as the request id, are added
+//  2. the transformers run, in the order they were added
+//  3. the filters of [WithFilter] and [WithFilterExpr] drop records
+//  4. [WithDedupKey] suppresses repeated records
+//  5. [WithEventSchemas] checks the records of events and fills in their templates
+//  6. the decorators of [WithLevelDecorator] add their attributes
+//  7. the banner is written, alerts are notified, [WithHistograms] counts
+//     and [WithWatchKeys] takes the latest values
+//  8. [WithSequenceNumbers] numbers the records that are left
+//
+// so a transformer sees the enriched record, and the filters see what it
+// made of it. Like [slog.HandlerOptions.ReplaceAttr] for whole records, only
+// with more to change: a transformer could raise the level of records with
+// an error attribute, or drop all but one in a hundred debug records.
+// Records it drops are counted in [Stats].
+func WithTransformer(fn RecordTransformer) Option {
+	return func(h *TextHandler) {
+		h.transformers = append(h.transformers, fn)
+	}
+}
+
+// stage is a step of the pipeline that prepares records for formatting.
+// It reports false when the record is dropped.
+type stage func(h *TextHandler, ctx context.Context, r slog.Record, raw bool) (slog.Record, bool)
+
+// pipeline lists the stages of the pipeline in order, as documented on
+// WithTransformer.
+var pipeline = []stage{
+	(*TextHandler).enrichStage,
+	(*TextHandler).transformStage,
+	(*TextHandler).filterStage,
+	(*TextHandler).dedupStage,
+	(*TextHandler).schemaStage,
+	(*TextHandler).decorateStage,
+	(*TextHandler).notifyStage,
+	(*TextHandler).sequenceStage,
+}
+
+func (h *TextHandler) enrichStage(ctx context.Context, r slog.Record, _ bool) (slog.Record, bool) {
+	return h.addContextAttrs(ctx, r), true
+}
+
+func (h *TextHandler) transformStage(ctx context.Context, r slog.Record, _ bool) (slog.Record, bool) {
+	for _, fn := range h.transformers {
+		var ok bool
+		if r, ok = fn(ctx, r); !ok {
+			return r, false
+		}
+	}
+	return r, true
+}
+
+func (h *TextHandler) filterStage(ctx context.Context, r slog.Record, _ bool) (slog.Record, bool) {
+	return r, len(h.filters) == 0 || h.keep(ctx, r)
+}
+
+func (h *TextHandler) dedupStage(_ context.Context, r slog.Record, raw bool) (slog.Record, bool) {
+	return r, h.dedup == nil || !h.dedup.suppress(h, r, raw)
+}
+
+func (h *TextHandler) notifyStage(ctx context.Context, r slog.Record, _ bool) (slog.Record, bool) {
+	if h.banner != nil && h.json == nil {
+		h.writeBanner()
+	}
+	if len(h.alerts) > 0 {
+		h.notify(ctx, r, h.module)
+	}
+	if h.histograms != nil {
+		h.histograms.observe(r)
+	}
+	if h.watches != nil {
+		h.watches.observe(r)
+	}
+	return r, true
+}
+
+func (h *TextHandler) sequenceStage(ctx context.Context, r slog.Record, _ bool) (slog.Record, bool) {
+	if h.seq != nil {
+		r = h.sequence(ctx, r)
+	}
+	return r, true
+}
+
+func (h *commonHandler) validateTransformers() []error {
+	for _, fn := range h.transformers {
+		if fn == nil {
+			return []error{errors.New("transformer must not be nil")}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformerPipeline(t *testing.T) {
+	escalate := func(_ context.Context, r slog.Record) (slog.Record, bool) {
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == "err" {
+				r.Level = slog.LevelError
+				return false
+			}
+			return true
+		})
+		return r, true
+	}
+	dropHealth := func(_ context.Context, r slog.Record) (slog.Record, bool) {
+		return r, r.Message != "healthz"
+	}
+	sawRequestID := false
+	check := func(_ context.Context, r slog.Record) (slog.Record, bool) {
+		r.Attrs(func(a slog.Attr) bool {
+			sawRequestID = sawRequestID || a.Key == RequestIDKey
+			return true
+		})
+		return r, true
+	}
+
+	var buf bytes.Buffer
+	h, err := NewE(&buf, nil, WithTerminalWidth(0),
+		WithTransformer(check),
+		WithTransformer(escalate),
+		WithTransformer(dropHealth),
+		WithFilterExpr(`level >= error`),
+		WithSequenceNumbers())
+	require.NoError(t, err)
+
+	log := slog.New(h)
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	log.InfoContext(ctx, "fetched")
+	log.InfoContext(ctx, "fetch failed", "err", "timeout")
+	log.ErrorContext(ctx, "healthz")
+
+	out := Plain(buf.String())
+	assert.NotContains(t, out, "fetched")
+	assert.True(t, MatchesLine(out, `\[ERROR\] req-1 fetch failed`), out)
+	assert.True(t, ContainsAttr(out, SequenceKey, 1), out)
+	assert.NotContains(t, out, "healthz")
+	assert.True(t, sawRequestID)
+	assert.Equal(t, uint64(2), h.Stats().Dropped)
+}
+
+func TestTransformerValidation(t *testing.T) {
+	_, err := NewE(&bytes.Buffer{}, nil, WithTransformer(nil))
+	assert.ErrorContains(t, err, "transformer must not be nil")
+}
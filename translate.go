@@ -0,0 +1,77 @@
+package trifle
+
+import (
+	"log/slog"
+	"strings"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+// Translator returns the text to show in place of s, or s itself when it
+// has no translation of it.
+type Translator func(s string) string
+
+// WithTranslator returns an Option that shows level names, such as "WARN"
+// or "ERROR+4", as t translates them, for tools used by people who don't
+// read English:
+//
+//	12:00:00.000 [AVISO]  disco casi lleno │ free: 2GB
+//
+// Only the output changes: filters, alerts and other handlers see levels
+// and messages as they were logged, and attribute keys stay as they are.
+func WithTranslator(t Translator) Option {
+	return func(h *TextHandler) {
+		h.levelTranslator = t
+	}
+}
+
+// WithMessageTranslator returns an Option that shows messages as t
+// translates them. A translation may refer to the attributes of the record
+// as "{key}", which are filled in, so that t can look up messages logged
+// as they are, such as "disk almost full", and place values where the
+// language puts them:
+//
+//	trifle.WithMessageTranslator(func(msg string) string {
+//		if msg == "disk almost full" {
+//			return "disco casi lleno, quedan {free}"
+//		}
+//		return msg
+//	})
+func WithMessageTranslator(t Translator) Option {
+	return func(h *TextHandler) {
+		h.messageTranslator = t
+	}
+}
+
+// levelLabel returns the framed name of level for the output, such as
+// " [INFO]  ", translated by the level translator if there is one.
+func (h *commonHandler) levelLabel(level slog.Level) string {
+	spec, ok := _levelToName[level]
+	if h.levelTranslator == nil {
+		if ok {
+			return spec
+		}
+		// Levels between the named ones, such as ERROR+4 for fatal
+		// records, are framed like the rest.
+		return " [" + level.String() + "] "
+	}
+
+	name := level.String()
+	if ok {
+		name = strings.Trim(spec, " []")
+	}
+	label := " [" + h.levelTranslator(name) + "]"
+	pad := max(LevelWidth-color.StringWidth(label), 1)
+	return label + strings.Repeat(" ", pad)
+}
+
+// translateMessage returns the message of r translated by the message
+// translator, with the attributes it refers to filled in.
+func (h *commonHandler) translateMessage(r slog.Record) string {
+	msg := h.messageTranslator(r.Message)
+	if strings.Contains(msg, "{") {
+		values, _ := h.eventValues(r)
+		msg = fillTemplate(msg, values)
+	}
+	return msg
+}
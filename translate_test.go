@@ -0,0 +1,41 @@
+package trifle
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslator(t *testing.T) {
+	names := map[string]string{"INFO": "INFO", "WARN": "AVISO", "ERROR": "ERROR", "ERROR+4": "FATAL"}
+	messages := map[string]string{"disk almost full": "disco casi lleno, quedan {free}"}
+	translate := func(m map[string]string) Translator {
+		return func(s string) string {
+			if t, ok := m[s]; ok {
+				return t
+			}
+			return s
+		}
+	}
+
+	var buf bytes.Buffer
+	h := New(&buf, &slog.HandlerOptions{Level: Trace}, WithTerminalWidth(0),
+		WithTranslator(translate(names)),
+		WithMessageTranslator(translate(messages)),
+		WithFilterExpr(`level >= debug`))
+
+	log := slog.New(h)
+	log.Info("started")
+	log.Warn("disk almost full", "free", "2GB")
+	log.Log(context.Background(), slog.LevelError+4, "gave up")
+	log.Debug("polled")
+
+	out := Plain(buf.String())
+	assert.True(t, MatchesLine(out, ` \[INFO\]  started$`), out)
+	assert.True(t, MatchesLine(out, ` \[AVISO\] disco casi lleno, quedan 2GB │ free: 2GB$`), out)
+	assert.True(t, MatchesLine(out, ` \[FATAL\] gave up$`), out)
+	assert.True(t, MatchesLine(out, ` \[DEBUG\] polled$`), out)
+}
@@ -0,0 +1,122 @@
+package trifle
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// WithAdaptiveVerbosity returns an Option that keeps records below Warn
+// short, and shows everything for Warn and above. A record below Warn shows
+// at most top of its attributes, preferring those given to
+// [WithCriticalKeys], then those given to [WithImportantKeys], then the
+// first ones, and says how many it left out:
+//
+//	12:00:00.000 [INFO]  served │ path: /users status: 200 …+3 attrs
+//
+// Verbose attributes, such as [Details] and [Diff], are left out of them
+// too, while a [Hint] is kept. A record at Warn or above shows all its
+// attributes, so the console stays calm while failures are shown in full.
+//
+// Only the attributes of the record are collapsed: those added with
+// WithAttrs and the values of the keys given to [WithContextKey] are always
+// shown. Other handlers, alerts and filters see the whole record.
+func WithAdaptiveVerbosity(top int) Option {
+	return func(h *TextHandler) {
+		h.compactTop = top
+		h.compact = true
+	}
+}
+
+// compactRecord returns r with the attributes that WithAdaptiveVerbosity
+// leaves out of it removed, and how many those are.
+func (h *commonHandler) compactRecord(r slog.Record) (slog.Record, int) {
+	if !h.compact || r.Level >= slog.LevelWarn {
+		return r, 0
+	}
+
+	// Rank the attributes that count against the limit, to find the ones
+	// to keep.
+	type ranked struct{ index, rank int }
+	var (
+		candidates []ranked
+		verbose    int
+		i          int
+	)
+	r.Attrs(func(a slog.Attr) bool {
+		switch {
+		case h.isContextKey(a.Key):
+		case isFootnote(a):
+			if isVerbose(a) {
+				verbose++
+			}
+		default:
+			candidates = append(candidates, ranked{i, h.keyRank(a.Key)})
+		}
+		i++
+		return true
+	})
+	if len(candidates) <= h.compactTop && verbose == 0 {
+		return r, 0
+	}
+
+	slices.SortStableFunc(candidates, func(a, b ranked) int { return a.rank - b.rank })
+	keep := make(map[int]bool, h.compactTop)
+	for _, c := range candidates[:min(h.compactTop, len(candidates))] {
+		keep[c.index] = true
+	}
+
+	i = 0
+	r = CloneReplaceAttrs(r, func(a slog.Attr) slog.Attr {
+		shown := keep[i] || h.isContextKey(a.Key) || isFootnote(a) && !isVerbose(a)
+		i++
+		if !shown {
+			return slog.Attr{}
+		}
+		return a
+	})
+	return r, len(candidates) - len(keep) + verbose
+}
+
+// isVerbose reports whether the footnote a is left out of records by
+// WithAdaptiveVerbosity.
+func isVerbose(a slog.Attr) bool {
+	_, ok := a.Value.LogValuer().(hint)
+	return !ok
+}
+
+// isContextKey reports whether a record attribute with key is shown before
+// the message, see WithContextKey.
+func (h *commonHandler) isContextKey(key string) bool {
+	return len(h.groups) == 0 && slices.Contains(h.contextKeys, key)
+}
+
+// keyRank orders keys by how much they are highlighted, critical first.
+func (h *commonHandler) keyRank(key string) int {
+	switch {
+	case h.criticalKeys[key]:
+		return 0
+	case h.importantKeys[key]:
+		return 1
+	}
+	return 2
+}
+
+// appendCollapsed writes how many attributes WithAdaptiveVerbosity left
+// out of the record.
+func (s *handleState) appendCollapsed(n int) {
+	noun := "attrs"
+	if n == 1 {
+		noun = "attr"
+	}
+	s.buf.WriteString(s.sep)
+	s.buf.WriteString(footnoteColor.Sprint(fmt.Sprintf("…+%d %s", n, noun)))
+	s.sep = s.h.attrSep()
+}
+
+func (h *commonHandler) validateVerbosity() []error {
+	if h.compact && h.compactTop < 0 {
+		return []error{fmt.Errorf("adaptive verbosity must keep a non-negative number of attrs, got %d", h.compactTop)}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdaptiveVerbosity(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithAdaptiveVerbosity(2))
+	log := slog.New(h)
+
+	log.Info("served", "path", "/users", "status", 200, "bytes", 512, "agent", "curl", "ms", 3)
+	log.Error("failed", "path", "/users", "status", 500, "bytes", 0, "agent", "curl", "ms", 3)
+
+	out := Plain(buf.String())
+	assert.True(t, MatchesLine(out, `\[INFO\]  served │ path: /users status: 200 …\+3 attrs$`), out)
+	assert.True(t, MatchesLine(out, `\[ERROR\] failed │ path: /users status: 500 bytes: 0 agent: curl ms: 3$`), out)
+}
+
+func TestAdaptiveVerbosityPrefersHighlightedKeys(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithAdaptiveVerbosity(2),
+		WithCriticalKeys("error"), WithImportantKeys("user"))
+
+	slog.New(h).Info("retrying", "attempt", 2, "user", "ada", "delay", "1s", "error", "timeout")
+
+	out := Plain(buf.String())
+	assert.True(t, MatchesLine(out, `\[INFO\]  retrying │ user: ada error: timeout …\+2 attrs$`), out)
+}
+
+func TestAdaptiveVerbosityFootnotes(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithAdaptiveVerbosity(1))
+	log := slog.New(h)
+
+	log.Info("stale", "path", "/users", Hint("run migrate"), Details("expected", 1, "got", 2))
+	log.Warn("stale", "path", "/users", Hint("run migrate"), Details("expected", 1, "got", 2))
+
+	out := Plain(buf.String())
+	assert.True(t, MatchesLine(out, `\[INFO\]  stale │ path: /users …\+1 attr$`), out)
+	assert.Equal(t, 2, bytes.Count(buf.Bytes(), []byte("↳ run migrate")), out)
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("expected:")), out)
+}
+
+func TestAdaptiveVerbosityKeepsContextKeys(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithAdaptiveVerbosity(0), WithContextKey("request_id"))
+
+	slog.New(h).With("user", "ada").Info("served", "request_id", "req-1", "path", "/users")
+
+	out := Plain(buf.String())
+	assert.True(t, MatchesLine(out, `\[INFO\]  req-1 served │ user: ada …\+1 attr$`), out)
+}
+
+func TestAdaptiveVerbosityUnderLimit(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithAdaptiveVerbosity(3))
+
+	slog.New(h).Info("served", "path", "/users")
+
+	assert.NotContains(t, buf.String(), "…")
+	assert.True(t, ContainsAttr(buf.String(), "path", "/users"))
+}
+
+func TestAdaptiveVerbosityNegative(t *testing.T) {
+	_, err := NewE(&bytes.Buffer{}, nil, WithAdaptiveVerbosity(-1))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "got -1")
+}
@@ -0,0 +1,230 @@
+package trifle
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-isatty"
+	"miren.dev/trifle/pkg/color"
+)
+
+var watchColor = color.New(color.ReverseVideo)
+
+// WithWatchKeys returns an Option that keeps the latest values of keys,
+// such as queue_depth or goroutines, from the records written, for live
+// metrics without a dashboard. On a terminal, they are shown in a status
+// line kept below the output, which is redrawn as records carrying them
+// are written:
+//
+//	12:00:01.000 [INFO]  dispatched │ queue_depth: 12
+//	 latest: queue_depth=12 goroutines=40
+//
+// [TextHandler.Summary] writes them in a line before its own, in place of
+// the status line on a terminal, so that files get them too.
+// [TextHandler.Watched] returns them at any time. Keys match the attributes
+// of the log call, qualified by their groups as in "pool.idle", as for
+// [WithHistograms].
+func WithWatchKeys(keys ...string) Option {
+	return func(h *TextHandler) {
+		if h.watches == nil {
+			h.watches = &watches{}
+			if isTerminal(h.w) && h.json == nil {
+				h.w = &statusWriter{w: h.w, watches: h.watches}
+			}
+		}
+		h.watches.keys = append(h.watches.keys, keys...)
+	}
+}
+
+// isTerminal reports whether w writes to a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(interface{ Fd() uintptr })
+	return ok && (isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd()))
+}
+
+// watches keeps the latest values of the keys of WithWatchKeys. It is
+// shared among clones.
+type watches struct {
+	keys []string
+
+	mu     sync.Mutex
+	latest map[string]slog.Value
+	done   bool // the status line is taken down, see Summary
+}
+
+// observe keeps the values of r for the keys of ws.
+func (ws *watches) observe(r slog.Record) {
+	r.Attrs(func(a slog.Attr) bool {
+		ws.observeAttr("", a)
+		return true
+	})
+}
+
+func (ws *watches) observeAttr(prefix string, a slog.Attr) {
+	key := a.Key
+	if prefix != "" {
+		key = prefix + "." + a.Key
+	}
+
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		for _, ga := range v.Group() {
+			ws.observeAttr(key, ga)
+		}
+		return
+	}
+	if !slices.Contains(ws.keys, key) {
+		return
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.latest == nil {
+		ws.latest = make(map[string]slog.Value)
+	}
+	ws.latest[key] = v
+}
+
+// snapshot returns the latest values, in the order of the keys.
+func (ws *watches) snapshot() []slog.Attr {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	var out []slog.Attr
+	for _, key := range ws.keys {
+		if v, ok := ws.latest[key]; ok {
+			out = append(out, slog.Attr{Key: key, Value: v})
+		}
+	}
+	return out
+}
+
+// line describes the latest values in one line, or returns "" if there
+// are none yet.
+func (ws *watches) line() string {
+	attrs := ws.snapshot()
+	if len(attrs) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("latest:")
+	for _, a := range attrs {
+		v := a.Value.String()
+		if needsQuoting(v) {
+			v = strconv.Quote(v)
+		}
+		b.WriteString(" " + a.Key + "=" + v)
+	}
+	return b.String()
+}
+
+// status returns the status line to draw below the output, if any.
+func (ws *watches) status() string {
+	ws.mu.Lock()
+	done := ws.done
+	ws.mu.Unlock()
+
+	if done {
+		return ""
+	}
+	if line := ws.line(); line != "" {
+		return watchColor.Sprint(" " + line + " ")
+	}
+	return ""
+}
+
+// takeDown stops drawing the status line.
+func (ws *watches) takeDown() {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	ws.done = true
+}
+
+// statusWriter writes to a terminal while keeping the status line of
+// WithWatchKeys below the output. The line is erased before every write and
+// drawn again after it, so records scroll up past it. Writes are expected
+// to end in a newline, as records do.
+type statusWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	watches *watches
+	drawn   bool // the status line is on the terminal
+}
+
+func (s *statusWriter) Write(p []byte) (int, error) {
+	return s.write(nil, p)
+}
+
+// write writes p to the terminal, or to other instead when it isn't nil,
+// another stream to the same terminal, with the status line taken off the
+// terminal meanwhile.
+func (s *statusWriter) write(other io.Writer, p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var buf bytes.Buffer
+	if s.drawn {
+		buf.WriteString("\r\x1b[2K")
+		s.drawn = false
+	}
+	if other != nil {
+		if buf.Len() > 0 {
+			if _, err := s.w.Write(buf.Bytes()); err != nil {
+				return 0, err
+			}
+			buf.Reset()
+		}
+		if _, err := other.Write(p); err != nil {
+			return 0, err
+		}
+	} else {
+		buf.Write(p)
+	}
+
+	// Wrapping is turned off while the line is written, so that a line
+	// wider than the terminal is cut off rather than taking more lines than
+	// the erasing above knows of.
+	if line := s.watches.status(); line != "" {
+		buf.WriteString("\x1b[?7l" + line + "\x1b[?7h")
+		s.drawn = true
+	}
+	if buf.Len() > 0 {
+		if _, err := s.w.Write(buf.Bytes()); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// sharing returns a writer to w, another stream to the same terminal as s,
+// that takes the status line down while it writes.
+func (s *statusWriter) sharing(w io.Writer) io.Writer {
+	return &statusSharer{s: s, w: w}
+}
+
+type statusSharer struct {
+	s *statusWriter
+	w io.Writer
+}
+
+func (ss *statusSharer) Write(p []byte) (int, error) {
+	return ss.s.write(ss.w, p)
+}
+
+// Watched returns the latest values of the keys of [WithWatchKeys] seen so
+// far, by h and every handler derived from it, in the order of the keys.
+// Keys without any values are left out.
+func (h *TextHandler) Watched() []slog.Attr {
+	if h.watches == nil {
+		return nil
+	}
+	return h.watches.snapshot()
+}
@@ -0,0 +1,85 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestWatchKeys(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithWatchKeys("queue_depth", "pool.idle"))
+	log := slog.New(h)
+
+	assert.Empty(t, h.Watched())
+
+	log.Info("dispatched", "queue_depth", 12)
+	log.With("worker", 1).Info("dispatched", "queue_depth", 9, slog.Group("pool", "idle", 3))
+	log.Info("unrelated", "goroutines", 40)
+
+	assert.Equal(t, []slog.Attr{slog.Int("queue_depth", 9), slog.Int("pool.idle", 3)}, h.Watched())
+	assert.NotContains(t, buf.String(), "latest:", "no status line when not on a terminal")
+
+	h.Summary()
+	assert.True(t, MatchesLine(Plain(buf.String()), `^latest: queue_depth=9 pool.idle=3$`), buf.String())
+}
+
+func TestWatchKeysStatusLine(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	var term bytes.Buffer
+	h := New(&term, nil, WithTerminalWidth(0), WithWatchKeys("queue_depth"))
+	// Tests don't run on a terminal, so put in what WithWatchKeys would.
+	h.w = &statusWriter{w: h.w, watches: h.watches}
+	log := slog.New(h)
+
+	log.Info("starting")
+	assert.NotContains(t, term.String(), "latest:", "no status line before any values")
+
+	log.Info("dispatched", "queue_depth", 12)
+	assert.True(t, strings.HasSuffix(term.String(), "\x1b[?7l latest: queue_depth=12 \x1b[?7h"), term.String())
+
+	term.Reset()
+	log.Info("dispatched", "queue_depth", 7)
+	assert.True(t, strings.HasPrefix(term.String(), "\r\x1b[2K"), "the previous status line is erased")
+	assert.Contains(t, term.String(), "dispatched")
+	assert.True(t, strings.HasSuffix(term.String(), " latest: queue_depth=7 \x1b[?7h"), term.String())
+
+	term.Reset()
+	h.Summary()
+	assert.True(t, strings.HasPrefix(term.String(), "\r\x1b[2K"), term.String())
+	assert.Contains(t, term.String(), "latest: queue_depth=7\n")
+	assert.NotContains(t, term.String(), "\x1b[?7l", "Summary takes the status line down")
+}
+
+func TestWatchKeysSharedTerminal(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = true
+
+	var term, errTerm bytes.Buffer
+	h := New(&term, nil, WithTerminalWidth(0), WithWatchKeys("queue_depth"))
+	s := &statusWriter{w: h.w, watches: h.watches}
+	h.w = s
+	h.errW = s.sharing(&errTerm)
+	log := slog.New(h)
+
+	log.Info("dispatched", "queue_depth", 12)
+	term.Reset()
+	log.Error("stalled", "queue_depth", 30)
+
+	assert.Contains(t, errTerm.String(), "stalled")
+	assert.True(t, strings.HasPrefix(term.String(), "\r\x1b[2K"), term.String())
+	assert.True(t, strings.HasSuffix(term.String(), " latest: queue_depth=30 \x1b[?7h"), term.String())
+}
+
+func TestWatchKeysEmpty(t *testing.T) {
+	_, err := NewE(&bytes.Buffer{}, nil, WithWatchKeys(""))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "watch key must not be empty")
+}
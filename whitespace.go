@@ -0,0 +1,108 @@
+package trifle
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"miren.dev/trifle/pkg/color"
+)
+
+var whitespaceColor = color.New(color.Faint)
+
+// WithVisibleWhitespace returns an Option that shows the invisible parts of
+// string values, dimmed, rather than leaving them to quoting: leading and
+// trailing spaces as ·, tabs as →, control characters as their symbols,
+// such as ␀ and ␍, and other invisible characters, such as zero-width or
+// non-breaking spaces, by their code point:
+//
+//	name: ··admin→   path: /tmp/x␍   id: <U+FEFF>42
+//
+// It is meant for debugging input where the invisible characters are the
+// bug. Lines of multiline values are shown the same way, undimmed.
+func WithVisibleWhitespace() Option {
+	return func(h *TextHandler) {
+		h.visibleWhitespace = true
+	}
+}
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// showWhitespace renders v with its invisible characters made visible when
+// it is a string that has any. Other values are returned as they are.
+func showWhitespace(v slog.Value) slog.Value {
+	if v.Kind() != slog.KindString {
+		return v
+	}
+	s := v.String()
+	if !utf8.ValidString(s) {
+		return v
+	}
+
+	plain := visibleLines(s, false)
+	if plain == s {
+		return v
+	}
+	if strings.Contains(s, "\n") {
+		// Multiline values are written line by line, without styling.
+		return slog.StringValue(plain)
+	}
+
+	styled := visibleLines(s, true)
+	if needsQuoting(plain) {
+		styled = `"` + quoteEscaper.Replace(styled) + `"`
+	}
+	return slog.AnyValue(rendered(styled))
+}
+
+func visibleLines(s string, styled bool) string {
+	var b strings.Builder
+	for i, line := range strings.Split(s, "\n") {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		visibleLine(&b, line, styled)
+	}
+	return b.String()
+}
+
+// visibleLine writes line to b with its invisible characters replaced,
+// dimming each run of replacements when styled.
+func visibleLine(b *strings.Builder, line string, styled bool) {
+	lead := len(line) - len(strings.TrimLeft(line, " \t\r"))
+	trail := len(line) - len(strings.TrimRight(line, " \t\r"))
+
+	var run strings.Builder
+	flush := func() {
+		if run.Len() == 0 {
+			return
+		}
+		if styled {
+			b.WriteString(whitespaceColor.Sprint(run.String()))
+		} else {
+			b.WriteString(run.String())
+		}
+		run.Reset()
+	}
+
+	for i, r := range line {
+		switch {
+		case r == ' ' && (i < lead || i >= len(line)-trail):
+			run.WriteRune('·')
+		case r == '\t':
+			run.WriteRune('→')
+		case r < 0x20:
+			run.WriteRune(0x2400 + r) // the Control Pictures block
+		case r == 0x7f:
+			run.WriteRune('␡')
+		case r >= utf8.RuneSelf && (unicode.IsSpace(r) || !unicode.IsPrint(r)):
+			fmt.Fprintf(&run, "<U+%04X>", r)
+		default:
+			flush()
+			b.WriteRune(r)
+		}
+	}
+	flush()
+}
@@ -0,0 +1,63 @@
+package trifle
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"miren.dev/trifle/pkg/color"
+)
+
+func TestVisibleWhitespace(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithVisibleWhitespace())
+
+	slog.New(h).Info("parsed",
+		"name", "  admin\t",
+		"path", "/tmp/x\r",
+		"id", "\ufeff42",
+		"nul", "a\x00b",
+		"plain", "ok",
+		"spaced", "two words ")
+
+	out := Plain(buf.String())
+	assert.Contains(t, out, "name: ··admin→")
+	assert.Contains(t, out, "path: /tmp/x␍")
+	assert.Contains(t, out, "id: <U+FEFF>42")
+	assert.Contains(t, out, "nul: a␀b")
+	assert.Contains(t, out, "plain: ok")
+	assert.Contains(t, out, `spaced: "two words·"`)
+}
+
+func TestVisibleWhitespaceDimmed(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+	color.NoColor = false
+
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithVisibleWhitespace())
+
+	slog.New(h).Info("parsed", "name", "admin  ")
+
+	assert.Contains(t, buf.String(), "admin"+whitespaceColor.Sprint("··"))
+}
+
+func TestVisibleWhitespaceMultiline(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0), WithVisibleWhitespace())
+
+	slog.New(h).Info("read", "body", "first \r\n\tsecond")
+
+	out := Plain(buf.String())
+	assert.Contains(t, out, "│ first·␍")
+	assert.Contains(t, out, "│ →second")
+}
+
+func TestWithoutVisibleWhitespace(t *testing.T) {
+	var buf bytes.Buffer
+	h := New(&buf, nil, WithTerminalWidth(0))
+
+	slog.New(h).Info("parsed", "name", "  admin\t")
+
+	assert.True(t, ContainsAttr(buf.String(), "name", "  admin\t"))
+}